@@ -0,0 +1,430 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/ignore"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pathspec"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Build a pathspec from command line paths, which are relative to the
+// directory the command runs in.
+func getPathspec(git *fs.Git, c *cli.Context, args []string) (pathspec.Pathspec, error) {
+	cwd, err := filepath.Abs(c.String("C"))
+
+	if err != nil {
+		return pathspec.Pathspec{}, err
+	}
+
+	patterns := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		abs := arg
+
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(cwd, arg)
+		}
+
+		rel, err := filepath.Rel(git.WorkTree(), abs)
+
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return pathspec.Pathspec{}, errors.GitError{Message: fmt.Sprintf("%s: '%s' is outside repository", arg, git.WorkTree())}
+		}
+
+		patterns = append(patterns, filepath.ToSlash(rel))
+	}
+
+	return pathspec.New(patterns), nil
+}
+
+// Hash the content of a working tree file as a blob, writing it if asked to.
+func hashWorkTreeFile(git *fs.Git, name string, info os.FileInfo, write bool) (plumbing.Hash, error) {
+	path := filepath.Join(git.WorkTree(), filepath.FromSlash(name))
+
+	var content []byte
+	var err error
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		var target string
+
+		target, err = os.Readlink(path)
+		content = []byte(target)
+	} else {
+		content, err = ioutil.ReadFile(path)
+	}
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if !write {
+		return objfile.HashObject(git.ObjectFormat(), objfile.Blob, content), nil
+	}
+
+	return git.WriteObject(objfile.Blob, content)
+}
+
+// The matcher of the ignored files of the working tree, taking the user's
+// excludes file from cfg.
+func ignoreMatcher(git *fs.Git, cfg *config.Config) (*ignore.Matcher, error) {
+	excludesFile, err := config.ExcludesFile(cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ignore.NewMatcher(git.WorkTree(), git.GitDir(), excludesFile), nil
+}
+
+// Find the files of the working tree that could be staged. Untracked files
+// ignored by .gitignore are left out unless forced; the ones named explicitly
+// by spec are returned so that the user can be told about them.
+func listWorkTreeFiles(git *fs.Git, idx *index.Index, spec pathspec.Pathspec, matcher *ignore.Matcher, force bool) (map[string]os.FileInfo, []string, error) {
+	files := make(map[string]os.FileInfo)
+	ignored := []string{}
+
+	tracked := make(map[string]bool)
+
+	for _, e := range idx.Entries {
+		for name := e.Name; name != "."; name = path.Dir(name) {
+			tracked[name] = true
+		}
+	}
+
+	explicit := func(name string) bool {
+		for _, pattern := range spec.Patterns {
+			if pattern == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	err := git.WalkWorkTree(func(name string, info os.FileInfo) error {
+		isIgnored := !force && !tracked[name] && matcher.Ignored(name, info.IsDir())
+
+		if isIgnored && explicit(name) {
+			ignored = append(ignored, name)
+		}
+
+		if info.IsDir() {
+			if isIgnored || !spec.MatchesDirectory(name) {
+				return filepath.SkipDir
+			}
+
+			matcher.EnterDir(name)
+
+			return nil
+		}
+
+		if !isIgnored && spec.Match(name) {
+			files[name] = info
+		}
+
+		return nil
+	})
+
+	return files, ignored, err
+}
+
+type addOptions struct {
+	all         bool
+	update      bool
+	dryRun      bool
+	verbose     bool
+	intentToAdd bool
+	force       bool
+}
+
+// Stage the files matched by spec into idx, returning the report lines and
+// the ignored paths that were named explicitly but not staged.
+func addToIndex(git *fs.Git, idx *index.Index, spec pathspec.Pathspec, matcher *ignore.Matcher, opts addOptions) ([]string, []string, error) {
+	files, ignored, err := listWorkTreeFiles(git, idx, spec, matcher, opts.force)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matched := make([]bool, len(spec.Patterns))
+	markMatched := func(name string) {
+		for i := range spec.Patterns {
+			matched[i] = matched[i] || spec.MatchPattern(i, name)
+		}
+	}
+
+	tracked := make(map[string]bool)
+
+	for _, e := range idx.Entries {
+		if spec.Match(e.Name) {
+			tracked[e.Name] = true
+			markMatched(e.Name)
+		}
+	}
+
+	for _, name := range ignored {
+		markMatched(name)
+	}
+
+	names := []string{}
+
+	for name := range files {
+		markMatched(name)
+		names = append(names, name)
+	}
+
+	for i, ok := range matched {
+		if !ok {
+			return nil, nil, errors.GitError{Message: fmt.Sprintf("pathspec '%s' did not match any files", spec.Patterns[i])}
+		}
+	}
+
+	report := []string{}
+
+	// Paths staged before but gone from the working tree are removed.
+	if !opts.intentToAdd {
+		for name := range tracked {
+			if _, ok := files[name]; !ok {
+				report = append(report, fmt.Sprintf("remove '%s'", name))
+
+				if !opts.dryRun {
+					idx.Remove(name)
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		info := files[name]
+		existing, ok := idx.Entry(name, index.Merged)
+
+		if opts.update && !tracked[name] {
+			continue
+		}
+
+		if opts.intentToAdd {
+			if tracked[name] {
+				continue
+			}
+
+			report = append(report, fmt.Sprintf("add '%s'", name))
+
+			if opts.dryRun {
+				continue
+			}
+
+			// Intent-to-add entries point at the empty blob.
+			hash, err := git.WriteObject(objfile.Blob, nil)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			e := &index.Entry{
+				Name:        name,
+				Mode:        tree.ModeFromFileInfo(info),
+				Hash:        hash,
+				IntentToAdd: true,
+			}
+			e.FillStat(info)
+			e.Size = 0
+
+			idx.Add(e)
+
+			continue
+		}
+
+		mode := tree.ModeFromFileInfo(info)
+
+		// Files whose stat data is unchanged are not rehashed.
+		if ok && !existing.IntentToAdd && existing.Mode == mode && existing.StatMatches(info) {
+			continue
+		}
+
+		hash, err := hashWorkTreeFile(git, name, info, !opts.dryRun)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !ok || existing.IntentToAdd || existing.Hash != hash || existing.Mode != mode {
+			report = append(report, fmt.Sprintf("add '%s'", name))
+		}
+
+		if opts.dryRun {
+			continue
+		}
+
+		e := &index.Entry{Name: name, Mode: mode, Hash: hash}
+		e.FillStat(info)
+
+		idx.Add(e)
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		return strings.SplitN(report[i], " ", 2)[1] < strings.SplitN(report[j], " ", 2)[1]
+	})
+
+	return report, ignored, nil
+}
+
+var AddCommand = &cli.Command{
+	Name:      "add",
+	HelpName:  "add",
+	Usage:     "Add file contents to the index",
+	ArgsUsage: "[-A | -u] [-n] [-v] [-N] [-f] [<pathspec>...]",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "all",
+			Aliases: []string{"A"},
+			Value:   false,
+			Usage:   "Stage new, modified and deleted files, in the whole tree if no pathspec is given.",
+		},
+		&cli.BoolFlag{
+			Name:    "update",
+			Aliases: []string{"u"},
+			Value:   false,
+			Usage:   "Only stage modified and deleted files that are already tracked.",
+		},
+		&cli.BoolFlag{
+			Name:    "dry-run",
+			Aliases: []string{"n"},
+			Value:   false,
+			Usage:   "Don't actually add the file(s), just show what would be staged.",
+		},
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+			Value:   false,
+			Usage:   "Be verbose.",
+		},
+		&cli.BoolFlag{
+			Name:    "intent-to-add",
+			Aliases: []string{"N"},
+			Value:   false,
+			Usage:   "Record only the fact that the path will be added later.",
+		},
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"f"},
+			Value:   false,
+			Usage:   "Allow adding otherwise ignored files.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for add command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		opts := addOptions{
+			all:         c.Bool("all"),
+			update:      c.Bool("update"),
+			dryRun:      c.Bool("dry-run"),
+			verbose:     c.Bool("verbose"),
+			intentToAdd: c.Bool("intent-to-add"),
+			force:       c.Bool("force"),
+		}
+
+		if opts.all && opts.update {
+			return cli.Exit("-A and -u are mutually incompatible", 128)
+		}
+
+		if len(args) == 0 && !opts.all && !opts.update {
+			fmt.Fprintln(c.App.Writer, "Nothing specified, nothing added.")
+
+			return nil
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		if _, err = repo.Worktree(); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+
+		spec, err := getPathspec(git, c, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		idx, err := git.ReadIndex()
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		cfg, err := repo.Config(c.Context)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		matcher, err := ignoreMatcher(git, cfg)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		report, ignored, err := addToIndex(git, idx, spec, matcher, opts)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if opts.dryRun || opts.verbose {
+			for _, line := range report {
+				fmt.Fprintln(c.App.Writer, line)
+			}
+		}
+
+		if !opts.dryRun {
+			if err = git.WriteIndex(idx); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if len(ignored) > 0 {
+			message := "The following paths are ignored by one of your .gitignore files:\n"
+			message += strings.Join(ignored, "\n")
+			message += "\nUse -f if you really want to add them."
+
+			return cli.Exit(message, 1)
+		}
+
+		return nil
+	},
+}
@@ -1,20 +1,283 @@
 package commands
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
 	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
-	fs "github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
-	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
 )
 
+// Write the content of an object for humans: trees, of the given object
+// format, are listed like ls-tree does, everything else is shown as is.
+func prettyPrintObject(w io.Writer, format ditto.ObjectFormat, t ditto.ObjectType, r io.Reader) error {
+	if t != ditto.TreeObject {
+		_, err := io.Copy(w, r)
+
+		return err
+	}
+
+	iterator := tree.TreeEntryIterator(r, format)
+
+	for {
+		entry, err := iterator()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, entry.String(false))
+	}
+}
+
+const defaultBatchFormat = "%(objectname) %(objecttype) %(objectsize)"
+
+// Expand the %(atom) placeholders of a --batch-check format for an object.
+func expandBatchFormat(format string, hash ditto.Hash, info ditto.ObjectInfo, rest string) (string, error) {
+	var out strings.Builder
+
+	for {
+		start := strings.Index(format, "%(")
+
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(format[start:], ')')
+
+		if end < 0 {
+			break
+		}
+
+		out.WriteString(format[:start])
+
+		atom := format[start+2 : start+end]
+
+		switch atom {
+		case "objectname":
+			out.WriteString(hash.String())
+		case "objecttype":
+			out.WriteString(info.Type.String())
+		case "objectsize":
+			out.WriteString(strconv.FormatInt(info.Size, 10))
+		case "objectsize:disk":
+			out.WriteString(strconv.FormatInt(info.DiskSize, 10))
+		case "deltabase":
+			out.WriteString(info.DeltaBase.String())
+		case "rest":
+			out.WriteString(rest)
+		default:
+			return "", errors.GitError{Message: fmt.Sprintf("unknown format element: %%(%s)", atom)}
+		}
+
+		format = format[start+end+1:]
+	}
+
+	out.WriteString(format)
+
+	return out.String(), nil
+}
+
+// Streams objects named on the input, for --batch and its variants.
+type batchWriter struct {
+	ctx    context.Context
+	repo   *ditto.Repository
+	out    *bufio.Writer
+	format string
+}
+
+// Write the record of one object: its formatted header and, if asked for,
+// its content.
+func (b *batchWriter) writeObject(name string, contents bool) error {
+	rest := ""
+
+	// Only formats using %(rest) split the object name off the line.
+	if strings.Contains(b.format, "%(rest)") {
+		if i := strings.IndexAny(name, " \t"); i >= 0 {
+			name, rest = name[:i], strings.TrimLeft(name[i+1:], " \t")
+		}
+	}
+
+	hash, err := b.repo.Resolve(b.ctx, name)
+
+	if _, ok := err.(ditto.AmbiguousRevisionError); ok {
+		_, err = fmt.Fprintf(b.out, "%s ambiguous\n", name)
+
+		return err
+	}
+
+	var info ditto.ObjectInfo
+
+	if err == nil {
+		info, err = b.repo.Stat(b.ctx, hash)
+	}
+
+	if err != nil {
+		_, err = fmt.Fprintf(b.out, "%s missing\n", name)
+
+		return err
+	}
+
+	header, err := expandBatchFormat(b.format, hash, info, rest)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(b.out, header)
+
+	if !contents {
+		return nil
+	}
+
+	obj, err := b.repo.Object(b.ctx, hash)
+
+	if err != nil {
+		return err
+	}
+
+	b.out.Write(obj.Data)
+
+	return b.out.WriteByte('\n')
+}
+
+// Run cat-file in one of its batch modes, reading object names or commands
+// from the input until it ends.
+func runBatch(c *cli.Context, repo *ditto.Repository) error {
+	b := &batchWriter{
+		ctx:    c.Context,
+		repo:   repo,
+		out:    bufio.NewWriter(c.App.Writer),
+		format: defaultBatchFormat,
+	}
+
+	defer b.out.Flush()
+
+	contents := c.IsSet("batch")
+	flag := "batch-check"
+
+	if contents {
+		flag = "batch"
+	}
+
+	if c.IsSet(flag) {
+		if format := c.Generic(flag).(*optionalValue).value; format != "" {
+			b.format = format
+		}
+	}
+
+	// Without --buffer, output is flushed after every object.
+	flush := func() error {
+		if c.Bool("buffer") {
+			return nil
+		}
+
+		return b.out.Flush()
+	}
+
+	if c.Bool("batch-all-objects") {
+		hashes, err := repo.ListObjects(c.Context)
+
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range hashes {
+			if err = b.writeObject(hash.String(), contents); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	scanner := bufio.NewScanner(c.App.Reader)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !c.Bool("batch-command") {
+			if err := b.writeObject(line, contents); err != nil {
+				return err
+			}
+
+			if err := flush(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		command := strings.SplitN(line, " ", 2)
+		var err error
+
+		switch {
+		case command[0] == "contents" && len(command) == 2:
+			err = b.writeObject(command[1], true)
+		case command[0] == "info" && len(command) == 2:
+			err = b.writeObject(command[1], false)
+		case command[0] == "flush" && len(command) == 1:
+			if !c.Bool("buffer") {
+				return errors.GitError{Message: "flush is only for --buffer mode"}
+			}
+
+			err = b.out.Flush()
+
+			continue
+		default:
+			return errors.GitError{Message: fmt.Sprintf("unknown command: '%s'", line)}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = flush(); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// The single mode cat-file runs in, from its mutually exclusive flags.
+func catFileMode(c *cli.Context) (string, error) {
+	mode := ""
+
+	for _, flag := range []string{"t", "s", "e", "p"} {
+		if !c.Bool(flag) {
+			continue
+		}
+
+		if mode != "" {
+			return "", errors.GitError{Message: fmt.Sprintf("options '-%s' and '-%s' cannot be used together", mode, flag)}
+		}
+
+		mode = flag
+	}
+
+	return mode, nil
+}
+
 var CatFileCommand = &cli.Command{
-	Name:     "cat-file",
-	HelpName: "cat-file",
-	Usage:    "Provide content or type and size information for repository objects",
+	Name:      "cat-file",
+	HelpName:  "cat-file",
+	Usage:     "Provide content or type and size information for repository objects",
+	ArgsUsage: "(-t | -s | -e | -p | <type>) <object> | (--batch[=<format>] | --batch-check[=<format>] | --batch-command) [--batch-all-objects] [--buffer]",
 
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -23,78 +286,164 @@ var CatFileCommand = &cli.Command{
 			Value:   false,
 			Usage:   "Pretty-print the contents of <object> based on its type.",
 		},
+		&cli.BoolFlag{
+			Name:  "t",
+			Value: false,
+			Usage: "Instead of the content, show the object type identified by <object>.",
+		},
+		&cli.BoolFlag{
+			Name:  "s",
+			Value: false,
+			Usage: "Instead of the content, show the object size identified by <object>.",
+		},
+		&cli.BoolFlag{
+			Name:  "e",
+			Value: false,
+			Usage: "Exit with zero status if <object> exists and is a valid object.",
+		},
+		&cli.GenericFlag{
+			Name:  "batch",
+			Value: &optionalValue{},
+			Usage: "Print object information and contents for each object name read from the input, using the given format for the header.",
+		},
+		&cli.GenericFlag{
+			Name:  "batch-check",
+			Value: &optionalValue{},
+			Usage: "Print object information for each object name read from the input, using the given format.",
+		},
+		&cli.BoolFlag{
+			Name:  "batch-command",
+			Value: false,
+			Usage: "Read commands from the input: 'contents <object>', 'info <object>' or 'flush'.",
+		},
+		&cli.BoolFlag{
+			Name:  "batch-all-objects",
+			Value: false,
+			Usage: "Instead of reading a list of objects on the input, act on all objects in the repository.",
+		},
+		&cli.BoolFlag{
+			Name:  "buffer",
+			Value: false,
+			Usage: "Buffer the output of the batch modes instead of flushing it after each object.",
+		},
 	},
 
-	Before: func(c *cli.Context) error {
+	Action: func(c *cli.Context) error {
 		utils.InfoLogger.Println("Validating preconditions for cat-file command.")
 
-		if c.Args().Len() < 1 {
-			utils.ErrorLogger.Println("Need blob SHA to do a cat-file.")
+		args, err := commandArgs(c)
 
-			cli.Exit("Invalid argument", 1)
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
 		}
 
-		if !c.Bool("pretty-print") {
-			err := &errors.GitError{
-				Message: "cat-file called without -p flag.",
-			}
+		mode, err := catFileMode(c)
 
-			utils.ErrorLogger.Println(err.Error())
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
 
-			cli.Exit(err.Error(), 1)
+		if c.IsSet("batch") || c.IsSet("batch-check") || c.Bool("batch-command") {
+			if mode != "" || len(args) > 0 {
+				return cli.Exit("usage: git cat-file "+c.Command.ArgsUsage, 129)
+			}
 
-			return err
-		}
+			repo, err := openRepository(c)
 
-		return nil
-	},
+			if err != nil {
+				utils.ErrorLogger.Println(err.Error())
 
-	Action: func(c *cli.Context) error {
-		blobSha := c.Args().Get(0)
+				return cli.Exit(err.Error(), 128)
+			}
 
-		utils.InfoLogger.Printf("cat-file for blob SHA: %s\n", blobSha)
+			if err = runBatch(c, repo); err != nil {
+				utils.ErrorLogger.Println(err.Error())
 
-		workingDir := c.String("C")
+				return cli.Exit(err.Error(), 128)
+			}
 
-		git, err := fs.FindGit(workingDir)
+			return nil
+		}
 
-		if err != nil {
-			utils.ErrorLogger.Println(err.Error())
+		// Without a mode flag, the expected type comes first.
+		wantArgs := 1
 
-			cli.Exit(err.Error(), 1)
+		if mode == "" {
+			wantArgs = 2
+		}
 
-			return err
+		if len(args) != wantArgs {
+			return cli.Exit("usage: git cat-file "+c.Command.ArgsUsage, 129)
 		}
 
-		reader, err := git.GetObjectReader(blobSha)
+		rev := args[wantArgs-1]
+
+		utils.InfoLogger.Printf("cat-file for object: %s\n", rev)
+
+		repo, err := openRepository(c)
 
 		if err != nil {
 			utils.ErrorLogger.Println(err.Error())
 
-			return cli.Exit(err.Error(), 1)
+			return cli.Exit(err.Error(), 128)
 		}
 
-		objreader, err := objfile.NewReader(reader)
+		hash, err := repo.Resolve(c.Context, rev)
 
 		if err != nil {
 			utils.ErrorLogger.Println(err.Error())
 
-			return cli.Exit(err.Error(), 1)
+			return cli.Exit(err.Error(), 128)
 		}
 
-		objtype, _, err := objreader.Header()
+		if mode == "" {
+			t, err := ditto.ParseObjectType(args[0])
 
-		if objtype != objfile.Blob {
-			err = errors.GitError{Message: "Cannot cat-file a non blob"}
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			// Asking for a type dereferences tags and commits as needed.
+			if hash, err = repo.Peel(c.Context, hash, t, rev); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
 		}
 
+		info, err := repo.Stat(c.Context, hash)
+
 		if err != nil {
 			utils.ErrorLogger.Println(err.Error())
 
-			return cli.Exit(err.Error(), 1)
+			if mode == "e" {
+				return cli.Exit("", 1)
+			}
+
+			return cli.Exit(fmt.Sprintf("Not a valid object name %s", rev), 128)
 		}
 
-		io.Copy(c.App.Writer, objreader)
+		switch mode {
+		case "t":
+			fmt.Fprintln(c.App.Writer, info.Type)
+		case "s":
+			fmt.Fprintln(c.App.Writer, info.Size)
+		case "e":
+		default:
+			var obj *ditto.Object
+
+			if obj, err = repo.Object(c.Context, hash); err != nil {
+				break
+			}
+
+			if mode == "p" {
+				err = prettyPrintObject(c.App.Writer, obj.Hash.Format(), obj.Type, bytes.NewReader(obj.Data))
+			} else {
+				_, err = c.App.Writer.Write(obj.Data)
+			}
+		}
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
 
 		return nil
 	},
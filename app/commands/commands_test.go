@@ -2,6 +2,7 @@ package commands_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/commands"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/discover"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
 	"github.com/urfave/cli/v2"
 )
@@ -52,6 +55,26 @@ func TestMain(m *testing.M) {
 		commands.InitCommand,
 		commands.CatFileCommand,
 		commands.HashObjectCommand,
+		commands.CommitTreeCommand,
+		commands.PackObjectsCommand,
+		commands.IndexPackCommand,
+		commands.MkTagCommand,
+		commands.TagCommand,
+		commands.WriteTreeCommand,
+		commands.LsTreeCommand,
+		commands.AddCommand,
+		commands.StatusCommand,
+		commands.UpdateRefCommand,
+		commands.SymbolicRefCommand,
+		commands.ShowRefCommand,
+		commands.RevParseCommand,
+		commands.ConfigCommand,
+		commands.LogCommand,
+		commands.RevListCommand,
+		commands.CommitGraphCommand,
+		commands.DiffCommand,
+		commands.MergeBaseCommand,
+		commands.MergeCommand,
 	}
 
 	app.Flags = []cli.Flag{
@@ -128,6 +151,84 @@ func TestHashObject(t *testing.T) {
 	})
 }
 
+func TestHashObjectInput(t *testing.T) {
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	buf.Reset()
+
+	defer func() { app.Reader = os.Stdin }()
+
+	sum, err := ioutil.ReadFile("testdata/test.sum")
+
+	utils.Expect(t, err, nil)
+
+	commitContent := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author A U Thor <author@example.com> 1112911993 -0700\n" +
+		"committer C O Mitter <committer@example.com> 1112912053 -0700\n" +
+		"\n" +
+		"Initial commit\n"
+
+	cases := []struct {
+		testArgs []string
+		input    string
+		expected string
+	}{
+		{
+			testArgs: []string{"foo", "-C", gitDir, "hash-object", "--stdin"},
+			input:    "a\n",
+			expected: "78981922613b2afb6025042ff6bd878ac1994e85\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "hash-object", "-t", "tree", "--stdin"},
+			expected: "4b825dc642cb6eb9a060e54bf8d69288fbee4904\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "hash-object", "-t", "commit", "-w", "--stdin"},
+			input:    commitContent,
+			expected: "07aa2d0808984a15395272a831194def44801887\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "hash-object", "-t", "foo", "--literally", "--stdin"},
+			input:    "x",
+			expected: "e7ba76d95050d6668b78f62010713932d4576a19\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "hash-object", "--stdin", "testdata/test.dat"},
+			input:    "a\n",
+			expected: "78981922613b2afb6025042ff6bd878ac1994e85\n" + string(sum),
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "hash-object", "--stdin-paths"},
+			input:    "testdata/test.dat\ntestdata/test.dat\n",
+			expected: string(sum) + string(sum),
+		},
+	}
+
+	for _, c := range cases {
+		app.Reader = strings.NewReader(c.input)
+
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "cat-file", "-t", "07aa2d0808984a15395272a831194def44801887"}), nil)
+	utils.Expect(t, buf.String(), "commit\n")
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
 func TestCatFile(t *testing.T) {
 	data, err := ioutil.ReadFile("testdata/test.sum")
 
@@ -148,6 +249,8 @@ func TestCatFile(t *testing.T) {
 		{testArgs: []string{"foo", "init", gitDir}},
 		{testArgs: []string{"foo", "-C", gitDir, "hash-object", "-w", "testdata/test.dat"}},
 		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "-p", sha}, testStdout: true, stdoutFile: "testdata/test.dat"},
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "blob", sha[:7]}, testStdout: true, stdoutFile: "testdata/test.dat"},
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "-e", sha}},
 	}
 
 	for _, c := range cases {
@@ -170,3 +273,1658 @@ func TestCatFile(t *testing.T) {
 		}
 	})
 }
+
+// Run the test app, first resetting slice flags. urfave/cli keeps the values
+// of repeatable flags on the shared flag definitions across runs.
+func runApp(args []string) error {
+	for _, command := range app.Commands {
+		for _, flag := range command.Flags {
+			if sliceFlag, ok := flag.(*cli.StringSliceFlag); ok {
+				sliceFlag.Value = nil
+			}
+		}
+	}
+
+	return app.Run(args)
+}
+
+// Fix the author and committer identities so that commit hashes are stable.
+func TestInitOptions(t *testing.T) {
+	templates := filepath.Join(gitDir, "templates")
+	bare := filepath.Join(gitDir, "bare.git")
+	separate := filepath.Join(gitDir, "separate.git")
+	work := filepath.Join(gitDir, "work")
+
+	utils.Expect(t, os.MkdirAll(filepath.Join(templates, "info"), 0755), nil)
+	utils.Expect(t, ioutil.WriteFile(filepath.Join(templates, "description"), []byte("Templated\n"), 0644), nil)
+	utils.Expect(t, ioutil.WriteFile(filepath.Join(templates, "info", "exclude"), []byte("*.o\n"), 0644), nil)
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{
+			testArgs: []string{"foo", "init", "-b", "trunk", "--template", templates, gitDir},
+			expected: "Initialized empty Git repository in " + filepath.Join(gitDir, ".git") + "/\n",
+		},
+		{
+			testArgs: []string{"foo", "init", "-b", "other", gitDir},
+			expected: "Reinitialized existing Git repository in " + filepath.Join(gitDir, ".git") + "/\n",
+		},
+		{
+			testArgs: []string{"foo", "init", "--bare", bare},
+			expected: "Initialized empty Git repository in " + bare + "/\n",
+		},
+		{
+			testArgs: []string{"foo", "init", "-q", "--separate-git-dir", separate, work},
+		},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// Re-initializing leaves HEAD alone.
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "HEAD"), "ref: refs/heads/trunk\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "description"), "Templated\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "info", "exclude"), "*.o\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "config"), "[core]\n"+
+		"\trepositoryformatversion = 0\n"+
+		"\tfilemode = true\n"+
+		"\tbare = false\n"+
+		"\tlogallrefupdates = true\n")
+
+	utils.ExpectFileContent(t, filepath.Join(bare, "config"), "[core]\n"+
+		"\trepositoryformatversion = 0\n"+
+		"\tfilemode = true\n"+
+		"\tbare = true\n")
+	utils.Expect(t, utils.PathExists(filepath.Join(bare, "refs", "heads")), true)
+
+	utils.ExpectFileContent(t, filepath.Join(work, ".git"), "gitdir: "+separate+"\n")
+	utils.Expect(t, utils.PathExists(filepath.Join(separate, "HEAD")), true)
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func setTestIdent(t *testing.T) {
+	env := map[string]string{
+		"GIT_AUTHOR_NAME":     "A U Thor",
+		"GIT_AUTHOR_EMAIL":    "author@example.com",
+		"GIT_AUTHOR_DATE":     "1112911993 -0700",
+		"GIT_COMMITTER_NAME":  "C O Mitter",
+		"GIT_COMMITTER_EMAIL": "committer@example.com",
+		"GIT_COMMITTER_DATE":  "1112912053 -0700",
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+}
+
+func TestCatFileTypes(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "write-tree"},
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial", "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	// The commit hash is the last line of output.
+	output := strings.Fields(buf.String())
+	commit := output[len(output)-1]
+	buf.Reset()
+
+	commitContent := "tree 45c21af186f9ffa4b124b583fde2b6ff53efa3b5\n" +
+		"author A U Thor <author@example.com> 1112911993 -0700\n" +
+		"committer C O Mitter <committer@example.com> 1112912053 -0700\n" +
+		"\n" +
+		"Initial\n"
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "-t", commit}, expected: "commit\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "-s", commit}, expected: fmt.Sprintf("%d\n", len(commitContent))},
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "-p", commit}, expected: commitContent},
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "-t", commit + ":dir"}, expected: "tree\n"},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "cat-file", "-p", commit + "^{tree}"},
+			expected: "100644 blob 78981922613b2afb6025042ff6bd878ac1994e85\ta.txt\n" +
+				"100644 blob 587be6b4c3f93f93c489c0111bba5596147a26cb\tdir.txt\n" +
+				"040000 tree 40f4f0941fcf256f06c7f3b34b7d116f5376cbc6\tdir\n",
+		},
+		{testArgs: []string{"foo", "-C", gitDir, "cat-file", "blob", commit + ":a.txt"}, expected: "a\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestCatFileBatch(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "write-tree"},
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial", "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	defer func() { app.Reader = os.Stdin }()
+
+	cases := []struct {
+		testArgs []string
+		input    string
+		expected string
+	}{
+		{
+			testArgs: []string{"foo", "-C", gitDir, "cat-file", "--batch-check"},
+			input:    "45c21af186f9ffa4b124b583fde2b6ff53efa3b5:a.txt\nmissing\n",
+			expected: "78981922613b2afb6025042ff6bd878ac1994e85 blob 2\nmissing missing\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "cat-file", "--batch"},
+			input:    "78981922613b2afb6025042ff6bd878ac1994e85\n",
+			expected: "78981922613b2afb6025042ff6bd878ac1994e85 blob 2\na\n\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "cat-file", "--batch-check=%(objecttype) %(rest)"},
+			input:    "40f4f0941fcf256f06c7f3b34b7d116f5376cbc6 the subtree\n",
+			expected: "tree the subtree\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "cat-file", "--batch-command"},
+			input:    "info 587be6b4c3f93f93c489c0111bba5596147a26cb\ncontents 78981922613b2afb6025042ff6bd878ac1994e85\n",
+			expected: "587be6b4c3f93f93c489c0111bba5596147a26cb blob 2\n" +
+				"78981922613b2afb6025042ff6bd878ac1994e85 blob 2\na\n\n",
+		},
+	}
+
+	for _, c := range cases {
+		app.Reader = strings.NewReader(c.input)
+
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// All the objects written so far: four blobs, three trees and a commit.
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "cat-file", "--batch-all-objects", "--batch-check=%(objecttype)"}), nil)
+	utils.Expect(t, len(strings.Fields(buf.String())), 8)
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestCommitTree(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	emptyTree, err := git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+	utils.Expect(t, emptyTree.String(), "4b825dc642cb6eb9a060e54bf8d69288fbee4904")
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{
+			testArgs: []string{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree.String()},
+			expected: "07aa2d0808984a15395272a831194def44801887\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "commit-tree", "-p", "07aa2d0808984a15395272a831194def44801887", "-m", "Second", "-m", "Body", emptyTree.String()},
+			expected: "a01e2a701200d3efcb2c74b66ee9a4be855ff583\n",
+		},
+		{
+			// Flags may follow the tree, as in git.
+			testArgs: []string{"foo", "-C", gitDir, "commit-tree", emptyTree.String(), "-p", "07aa2d0808984a15395272a831194def44801887", "-m", "Second", "-m", "Body"},
+			expected: "a01e2a701200d3efcb2c74b66ee9a4be855ff583\n",
+		},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// Without an identity in the environment, the config gives it, the
+	// committer one ahead of user.name.
+	setup := [][]string{
+		{"foo", "-C", gitDir, "config", "user.name", "U"},
+		{"foo", "-C", gitDir, "config", "user.email", "u@example.com"},
+		{"foo", "-C", gitDir, "config", "committer.name", "C"},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME"} {
+		os.Unsetenv(name)
+	}
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-tree", "-m", "Config", emptyTree.String()}), nil)
+	utils.Expect(t, buf.String(), "e336ab600339b5553f28a2d9f7f67beb8bcf7297\n")
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(src)
+
+	if err != nil {
+		t.Fatalf("Could not read %s: %s", src, err.Error())
+	}
+
+	if err = ioutil.WriteFile(dst, data, 0644); err != nil {
+		t.Fatalf("Could not write %s: %s", dst, err.Error())
+	}
+}
+
+func TestCatFilePacked(t *testing.T) {
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	packs, err := filepath.Glob("testdata/pack/pack-*")
+	utils.Expect(t, err, nil)
+
+	for _, p := range packs {
+		copyFile(t, p, filepath.Join(gitDir, ".git/objects/pack", filepath.Base(p)))
+	}
+
+	buf.Reset()
+
+	// The blob is stored as a delta against a larger blob in the pack.
+	err = app.Run([]string{"foo", "-C", gitDir, "cat-file", "-p", "c874c654bf4315996ffd19fb6af81d75992cb6e3"})
+
+	utils.Expect(t, err, nil)
+	utils.ExpectFileContent(t, "testdata/packed.dat", buf.String())
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestPackObjects(t *testing.T) {
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+	buf.Reset()
+
+	utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "hash-object", "-w", "testdata/test.dat"}), nil)
+
+	sha := strings.TrimSpace(buf.String())
+	buf.Reset()
+
+	app.Reader = strings.NewReader(sha + "\n")
+	defer func() { app.Reader = os.Stdin }()
+
+	err := app.Run([]string{"foo", "-C", gitDir, "pack-objects", filepath.Join(gitDir, ".git/objects/pack/pack")})
+	utils.Expect(t, err, nil)
+
+	checksum := strings.TrimSpace(buf.String())
+	buf.Reset()
+
+	utils.Expect(t, utils.PathExists(filepath.Join(gitDir, ".git/objects/pack", "pack-"+checksum+".idx")), true)
+
+	// With the loose object gone, the object must be read from the new pack.
+	utils.Expect(t, os.RemoveAll(filepath.Join(gitDir, ".git/objects", sha[:2])), nil)
+
+	err = app.Run([]string{"foo", "-C", gitDir, "cat-file", "-p", sha})
+	utils.Expect(t, err, nil)
+	utils.ExpectFileContent(t, "testdata/test.dat", buf.String())
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestIndexPack(t *testing.T) {
+	packs, err := filepath.Glob("testdata/pack/pack-*.pack")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(packs), 1)
+
+	idxPath := filepath.Join(os.TempDir(), "git_ditto_test_index_pack.idx")
+	defer os.Remove(idxPath)
+
+	err = app.Run([]string{"foo", "index-pack", "-o", idxPath, packs[0]})
+	utils.Expect(t, err, nil)
+	utils.Expect(t, buf.String(), "cfcc643d103306a5ca2ba16d8ec63258f141cb5b\n")
+
+	buf.Reset()
+
+	expected, err := ioutil.ReadFile(strings.TrimSuffix(packs[0], ".pack") + ".idx")
+	utils.Expect(t, err, nil)
+
+	actual, err := ioutil.ReadFile(idxPath)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, bytes.Equal(actual, expected), true)
+}
+
+func TestTag(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	emptyTree, err := git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	buf.Reset()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree.String()}), nil)
+
+	head := strings.TrimSpace(buf.String())
+	buf.Reset()
+
+	err = ioutil.WriteFile(filepath.Join(gitDir, ".git/refs/heads/master"), []byte(head+"\n"), 0644)
+	utils.Expect(t, err, nil)
+
+	cases := []struct {
+		testArgs []string
+		expected string
+		ref      string
+		refValue string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "tag", "light"}, ref: "light", refValue: head},
+		{testArgs: []string{"foo", "-C", gitDir, "tag", "-m", "Release 1.0", "v1.0"}, ref: "v1.0", refValue: "71c355436410215b0c785456f6e89c4d19eedcd9"},
+		{testArgs: []string{"foo", "-C", gitDir, "tag"}, expected: "light\nv1.0\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "tag", "-d", "light"}, expected: "Deleted tag 'light' (was 07aa2d0)\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "tag", "-l", "v*"}, expected: "v1.0\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		if c.ref != "" {
+			utils.ExpectFileContent(t, filepath.Join(gitDir, ".git/refs/tags", c.ref), c.refValue+"\n")
+		}
+
+		buf.Reset()
+	}
+
+	// mktag accepts the canonical content of the annotated tag created above.
+	_, content, err := git.ReadObject("71c355436410215b0c785456f6e89c4d19eedcd9")
+	utils.Expect(t, err, nil)
+
+	app.Reader = bytes.NewReader(content)
+	defer func() { app.Reader = os.Stdin }()
+
+	utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "mktag"}), nil)
+	utils.Expect(t, buf.String(), "71c355436410215b0c785456f6e89c4d19eedcd9\n")
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+// Populate the working tree of the test repository.
+func writeWorkTree(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(gitDir, name)
+
+		utils.Expect(t, os.MkdirAll(filepath.Dir(path), 0755), nil)
+		utils.Expect(t, ioutil.WriteFile(path, []byte(content), 0644), nil)
+	}
+}
+
+// Files whose tree is 45c21af186f9ffa4b124b583fde2b6ff53efa3b5.
+var testWorkTree = map[string]string{
+	"a.txt":         "a\n",
+	"dir.txt":       "x\n",
+	"dir/b.txt":     "b\n",
+	"dir/sub/c.txt": "c\n",
+}
+
+func TestWriteTree(t *testing.T) {
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "write-tree"}, expected: "45c21af186f9ffa4b124b583fde2b6ff53efa3b5\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "ls-tree", "--name-only", "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"}, expected: "a.txt\ndir.txt\ndir\n"},
+	}
+
+	for _, c := range cases {
+		err := app.Run(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestAdd(t *testing.T) {
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		setup    func()
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "add", "-n", "dir"}, expected: "add 'dir/b.txt'\nadd 'dir/sub/c.txt'\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "add", "."}},
+		{testArgs: []string{"foo", "-C", gitDir, "write-tree"}, expected: "45c21af186f9ffa4b124b583fde2b6ff53efa3b5\n"},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "add", "-u", "-v"},
+			setup: func() {
+				writeWorkTree(t, map[string]string{"a.txt": "changed\n", "new.txt": "new\n"})
+				utils.Expect(t, os.Remove(filepath.Join(gitDir, "dir/sub/c.txt")), nil)
+			},
+			expected: "add 'a.txt'\nremove 'dir/sub/c.txt'\n",
+		},
+		{testArgs: []string{"foo", "-C", gitDir, "add", "-N", "-v", "new.txt"}, expected: "add 'new.txt'\n"},
+	}
+
+	for _, c := range cases {
+		if c.setup != nil {
+			c.setup()
+		}
+
+		err := app.Run(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	idx, err := git.ReadIndex()
+	utils.Expect(t, err, nil)
+
+	names := []string{}
+
+	for _, e := range idx.Entries {
+		names = append(names, e.Name)
+		utils.Expect(t, e.MTime.IsZero(), false)
+	}
+
+	utils.Expect(t, names, []string{"a.txt", "dir.txt", "dir/b.txt", "new.txt"})
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestStatus(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "add", "."}), nil)
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		setup    func()
+		expected string
+	}{
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s", "-b"},
+			expected: "## No commits yet on master\nA  a.txt\nA  dir.txt\nA  dir/b.txt\nA  dir/sub/c.txt\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status"},
+			setup: func() {
+				utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "write-tree"}), nil)
+				buf.Reset()
+				utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-tree", "-m", "Initial", "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"}), nil)
+				writeWorkTree(t, map[string]string{".git/refs/heads/master": buf.String()})
+				buf.Reset()
+			},
+			expected: "On branch master\nnothing to commit, working tree clean\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s"},
+			setup: func() {
+				writeWorkTree(t, map[string]string{"a.txt": "changed\n", "new.txt": "new\n"})
+				utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "add", "new.txt"}), nil)
+				utils.Expect(t, os.Remove(filepath.Join(gitDir, "dir/sub/c.txt")), nil)
+				writeWorkTree(t, map[string]string{".gitignore": "*.log\n", "x.log": "l\n", "u/f": "u\n"})
+			},
+			expected: " M a.txt\n D dir/sub/c.txt\nA  new.txt\n?? .gitignore\n?? u/\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s", "--ignored", "--untracked-files=all"},
+			expected: " M a.txt\n D dir/sub/c.txt\nA  new.txt\n?? .gitignore\n?? u/f\n!! x.log\n",
+		},
+		{
+			// The user's excludes file applies too, and directories holding
+			// only ignored files are reported once.
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s", "--ignored"},
+			setup: func() {
+				writeWorkTree(t, map[string]string{".git/excludes": "*.tmp\n", "t.tmp": "t\n", "logs/a.log": "a\n", "logs/b.log": "b\n", "u/g.log": "g\n"})
+				utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "config", "core.excludesFile", filepath.Join(gitDir, ".git", "excludes")}), nil)
+			},
+			expected: " M a.txt\n D dir/sub/c.txt\nA  new.txt\n?? .gitignore\n?? u/\n!! logs/\n!! t.tmp\n!! u/g.log\n!! x.log\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s", "dir"},
+			expected: " D dir/sub/c.txt\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status", "--porcelain=v2", "-b"},
+			expected: "# branch.oid 1b7a42e786b60f6515a351a25f1745a3a176ef24\n" +
+				"# branch.head master\n" +
+				"1 .M N... 100644 100644 100644 78981922613b2afb6025042ff6bd878ac1994e85 78981922613b2afb6025042ff6bd878ac1994e85 a.txt\n" +
+				"1 .D N... 100644 100644 000000 f2ad6c76f0115a6ba5b00456a849810e7ec0af20 f2ad6c76f0115a6ba5b00456a849810e7ec0af20 dir/sub/c.txt\n" +
+				"1 A. N... 000000 100644 100644 0000000000000000000000000000000000000000 3e757656cf36eca53338e520d134963a44f793f8 new.txt\n" +
+				"? .gitignore\n" +
+				"? u/\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status"},
+			expected: "On branch master\n" +
+				"Changes to be committed:\n" +
+				"  (use \"git restore --staged <file>...\" to unstage)\n" +
+				"\tnew file:   new.txt\n" +
+				"\n" +
+				"Changes not staged for commit:\n" +
+				"  (use \"git add/rm <file>...\" to update what will be committed)\n" +
+				"  (use \"git restore <file>...\" to discard changes in working directory)\n" +
+				"\tmodified:   a.txt\n" +
+				"\tdeleted:    dir/sub/c.txt\n" +
+				"\n" +
+				"Untracked files:\n" +
+				"  (use \"git add <file>...\" to include in what will be committed)\n" +
+				"\t.gitignore\n" +
+				"\tu/\n" +
+				"\n",
+		},
+		{
+			// A file where a tracked directory was.
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s"},
+			setup: func() {
+				utils.Expect(t, os.RemoveAll(filepath.Join(gitDir, "dir/sub")), nil)
+				writeWorkTree(t, map[string]string{"dir/sub": "file\n"})
+			},
+			expected: " M a.txt\n D dir/sub/c.txt\nA  new.txt\n?? .gitignore\n?? dir/sub\n?? u/\n",
+		},
+		{
+			// Staged renames are paired up.
+			testArgs: []string{"foo", "-C", gitDir, "status", "--porcelain=v2", "--untracked-files=no"},
+			setup: func() {
+				utils.Expect(t, os.Rename(filepath.Join(gitDir, "dir.txt"), filepath.Join(gitDir, "renamed.txt")), nil)
+				utils.Expect(t, app.Run([]string{"foo", "-C", gitDir, "add", "dir.txt", "renamed.txt"}), nil)
+			},
+			expected: "" +
+				"1 .M N... 100644 100644 100644 78981922613b2afb6025042ff6bd878ac1994e85 78981922613b2afb6025042ff6bd878ac1994e85 a.txt\n" +
+				"1 .D N... 100644 100644 000000 f2ad6c76f0115a6ba5b00456a849810e7ec0af20 f2ad6c76f0115a6ba5b00456a849810e7ec0af20 dir/sub/c.txt\n" +
+				"1 A. N... 000000 100644 100644 0000000000000000000000000000000000000000 3e757656cf36eca53338e520d134963a44f793f8 new.txt\n" +
+				"2 R. N... 100644 100644 100644 587be6b4c3f93f93c489c0111bba5596147a26cb 587be6b4c3f93f93c489c0111bba5596147a26cb R100 renamed.txt\tdir.txt\n",
+		},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "status", "-s", "--untracked-files=no"},
+			expected: " M a.txt\n D dir/sub/c.txt\nA  new.txt\nR  dir.txt -> renamed.txt\n",
+		},
+	}
+
+	for _, c := range cases {
+		if c.setup != nil {
+			c.setup()
+		}
+
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestRefs(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	_, err = git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", "4b825dc642cb6eb9a060e54bf8d69288fbee4904"}), nil)
+
+	buf.Reset()
+
+	first := "07aa2d0808984a15395272a831194def44801887"
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "update-ref", "HEAD", first, ""}},
+		{testArgs: []string{"foo", "-C", gitDir, "symbolic-ref", "HEAD"}, expected: "refs/heads/master\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "symbolic-ref", "--short", "HEAD"}, expected: "master\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "update-ref", "refs/heads/topic", "master"}},
+		{testArgs: []string{"foo", "-C", gitDir, "tag", "v1"}},
+		{
+			testArgs: []string{"foo", "-C", gitDir, "show-ref", "--head"},
+			expected: first + " HEAD\n" + first + " refs/heads/master\n" + first + " refs/heads/topic\n" + first + " refs/tags/v1\n",
+		},
+		{testArgs: []string{"foo", "-C", gitDir, "show-ref", "-s", "topic"}, expected: first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "update-ref", "-d", "refs/heads/topic", first}},
+		{testArgs: []string{"foo", "-C", gitDir, "show-ref", "--heads"}, expected: first + " refs/heads/master\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "symbolic-ref", "HEAD", "refs/heads/other"}},
+		{testArgs: []string{"foo", "-C", gitDir, "symbolic-ref", "HEAD"}, expected: "refs/heads/other\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestRevParse(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	_, err = git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	first := "07aa2d0808984a15395272a831194def44801887"
+	second := "a01e2a701200d3efcb2c74b66ee9a4be855ff583"
+	emptyTree := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", first, "-m", "Second", "-m", "Body", emptyTree},
+		{"foo", "-C", gitDir, "update-ref", "-m", "first", "HEAD", first},
+		{"foo", "-C", gitDir, "update-ref", "HEAD", second},
+		{"foo", "-C", gitDir, "tag", "-m", "Tagged", "v1", "HEAD~"},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD", "master", "@"}, expected: second + "\n" + second + "\n" + second + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD~", "HEAD^1", "master~1^0"}, expected: first + "\n" + first + "\n" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "a01e2a7", "HEAD^{tree}", "v1^{}"}, expected: second + "\n" + emptyTree + "\n" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD:", ":/Initial", "HEAD^{/Second}"}, expected: emptyTree + "\n" + first + "\n" + second + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD~..master"}, expected: second + "\n^" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD@{1}", "master@{0}"}, expected: first + "\n" + second + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD@{now}", "master@{2000-01-01}"}, expected: second + "\n" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD^!", "HEAD^@"}, expected: second + "\n^" + first + "\n" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD^-", "HEAD^-1"}, expected: second + "\n^" + first + "\n" + second + "\n^" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "HEAD^!"}, expected: second + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "--is-bare-repository", "--git-dir"}, expected: "false\n.git\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "--git-dir", "--show-prefix", "--is-bare-repository", "HEAD"}, expected: ".git\n\nfalse\n" + second + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "HEAD", "--is-bare-repository", "HEAD~"}, expected: second + "\nfalse\n" + first + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "--short", "HEAD"}, expected: "a01e2a7\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "--abbrev-ref", "HEAD"}, expected: "master\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "--symbolic-full-name", "v1"}, expected: "refs/tags/v1\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-parse", "--verify", "master^{commit}"}, expected: second + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "ls-tree", "master"}},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestConfig(t *testing.T) {
+	global := filepath.Join(os.TempDir(), "git_ditto_test_global_config")
+
+	os.Setenv("GIT_CONFIG_GLOBAL", global)
+	os.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	t.Cleanup(func() {
+		os.Unsetenv("GIT_CONFIG_GLOBAL")
+		os.Unsetenv("GIT_CONFIG_NOSYSTEM")
+		os.Remove(global)
+	})
+
+	// New repositories start on init.defaultBranch.
+	utils.Expect(t, runApp([]string{"foo", "config", "--global", "init.defaultBranch", "main"}), nil)
+	utils.Expect(t, runApp([]string{"foo", "init", gitDir}), nil)
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	buf.Reset()
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "config", "core.bare", "false"},
+		{"foo", "-C", gitDir, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"},
+		{"foo", "-C", gitDir, "config", "--add", "remote.origin.fetch", "+refs/tags/*:refs/tags/*"},
+		{"foo", "-C", gitDir, "config", "--int", "pack.window", "1k"},
+		{"foo", "-C", gitDir, "config", "user.name", " Padded "},
+		{"foo", "-C", gitDir, "config", "--unset", "core.bare"},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "config"), "[core]\n"+
+		"\trepositoryformatversion = 0\n"+
+		"\tfilemode = true\n"+
+		"\tlogallrefupdates = true\n"+
+		"[remote \"origin\"]\n"+
+		"\tfetch = +refs/heads/*:refs/remotes/origin/*\n"+
+		"\tfetch = +refs/tags/*:refs/tags/*\n"+
+		"[pack]\n"+
+		"\twindow = 1024\n"+
+		"[user]\n"+
+		"\tname = \" Padded \"\n")
+
+	// A key without a value, which is not the same as true.
+	f, err := os.OpenFile(filepath.Join(gitDir, ".git", "config"), os.O_APPEND|os.O_WRONLY, 0644)
+	utils.Expect(t, err, nil)
+
+	_, err = f.WriteString("[extra]\n\tflag\n")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, f.Close(), nil)
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "config", "remote.origin.fetch"}, expected: "+refs/tags/*:refs/tags/*\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--get-all", "remote.origin.fetch"}, expected: "+refs/heads/*:refs/remotes/origin/*\n+refs/tags/*:refs/tags/*\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--get-regexp", "^(pack|user)\\."}, expected: "pack.window 1024\nuser.name  Padded \n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--type=bool", "pack.window"}, expected: "true\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--show-scope", "--get", "init.defaultbranch"}, expected: "global\tmain\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--show-origin", "--get", "pack.window"}, expected: "file:.git/config\t1024\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--local", "-l"}, expected: "core.repositoryformatversion=0\n" +
+			"core.filemode=true\n" +
+			"core.logallrefupdates=true\n" +
+			"remote.origin.fetch=+refs/heads/*:refs/remotes/origin/*\n" +
+			"remote.origin.fetch=+refs/tags/*:refs/tags/*\n" +
+			"pack.window=1024\n" +
+			"user.name= Padded \n" +
+			"extra.flag\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "extra.flag"}, expected: "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--bool", "extra.flag"}, expected: "true\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--get-regexp", "^extra\\."}, expected: "extra.flag\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "config", "--show-origin", "--get-regexp", "--type=bool", "^extra\\."}, expected: "file:.git/config\textra.flag true\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestLog(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	_, err = git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	emptyTree := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	filesTree := "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"
+	initial := "07aa2d0808984a15395272a831194def44801887"
+	files := "0f1b80a9b68c42e7a1dec842251a239a27d9e8eb"
+	side := "d16b0fc3eef9824229b0cbadbfbf7be936eb2f55"
+	merge := "e8bc54f46806cd2c38ea1f2a6799d765f66ab0ac"
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "write-tree"},
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Add files", filesTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Side", "-m", "Body", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", files, "-p", side, "-m", "Merge side", filesTree},
+		{"foo", "-C", gitDir, "update-ref", "HEAD", merge},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--oneline"}, expected: "e8bc54f Merge side\n0f1b80a Add files\nd16b0fc Side\n07aa2d0 Initial commit\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "-n", "1"}, expected: "commit " + merge + "\n" +
+			"Merge: 0f1b80a d16b0fc\n" +
+			"Author: A U Thor <author@example.com>\n" +
+			"Date:   Thu Apr 7 15:13:13 2005 -0700\n" +
+			"\n" +
+			"    Merge side\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--format=%h %p %s|%b", side}, expected: "d16b0fc 07aa2d0 Side|Body\n\n07aa2d0  Initial commit|\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--pretty=format:%an %ai", "-n", "2"}, expected: "A U Thor 2005-04-07 15:13:13 -0700\nA U Thor 2005-04-07 15:13:13 -0700"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--reverse", "-n", "2", "--format=%s"}, expected: "Add files\nMerge side\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--first-parent", "--format=%s"}, expected: "Merge side\nAdd files\nInitial commit\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--grep=^S", "--format=%s"}, expected: "Side\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--author=Nobody", "--format=%s"}},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--until=1000000000", "--format=%s"}},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--format=%s", "--", "a.txt"}, expected: "Add files\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--graph", "--oneline"}, expected: "*   e8bc54f Merge side\n" +
+			"|\\  \n" +
+			"| * d16b0fc Side\n" +
+			"* | 0f1b80a Add files\n" +
+			"|/  \n" +
+			"* 07aa2d0 Initial commit\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestRevList(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	_, err = git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	emptyTree := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	filesTree := "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"
+	initial := "07aa2d0808984a15395272a831194def44801887"
+	files := "0f1b80a9b68c42e7a1dec842251a239a27d9e8eb"
+	side := "d16b0fc3eef9824229b0cbadbfbf7be936eb2f55"
+	merge := "e8bc54f46806cd2c38ea1f2a6799d765f66ab0ac"
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "write-tree"},
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Add files", filesTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Side", "-m", "Body", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", files, "-p", side, "-m", "Merge side", filesTree},
+		{"foo", "-C", gitDir, "update-ref", "HEAD", merge},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "HEAD"}, expected: merge + "\n" + files + "\n" + side + "\n" + initial + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--topo-order", "HEAD"}, expected: merge + "\n" + side + "\n" + files + "\n" + initial + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--count", "--all"}, expected: "4\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", side + "..HEAD"}, expected: merge + "\n" + files + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "HEAD", "^" + files}, expected: merge + "\n" + side + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--left-right", files + "..." + side}, expected: "<" + files + "\n>" + side + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--left-right", "--count", files + "..." + side}, expected: "1\t1\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--objects", side + ".." + files}, expected: files + "\n" +
+			filesTree + " \n" +
+			"78981922613b2afb6025042ff6bd878ac1994e85 a.txt\n" +
+			"587be6b4c3f93f93c489c0111bba5596147a26cb dir.txt\n" +
+			"40f4f0941fcf256f06c7f3b34b7d116f5376cbc6 dir\n" +
+			"61780798228d17af2d34fce4cfbdf35556832472 dir/b.txt\n" +
+			"cf67e9ef3a0fc6d858423fc177f2fbbe985a6f17 dir/sub\n" +
+			"f2ad6c76f0115a6ba5b00456a849810e7ec0af20 dir/sub/c.txt\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--ancestry-path", side + "..HEAD"}, expected: merge + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--merges", "HEAD"}, expected: merge + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--max-parents=0", "HEAD"}, expected: initial + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--first-parent", "--reverse", "HEAD"}, expected: initial + "\n" + files + "\n" + merge + "\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestCommitGraph(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	_, err = git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	emptyTree := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	filesTree := "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"
+	initial := "07aa2d0808984a15395272a831194def44801887"
+	files := "0f1b80a9b68c42e7a1dec842251a239a27d9e8eb"
+	side := "d16b0fc3eef9824229b0cbadbfbf7be936eb2f55"
+	merge := "e8bc54f46806cd2c38ea1f2a6799d765f66ab0ac"
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "write-tree"},
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Add files", filesTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Side", "-m", "Body", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", files, "-p", side, "-m", "Merge side", filesTree},
+		{"foo", "-C", gitDir, "update-ref", "HEAD", merge},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	infoDir := filepath.Join(git.ObjectDir(), "info")
+
+	// The checksum closing a commit-graph file, which Git writes the same.
+	checksum := func() string {
+		data, err := ioutil.ReadFile(filepath.Join(infoDir, "commit-graph"))
+		utils.Expect(t, err, nil)
+
+		return hex.EncodeToString(data[len(data)-20:])
+	}
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-graph", "write", "--reachable"}), nil)
+	utils.Expect(t, checksum(), "b0f204f0cd2d0549bee9eb6c431a99d55e19deb7")
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-graph", "write", "--reachable", "--changed-paths"}), nil)
+	utils.Expect(t, checksum(), "ba1f8d3bf0232aa1bbb6f5c6995526cfc39a713a")
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "commit-graph", "verify"}, expected: ""},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--topo-order", "HEAD"}, expected: merge + "\n" + side + "\n" + files + "\n" + initial + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "rev-list", "--objects", side + ".." + files}, expected: files + "\n" +
+			filesTree + " \n" +
+			"78981922613b2afb6025042ff6bd878ac1994e85 a.txt\n" +
+			"587be6b4c3f93f93c489c0111bba5596147a26cb dir.txt\n" +
+			"40f4f0941fcf256f06c7f3b34b7d116f5376cbc6 dir\n" +
+			"61780798228d17af2d34fce4cfbdf35556832472 dir/b.txt\n" +
+			"cf67e9ef3a0fc6d858423fc177f2fbbe985a6f17 dir/sub\n" +
+			"f2ad6c76f0115a6ba5b00456a849810e7ec0af20 dir/sub/c.txt\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--oneline", "--", "dir"}, expected: "0f1b80a Add files\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "log", "--format=%h %an", "-n", "1"}, expected: "e8bc54f A U Thor\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// Split the graph into a chain of two layers, which a single graph
+	// holding every commit already would stop.
+	utils.Expect(t, os.Remove(filepath.Join(infoDir, "commit-graph")), nil)
+
+	app.Reader = strings.NewReader(files + "\n")
+	defer func() { app.Reader = os.Stdin }()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-graph", "write", "--stdin-commits", "--split"}), nil)
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-graph", "write", "--reachable", "--split=no-merge"}), nil)
+
+	_, err = os.Stat(filepath.Join(infoDir, "commit-graph"))
+	utils.Expect(t, os.IsNotExist(err), true)
+
+	chain, err := ioutil.ReadFile(filepath.Join(infoDir, "commit-graphs", "commit-graph-chain"))
+	utils.Expect(t, err, nil)
+	utils.Expect(t, strings.Count(string(chain), "\n"), 2)
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "commit-graph", "verify"}), nil)
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "rev-list", "--count", side + "..HEAD"}), nil)
+	utils.Expect(t, buf.String(), "2\n")
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, testWorkTree)
+
+	git, err := discover.Find(gitDir)
+	utils.Expect(t, err, nil)
+
+	_, err = git.WriteObject(objfile.Tree, []byte{})
+	utils.Expect(t, err, nil)
+
+	emptyTree := "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	filesTree := "45c21af186f9ffa4b124b583fde2b6ff53efa3b5"
+	initial := "07aa2d0808984a15395272a831194def44801887"
+	files := "0f1b80a9b68c42e7a1dec842251a239a27d9e8eb"
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "write-tree"},
+		{"foo", "-C", gitDir, "commit-tree", "-m", "Initial commit", emptyTree},
+		{"foo", "-C", gitDir, "commit-tree", "-p", initial, "-m", "Add files", filesTree},
+		{"foo", "-C", gitDir, "update-ref", "HEAD", files},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	// Nothing changed yet.
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "diff", "--exit-code"}), nil)
+	utils.Expect(t, buf.String(), "")
+
+	writeWorkTree(t, map[string]string{"a.txt": "a\nb\n"})
+
+	patch := "diff --git a/a.txt b/a.txt\n" +
+		"index 7898192..422c2b7 100644\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -1 +1,2 @@\n"
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--stat", initial, files}, expected: "" +
+			" a.txt         | 1 +\n" +
+			" dir.txt       | 1 +\n" +
+			" dir/b.txt     | 1 +\n" +
+			" dir/sub/c.txt | 1 +\n" +
+			" 4 files changed, 4 insertions(+)\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--name-status", initial + ".." + files, "--", "dir"}, expected: "A\tdir/b.txt\nA\tdir/sub/c.txt\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff"}, expected: patch + " a\n+b\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--word-diff", "HEAD"}, expected: patch + "a\n{+b+}\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--raw"}, expected: ":100644 100644 7898192 0000000 M\ta.txt\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--cached"}, expected: ""},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// Stage a rename along with the change.
+	utils.Expect(t, os.Rename(filepath.Join(gitDir, "dir", "b.txt"), filepath.Join(gitDir, "dir", "moved.txt")), nil)
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "add", "."}), nil)
+
+	cases = []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--cached", "--name-status"}, expected: "M\ta.txt\nR100\tdir/b.txt\tdir/moved.txt\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--cached", "--no-renames", "--name-status"}, expected: "M\ta.txt\nD\tdir/b.txt\nA\tdir/moved.txt\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--cached", "--stat"}, expected: "" +
+			" a.txt                    | 1 +\n" +
+			" dir/{b.txt => moved.txt} | 0\n" +
+			" 2 files changed, 1 insertion(+)\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--numstat", "--summary", "HEAD"}, expected: "" +
+			"1\t0\ta.txt\n" +
+			"0\t0\tdir/{b.txt => moved.txt}\n" +
+			" rename dir/{b.txt => moved.txt} (100%)\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff"}, expected: ""},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// Change whitespace and a binary file.
+	writeWorkTree(t, map[string]string{"b.dat": "bin\x00"})
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "add", "b.dat"}), nil)
+	writeWorkTree(t, map[string]string{"a.txt": "a \n\nb\n", "b.dat": "bin\x00 more"})
+
+	binary := "diff --git a/b.dat b/b.dat\n" +
+		"index bf30bca..3f6e525 100644\n" +
+		"Binary files a/b.dat and b/b.dat differ\n"
+
+	cases = []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "-w"}, expected: "" +
+			"diff --git a/a.txt b/a.txt\n" +
+			"index 422c2b7..e177ca9 100644\n" +
+			"--- a/a.txt\n" +
+			"+++ b/a.txt\n" +
+			"@@ -1,2 +1,3 @@\n" +
+			" a \n" +
+			"+\n" +
+			" b\n" + binary},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "-w", "--ignore-blank-lines"}, expected: binary},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "-w", "--ignore-blank-lines", "--exit-code", "--", "a.txt"}, expected: ""},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "--diff-algorithm", "histogram", "--stat"}, expected: "" +
+			" a.txt |   3 ++-\n" +
+			" b.dat | Bin 4 -> 9 bytes\n" +
+			" 2 files changed, 2 insertions(+), 1 deletion(-)\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "diff", "-b", "--numstat"}, expected: "1\t0\ta.txt\n-\t-\tb.dat\n"},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	// A file where a tracked directory was deletes what it held.
+	utils.Expect(t, os.RemoveAll(filepath.Join(gitDir, "dir", "sub")), nil)
+	writeWorkTree(t, map[string]string{"dir/sub": "file\n"})
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "diff", "--name-status"}), nil)
+	utils.Expect(t, buf.String(), "M\ta.txt\nM\tb.dat\nD\tdir/sub/c.txt\n")
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+// Commit the working tree on the given parents, returning the commit id.
+func commitWorkTree(t *testing.T, message string, parents ...string) string {
+	t.Helper()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "add", "."}), nil)
+
+	buf.Reset()
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "write-tree"}), nil)
+
+	args := []string{"foo", "-C", gitDir, "commit-tree", "-m", message}
+
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+
+	args = append(args, strings.TrimSpace(buf.String()))
+
+	buf.Reset()
+	utils.Expect(t, runApp(args), nil)
+
+	hash := strings.TrimSpace(buf.String())
+
+	buf.Reset()
+
+	return hash
+}
+
+func TestMergeBase(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, map[string]string{"a.txt": "a\n"})
+	base := commitWorkTree(t, "Base")
+
+	writeWorkTree(t, map[string]string{"a.txt": "x\n"})
+	x := commitWorkTree(t, "X", base)
+
+	writeWorkTree(t, map[string]string{"a.txt": "y\n"})
+	y := commitWorkTree(t, "Y", base)
+
+	writeWorkTree(t, map[string]string{"a.txt": "z\n"})
+	z := commitWorkTree(t, "Z", base)
+
+	// A criss-cross merge of x and y, leaving two merge bases.
+	writeWorkTree(t, map[string]string{"a.txt": "xy\n"})
+	xy := commitWorkTree(t, "XY", x, y)
+	yx := commitWorkTree(t, "YX", y, x)
+
+	cases := []struct {
+		testArgs []string
+		expected string
+	}{
+		{testArgs: []string{"foo", "-C", gitDir, "merge-base", x, y}, expected: base + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "merge-base", xy, x}, expected: x + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "merge-base", "--octopus", x, y, z}, expected: base + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "merge-base", "--independent", base, x, xy, z}, expected: xy + "\n" + z + "\n"},
+		{testArgs: []string{"foo", "-C", gitDir, "merge-base", "--is-ancestor", base, xy}},
+	}
+
+	for _, c := range cases {
+		err := runApp(c.testArgs)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, buf.String(), c.expected)
+
+		buf.Reset()
+	}
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge-base", "--all", xy, yx}), nil)
+
+	bases := strings.Fields(buf.String())
+
+	utils.Expect(t, len(bases), 2)
+	utils.Expect(t, bases[0] != bases[1] && (bases[0] == x || bases[0] == y) && (bases[1] == x || bases[1] == y), true)
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	lines := "1\n2\n3\n4\n5\n6\n"
+
+	writeWorkTree(t, map[string]string{"a.txt": "a\n" + lines, "b.txt": "b\n"})
+	base := commitWorkTree(t, "Base")
+
+	writeWorkTree(t, map[string]string{"b.txt": "b\nside\n"})
+	side := commitWorkTree(t, "Side", base)
+
+	// Back to the base on master, with side ahead of it.
+	writeWorkTree(t, map[string]string{"b.txt": "b\n"})
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "add", "."},
+		{"foo", "-C", gitDir, "update-ref", "HEAD", base},
+		{"foo", "-C", gitDir, "update-ref", "refs/heads/side", side},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge", "side"}), nil)
+	utils.Expect(t, buf.String(), "Updating "+base[:7]+".."+side[:7]+"\n"+
+		"Fast-forward\n"+
+		" b.txt | 1 +\n"+
+		" 1 file changed, 1 insertion(+)\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "ORIG_HEAD"), base+"\n")
+
+	buf.Reset()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge", "side"}), nil)
+	utils.Expect(t, buf.String(), "Already up to date.\n")
+
+	buf.Reset()
+
+	// Diverge: master changes the top of a.txt, side the bottom.
+	forwarded := side
+
+	writeWorkTree(t, map[string]string{"a.txt": "a\n" + lines + "side\n"})
+	side = commitWorkTree(t, "More side", forwarded)
+
+	writeWorkTree(t, map[string]string{"a.txt": "master\n" + lines})
+	master := commitWorkTree(t, "Master", forwarded)
+
+	setup = [][]string{
+		{"foo", "-C", gitDir, "update-ref", "HEAD", master},
+		{"foo", "-C", gitDir, "update-ref", "refs/heads/side", side},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	// Stop before committing, then abort.
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge", "--no-commit", "side"}), nil)
+	utils.Expect(t, buf.String(), "Auto-merging a.txt\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, ".git", "MERGE_HEAD"), side+"\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, "a.txt"), "master\n"+lines+"side\n")
+
+	buf.Reset()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge", "--abort"}), nil)
+	utils.Expect(t, utils.PathExists(filepath.Join(gitDir, ".git", "MERGE_HEAD")), false)
+	utils.ExpectFileContent(t, filepath.Join(gitDir, "a.txt"), "master\n"+lines)
+
+	buf.Reset()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge", "side"}), nil)
+	utils.Expect(t, buf.String(), "Auto-merging a.txt\n"+
+		"Merge made by the 'ort' strategy.\n"+
+		" a.txt | 1 +\n"+
+		" 1 file changed, 1 insertion(+)\n")
+
+	buf.Reset()
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "log", "--format=%s|%p", "-n", "1"}), nil)
+	utils.Expect(t, buf.String(), "Merge branch 'side'|"+master[:7]+" "+side[:7]+"\n")
+
+	buf.Reset()
+
+	// Squash a new commit of side, leaving HEAD where it is.
+	writeWorkTree(t, map[string]string{"c.txt": "c\n"})
+	squashed := commitWorkTree(t, "Add c", side)
+	utils.Expect(t, os.Remove(filepath.Join(gitDir, "c.txt")), nil)
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "add", "."}), nil)
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "update-ref", "refs/heads/side", squashed}), nil)
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "merge", "--squash", "side"}), nil)
+	utils.Expect(t, buf.String(), "Squash commit -- not updating HEAD\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, "c.txt"), "c\n")
+	utils.Expect(t, utils.PathExists(filepath.Join(gitDir, ".git", "SQUASH_MSG")), true)
+	utils.Expect(t, utils.PathExists(filepath.Join(gitDir, ".git", "MERGE_HEAD")), false)
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
+
+// Run the test app for a command expected to fail, returning its exit code.
+// urfave/cli exits the process for errors carrying an exit code, so the
+// exit is caught instead.
+func runAppExitCode(t *testing.T, args []string) int {
+	t.Helper()
+
+	exiter := cli.OsExiter
+	code := 0
+
+	cli.OsExiter = func(c int) { code = c }
+	defer func() { cli.OsExiter = exiter }()
+
+	utils.Expect(t, runApp(args) != nil, true)
+
+	return code
+}
+
+func TestMergeDirectoryFile(t *testing.T) {
+	setTestIdent(t)
+
+	utils.Expect(t, app.Run([]string{"foo", "init", gitDir}), nil)
+
+	writeWorkTree(t, map[string]string{"a.txt": "a\n"})
+	base := commitWorkTree(t, "Base")
+
+	// Side makes df a directory, master a file.
+	writeWorkTree(t, map[string]string{"df/x": "x\n"})
+	side := commitWorkTree(t, "Side", base)
+
+	utils.Expect(t, os.RemoveAll(filepath.Join(gitDir, "df")), nil)
+	writeWorkTree(t, map[string]string{"df": "f\n"})
+
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "add", "."}), nil)
+
+	master := commitWorkTree(t, "Master", base)
+
+	setup := [][]string{
+		{"foo", "-C", gitDir, "update-ref", "HEAD", master},
+		{"foo", "-C", gitDir, "update-ref", "refs/heads/side", side},
+	}
+
+	for _, args := range setup {
+		utils.Expect(t, runApp(args), nil)
+	}
+
+	buf.Reset()
+
+	utils.Expect(t, runAppExitCode(t, []string{"foo", "-C", gitDir, "merge", "side"}), 1)
+	utils.Expect(t, buf.String(), ""+
+		"CONFLICT (file/directory): directory in the way of df from HEAD; moving it to df~HEAD instead.\n"+
+		"Automatic merge failed; fix conflicts and then commit the result.\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, "df", "x"), "x\n")
+	utils.ExpectFileContent(t, filepath.Join(gitDir, "df~HEAD"), "f\n")
+
+	buf.Reset()
+
+	// The file is recorded as our side of a conflict at its new path.
+	utils.Expect(t, runApp([]string{"foo", "-C", gitDir, "status", "--short"}), nil)
+	utils.Expect(t, buf.String(), "D  df\nA  df/x\nAU df~HEAD\n")
+
+	buf.Reset()
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(gitDir)
+
+		if err != nil {
+			fmt.Printf("Could not cleanup after init: %s\n", err.Error())
+		}
+	})
+}
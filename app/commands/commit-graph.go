@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commitgraph"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// The commits to write a commit-graph of: those reachable from the refs,
+// those named on the input, or by default all those in packs.
+func commitGraphSources(c *cli.Context, git *fs.Git) ([]plumbing.Hash, error) {
+	resolver := revision.NewResolver(git)
+	hashes := []plumbing.Hash{}
+
+	switch {
+	case c.Bool("reachable"):
+		tips, err := allTips(git, resolver)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tip := range tips {
+			hashes = append(hashes, tip.Hash)
+		}
+	case c.Bool("stdin-commits"):
+		scanner := bufio.NewScanner(mainReader(c))
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+
+			if line == "" {
+				continue
+			}
+
+			hash, err := plumbing.NewHash(line)
+
+			if err == nil {
+				hash, err = resolver.Peel(hash, objfile.Commit, line)
+			}
+
+			if err != nil {
+				return nil, errors.GitError{Message: fmt.Sprintf("invalid commit object id: %s", line)}
+			}
+
+			hashes = append(hashes, hash)
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		packed, err := git.ListPackedObjects(c.Context)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hash := range packed {
+			info, err := git.StatObject(hash)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if info.Type == objfile.Commit {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+
+	return hashes, nil
+}
+
+var commitGraphWriteCommand = &cli.Command{
+	Name:     "write",
+	HelpName: "commit-graph write",
+	Usage:    "Write a commit-graph file",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "reachable",
+			Value: false,
+			Usage: "Walk commits starting at all refs.",
+		},
+		&cli.BoolFlag{
+			Name:  "stdin-commits",
+			Value: false,
+			Usage: "Walk commits starting at the commits listed on the standard input.",
+		},
+		&cli.BoolFlag{
+			Name:  "append",
+			Value: false,
+			Usage: "Include all commits that are present in the existing commit-graph file.",
+		},
+		&cli.BoolFlag{
+			Name:  "changed-paths",
+			Value: false,
+			Usage: "Compute and write the changed-path Bloom filters of the commits.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-changed-paths",
+			Value: false,
+			Usage: "Do not write changed-path Bloom filters, even if the existing commit-graph has them.",
+		},
+		&cli.GenericFlag{
+			Name:  "split",
+			Value: &optionalValue{implied: commitgraph.SplitMerge},
+			Usage: "Write the commits as a new layer of a commit-graph chain, merging layers unless no-merge is given, or replacing the chain with replace.",
+		},
+		&cli.IntFlag{
+			Name:  "size-multiple",
+			Value: commitgraph.DefaultSizeMultiple,
+			Usage: "Merge layers of the chain not bigger than this many times the new layer.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for commit-graph write command.")
+
+		if _, err := commandArgs(c); err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		if c.Bool("reachable") && c.Bool("stdin-commits") {
+			return cli.Exit("options '--reachable' and '--stdin-commits' cannot be used together", 128)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+		opts := commitgraph.WriteOptions{
+			ChangedPaths: c.Bool("changed-paths"),
+			Append:       c.Bool("append"),
+			Split:        c.IsSet("split"),
+			SizeMultiple: c.Int("size-multiple"),
+		}
+
+		if opts.Split {
+			opts.SplitStrategy = c.Generic("split").(*optionalValue).value
+
+			switch opts.SplitStrategy {
+			case commitgraph.SplitMerge, commitgraph.SplitNoMerge, commitgraph.SplitReplace:
+			default:
+				return cli.Exit(fmt.Sprintf("unrecognized --split argument, %s", opts.SplitStrategy), 129)
+			}
+		}
+
+		writer, err := commitgraph.NewWriter(git, opts)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		// Keep writing Bloom filters once the graph has them.
+		if current := writer.Current(); current != nil && current.HasBloomFilters() && !c.Bool("no-changed-paths") {
+			writer.EnableChangedPaths()
+		}
+
+		hashes, err := commitGraphSources(c, git)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if err := writer.Write(hashes); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		return nil
+	},
+}
+
+var commitGraphVerifyCommand = &cli.Command{
+	Name:     "verify",
+	HelpName: "commit-graph verify",
+	Usage:    "Verify the commit-graph file",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "shallow",
+			Value: false,
+			Usage: "Only check the tip layer of a commit-graph chain.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for commit-graph verify command.")
+
+		if _, err := commandArgs(c); err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+		graph, err := commitgraph.Open(git.ObjectDir(), git.ObjectFormat())
+
+		if err != nil {
+			fmt.Fprintf(c.App.ErrWriter, "error: %s\n", err.Error())
+
+			return cli.Exit("", 1)
+		}
+
+		if graph == nil {
+			return nil
+		}
+
+		problems := graph.Verify(git.ReadObjectByHash, c.Bool("shallow"))
+
+		for _, problem := range problems {
+			fmt.Fprintf(c.App.ErrWriter, "error: %s\n", problem.Error())
+		}
+
+		if len(problems) > 0 {
+			return cli.Exit("", 1)
+		}
+
+		return nil
+	},
+}
+
+var CommitGraphCommand = &cli.Command{
+	Name:     "commit-graph",
+	HelpName: "commit-graph",
+	Usage:    "Write and verify Git commit-graph files",
+	Subcommands: []*cli.Command{
+		commitGraphWriteCommand,
+		commitGraphVerifyCommand,
+	},
+}
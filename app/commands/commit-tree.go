@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Check that the object named by rev exists and has the expected type.
+func expectObjectType(ctx context.Context, repo *ditto.Repository, rev string, expected ditto.ObjectType) (ditto.Hash, error) {
+	hash, err := repo.Resolve(ctx, rev)
+
+	if err != nil {
+		return hash, err
+	}
+
+	info, err := repo.Stat(ctx, hash)
+
+	if err != nil {
+		return hash, err
+	}
+
+	if info.Type != expected {
+		return hash, errors.GitError{Message: fmt.Sprintf("%s is a %s, not a %s", rev, info.Type, expected)}
+	}
+
+	return hash, nil
+}
+
+func getCommitMessage(c *cli.Context) (string, error) {
+	if messages := c.StringSlice("m"); len(messages) > 0 {
+		return strings.Join(messages, "\n\n") + "\n", nil
+	}
+
+	if file := c.String("F"); file != "" {
+		if file == "-" {
+			data, err := ioutil.ReadAll(c.App.Reader)
+
+			return string(data), err
+		}
+
+		data, err := ioutil.ReadFile(file)
+
+		return string(data), err
+	}
+
+	data, err := ioutil.ReadAll(c.App.Reader)
+
+	return string(data), err
+}
+
+var CommitTreeCommand = &cli.Command{
+	Name:      "commit-tree",
+	HelpName:  "commit-tree",
+	Usage:     "Create a new commit object",
+	ArgsUsage: "[-p <parent>]... [-m <message>]... [-F <file>] <tree>",
+
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "p",
+			Usage: "Each -p indicates the id of a parent commit object.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "m",
+			Usage: "A paragraph in the commit log message. Can be given more than once.",
+		},
+		&cli.StringFlag{
+			Name:  "F",
+			Usage: "Read the commit log message from the given file. Use - to read from the standard input.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for commit-tree command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		if len(args) != 1 {
+			err := errors.GitError{Message: "Need exactly one tree_sha to create a commit."}
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		treeHash, err := expectObjectType(c.Context, repo, args[0], ditto.TreeObject)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		newCommit := &ditto.Commit{Tree: treeHash}
+
+		for _, parent := range c.StringSlice("p") {
+			parentHash, err := expectObjectType(c.Context, repo, parent, ditto.CommitObject)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			newCommit.Parents = append(newCommit.Parents, parentHash)
+		}
+
+		if newCommit.Author, err = repo.Identity(c.Context, ditto.AuthorRole); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if newCommit.Committer, err = repo.Identity(c.Context, ditto.CommitterRole); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if newCommit.Message, err = getCommitMessage(c); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		hash, err := repo.WriteCommit(c.Context, newCommit)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		fmt.Fprintln(c.App.Writer, hash.String())
+
+		return nil
+	},
+}
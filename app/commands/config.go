@@ -0,0 +1,528 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// The actions of the config command, of which only one can be given.
+var configActions = []string{"get", "get-all", "get-regexp", "add", "unset", "unset-all", "list"}
+
+// The action config runs: one of configActions, or set, or get when only a
+// name is given.
+func configAction(c *cli.Context, args []string) (string, error) {
+	action := ""
+
+	for _, flag := range configActions {
+		if !c.Bool(flag) {
+			continue
+		}
+
+		if action != "" {
+			return "", errors.GitError{Message: "only one action at a time"}
+		}
+
+		action = flag
+	}
+
+	if action != "" {
+		return action, nil
+	}
+
+	switch len(args) {
+	case 1:
+		return "get", nil
+	case 2:
+		return "set", nil
+	}
+
+	return "", errors.GitError{Message: fmt.Sprintf("usage: git config %s", c.Command.ArgsUsage)}
+}
+
+// The type values are read or written as, from --type or its shorthands.
+func configType(c *cli.Context) (string, error) {
+	typ := c.String("type")
+
+	for _, flag := range []string{"bool", "int", "bool-or-int", "path"} {
+		if !c.Bool(flag) {
+			continue
+		}
+
+		if typ != "" && typ != flag {
+			return "", errors.GitError{Message: "only one type at a time"}
+		}
+
+		typ = flag
+	}
+
+	switch typ {
+	case "", "bool", "int", "bool-or-int", "path", "color":
+		return typ, nil
+	}
+
+	return "", errors.GitError{Message: fmt.Sprintf("unrecognized --type argument, %s", typ)}
+}
+
+// Canonicalize a value as its type, e.g. yes as true for a bool. name is the
+// variable it is set for, in errors.
+func formatConfigValue(typ, name, value string) (string, error) {
+	switch typ {
+	case "bool":
+		b, err := config.ParseBool(value)
+
+		if err != nil {
+			return "", errors.GitError{Message: fmt.Sprintf("bad boolean config value '%s' for '%s'", value, name)}
+		}
+
+		return strconv.FormatBool(b), nil
+	case "int":
+		n, err := config.ParseInt(value)
+
+		if err != nil {
+			return "", errors.GitError{Message: fmt.Sprintf("bad numeric config value '%s' for '%s': %s", value, name, err.Error())}
+		}
+
+		return strconv.FormatInt(n, 10), nil
+	case "bool-or-int":
+		if n, err := config.ParseInt(value); err == nil {
+			return strconv.FormatInt(n, 10), nil
+		}
+
+		return formatConfigValue("bool", name, value)
+	case "path":
+		return config.ExpandPath(value)
+	case "color":
+		return config.ParseColor(value)
+	}
+
+	return value, nil
+}
+
+// Canonicalize the value of an entry as its type. A key without a value is
+// shown as nothing at all unless read as a boolean, which it is true for.
+func formatConfigEntry(typ string, e config.Entry) (string, error) {
+	if !e.NoValue {
+		return formatConfigValue(typ, e.Name(), e.Value)
+	}
+
+	switch typ {
+	case "bool", "bool-or-int":
+		return "true", nil
+	case "path", "color":
+		return "", errors.GitError{Message: fmt.Sprintf("missing value for '%s'", e.Name())}
+	}
+
+	return formatConfigValue(typ, e.Name(), "")
+}
+
+// The scope a config command is limited to, and the file it reads and
+// writes if there is a single one. Without any scope given, reads see every
+// scope and writes go to the repository's config.
+func configScope(c *cli.Context, gitDir string) (config.Scope, string, error) {
+	scope := config.ScopeUnknown
+
+	for flag, s := range map[string]config.Scope{
+		"system":   config.ScopeSystem,
+		"global":   config.ScopeGlobal,
+		"local":    config.ScopeLocal,
+		"worktree": config.ScopeWorktree,
+	} {
+		if !c.Bool(flag) {
+			continue
+		}
+
+		if scope != config.ScopeUnknown {
+			return scope, "", errors.GitError{Message: "only one config file at a time"}
+		}
+
+		scope = s
+	}
+
+	if c.IsSet("file") {
+		if scope != config.ScopeUnknown {
+			return scope, "", errors.GitError{Message: "only one config file at a time"}
+		}
+
+		return config.ScopeCommand, c.String("file"), nil
+	}
+
+	switch scope {
+	case config.ScopeUnknown:
+		// Outside of a repository there is only something to read.
+		if gitDir == "" {
+			return scope, "", nil
+		}
+
+		path, err := config.Path(config.ScopeLocal, gitDir)
+
+		return scope, path, err
+	case config.ScopeWorktree:
+		// The worktree config is only separate from the repository's config
+		// when the extension for it is on.
+		path, err := config.Path(config.ScopeLocal, gitDir)
+
+		if err != nil {
+			return scope, "", err
+		}
+
+		cfg, err := config.LoadFile(path, config.ScopeLocal, gitDir, false)
+
+		if err != nil {
+			return scope, "", err
+		}
+
+		if on, err := cfg.Bool("extensions.worktreeConfig", false); err != nil || !on {
+			scope = config.ScopeLocal
+		}
+	}
+
+	path, err := config.Path(scope, gitDir)
+
+	return scope, path, err
+}
+
+// Prints entries with the --show-scope and --show-origin prefixes asked for.
+type configPrinter struct {
+	out        io.Writer
+	showOrigin bool
+	showScope  bool
+
+	// Origins inside this directory are shown relative to it.
+	base string
+}
+
+func (p *configPrinter) print(e config.Entry, text string) {
+	if p.showScope {
+		fmt.Fprintf(p.out, "%s\t", e.Scope)
+	}
+
+	if p.showOrigin {
+		if e.Origin == "" {
+			fmt.Fprint(p.out, "command line:\t")
+		} else {
+			origin := e.Origin
+
+			if rel, err := filepath.Rel(p.base, origin); err == nil && p.base != "" && !strings.HasPrefix(rel, "..") {
+				origin = rel
+			}
+
+			fmt.Fprintf(p.out, "file:%s\t", filepath.ToSlash(origin))
+		}
+	}
+
+	fmt.Fprintln(p.out, text)
+}
+
+// Change a config file, mapping failures to git's exit codes: 5 when the
+// variable does not have the single value the change expects.
+func writeConfig(action, path, name, value string) error {
+	var err error
+
+	switch action {
+	case "set":
+		err = config.Set(path, name, value)
+	case "add":
+		err = config.Add(path, name, value)
+	case "unset":
+		err = config.Unset(path, name)
+	case "unset-all":
+		err = config.UnsetAll(path, name)
+	}
+
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case config.InvalidKeyError:
+		if e.NoSection {
+			return cli.Exit(err.Error(), 2)
+		}
+
+		return cli.Exit(err.Error(), 1)
+	case config.NotSetError:
+		return cli.Exit("", 5)
+	case config.MultipleValuesError:
+		if action == "set" {
+			return cli.Exit(fmt.Sprintf("%s\ncannot overwrite multiple values with a single value\n       Use --add or --unset-all to change %s.", err.Error(), name), 5)
+		}
+
+		return cli.Exit(err.Error(), 5)
+	}
+
+	return cli.Exit(err.Error(), 4)
+}
+
+var ConfigCommand = &cli.Command{
+	Name:      "config",
+	HelpName:  "config",
+	Usage:     "Get and set repository or global options",
+	ArgsUsage: "[<file-option>] [--type=<type>] [--show-origin] [--show-scope] (<name> [<value>] | --get <name> | --get-all <name> | --get-regexp <pattern> | --add <name> <value> | --unset <name> | --unset-all <name> | -l)",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "global",
+			Value: false,
+			Usage: "Use the global config file, ~/.gitconfig.",
+		},
+		&cli.BoolFlag{
+			Name:  "system",
+			Value: false,
+			Usage: "Use the system-wide config file, /etc/gitconfig.",
+		},
+		&cli.BoolFlag{
+			Name:  "local",
+			Value: false,
+			Usage: "Use the repository config file, .git/config.",
+		},
+		&cli.BoolFlag{
+			Name:  "worktree",
+			Value: false,
+			Usage: "Use the config file of the worktree, .git/config.worktree if extensions.worktreeConfig is on.",
+		},
+		&cli.StringFlag{
+			Name:    "file",
+			Aliases: []string{"f"},
+			Usage:   "Use the given config file.",
+		},
+		&cli.BoolFlag{
+			Name:  "get",
+			Value: false,
+			Usage: "Get the value for a given key, the last one if it has several. Exits with 1 if it is not set.",
+		},
+		&cli.BoolFlag{
+			Name:  "get-all",
+			Value: false,
+			Usage: "Like --get, but print all the values of a multi-valued key.",
+		},
+		&cli.BoolFlag{
+			Name:  "get-regexp",
+			Value: false,
+			Usage: "Like --get-all, but for every key matching a regular expression, printing the key names as well.",
+		},
+		&cli.BoolFlag{
+			Name:  "add",
+			Value: false,
+			Usage: "Add a new value to a key without altering any existing values.",
+		},
+		&cli.BoolFlag{
+			Name:  "unset",
+			Value: false,
+			Usage: "Remove the line matching the key from the config file.",
+		},
+		&cli.BoolFlag{
+			Name:  "unset-all",
+			Value: false,
+			Usage: "Remove all lines matching the key from the config file.",
+		},
+		&cli.BoolFlag{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Value:   false,
+			Usage:   "List all variables set in the config files, along with their values.",
+		},
+		&cli.BoolFlag{
+			Name:  "show-origin",
+			Value: false,
+			Usage: "Prefix the output with the origin of each value: the file it is set in, or the command line.",
+		},
+		&cli.BoolFlag{
+			Name:  "show-scope",
+			Value: false,
+			Usage: "Prefix the output with the scope of each value: system, global, local, worktree or command.",
+		},
+		&cli.BoolFlag{
+			Name:  "includes",
+			Value: false,
+			Usage: "Follow include.path and includeIf directives when reading a single config file.",
+		},
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "Check and canonicalize values as bool, int, bool-or-int, path or color.",
+		},
+		&cli.BoolFlag{
+			Name:  "bool",
+			Value: false,
+			Usage: "Same as --type=bool.",
+		},
+		&cli.BoolFlag{
+			Name:  "int",
+			Value: false,
+			Usage: "Same as --type=int.",
+		},
+		&cli.BoolFlag{
+			Name:  "bool-or-int",
+			Value: false,
+			Usage: "Same as --type=bool-or-int.",
+		},
+		&cli.BoolFlag{
+			Name:  "path",
+			Value: false,
+			Usage: "Same as --type=path.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for config command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		action, err := configAction(c, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		typ, err := configType(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		wantArgs := map[string]int{"get": 1, "get-all": 1, "get-regexp": 1, "set": 2, "add": 2, "unset": 1, "unset-all": 1, "list": 0}
+
+		if len(args) != wantArgs[action] {
+			return cli.Exit("usage: git config "+c.Command.ArgsUsage, 129)
+		}
+
+		// The config outside of a repository is still there to read, and to
+		// write with --global, --system or --file.
+		gitDir, base := "", ""
+
+		if repo, err := openRepository(c); err == nil {
+			gitDir, base = repo.GitDir(), repo.GitDir()
+
+			if worktree, err := repo.Worktree(); err == nil {
+				base = worktree.Root()
+			}
+		}
+
+		scope, path, err := configScope(c, gitDir)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		name := ""
+
+		if len(args) > 0 {
+			name = args[0]
+		}
+
+		switch action {
+		case "set", "add", "unset", "unset-all":
+			if path == "" {
+				return cli.Exit("not in a git directory", 128)
+			}
+
+			value := ""
+
+			if len(args) > 1 {
+				value = args[1]
+			}
+
+			if value, err = formatConfigValue(typ, name, value); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			return writeConfig(action, path, name, value)
+		}
+
+		if scope != config.ScopeUnknown && action == "list" && !utils.PathExists(path) {
+			return cli.Exit(fmt.Sprintf("unable to read config file '%s': No such file or directory", path), 128)
+		}
+
+		var cfg *config.Config
+
+		// Includes are only followed by default when reading every scope.
+		if scope == config.ScopeUnknown {
+			cfg, err = config.LoadAll(gitDir)
+		} else {
+			cfg, err = config.LoadFile(path, scope, gitDir, c.Bool("includes"))
+		}
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		printer := &configPrinter{
+			out:        c.App.Writer,
+			showOrigin: c.Bool("show-origin"),
+			showScope:  c.Bool("show-scope"),
+			base:       base,
+		}
+
+		var entries []config.Entry
+
+		switch action {
+		case "list":
+			for _, e := range cfg.Entries {
+				if e.NoValue {
+					printer.print(e, e.Name())
+				} else {
+					printer.print(e, e.Name()+"="+e.Value)
+				}
+			}
+
+			return nil
+		case "get-regexp":
+			pattern, err := regexp.Compile(name)
+
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("invalid key pattern: %s", name), 6)
+			}
+
+			for _, e := range cfg.Entries {
+				if pattern.MatchString(e.Name()) {
+					entries = append(entries, e)
+				}
+			}
+		default:
+			if _, _, _, err := config.ParseName(name); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			entries = cfg.Find(name)
+
+			if action == "get" && len(entries) > 0 {
+				entries = entries[len(entries)-1:]
+			}
+		}
+
+		if len(entries) == 0 {
+			return cli.Exit("", 1)
+		}
+
+		for _, e := range entries {
+			value, err := formatConfigEntry(typ, e)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if action == "get-regexp" {
+				if e.NoValue && typ == "" {
+					value = e.Name()
+				} else {
+					value = e.Name() + " " + value
+				}
+			}
+
+			printer.print(e, value)
+		}
+
+		return nil
+	},
+}
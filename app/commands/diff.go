@@ -0,0 +1,728 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pathspec"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Split the arguments of diff into the trees to compare and the paths to
+// limit them to. <a>..<b> compares a with b and <a>...<b> the merge base
+// of both with b.
+func splitDiffArguments(c *cli.Context, git *fs.Git, resolver *revision.Resolver, args []string) ([]plumbing.Hash, pathspec.Pathspec, error) {
+	trees := []plumbing.Hash{}
+	paths := []string{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			paths = append(paths, args[i+1:]...)
+
+			break
+		}
+
+		resolved, err := resolveDiffRevision(resolver, arg)
+
+		if err == nil {
+			trees = append(trees, resolved...)
+
+			continue
+		}
+
+		if !revision.IsNotFound(err) {
+			return nil, pathspec.Pathspec{}, err
+		}
+
+		if _, statErr := os.Lstat(filepath.Join(c.String("C"), arg)); statErr != nil {
+			return nil, pathspec.Pathspec{}, errors.GitError{Message: fmt.Sprintf("ambiguous argument '%s': unknown revision or path not in the working tree.\n"+
+				"Use '--' to separate paths from revisions, like this:\n"+
+				"'git <command> [<revision>...] -- [<file>...]'", arg)}
+		}
+
+		paths = append(paths, args[i:]...)
+
+		break
+	}
+
+	if git.IsBare() {
+		return trees, pathspec.New(paths), nil
+	}
+
+	spec, err := getPathspec(git, c, paths)
+
+	return trees, spec, err
+}
+
+// Resolve a revision of diff to its tree, or a range to the trees at its
+// ends. Of the parent shorthands, <rev>^! and <rev>^-<n> compare rev with
+// its parent, and <rev>^@ stands for the parents.
+func resolveDiffRevision(resolver *revision.Resolver, arg string) ([]plumbing.Hash, error) {
+	if _, _, ok := revision.ParentShorthand(arg); ok && !strings.Contains(arg, "..") {
+		tips, err := resolver.ResolveRange(arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hidden, shown := []plumbing.Hash{}, []plumbing.Hash{}
+
+		for _, tip := range tips {
+			if tip.Hidden {
+				hidden = append(hidden, tip.Hash)
+			} else {
+				shown = append(shown, tip.Hash)
+			}
+		}
+
+		trees := []plumbing.Hash{}
+
+		for _, hash := range append(hidden, shown...) {
+			tree, err := resolver.Peel(hash, objfile.Tree, arg)
+
+			if err != nil {
+				return nil, err
+			}
+
+			trees = append(trees, tree)
+		}
+
+		return trees, nil
+	}
+
+	if !strings.Contains(arg, "..") {
+		hash, err := resolver.Resolve(arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tree, err := resolver.Peel(hash, objfile.Tree, arg)
+
+		return []plumbing.Hash{tree}, err
+	}
+
+	tips, err := resolver.ResolveRange(arg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := tips[0].Hash, tips[1].Hash
+
+	// The merge base of a symmetric difference is its third tip.
+	if strings.Contains(arg, "...") {
+		if len(tips) < 3 {
+			return nil, errors.GitError{Message: fmt.Sprintf("%s: no merge base", arg)}
+		}
+
+		from = tips[2].Hash
+	}
+
+	trees := []plumbing.Hash{}
+
+	for _, hash := range []plumbing.Hash{from, to} {
+		tree, err := resolver.Peel(hash, objfile.Tree, arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		trees = append(trees, tree)
+	}
+
+	return trees, nil
+}
+
+// The tree of HEAD, or the empty tree on an unborn branch.
+func headTree(git *fs.Git, resolver *revision.Resolver) (plumbing.Hash, error) {
+	_, head, err := refs.NewStore(git.GitDir()).Head()
+
+	if err != nil || head.IsZero() {
+		return plumbing.ZeroHash, err
+	}
+
+	return resolver.Peel(head, objfile.Tree, refs.Head)
+}
+
+// How diff finds renames and copies: from the diff.renames configuration,
+// overridden by the command line.
+func diffRenameOptions(c *cli.Context, repo *ditto.Repository) (*diff.RenameOptions, error) {
+	cfg, err := repo.Config(c.Context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := cfg.Int("diff.renameLimit", diff.DefaultRenameLimit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &diff.RenameOptions{Limit: int(limit)}
+	detect := true
+
+	if value, ok := cfg.Get("diff.renames"); ok {
+		switch strings.ToLower(value) {
+		case "copy", "copies":
+			opts.Copies = true
+		default:
+			if detect, err = cfg.Bool("diff.renames", true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, flag := range []string{"find-renames", "find-copies"} {
+		if !c.IsSet(flag) {
+			continue
+		}
+
+		detect = true
+		opts.Copies = opts.Copies || flag == "find-copies"
+
+		if value := c.Generic(flag).(*optionalValue).value; value != "" {
+			if opts.MinScore, err = diff.ParseScore(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if c.Bool("find-copies-harder") {
+		detect, opts.Copies = true, true
+	}
+
+	if c.Bool("no-renames") || !detect {
+		return nil, nil
+	}
+
+	return opts, nil
+}
+
+// Parse --stat=<width>[,<name-width>[,<count>]].
+func parseStatOptions(value string) (diff.StatOptions, error) {
+	opts := diff.StatOptions{}
+
+	if value == "" {
+		return opts, nil
+	}
+
+	fields := []*int{&opts.Width, &opts.NameWidth, &opts.Count}
+	parts := strings.Split(value, ",")
+
+	if len(parts) > len(fields) {
+		return opts, errors.GitError{Message: fmt.Sprintf("invalid --stat value: %s", value)}
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+
+		if err != nil {
+			return opts, errors.GitError{Message: fmt.Sprintf("invalid --stat value: %s", value)}
+		}
+
+		*fields[i] = n
+	}
+
+	return opts, nil
+}
+
+// The width of the terminal, as COLUMNS tells.
+func terminalColumns() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+
+	return diff.DefaultStatWidth
+}
+
+// How diff shows changes, from the command line and the diff.algorithm and
+// diff.indentHeuristic configuration.
+func diffOptions(c *cli.Context, repo *ditto.Repository) (*diff.Options, error) {
+	git := internalGit(repo)
+	opts := &diff.Options{
+		Context:          c.Int("unified"),
+		InterHunkContext: c.Int("inter-hunk-context"),
+		SrcPrefix:        c.String("src-prefix"),
+		DstPrefix:        c.String("dst-prefix"),
+		IgnoreBlankLines: c.Bool("ignore-blank-lines"),
+		Text:             c.Bool("text"),
+		Lines: diff.LineOptions{
+			IgnoreAllSpace:    c.Bool("ignore-all-space"),
+			IgnoreSpaceChange: c.Bool("ignore-space-change"),
+			IgnoreSpaceAtEOL:  c.Bool("ignore-space-at-eol"),
+			IgnoreCRAtEOL:     c.Bool("ignore-cr-at-eol"),
+		},
+	}
+
+	if c.Bool("no-prefix") {
+		opts.SrcPrefix, opts.DstPrefix = "", ""
+	}
+
+	if !c.Bool("full-index") {
+		opts.Abbrev = func(hash plumbing.Hash) (string, error) {
+			return revision.Abbreviate(git, hash, revision.DefaultAbbrev)
+		}
+	}
+
+	if c.IsSet("word-diff") {
+		opts.WordDiff = c.Generic("word-diff").(*optionalValue).value
+
+		if _, ok := map[string]bool{"plain": true, "porcelain": true, "none": true}[opts.WordDiff]; !ok {
+			return nil, errors.GitError{Message: fmt.Sprintf("bad --word-diff argument: %s", opts.WordDiff)}
+		}
+	}
+
+	cfg, err := repo.Config(c.Context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, _ := cfg.Get("diff.algorithm")
+
+	switch {
+	case c.IsSet("diff-algorithm"):
+		algorithm = c.String("diff-algorithm")
+	case c.Bool("histogram"):
+		algorithm = "histogram"
+	case c.Bool("patience"):
+		algorithm = "patience"
+	case c.Bool("minimal"):
+		algorithm = "minimal"
+	}
+
+	if algorithm != "" {
+		if opts.Lines.Algorithm, err = diff.ParseAlgorithm(algorithm); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Lines.IndentHeuristic, err = cfg.Bool("diff.indentHeuristic", true); err != nil {
+		return nil, err
+	}
+
+	if c.IsSet("indent-heuristic") {
+		opts.Lines.IndentHeuristic = c.Bool("indent-heuristic")
+	}
+
+	if c.Bool("no-indent-heuristic") {
+		opts.Lines.IndentHeuristic = false
+	}
+
+	opts.Stat.Width = terminalColumns()
+
+	if c.IsSet("stat") {
+		stat, err := parseStatOptions(c.Generic("stat").(*optionalValue).value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stat.Width > 0 {
+			opts.Stat.Width = stat.Width
+		}
+
+		opts.Stat.NameWidth, opts.Stat.Count = stat.NameWidth, stat.Count
+	}
+
+	return opts, nil
+}
+
+// Write the changes in the formats asked for, in git's order: the names,
+// the statistics, the summary, then the patch.
+func writeDiff(c *cli.Context, git *fs.Git, changes []*diff.Change, opts *diff.Options) error {
+	statFormats := c.IsSet("stat") || c.Bool("numstat") || c.Bool("shortstat")
+	listFormats := c.Bool("raw") || c.Bool("name-only") || c.Bool("name-status")
+	patch := c.Bool("patch") || c.IsSet("word-diff") && opts.WordDiff != "none" ||
+		!statFormats && !listFormats && !c.Bool("summary") && !c.Bool("no-patch")
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	out := bufio.NewWriter(c.App.Writer)
+	separate := false
+
+	switch {
+	case c.Bool("name-status"):
+		diff.WriteNameStatus(out, changes)
+	case c.Bool("raw"):
+		// Raw output abbreviates object names even with --full-index.
+		raw := *opts
+		raw.Abbrev = func(hash plumbing.Hash) (string, error) {
+			return revision.Abbreviate(git, hash, revision.DefaultAbbrev)
+		}
+
+		if err := diff.WriteRaw(out, changes, &raw); err != nil {
+			return err
+		}
+	case c.Bool("name-only"):
+		diff.WriteNameOnly(out, changes)
+	}
+
+	separate = listFormats
+
+	if statFormats {
+		stats, err := diff.Stats(changes, opts)
+
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("numstat") {
+			diff.WriteNumstat(out, stats)
+		}
+
+		if c.IsSet("stat") {
+			diff.WriteStat(out, stats, opts.Stat)
+		}
+
+		if c.Bool("shortstat") {
+			diff.WriteShortstat(out, stats)
+		}
+
+		separate = true
+	}
+
+	if c.Bool("summary") {
+		diff.WriteSummary(out, changes)
+		separate = true
+	}
+
+	if patch {
+		if separate {
+			out.WriteString("\n")
+		}
+
+		if err := diff.WritePatch(out, changes, opts); err != nil {
+			return err
+		}
+	}
+
+	return out.Flush()
+}
+
+var DiffCommand = &cli.Command{
+	Name:      "diff",
+	HelpName:  "diff",
+	Usage:     "Show changes between commits, commit and working tree, etc",
+	ArgsUsage: "[--cached] [<commit> [<commit>]] [--] [<path>...]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "cached",
+			Aliases: []string{"staged"},
+			Value:   false,
+			Usage:   "Show the changes staged for the next commit, relative to HEAD or the given commit.",
+		},
+		&cli.BoolFlag{
+			Name:    "patch",
+			Aliases: []string{"p", "u"},
+			Value:   false,
+			Usage:   "Generate a patch, the default.",
+		},
+		&cli.BoolFlag{
+			Name:    "no-patch",
+			Aliases: []string{"s"},
+			Value:   false,
+			Usage:   "Suppress the patch.",
+		},
+		&cli.IntFlag{
+			Name:    "unified",
+			Aliases: []string{"U"},
+			Value:   3,
+			Usage:   "Generate diffs with <n> lines of context.",
+		},
+		&cli.IntFlag{
+			Name:  "inter-hunk-context",
+			Value: 0,
+			Usage: "Show the context between hunks up to <n> lines, fusing them.",
+		},
+		&cli.BoolFlag{
+			Name:  "raw",
+			Value: false,
+			Usage: "List the changes with the modes and object names of both sides.",
+		},
+		&cli.GenericFlag{
+			Name:  "stat",
+			Value: &optionalValue{},
+			Usage: "Generate a diffstat, --stat[=<width>[,<name-width>[,<count>]]].",
+		},
+		&cli.BoolFlag{
+			Name:  "numstat",
+			Value: false,
+			Usage: "Show the number of added and deleted lines of each file in decimal.",
+		},
+		&cli.BoolFlag{
+			Name:  "shortstat",
+			Value: false,
+			Usage: "Output only the last line of the --stat format.",
+		},
+		&cli.BoolFlag{
+			Name:  "summary",
+			Value: false,
+			Usage: "Summarize creations, deletions, renames and mode changes.",
+		},
+		&cli.BoolFlag{
+			Name:  "name-only",
+			Value: false,
+			Usage: "Show only the names of changed files.",
+		},
+		&cli.BoolFlag{
+			Name:  "name-status",
+			Value: false,
+			Usage: "Show only the names and status of changed files.",
+		},
+		&cli.GenericFlag{
+			Name:    "find-renames",
+			Aliases: []string{"M"},
+			Value:   &optionalValue{},
+			Usage:   "Detect renames, of files at least <n> similar.",
+		},
+		// No -C, which would hide the -C of the main command.
+		&cli.GenericFlag{
+			Name:  "find-copies",
+			Value: &optionalValue{},
+			Usage: "Detect copies as well as renames, of files at least <n> similar.",
+		},
+		&cli.BoolFlag{
+			Name:  "find-copies-harder",
+			Value: false,
+			Usage: "Look for the sources of copies among unmodified files too.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-renames",
+			Value: false,
+			Usage: "Turn off rename detection.",
+		},
+		&cli.GenericFlag{
+			Name:  "word-diff",
+			Value: &optionalValue{implied: "plain"},
+			Usage: "Show a word diff, delimiting changed words: plain, porcelain or none.",
+		},
+		&cli.StringFlag{
+			Name:  "diff-algorithm",
+			Usage: "Choose a diff algorithm: myers, minimal, patience or histogram.",
+		},
+		&cli.BoolFlag{
+			Name:  "minimal",
+			Value: false,
+			Usage: "Spend extra time to make sure the smallest possible diff is produced.",
+		},
+		&cli.BoolFlag{
+			Name:  "patience",
+			Value: false,
+			Usage: "Generate a diff using the \"patience diff\" algorithm.",
+		},
+		&cli.BoolFlag{
+			Name:  "histogram",
+			Value: false,
+			Usage: "Generate a diff using the \"histogram diff\" algorithm.",
+		},
+		&cli.BoolFlag{
+			Name:  "indent-heuristic",
+			Value: true,
+			Usage: "Shift the boundaries of hunks to make patches easier to read, the default.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-indent-heuristic",
+			Value: false,
+			Usage: "Disable the indent heuristic.",
+		},
+		&cli.BoolFlag{
+			Name:    "ignore-all-space",
+			Aliases: []string{"w"},
+			Value:   false,
+			Usage:   "Ignore whitespace when comparing lines.",
+		},
+		&cli.BoolFlag{
+			Name:    "ignore-space-change",
+			Aliases: []string{"b"},
+			Value:   false,
+			Usage:   "Ignore changes in amount of whitespace.",
+		},
+		&cli.BoolFlag{
+			Name:  "ignore-space-at-eol",
+			Value: false,
+			Usage: "Ignore changes in whitespace at end of line.",
+		},
+		&cli.BoolFlag{
+			Name:  "ignore-cr-at-eol",
+			Value: false,
+			Usage: "Ignore carriage-return at the end of line.",
+		},
+		&cli.BoolFlag{
+			Name:  "ignore-blank-lines",
+			Value: false,
+			Usage: "Ignore changes whose lines are all blank.",
+		},
+		&cli.BoolFlag{
+			Name:    "text",
+			Aliases: []string{"a"},
+			Value:   false,
+			Usage:   "Treat all files as text.",
+		},
+		&cli.BoolFlag{
+			Name:  "full-index",
+			Value: false,
+			Usage: "Show full object names in the index lines of patches.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-prefix",
+			Value: false,
+			Usage: "Do not show any source or destination prefix.",
+		},
+		&cli.StringFlag{
+			Name:  "src-prefix",
+			Value: "a/",
+			Usage: "Show the given source prefix instead of \"a/\".",
+		},
+		&cli.StringFlag{
+			Name:  "dst-prefix",
+			Value: "b/",
+			Usage: "Show the given destination prefix instead of \"b/\".",
+		},
+		&cli.BoolFlag{
+			Name:  "exit-code",
+			Value: false,
+			Usage: "Exit with 1 if there were differences and 0 otherwise.",
+		},
+		&cli.BoolFlag{
+			Name:  "quiet",
+			Value: false,
+			Usage: "Disable all output, implying --exit-code.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for diff command.")
+
+		args, err := argsWithSeparator(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+		resolver := revision.NewResolver(git)
+		trees, spec, err := splitDiffArguments(c, git, resolver, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if len(trees) > 2 || len(trees) == 2 && c.Bool("cached") {
+			return cli.Exit("usage: git diff [<options>] [<commit>] [--] [<path>...]", 129)
+		}
+
+		renames, err := diffRenameOptions(c, repo)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		diffOpts, err := diffOptions(c, repo)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		opts := diff.CompareOptions{Pathspec: spec, Unmodified: c.Bool("find-copies-harder")}
+
+		var changes []*diff.Change
+		var idx *index.Index
+		refreshed := false
+
+		if len(trees) < 2 {
+			if git.IsBare() {
+				return cli.Exit("this operation must be run in a work tree", 128)
+			}
+
+			if idx, err = git.ReadIndex(); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		switch {
+		case len(trees) == 2:
+			changes, err = diff.Trees(git, trees[0], trees[1], opts)
+		case c.Bool("cached"):
+			if len(trees) == 0 {
+				head, err := headTree(git, resolver)
+
+				if err != nil {
+					return cli.Exit(err.Error(), 128)
+				}
+
+				trees = append(trees, head)
+			}
+
+			changes, err = diff.TreeToIndex(git, trees[0], idx, opts)
+		case len(trees) == 1:
+			changes, refreshed, err = diff.TreeToWorkTree(git, trees[0], idx, opts)
+		default:
+			changes, refreshed, err = diff.IndexToWorkTree(git, idx, opts)
+		}
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		// Refreshing the index is opportunistic, like in Git.
+		if refreshed {
+			if err := git.WriteIndex(idx); err != nil {
+				utils.WarningLogger.Printf("Could not refresh the index: %s\n", err.Error())
+			}
+		}
+
+		if renames != nil {
+			if changes, err = diff.DetectRenames(changes, *renames); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if !c.Bool("quiet") {
+			if err := writeDiff(c, git, changes, diffOpts); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if c.Bool("exit-code") || c.Bool("quiet") {
+			changed, err := diff.HasChanges(changes, diffOpts)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if changed {
+				return cli.Exit("", 1)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,260 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// optionalValue is a flag that can be given with or without a value, like
+// git's --porcelain[=<version>]. Given alone, it takes its implied value.
+// Since values are kept across runs, check c.IsSet before reading it.
+type optionalValue struct {
+	implied string
+	value   string
+}
+
+func (v *optionalValue) String() string {
+	return v.value
+}
+
+func (v *optionalValue) Set(value string) error {
+	if value == "true" {
+		value = v.implied
+	}
+
+	v.value = value
+
+	return nil
+}
+
+// Lets the flag package accept the flag without a value.
+func (v *optionalValue) IsBoolFlag() bool {
+	return true
+}
+
+// One flag or argument of a command line, in the order given. Arguments
+// have no flag name.
+type commandLineItem struct {
+	flag  string
+	value string
+}
+
+// Whether a flag takes a value, given as the next argument or after "=".
+func takesValue(flag cli.Flag) bool {
+	switch f := flag.(type) {
+	case *cli.BoolFlag:
+		return false
+	case *cli.GenericFlag:
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+
+		return !ok || !b.IsBoolFlag()
+	}
+
+	return true
+}
+
+// Split a command line into its flags and arguments. Like in git, flags
+// may come after arguments, up to a "--", which is kept as an argument.
+// Flags without a value get "true", and combined short flags are split
+// for commands handling them.
+func splitCommandLine(c *cli.Context, raw []string) ([]commandLineItem, error) {
+	flags := map[string]cli.Flag{}
+
+	for _, flag := range c.Command.Flags {
+		for _, name := range flag.Names() {
+			flags[name] = flag
+		}
+	}
+
+	items := []commandLineItem{}
+
+	for i := 0; i < len(raw); i++ {
+		arg := raw[i]
+
+		if arg == "--" {
+			for _, arg := range raw[i:] {
+				items = append(items, commandLineItem{value: arg})
+			}
+
+			break
+		}
+
+		// A lone "-" names the standard input.
+		if len(arg) < 2 || arg[0] != '-' {
+			items = append(items, commandLineItem{value: arg})
+
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+
+		flag, ok := flags[name]
+
+		if !ok && c.Command.UseShortOptionHandling && !hasValue && !strings.HasPrefix(arg, "--") {
+			short := []commandLineItem{}
+
+			for _, r := range name {
+				if flag, ok := flags[string(r)]; ok && !takesValue(flag) {
+					short = append(short, commandLineItem{flag: string(r), value: "true"})
+				}
+			}
+
+			if len(short) == len(name) {
+				items = append(items, short...)
+
+				continue
+			}
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("flag provided but not defined: %s", arg)
+		}
+
+		if !hasValue {
+			value = "true"
+
+			if takesValue(flag) {
+				if i+1 == len(raw) {
+					return nil, fmt.Errorf("flag needs an argument: %s", arg)
+				}
+
+				i++
+				value = raw[i]
+			}
+		}
+
+		items = append(items, commandLineItem{flag: name, value: value})
+	}
+
+	return items, nil
+}
+
+// The command line of a command as given, which the parsed flags and
+// arguments do not keep the order of. The "--" that ended flags is kept.
+func commandLine(c *cli.Context) ([]commandLineItem, error) {
+	lineage := c.Lineage()
+
+	if len(lineage) < 2 {
+		return splitCommandLine(c, c.Args().Slice())
+	}
+
+	// The parent context holds the command line of the command.
+	return splitCommandLine(c, lineage[1].Args().Tail())
+}
+
+// Whether the flags of a command were ended by a "--" the flag parser
+// dropped, so that all of its arguments are plain arguments.
+func separatorEndedFlags(c *cli.Context) bool {
+	lineage := c.Lineage()
+
+	if len(lineage) < 2 {
+		return false
+	}
+
+	raw := lineage[1].Args().Tail()
+	n := len(raw) - c.Args().Len()
+
+	return n > 0 && raw[n-1] == "--"
+}
+
+// The arguments of a command, with the "--" that ended its flags, if any.
+// The flag parser stops at the first argument, so the flags given after
+// it are set here. Commands taking both revisions and paths need the "--"
+// to tell them apart.
+func argsWithSeparator(c *cli.Context) ([]string, error) {
+	if separatorEndedFlags(c) {
+		return append([]string{"--"}, c.Args().Slice()...), nil
+	}
+
+	items, err := splitCommandLine(c, c.Args().Slice())
+
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+
+	for _, item := range items {
+		if item.flag == "" {
+			args = append(args, item.value)
+
+			continue
+		}
+
+		if err = setFlag(c, item.flag, item.value); err != nil {
+			return nil, err
+		}
+	}
+
+	return args, nil
+}
+
+// The arguments of a command, wherever its flags were given among them.
+func commandArgs(c *cli.Context) ([]string, error) {
+	args, err := argsWithSeparator(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, arg := range args {
+		if arg == "--" {
+			return append(args[:i:i], args[i+1:]...), nil
+		}
+	}
+
+	return args, nil
+}
+
+// Set a flag given after the arguments of a command under all of its
+// names, as the flag parser does for the flags before them. Slices have
+// one value for all of their names.
+func setFlag(c *cli.Context, name, value string) error {
+	names := []string{name}
+
+	for _, flag := range c.Command.Flags {
+		if _, ok := flag.(*cli.StringSliceFlag); !ok && contains(flag.Names(), name) {
+			names = flag.Names()
+		}
+	}
+
+	for _, name := range names {
+		if err := c.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The input of the main app. Subcommands run as apps of their own, which do
+// not get its reader.
+func mainReader(c *cli.Context) io.Reader {
+	reader := c.App.Reader
+
+	for _, ctx := range c.Lineage() {
+		if ctx.App != nil {
+			reader = ctx.App.Reader
+		}
+	}
+
+	return reader
+}
@@ -1,24 +1,99 @@
 package commands
 
 import (
+	"bufio"
+	"context"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
 	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
-	fs "github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
 )
 
+// Check that content parses as an object of the given type, so that
+// malformed trees, commits and tags never make it into the object store.
+func validateObjectSyntax(format ditto.ObjectFormat, t objfile.GitObjectType, content []byte) error {
+	var err error
+
+	switch t {
+	case objfile.Tree:
+		_, err = tree.Decode(content, format)
+	case objfile.Commit:
+		if _, err = commit.Decode(content); err != nil {
+			err = errors.GitError{Message: "corrupt commit"}
+		}
+	case objfile.Tag:
+		if _, err = tag.Decode(content); err != nil {
+			err = errors.GitError{Message: "corrupt tag"}
+		}
+	}
+
+	return err
+}
+
+type hashObjectOptions struct {
+	typeName  string
+	write     bool
+	literally bool
+	format    ditto.ObjectFormat
+}
+
+// Hash content as an object, writing it to the object store if asked to.
+// repo is only needed, and may only be nil, when the object is not written.
+func hashObjectContent(ctx context.Context, repo *ditto.Repository, content []byte, opts hashObjectOptions) (ditto.Hash, error) {
+	if opts.literally {
+		if !opts.write {
+			return objfile.HashLiteral(opts.format, opts.typeName, content), nil
+		}
+
+		return repo.WriteLiteralObject(ctx, opts.typeName, content)
+	}
+
+	t, err := objfile.DetectObjectType(opts.typeName)
+
+	if err != nil {
+		return ditto.ZeroHash, err
+	}
+
+	if err = validateObjectSyntax(opts.format, t, content); err != nil {
+		return ditto.ZeroHash, err
+	}
+
+	if !opts.write {
+		return objfile.HashObject(opts.format, t, content), nil
+	}
+
+	return repo.WriteObject(ctx, t, content)
+}
+
+// Hash the content of the file at path as an object.
+func hashObjectFile(ctx context.Context, repo *ditto.Repository, path string, opts hashObjectOptions) (ditto.Hash, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return ditto.ZeroHash, errors.GitError{Message: fmt.Sprintf("could not open '%s' for reading: No such file or directory", path)}
+	}
+
+	if err != nil {
+		return ditto.ZeroHash, err
+	}
+
+	return hashObjectContent(ctx, repo, content, opts)
+}
+
 var HashObjectCommand = &cli.Command{
-	Name:     "hash-object",
-	HelpName: "hash-object",
-	Usage:    "Compute object ID and optionally creates a blob from a file",
+	Name:      "hash-object",
+	HelpName:  "hash-object",
+	Usage:     "Compute object ID and optionally creates a blob from a file",
+	ArgsUsage: "[-t <type>] [-w] [--stdin [--literally]] [<file>...] | [-t <type>] [-w] --stdin-paths",
 
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -31,114 +106,115 @@ var HashObjectCommand = &cli.Command{
 			Value: "blob",
 			Usage: "Specify the type",
 		},
+		&cli.BoolFlag{
+			Name:  "stdin",
+			Value: false,
+			Usage: "Read the object from standard input instead of from a file",
+		},
+		&cli.BoolFlag{
+			Name:  "stdin-paths",
+			Value: false,
+			Usage: "Read file names from standard input, one per line, instead of from the command line",
+		},
+		&cli.BoolFlag{
+			Name:  "literally",
+			Value: false,
+			Usage: "Allow any type and skip the syntax checks, to create corrupt objects for debugging",
+		},
 	},
 	Action: func(c *cli.Context) error {
-		utils.InfoLogger.Println("Validating preconditions for init command.")
-
-		workingDir := c.String("C")
+		utils.InfoLogger.Println("Validating preconditions for hash-object command.")
 
-		git, err := fs.FindGit(workingDir)
+		args, err := commandArgs(c)
 
 		if err != nil {
-			utils.ErrorLogger.Println(err.Error())
-
-			cli.Exit(err.Error(), 1)
-
-			return err
+			return cli.Exit(err.Error(), 129)
 		}
 
-		// Check if the file arg is supplied.
-		if c.Args().Len() < 1 {
-			err = errors.GitError{Message: "Need file-path to hash object"}
-
-			return cli.Exit(err.Error(), 1)
+		opts := hashObjectOptions{
+			typeName:  c.String("t"),
+			write:     c.Bool("w"),
+			literally: c.Bool("literally"),
 		}
 
-		path := c.Args().First()
-
-		// Check if the object file path exists
-		if !utils.PathExists(path) {
-			err = errors.GitError{Message: fmt.Sprintf("Object not found at path %s", path)}
+		if c.Bool("stdin-paths") {
+			if c.Bool("stdin") {
+				return cli.Exit("Can't use --stdin-paths with --stdin", 129)
+			}
 
-			return cli.Exit(err.Error(), 1)
+			if len(args) > 0 {
+				return cli.Exit("Can't specify files with --stdin-paths", 129)
+			}
+		} else if !c.Bool("stdin") && len(args) < 1 {
+			return cli.Exit("usage: git hash-object "+c.Command.ArgsUsage, 129)
 		}
 
-		f, err := os.Open(path)
-
-		if err != nil {
-			return err
+		if _, err := objfile.DetectObjectType(opts.typeName); err != nil && !opts.literally {
+			return cli.Exit(fmt.Sprintf("invalid object type \"%s\"", opts.typeName), 128)
 		}
 
-		defer f.Close()
-
-		rawWriter := ioutil.Discard
-		tempLocation := ""
-		var tempFile *os.File
-
-		if c.Bool("w") {
-			tempFile, err = git.GetTempObjectFile()
+		// A repository is only needed to write objects. Outside of one,
+		// objects are hashed with SHA-1.
+		repo, err := openRepository(c)
 
-			if err != nil {
-				return cli.Exit(err.Error(), 1)
-			}
+		if err == nil {
+			opts.format = repo.ObjectFormat()
+		} else if opts.write {
+			utils.ErrorLogger.Println(err.Error())
 
-			tempLocation = tempFile.Name()
+			return cli.Exit(err.Error(), 128)
+		}
 
-			rawWriter = tempFile
+		if c.Bool("stdin") {
+			content, err := ioutil.ReadAll(c.App.Reader)
 
 			if err != nil {
-				return cli.Exit(err.Error(), 1)
+				return cli.Exit(err.Error(), 128)
 			}
-		}
-
-		objWriter, err := objfile.NewWriter(rawWriter)
 
-		if err != nil {
-			return cli.Exit(err.Error(), 1)
-		}
+			hash, err := hashObjectContent(c.Context, repo, content, opts)
 
-		defer objWriter.Close()
+			if err != nil {
+				utils.ErrorLogger.Println(err.Error())
 
-		fi, err := f.Stat()
+				return cli.Exit(err.Error(), 128)
+			}
 
-		if err != nil {
-			return cli.Exit(err.Error(), 1)
+			fmt.Fprintln(c.App.Writer, hash.String())
 		}
 
-		objWriter.WriteHeader(objfile.Blob, fi.Size())
-
-		io.Copy(objWriter, f)
-
-		hash := objWriter.Hash().String()
-
-		fmt.Fprintln(c.App.Writer, hash)
-
-		if c.Bool("w") {
-			err = objWriter.Close()
+		printFileHash := func(path string) error {
+			hash, err := hashObjectFile(c.Context, repo, path, opts)
 
 			if err != nil {
-				return cli.Exit(err.Error(), 1)
-			}
+				utils.ErrorLogger.Println(err.Error())
 
-			err = tempFile.Close()
-
-			if err != nil {
-				return cli.Exit(err.Error(), 1)
+				return cli.Exit(err.Error(), 128)
 			}
 
-			objectPath := git.ComputeObjectPath(hash)
-			objectDir := filepath.Dir(objectPath)
+			fmt.Fprintln(c.App.Writer, hash.String())
 
-			err := os.MkdirAll(objectDir, os.ModePerm)
+			return nil
+		}
 
-			if err != nil {
-				return cli.Exit(err.Error(), 1)
+		// Paths read from the input are hashed as they come in.
+		if c.Bool("stdin-paths") {
+			scanner := bufio.NewScanner(c.App.Reader)
+
+			for scanner.Scan() {
+				if err := printFileHash(scanner.Text()); err != nil {
+					return err
+				}
 			}
 
-			err = os.Rename(tempLocation, objectPath)
+			if err := scanner.Err(); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
 
-			if err != nil {
-				return cli.Exit(err.Error(), 1)
+		for _, path := range args {
+			if err := printFileHash(path); err != nil {
+				return err
 			}
 		}
 
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pack"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+var IndexPackCommand = &cli.Command{
+	Name:      "index-pack",
+	HelpName:  "index-pack",
+	Usage:     "Build pack index file for an existing packed archive",
+	ArgsUsage: "[-o <index-file>] [--object-format=<hash-algorithm>] (--stdin | <pack-file>)",
+
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "o",
+			Usage: "Write the generated pack index into the specified file.",
+		},
+		&cli.StringFlag{
+			Name:  "object-format",
+			Usage: "The hash algorithm of the pack, by default that of the repository, or sha1 outside of one.",
+		},
+		&cli.BoolFlag{
+			Name:  "stdin",
+			Value: false,
+			Usage: "Read the pack from the standard input and store it in the repository.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for index-pack command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		var data []byte
+		var resolve pack.ExternalResolver
+		var packPath string
+
+		idxPath := c.String("o")
+		format := ditto.SHA1
+
+		if repo, err := openRepository(c); err == nil {
+			format = repo.ObjectFormat()
+		}
+
+		if c.IsSet("object-format") {
+			if format, err = ditto.ParseObjectFormat(c.String("object-format")); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if c.Bool("stdin") {
+			repo, err := openRepository(c)
+
+			if err != nil {
+				utils.ErrorLogger.Println(err.Error())
+
+				return cli.Exit(err.Error(), 1)
+			}
+
+			// Thin packs have delta bases among the objects of the repository.
+			resolve = repo.Objects().Get
+
+			data, err = ioutil.ReadAll(c.App.Reader)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			packPath = repo.PackDir()
+		} else {
+			if len(args) != 1 {
+				err = errors.GitError{Message: "Need a pack-file to index, or --stdin."}
+
+				return cli.Exit(err.Error(), 1)
+			}
+
+			packPath = args[0]
+
+			if !strings.HasSuffix(packPath, ".pack") {
+				err = errors.GitError{Message: fmt.Sprintf("Packfile name '%s' does not end with '.pack'", packPath)}
+
+				return cli.Exit(err.Error(), 128)
+			}
+
+			data, err = ioutil.ReadFile(packPath)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		entries, checksum, err := pack.IndexPack(data, format, resolve)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if c.Bool("stdin") {
+			packPath = filepath.Join(packPath, fmt.Sprintf("pack-%s.pack", checksum))
+
+			if !utils.PathExists(packPath) {
+				if err = ioutil.WriteFile(packPath, data, 0444); err != nil {
+					return cli.Exit(err.Error(), 128)
+				}
+			}
+		}
+
+		if idxPath == "" {
+			idxPath = strings.TrimSuffix(packPath, ".pack") + ".idx"
+		}
+
+		idx := bytes.NewBuffer(nil)
+
+		if err = pack.WriteIndex(idx, entries, checksum); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if err = ioutil.WriteFile(idxPath, idx.Bytes(), 0444); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if c.Bool("stdin") {
+			fmt.Fprintf(c.App.Writer, "pack\t%s\n", checksum)
+		} else {
+			fmt.Fprintln(c.App.Writer, checksum.String())
+		}
+
+		return nil
+	},
+}
@@ -2,159 +2,106 @@ package commands
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/urfave/cli/v2"
 
-	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
 	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
 )
 
-func getDirsToMake() []string {
-	return []string{".git", ".git/objects", ".git/refs", ".git/objects/pack", ".git/refs/heads", ".git/refs/tags"}
-}
-
-func checkEmptyRepoTarget(targetPath string) error {
-	for _, dir := range getDirsToMake() {
-		path := filepath.Join(targetPath, dir)
-		if utils.PathExists(path) {
-			return &errors.PathError{
-				Op:   "AlreadyExists",
-				Path: path,
-				Err:  nil,
-			}
-		}
+func getTargetDir(args []string) (string, error) {
+	if len(args) > 0 {
+		return filepath.Abs(args[0])
 	}
 
-	return nil
+	return os.Getwd()
 }
 
-func getTargetDir(c *cli.Context) (string, error) {
-	curDir, err := os.Getwd()
-
-	if err != nil {
-		return "", err
-	}
+var InitCommand = &cli.Command{
+	Name:      "init",
+	HelpName:  "init",
+	Usage:     "Create an empty Git repository or reinitialize an existing one",
+	ArgsUsage: "[-q] [--bare] [--template=<template-directory>] [--separate-git-dir <git-dir>] [--object-format=<format>] [-b <branch-name>] [<directory>]",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Value:   false,
+			Usage:   "Only print error and warning messages.",
+		},
+		&cli.BoolFlag{
+			Name:  "bare",
+			Value: false,
+			Usage: "Create a bare repository, without a working tree.",
+		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "Specify the directory from which templates will be used.",
+		},
+		&cli.StringFlag{
+			Name:  "separate-git-dir",
+			Usage: "Create the repository at <git-dir>, with a .git file in the working tree pointing at it.",
+		},
+		&cli.StringFlag{
+			Name:  "object-format",
+			Usage: "Specify the hash algorithm to use for the repository.",
+		},
+		&cli.StringFlag{
+			Name:    "initial-branch",
+			Aliases: []string{"b"},
+			Usage:   "Use the given name for the initial branch, instead of init.defaultBranch.",
+		},
+	},
 
-	targetDir := curDir
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for init command.")
 
-	if c.Args().Len() > 0 {
-		targetDir, err = filepath.Abs(c.Args().Get(0))
+		args, err := commandArgs(c)
 
 		if err != nil {
-			return "", err
+			return cli.Exit(err.Error(), 129)
 		}
-	}
 
-	return targetDir, nil
-}
-
-func validateInitCommand(c *cli.Context) error {
-	// If this is a init command without a target, the .git folder should not exist.
-	// Else the .git folder within the target folder should not exist.
-	targetDir, err := getTargetDir(c)
+		targetDir, err := getTargetDir(args)
 
-	if err != nil {
-		return err
-	}
-
-	return checkEmptyRepoTarget(targetDir)
-}
-
-func initializeGitHead(c *cli.Context) error {
-	headFileContents := []byte("ref: refs/heads/master\n")
-
-	targetDir, err := getTargetDir(c)
-
-	if err != nil {
-		return err
-	}
-
-	headFilePath := filepath.Join(targetDir, ".git/HEAD")
-
-	if err := ioutil.WriteFile(headFilePath, headFileContents, 0644); err != nil {
-		return &errors.PathError{
-			Op:   "Write",
-			Path: headFilePath,
-			Err:  err,
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
 		}
-	}
-
-	return nil
-}
 
-func initializeGitDirs(c *cli.Context) error {
-	targetDir, err := getTargetDir(c)
-
-	if err != nil {
-		return err
-	}
-
-	if c.Args().Len() > 0 {
-		err = os.Mkdir(targetDir, 0755)
-	}
-
-	if err != nil {
-		return err
-	}
-
-	for _, dir := range getDirsToMake() {
-		dirToMake := filepath.Join(targetDir, dir)
-
-		if err := os.Mkdir(dirToMake, 0755); err != nil {
-			return &errors.PathError{
-				Op:   "Mkdir",
-				Path: dirToMake,
-				Err:  err,
-			}
+		opts := ditto.InitOptions{
+			Bare:           c.Bool("bare"),
+			InitialBranch:  c.String("initial-branch"),
+			TemplateDir:    c.String("template"),
+			ObjectFormat:   c.String("object-format"),
+			SeparateGitDir: c.String("separate-git-dir"),
 		}
-	}
-
-	return nil
-}
-
-var InitCommand = &cli.Command{
-	Name:     "init",
-	HelpName: "init",
-	Usage:    "Initialize a git repository",
 
-	Before: func(c *cli.Context) error {
-		utils.InfoLogger.Println("Validating preconditions for init command.")
+		utils.InfoLogger.Println("Creating the repository.")
 
-		err := validateInitCommand(c)
+		repo, reinit, err := ditto.InitWithOptions(c.Context, targetDir, opts)
 
 		if err != nil {
-			utils.ErrorLogger.Printf("Error when validating preconditions: %s\n", err.Error())
+			utils.ErrorLogger.Printf("Error when creating the repository: %s\n", err.Error())
 
-			utils.PrintError(err.Error(), c.Command.Name)
-			cli.Exit(err.Error(), 1)
+			return cli.Exit(err.Error(), 128)
 		}
 
-		return err
-	},
-
-	Action: func(c *cli.Context) error {
-		utils.InfoLogger.Println("Creating directories.")
-
-		err := initializeGitDirs(c)
-
-		if err != nil {
-			utils.ErrorLogger.Printf("Error when creating directories: %s\n", err.Error())
-			return err
+		if reinit && opts.InitialBranch != "" {
+			fmt.Fprintf(c.App.ErrWriter, "warning: re-init: ignored --initial-branch=%s\n", opts.InitialBranch)
 		}
 
-		utils.InfoLogger.Println("Creating HEAD marker.")
-
-		err = initializeGitHead(c)
-
-		if err != nil {
-			utils.ErrorLogger.Printf("Error when creating HEAD marker: %s\n", err.Error())
-			return err
+		if c.Bool("quiet") {
+			return nil
 		}
 
-		fmt.Fprintln(c.App.Writer, "Initialized git directory")
+		if reinit {
+			fmt.Fprintf(c.App.Writer, "Reinitialized existing Git repository in %s/\n", repo.GitDir())
+		} else {
+			fmt.Fprintf(c.App.Writer, "Initialized empty Git repository in %s/\n", repo.GitDir())
+		}
 
 		return nil
 	},
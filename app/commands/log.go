@@ -0,0 +1,366 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/graph"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pathspec"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pretty"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Split the arguments of log into the tips to walk and the paths to limit
+// history to. Paths follow "--", or the first argument that is not a
+// revision but names a file in the working tree.
+func splitRevisionsAndPaths(c *cli.Context, git *fs.Git, resolver *revision.Resolver, args []string) ([]revision.Tip, pathspec.Pathspec, error) {
+	tips := []revision.Tip{}
+	paths := []string{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			paths = append(paths, args[i+1:]...)
+
+			break
+		}
+
+		resolved, err := resolver.ResolveRange(arg)
+
+		if err == nil {
+			tips = append(tips, resolved...)
+
+			continue
+		}
+
+		if !revision.IsNotFound(err) {
+			return nil, pathspec.Pathspec{}, err
+		}
+
+		if _, statErr := os.Lstat(filepath.Join(c.String("C"), arg)); statErr != nil {
+			return nil, pathspec.Pathspec{}, errors.GitError{Message: fmt.Sprintf("ambiguous argument '%s': unknown revision or path not in the working tree.\n"+
+				"Use '--' to separate paths from revisions, like this:\n"+
+				"'git <command> [<revision>...] -- [<file>...]'", arg)}
+		}
+
+		paths = append(paths, args[i:]...)
+
+		break
+	}
+
+	if git.IsBare() {
+		return tips, pathspec.New(paths), nil
+	}
+
+	spec, err := getPathspec(git, c, paths)
+
+	return tips, spec, err
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return nil, errors.GitError{Message: fmt.Sprintf("invalid regex '%s': %s", pattern, err.Error())}
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+// Build the walk options from the filtering flags of log.
+func walkOptions(c *cli.Context, paths pathspec.Pathspec) (revision.WalkOptions, error) {
+	opts := revision.WalkOptions{
+		FirstParent: c.Bool("first-parent"),
+		Paths:       paths,
+		MaxParents:  -1,
+		TopoOrder:   c.Bool("graph"),
+	}
+
+	now := time.Now()
+
+	var err error
+
+	if c.IsSet("since") {
+		if opts.Since, err = commit.ParseApproxDate(c.String("since"), now); err != nil {
+			return opts, err
+		}
+	}
+
+	if c.IsSet("until") {
+		if opts.Until, err = commit.ParseApproxDate(c.String("until"), now); err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.Authors, err = compilePatterns(c.StringSlice("author")); err != nil {
+		return opts, err
+	}
+
+	opts.Grep, err = compilePatterns(c.StringSlice("grep"))
+
+	return opts, err
+}
+
+// Write the log of the commits listed, drawing the graph next to them when
+// there is one.
+func writeLog(w io.Writer, formatter *pretty.Formatter, entries []*revision.Entry, g *graph.Graph, listed map[plumbing.Hash]bool, firstParent bool) error {
+	missingNewline := false
+	shown := make(map[plumbing.Hash]bool)
+
+	for i, entry := range entries {
+		if g != nil {
+			// Only parents listed below the commit have a line in the
+			// graph, and only the first one when following first parents.
+			parents := []plumbing.Hash{}
+
+			for j, parent := range entry.Parents {
+				if j > 0 && firstParent {
+					break
+				}
+
+				if listed[parent] && !shown[parent] {
+					parents = append(parents, parent)
+				}
+			}
+
+			g.Update(entry.Hash, parents)
+			shown[entry.Hash] = true
+		}
+
+		if i > 0 && formatter.Separated() {
+			if g != nil && !missingNewline {
+				io.WriteString(w, g.PaddingLine())
+			}
+
+			io.WriteString(w, "\n")
+		}
+
+		text, err := formatter.Format(entry.Hash, entry.Commit)
+
+		if err != nil {
+			return err
+		}
+
+		missingNewline = len(text) == 0 || text[len(text)-1] != '\n'
+
+		if g != nil {
+			g.ShowCommit(w)
+			g.ShowMessage(w, text)
+		} else {
+			io.WriteString(w, text)
+		}
+
+		if formatter.Terminated() {
+			if g != nil && !missingNewline {
+				io.WriteString(w, g.PaddingLine())
+			}
+
+			io.WriteString(w, "\n")
+		}
+	}
+
+	return nil
+}
+
+var LogCommand = &cli.Command{
+	Name:      "log",
+	HelpName:  "log",
+	Usage:     "Show commit logs",
+	ArgsUsage: "[<revision-range>] [[--] <path>...]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "oneline",
+			Value: false,
+			Usage: "Show each commit on a single line, with its abbreviated name and title.",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"pretty"},
+			Usage:   "Pretty-print commits in the given format: oneline, short, medium, full, fuller, raw, format:<string> or tformat:<string>.",
+		},
+		&cli.IntFlag{
+			Name:    "max-count",
+			Aliases: []string{"n"},
+			Value:   -1,
+			Usage:   "Limit the number of commits to output.",
+		},
+		&cli.StringFlag{
+			Name:    "since",
+			Aliases: []string{"after"},
+			Usage:   "Show commits more recent than a specific date.",
+		},
+		&cli.StringFlag{
+			Name:    "until",
+			Aliases: []string{"before"},
+			Usage:   "Show commits older than a specific date.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "author",
+			Usage: "Limit the commits output to ones with an author matching the pattern.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "grep",
+			Usage: "Limit the commits output to ones with a message matching the pattern.",
+		},
+		&cli.BoolFlag{
+			Name:  "first-parent",
+			Value: false,
+			Usage: "Follow only the first parent commit upon seeing a merge commit.",
+		},
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Value: false,
+			Usage: "Output the commits chosen to be shown in reverse order.",
+		},
+		&cli.BoolFlag{
+			Name:  "graph",
+			Value: false,
+			Usage: "Draw a text-based graphical representation of the commit history.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for log command.")
+
+		args, err := argsWithSeparator(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		if c.Bool("reverse") && c.Bool("graph") {
+			return cli.Exit("options '--reverse' and '--graph' cannot be used together", 128)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+		resolver := revision.NewResolver(git)
+		tips, paths, err := splitRevisionsAndPaths(c, git, resolver, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if len(tips) == 0 {
+			branch, head, err := refs.NewStore(git.GitDir()).Head()
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if head.IsZero() {
+				return cli.Exit(fmt.Sprintf("your current branch '%s' does not have any commits yet", refs.ShortName(branch)), 128)
+			}
+
+			tips = append(tips, revision.Tip{Hash: head})
+		}
+
+		spec := c.String("format")
+
+		if c.Bool("oneline") && !c.IsSet("format") {
+			spec = "oneline"
+		}
+
+		formatter, err := pretty.New(git, spec)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		formatter.AbbrevCommit = c.Bool("oneline")
+
+		opts, err := walkOptions(c, paths)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		walker := revision.NewWalker(c.Context, git, opts)
+
+		for _, tip := range tips {
+			if err := walker.Add(tip); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		// The graph needs every commit listed, to know which parents
+		// have a line; otherwise the walk stops once enough are found.
+		limit := c.Int("max-count")
+		entries := []*revision.Entry{}
+		listed := make(map[plumbing.Hash]bool)
+
+		for limit < 0 || len(entries) < limit || c.Bool("graph") {
+			entry, err := walker.Next()
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if entry == nil {
+				break
+			}
+
+			entries = append(entries, entry)
+			listed[entry.Hash] = true
+		}
+
+		if limit >= 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+
+		if c.Bool("reverse") {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+
+		var g *graph.Graph
+
+		if c.Bool("graph") {
+			g = graph.New()
+
+			// Commits show the parents their line in the graph leads to,
+			// past those history simplification dropped.
+			if !paths.Empty() {
+				for _, entry := range entries {
+					rewritten := *entry.Commit
+					rewritten.Parents = entry.Parents
+					entry.Commit = &rewritten
+				}
+			}
+		}
+
+		out := bufio.NewWriter(c.App.Writer)
+
+		if err := writeLog(out, formatter, entries, g, listed, c.Bool("first-parent")); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		return out.Flush()
+	},
+}
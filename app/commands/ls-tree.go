@@ -2,12 +2,9 @@ package commands
 
 import (
 	"fmt"
-	"io"
 
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
 	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
-	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
-	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
-	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
 	"github.com/urfave/cli/v2"
 )
@@ -28,9 +25,13 @@ var LsTreeCommand = &cli.Command{
 	Action: func(c *cli.Context) (err error) {
 		utils.InfoLogger.Println("Validating preconditions for the init command.")
 
-		workingDir := c.String("C")
+		args, err := commandArgs(c)
 
-		git, err := fs.FindGit(workingDir)
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
 
 		if err != nil {
 			utils.ErrorLogger.Println(err.Error())
@@ -38,50 +39,34 @@ var LsTreeCommand = &cli.Command{
 			return cli.Exit(err.Error(), 1)
 		}
 
-		if c.Args().Len() < 1 {
+		if len(args) < 1 {
 			err = errors.GitError{Message: "Need tree_sha to list tree contents."}
 
 			return cli.Exit(err.Error(), 1)
 		}
 
-		reader, err := git.GetObjectReader(c.Args().First())
+		rev := args[0]
 
-		if err != nil {
-			return cli.Exit(err.Error(), 1)
-		}
-
-		objreader, err := objfile.NewReader(reader)
+		hash, err := repo.Resolve(c.Context, rev)
 
-		if err != nil {
-			return cli.Exit(err.Error(), 1)
+		if err == nil {
+			hash, err = repo.Peel(c.Context, hash, ditto.TreeObject, rev)
 		}
 
-		objtype, _, err := objreader.Header()
-
 		if err != nil {
-			return cli.Exit(err.Error(), 1)
+			return cli.Exit(err.Error(), 128)
 		}
 
-		if objtype != objfile.Tree {
-			err = errors.GitError{Message: "Cannot ls-tree a non-tree object"}
+		t, err := repo.Tree(c.Context, hash)
 
+		if err != nil {
 			return cli.Exit(err.Error(), 1)
 		}
 
-		iterator := tree.TreeEntryIterator(objreader)
-
-		for {
-			entry, err := iterator()
-
-			if err != nil {
-				if err == io.EOF {
-					return nil
-				}
-
-				return cli.Exit(err.Error(), 1)
-			}
-
+		for _, entry := range t.Entries {
 			fmt.Fprintln(c.App.Writer, entry.String(c.Bool("name-only")))
 		}
+
+		return nil
 	},
 }
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Resolve revisions to the commits they name, peeling tags.
+func resolveCommits(ctx context.Context, repo *ditto.Repository, revs []string) ([]ditto.Hash, error) {
+	commits := []ditto.Hash{}
+
+	for _, rev := range revs {
+		hash, err := repo.Resolve(ctx, rev)
+
+		if err != nil {
+			return nil, errors.GitError{Message: fmt.Sprintf("Not a valid object name %s", rev)}
+		}
+
+		if hash, err = repo.Peel(ctx, hash, ditto.CommitObject, rev); err != nil {
+			return nil, errors.GitError{Message: fmt.Sprintf("Not a valid commit name %s", rev)}
+		}
+
+		commits = append(commits, hash)
+	}
+
+	return commits, nil
+}
+
+var MergeBaseCommand = &cli.Command{
+	Name:      "merge-base",
+	HelpName:  "merge-base",
+	Usage:     "Find as good common ancestors as possible for a merge",
+	ArgsUsage: "[-a | --all] <commit> <commit>... | [-a | --all] --octopus <commit>... | --is-ancestor <commit> <commit> | --independent <commit>...",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "all",
+			Aliases: []string{"a"},
+			Value:   false,
+			Usage:   "Output all merge bases for the commits, instead of just one.",
+		},
+		&cli.BoolFlag{
+			Name:  "octopus",
+			Value: false,
+			Usage: "Compute the best common ancestors of all supplied commits, in preparation for an n-way merge.",
+		},
+		&cli.BoolFlag{
+			Name:  "independent",
+			Value: false,
+			Usage: "List the supplied commits that cannot be reached from any other.",
+		},
+		&cli.BoolFlag{
+			Name:  "is-ancestor",
+			Value: false,
+			Usage: "Check if the first commit is an ancestor of the second, exiting with 0 if so and 1 if not.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for merge-base command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		modes := 0
+
+		for _, mode := range []string{"octopus", "independent", "is-ancestor"} {
+			if c.Bool(mode) {
+				modes++
+			}
+		}
+
+		switch {
+		case modes > 1:
+			return cli.Exit("options '--octopus', '--independent' and '--is-ancestor' cannot be used together", 129)
+		case c.Bool("is-ancestor") && c.Bool("all"):
+			return cli.Exit("--is-ancestor cannot be used with --all", 128)
+		case c.Bool("is-ancestor") && len(args) != 2:
+			return cli.Exit("--is-ancestor takes exactly two commits", 128)
+		case modes == 0 && len(args) < 2 || len(args) < 1:
+			return cli.Exit("usage: git merge-base "+c.Command.ArgsUsage, 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		commits, err := resolveCommits(c.Context, repo, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		var result []ditto.Hash
+
+		switch {
+		case c.Bool("is-ancestor"):
+			ancestor, err := repo.IsAncestor(c.Context, commits[0], commits[1])
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if !ancestor {
+				return cli.Exit("", 1)
+			}
+
+			return nil
+		case c.Bool("independent"):
+			result, err = repo.IndependentCommits(c.Context, commits...)
+		case c.Bool("octopus"):
+			result, err = repo.OctopusMergeBases(c.Context, commits...)
+		default:
+			result, err = repo.MergeBases(c.Context, commits[0], commits[1:]...)
+		}
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if len(result) == 0 {
+			return cli.Exit("", 1)
+		}
+
+		// Only --independent lists every commit without --all.
+		if !c.Bool("all") && !c.Bool("independent") {
+			result = result[:1]
+		}
+
+		out := bufio.NewWriter(c.App.Writer)
+
+		for _, hash := range result {
+			fmt.Fprintln(out, hash.String())
+		}
+
+		return out.Flush()
+	},
+}
@@ -0,0 +1,735 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/checkout"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/merge"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pretty"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// The files a merge stopped before committing leaves in the git directory.
+var mergeStateFiles = []string{"MERGE_HEAD", "MERGE_MSG", "MERGE_MODE", "AUTO_MERGE"}
+
+// A merge in progress: the repository, and the commits and trees merged.
+type merging struct {
+	c    *cli.Context
+	repo *ditto.Repository
+	git  *fs.Git
+	idx  *index.Index
+	out  *bufio.Writer
+
+	branch      string
+	head        plumbing.Hash
+	headTree    plumbing.Hash
+	theirs      plumbing.Hash
+	theirsLabel string
+}
+
+// The default message of a merge commit: what was merged, by the kind of
+// ref named, and into which branch unless it is main or master.
+func mergeMessage(git *fs.Git, branch, name string) (string, error) {
+	ref, err := refs.NewStore(git.GitDir()).Dwim(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("Merge commit '%s'", name)
+
+	if ref != nil {
+		switch {
+		case strings.HasPrefix(ref.Name, refs.HeadsPrefix):
+			message = fmt.Sprintf("Merge branch '%s'", strings.TrimPrefix(ref.Name, refs.HeadsPrefix))
+		case strings.HasPrefix(ref.Name, refs.TagsPrefix):
+			message = fmt.Sprintf("Merge tag '%s'", strings.TrimPrefix(ref.Name, refs.TagsPrefix))
+		case strings.HasPrefix(ref.Name, refs.RemotesPrefix):
+			message = fmt.Sprintf("Merge remote-tracking branch '%s'", strings.TrimPrefix(ref.Name, refs.RemotesPrefix))
+		}
+	}
+
+	if short := strings.TrimPrefix(branch, refs.HeadsPrefix); short != "main" && short != "master" && branch != "" {
+		message += " into " + short
+	}
+
+	return message, nil
+}
+
+// How the merge treats files: renames from the merge.renames and
+// merge.renameLimit configuration, falling back to diff's, and the style
+// from merge.conflictStyle. Like git's, the merge compares files with the
+// histogram algorithm.
+func mergeOptions(repo *ditto.Repository, c *cli.Context, theirsLabel string) (merge.Options, error) {
+	opts := merge.Options{OurLabel: "HEAD", TheirLabel: theirsLabel, Algorithm: diff.Histogram}
+	cfg, err := repo.Config(c.Context)
+
+	if err != nil {
+		return opts, err
+	}
+
+	detect, err := cfg.Bool("diff.renames", true)
+
+	if err != nil {
+		return opts, err
+	}
+
+	if detect, err = cfg.Bool("merge.renames", detect); err != nil {
+		return opts, err
+	}
+
+	limit, err := cfg.Int("diff.renameLimit", 7000)
+
+	if err != nil {
+		return opts, err
+	}
+
+	if limit, err = cfg.Int("merge.renameLimit", limit); err != nil {
+		return opts, err
+	}
+
+	if detect {
+		opts.Renames = &diff.RenameOptions{Limit: int(limit)}
+	}
+
+	opts.Style, _ = cfg.Get("merge.conflictStyle")
+
+	switch opts.Style {
+	case "", merge.StyleMerge, merge.StyleDiff3:
+	default:
+		return opts, errors.GitError{Message: fmt.Sprintf("unknown style '%s' given for 'merge.conflictstyle'", opts.Style)}
+	}
+
+	return opts, nil
+}
+
+func (m *merging) writeStateFile(name, content string) error {
+	return ioutil.WriteFile(filepath.Join(m.git.GitDir(), name), []byte(content), 0644)
+}
+
+func (m *merging) updateRef(name string, hash plumbing.Hash) error {
+	return m.repo.Refs().Update(m.c.Context, name, hash, nil, true, "")
+}
+
+// Show what changed from the old HEAD to a tree, as a diffstat and a
+// summary, comparing files with the diff.algorithm configured.
+func (m *merging) writeStat(to plumbing.Hash, renames *diff.RenameOptions) error {
+	if m.c.Bool("no-stat") {
+		return nil
+	}
+
+	cfg, err := m.repo.Config(m.c.Context)
+
+	if err != nil {
+		return err
+	}
+
+	opts := &diff.Options{Stat: diff.StatOptions{Width: terminalColumns()}}
+
+	if algorithm, ok := cfg.Get("diff.algorithm"); ok {
+		if opts.Lines.Algorithm, err = diff.ParseAlgorithm(algorithm); err != nil {
+			return err
+		}
+	}
+
+	changes, err := diff.Trees(m.git, m.headTree, to, diff.CompareOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	if renames != nil {
+		if changes, err = diff.DetectRenames(changes, *renames); err != nil {
+			return err
+		}
+	}
+
+	stats, err := diff.Stats(changes, opts)
+
+	if err != nil {
+		return err
+	}
+
+	diff.WriteStat(m.out, stats, opts.Stat)
+	diff.WriteSummary(m.out, changes)
+
+	return nil
+}
+
+// The message of a squashed merge: the commits it takes in, the way log
+// shows them.
+func (m *merging) squashMessage() (string, error) {
+	formatter, err := pretty.New(m.git, "medium")
+
+	if err != nil {
+		return "", err
+	}
+
+	walker := revision.NewWalker(m.c.Context, m.git, revision.WalkOptions{MaxParents: -1})
+
+	if err := walker.Add(revision.Tip{Hash: m.theirs}); err != nil {
+		return "", err
+	}
+
+	if !m.head.IsZero() {
+		if err := walker.Add(revision.Tip{Hash: m.head, Hidden: true}); err != nil {
+			return "", err
+		}
+	}
+
+	commits := []string{}
+
+	for {
+		entry, err := walker.Next()
+
+		if err != nil {
+			return "", err
+		}
+
+		if entry == nil {
+			break
+		}
+
+		text, err := formatter.Format(entry.Hash, entry.Commit)
+
+		if err != nil {
+			return "", err
+		}
+
+		commits = append(commits, text)
+	}
+
+	return "Squashed commit of the following:\n\n" + strings.Join(commits, "\n"), nil
+}
+
+func (m *merging) squash() error {
+	message, err := m.squashMessage()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(m.out, "Squash commit -- not updating HEAD")
+
+	return m.writeStateFile("SQUASH_MSG", message)
+}
+
+// Fast-forward HEAD to their commit, or only the index and the working
+// tree when squashing.
+func (m *merging) fastForward(opts merge.Options) error {
+	theirsTree, err := revision.NewResolver(m.git).Peel(m.theirs, objfile.Tree, m.theirsLabel)
+
+	if err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if !m.head.IsZero() {
+		from, _ := revision.Abbreviate(m.git, m.head, revision.DefaultAbbrev)
+		to, _ := revision.Abbreviate(m.git, m.theirs, revision.DefaultAbbrev)
+
+		fmt.Fprintf(m.out, "Updating %s..%s\n", from, to)
+	}
+
+	if err := checkout.Update(m.git, m.idx, m.headTree, theirsTree, checkout.Options{Action: "merge"}); err != nil {
+		return m.failUpdate(err, "Aborting", 1)
+	}
+
+	if err := m.git.WriteIndex(m.idx); err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if !m.head.IsZero() {
+		fmt.Fprintln(m.out, "Fast-forward")
+	}
+
+	if m.c.Bool("squash") {
+		err = m.squash()
+	} else {
+		reason := "initial pull"
+
+		if !m.head.IsZero() {
+			reason = fmt.Sprintf("merge %s: Fast-forward", m.theirsLabel)
+		}
+
+		err = m.repo.Refs().Update(m.c.Context, refs.Head, m.theirs, nil, false, reason)
+	}
+
+	if err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if err := m.writeStat(theirsTree, opts.Renames); err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	return m.out.Flush()
+}
+
+// Report local changes an update would have thrown away.
+func (m *merging) failUpdate(err error, message string, code int) error {
+	if _, ok := err.(checkout.OverwriteError); !ok {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	m.out.Flush()
+	fmt.Fprintf(m.c.App.ErrWriter, "error: %s\n", err.Error())
+
+	if message != "Aborting" {
+		fmt.Fprintln(m.c.App.ErrWriter, "Aborting")
+	}
+
+	return cli.Exit(message, code)
+}
+
+// Merge the trees of both commits from their merge bases, then commit the
+// result, or leave it to be committed with the conflicts to resolve.
+func (m *merging) mergeTrees(opts merge.Options) error {
+	// The merge starts from the index, which must be HEAD's.
+	staged, err := diff.TreeToIndex(m.git, m.headTree, m.idx, diff.CompareOptions{})
+
+	if err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if len(staged) > 0 {
+		fmt.Fprintln(m.c.App.ErrWriter, "error: Your local changes to the following files would be overwritten by merge:")
+
+		for _, change := range staged {
+			fmt.Fprintf(m.c.App.ErrWriter, "  %s\n", change.Path())
+		}
+
+		return cli.Exit("Merge with strategy ort failed.", 2)
+	}
+
+	result, err := merge.Commits(m.c.Context, m.git, m.head, m.theirs, opts)
+
+	if err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if err := checkout.Update(m.git, m.idx, m.headTree, result.Tree, checkout.Options{Action: "merge"}); err != nil {
+		return m.failUpdate(err, "Merge with strategy ort failed.", 2)
+	}
+
+	for _, conflict := range result.Conflicts {
+		m.idx.Remove(conflict.Path)
+
+		for i, side := range conflict.Stages {
+			if side.Mode != 0 {
+				m.idx.Add(&index.Entry{Name: conflict.Path, Mode: side.Mode, Hash: side.Hash, Stage: conflict.Stage(i)})
+			}
+		}
+	}
+
+	if err := m.git.WriteIndex(m.idx); err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	for _, message := range result.Messages {
+		fmt.Fprintln(m.out, message)
+	}
+
+	if result.Clean() && !m.c.Bool("squash") && !m.c.Bool("no-commit") {
+		if err := m.commit(result.Tree); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		fmt.Fprintln(m.out, "Merge made by the 'ort' strategy.")
+
+		if err := m.writeStat(result.Tree, opts.Renames); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		return m.out.Flush()
+	}
+
+	if err := m.stop(result); err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if !result.Clean() {
+		fmt.Fprintln(m.out, "Automatic merge failed; fix conflicts and then commit the result.")
+		m.out.Flush()
+
+		return cli.Exit("", 1)
+	}
+
+	if err := m.out.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(m.c.App.ErrWriter, "Automatic merge went well; stopped before committing as requested")
+
+	return nil
+}
+
+// The message given with -m, or the default one.
+func (m *merging) message() (string, error) {
+	if messages := m.c.StringSlice("m"); len(messages) > 0 {
+		return strings.Join(messages, "\n\n") + "\n", nil
+	}
+
+	message, err := mergeMessage(m.git, m.branch, m.theirsLabel)
+
+	return message + "\n", err
+}
+
+func (m *merging) commit(tree plumbing.Hash) error {
+	message, err := m.message()
+
+	if err != nil {
+		return err
+	}
+
+	c := &ditto.Commit{Tree: tree, Parents: []plumbing.Hash{m.head, m.theirs}, Message: message}
+
+	if c.Author, err = m.repo.Identity(m.c.Context, ditto.AuthorRole); err != nil {
+		return err
+	}
+
+	if c.Committer, err = m.repo.Identity(m.c.Context, ditto.CommitterRole); err != nil {
+		return err
+	}
+
+	hash, err := m.repo.WriteCommit(m.c.Context, c)
+
+	if err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("merge %s: Merge made by the 'ort' strategy.", m.theirsLabel)
+
+	return m.repo.Refs().Update(m.c.Context, refs.Head, hash, &m.head, false, reason)
+}
+
+// Stop before committing, recording the merge for the commit that
+// concludes it, or only its message when squashing.
+func (m *merging) stop(result *merge.Result) error {
+	message := ""
+
+	if m.c.Bool("squash") {
+		if err := m.squash(); err != nil {
+			return err
+		}
+	} else {
+		var err error
+
+		if message, err = m.message(); err != nil {
+			return err
+		}
+
+		if err := m.updateRef("MERGE_HEAD", m.theirs); err != nil {
+			return err
+		}
+
+		if err := m.writeStateFile("MERGE_MODE", ""); err != nil {
+			return err
+		}
+	}
+
+	if !result.Clean() {
+		message += "\n# Conflicts:\n"
+
+		for _, conflict := range result.Conflicts {
+			message += "#\t" + conflict.Path + "\n"
+		}
+	}
+
+	if message != "" {
+		if err := m.writeStateFile("MERGE_MSG", message); err != nil {
+			return err
+		}
+	}
+
+	return m.updateRef("AUTO_MERGE", result.Tree)
+}
+
+// Throw away a merge stopped before committing, putting the index and the
+// working tree back to HEAD.
+func abortMerge(c *cli.Context, repo *ditto.Repository) error {
+	git := internalGit(repo)
+
+	if !utils.PathExists(filepath.Join(git.GitDir(), "MERGE_HEAD")) {
+		return cli.Exit("There is no merge to abort (MERGE_HEAD missing).", 128)
+	}
+
+	tree, err := headTree(git, revision.NewResolver(git))
+
+	if err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	idx, err := git.ReadIndex()
+
+	if err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if err := checkout.Update(git, idx, tree, tree, checkout.Options{Force: true}); err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	if err := git.WriteIndex(idx); err != nil {
+		return cli.Exit(err.Error(), 128)
+	}
+
+	for _, name := range mergeStateFiles {
+		if err := os.Remove(filepath.Join(git.GitDir(), name)); err != nil && !os.IsNotExist(err) {
+			return cli.Exit(err.Error(), 128)
+		}
+	}
+
+	return nil
+}
+
+// Refuse to merge over an unfinished one.
+func checkNoMergeInProgress(w io.Writer, git *fs.Git, idx *index.Index) error {
+	if idx.Conflicted() {
+		fmt.Fprintln(w, "error: Merging is not possible because you have unmerged files.")
+		fmt.Fprintln(w, "hint: Fix them up in the work tree, and then use 'git add/rm <file>'")
+		fmt.Fprintln(w, "hint: as appropriate to mark resolution and make a commit.")
+
+		return cli.Exit("Exiting because of an unresolved conflict.", 128)
+	}
+
+	if utils.PathExists(filepath.Join(git.GitDir(), "MERGE_HEAD")) {
+		return cli.Exit("You have not concluded your merge (MERGE_HEAD exists).\nPlease, commit your changes before you merge.", 128)
+	}
+
+	return nil
+}
+
+var MergeCommand = &cli.Command{
+	Name:      "merge",
+	HelpName:  "merge",
+	Usage:     "Join two development histories together",
+	ArgsUsage: "[--no-ff | --ff-only] [--squash] [--no-commit] [-m <msg>]... <commit> | --abort",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "ff",
+			Value: false,
+			Usage: "Fast-forward when the merged commit descends from HEAD, without creating a merge commit.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-ff",
+			Value: false,
+			Usage: "Create a merge commit even when the merge could fast-forward.",
+		},
+		&cli.BoolFlag{
+			Name:  "ff-only",
+			Value: false,
+			Usage: "Only fast-forward, refusing to merge otherwise.",
+		},
+		&cli.BoolFlag{
+			Name:  "squash",
+			Value: false,
+			Usage: "Update the index and the working tree as a merge would, without committing or recording the merge.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-commit",
+			Value: false,
+			Usage: "Stop before committing the merge, as if it failed.",
+		},
+		&cli.BoolFlag{
+			Name:  "abort",
+			Value: false,
+			Usage: "Abort the merge in progress, going back to the state before it.",
+		},
+		&cli.BoolFlag{
+			Name:    "no-stat",
+			Aliases: []string{"n"},
+			Value:   false,
+			Usage:   "Do not show a diffstat at the end of the merge.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-edit",
+			Value: false,
+			Usage: "Accept the merge message without editing it.",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-unrelated-histories",
+			Value: false,
+			Usage: "Merge histories that share no common ancestor.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "m",
+			Usage: "The message of the merge commit. Paragraphs given by several -m are concatenated.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for merge command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		if c.Bool("squash") && c.Bool("no-ff") {
+			return cli.Exit("options '--squash' and '--no-ff' cannot be used together", 128)
+		}
+
+		if c.Bool("ff-only") && c.Bool("no-ff") {
+			return cli.Exit("options '--ff-only' and '--no-ff' cannot be used together", 128)
+		}
+
+		if c.Bool("abort") && len(args) > 0 {
+			return cli.Exit("--abort expects no arguments", 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+
+		if git.IsBare() {
+			return cli.Exit("this operation must be run in a work tree", 128)
+		}
+
+		if c.Bool("abort") {
+			return abortMerge(c, repo)
+		}
+
+		switch {
+		case len(args) == 0:
+			return cli.Exit("No remote for the current branch.", 128)
+		case len(args) > 1:
+			return cli.Exit("Octopus merges are not supported.", 128)
+		}
+
+		idx, err := git.ReadIndex()
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if err := checkNoMergeInProgress(c.App.ErrWriter, git, idx); err != nil {
+			return err
+		}
+
+		m := &merging{c: c, repo: repo, git: git, idx: idx, out: bufio.NewWriter(c.App.Writer), theirsLabel: args[0]}
+		resolver := revision.NewResolver(git)
+
+		if m.theirs, err = resolver.Resolve(m.theirsLabel); err == nil {
+			m.theirs, err = resolver.Peel(m.theirs, objfile.Commit, m.theirsLabel)
+		}
+
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("merge: %s - not something we can merge", m.theirsLabel), 1)
+		}
+
+		if m.branch, m.head, err = refs.NewStore(git.GitDir()).Head(); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if m.headTree, err = headTree(git, resolver); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		opts, err := mergeOptions(repo, c, m.theirsLabel)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		cfg, err := repo.Config(c.Context)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		// merge.ff sets whether to fast-forward: always, never or only.
+		ff, ffOnly := true, false
+
+		if value, ok := cfg.Get("merge.ff"); ok {
+			if ffOnly = value == "only"; !ffOnly {
+				if ff, err = cfg.Bool("merge.ff", true); err != nil {
+					return cli.Exit(err.Error(), 128)
+				}
+			}
+		}
+
+		switch {
+		case c.Bool("no-ff"):
+			ff, ffOnly = false, false
+		case c.Bool("ff-only"):
+			ff, ffOnly = true, true
+		case c.Bool("ff"):
+			ff, ffOnly = true, false
+		}
+
+		if m.head.IsZero() {
+			return m.fastForward(opts)
+		}
+
+		if err := m.updateRef("ORIG_HEAD", m.head); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		upToDate, err := revision.IsAncestor(c.Context, git, m.theirs, m.head)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if upToDate {
+			if c.Bool("squash") {
+				fmt.Fprintln(m.out, "Already up to date. (nothing to squash)")
+			} else {
+				fmt.Fprintln(m.out, "Already up to date.")
+			}
+
+			return m.out.Flush()
+		}
+
+		canFastForward, err := revision.IsAncestor(c.Context, git, m.head, m.theirs)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		switch {
+		case canFastForward && ff:
+			return m.fastForward(opts)
+		case ffOnly:
+			return cli.Exit("Not possible to fast-forward, aborting.", 128)
+		}
+
+		if !c.Bool("allow-unrelated-histories") {
+			bases, err := revision.MergeBases(c.Context, git, m.head, m.theirs)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if len(bases) == 0 {
+				return cli.Exit("refusing to merge unrelated histories", 128)
+			}
+		}
+
+		return m.mergeTrees(opts)
+	},
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Check that a tag object is well formed and that the object it points at
+// exists with the type the tag claims.
+func validateTag(ctx context.Context, repo *ditto.Repository, data []byte) error {
+	t, err := tag.Decode(data)
+
+	if err != nil {
+		return err
+	}
+
+	if !tag.ValidName(t.Name) {
+		return errors.GitError{Message: fmt.Sprintf("Invalid tag name '%s'", t.Name)}
+	}
+
+	if t.Tagger == nil {
+		return errors.GitError{Message: "Malformed tag: missing tagger"}
+	}
+
+	if _, err = expectObjectType(ctx, repo, t.Object.String(), t.Type); err != nil {
+		return err
+	}
+
+	if canonical := t.Bytes(); string(canonical) != string(data) {
+		return errors.GitError{Message: "Malformed tag: not in canonical format"}
+	}
+
+	return nil
+}
+
+var MkTagCommand = &cli.Command{
+	Name:     "mktag",
+	HelpName: "mktag",
+	Usage:    "Creates a tag object with extra validation",
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for mktag command.")
+
+		if _, err := commandArgs(c); err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		data, err := ioutil.ReadAll(c.App.Reader)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		if err = validateTag(c.Context, repo, data); err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		hash, err := repo.WriteObject(c.Context, ditto.TagObject, data)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		fmt.Fprintln(c.App.Writer, hash.String())
+
+		return nil
+	},
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pack"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Read the objects named on each line of the input. Only the first field of
+// a line is used, so the output of `rev-list --objects` can be piped in.
+func readPackObjectList(repo *ditto.Repository, c *cli.Context) ([]pack.Object, error) {
+	objects := []pack.Object{}
+	seen := make(map[plumbing.Hash]bool)
+
+	scanner := bufio.NewScanner(c.App.Reader)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		hash, err := plumbing.NewHash(fields[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[hash] {
+			continue
+		}
+
+		seen[hash] = true
+
+		obj, err := repo.Object(c.Context, hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, pack.Object{Hash: hash, Type: obj.Type, Data: obj.Data})
+	}
+
+	return objects, scanner.Err()
+}
+
+// Write the pack and its index next to each other as
+// <base-name>-<checksum>.{pack,idx}.
+func writePackFiles(baseName string, format plumbing.ObjectFormat, objects []pack.Object, window, depth int) (plumbing.Hash, error) {
+	dir := filepath.Dir(baseName)
+
+	packFile, err := ioutil.TempFile(dir, "tmp_pack_")
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	defer os.Remove(packFile.Name())
+	defer packFile.Close()
+
+	writer := bufio.NewWriter(packFile)
+
+	entries, checksum, err := pack.WritePack(writer, format, objects, window, depth)
+
+	if err != nil {
+		return checksum, err
+	}
+
+	if err = writer.Flush(); err != nil {
+		return checksum, err
+	}
+
+	idxFile, err := ioutil.TempFile(dir, "tmp_idx_")
+
+	if err != nil {
+		return checksum, err
+	}
+
+	defer os.Remove(idxFile.Name())
+	defer idxFile.Close()
+
+	if err = pack.WriteIndex(idxFile, entries, checksum); err != nil {
+		return checksum, err
+	}
+
+	prefix := fmt.Sprintf("%s-%s", baseName, checksum)
+
+	for _, rename := range [][2]string{{packFile.Name(), prefix + ".pack"}, {idxFile.Name(), prefix + ".idx"}} {
+		if err = os.Chmod(rename[0], 0444); err != nil {
+			return checksum, err
+		}
+
+		if err = os.Rename(rename[0], rename[1]); err != nil {
+			return checksum, err
+		}
+	}
+
+	return checksum, nil
+}
+
+var PackObjectsCommand = &cli.Command{
+	Name:      "pack-objects",
+	HelpName:  "pack-objects",
+	Usage:     "Create a packed archive of objects read from the standard input",
+	ArgsUsage: "[--window=<n>] [--depth=<n>] (--stdout | <base-name>)",
+
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "window",
+			Value: pack.DefaultWindow,
+			Usage: "Number of preceding objects considered as delta bases for each object.",
+		},
+		&cli.IntFlag{
+			Name:  "depth",
+			Value: pack.DefaultDepth,
+			Usage: "Maximum length of a delta chain.",
+		},
+		&cli.BoolFlag{
+			Name:  "stdout",
+			Value: false,
+			Usage: "Write the pack contents to the standard output instead of a file.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for pack-objects command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		if !c.Bool("stdout") && len(args) != 1 {
+			err := errors.GitError{Message: "Need a base-name for the pack, or --stdout."}
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		objects, err := readPackObjectList(repo, c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		utils.InfoLogger.Printf("Packing %d objects\n", len(objects))
+
+		if c.Bool("stdout") {
+			writer := bufio.NewWriter(c.App.Writer)
+
+			if _, _, err = pack.WritePack(writer, repo.ObjectFormat(), objects, c.Int("window"), c.Int("depth")); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if err = writer.Flush(); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			return nil
+		}
+
+		checksum, err := writePackFiles(args[0], repo.ObjectFormat(), objects, c.Int("window"), c.Int("depth"))
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		fmt.Fprintln(c.App.Writer, checksum.String())
+
+		return nil
+	},
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+)
+
+// Open the repository a command runs in, the one containing the -C path.
+func openRepository(c *cli.Context) (*ditto.Repository, error) {
+	return ditto.Open(c.Context, c.String("C"))
+}
+
+// The lower level handle on a repository, for the commands built on the
+// internal packages rather than on the public API. It is built from what
+// the public API tells of the repository, as discovering it would, and
+// reads the same objects on disk without sharing any caches with it.
+func internalGit(repo *ditto.Repository) *fs.Git {
+	git := fs.NewGit(repo.GitDir(), repo.ObjectFormat(), nil)
+	git.SetWorkTree("")
+
+	if worktree, err := repo.Worktree(); err == nil {
+		git.SetWorkTree(worktree.Root())
+	}
+
+	return git
+}
@@ -0,0 +1,262 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// The tips of --all: HEAD and every ref pointing at a commit, possibly
+// through tags.
+func allTips(git *fs.Git, resolver *revision.Resolver) ([]revision.Tip, error) {
+	store := refs.NewStore(git.GitDir())
+	tips := []revision.Tip{}
+
+	if _, head, err := store.Head(); err == nil && !head.IsZero() {
+		tips = append(tips, revision.Tip{Hash: head})
+	}
+
+	all, err := store.List("refs/")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range all {
+		hash, err := resolver.Peel(ref.Hash, objfile.Commit, ref.Name)
+
+		if err != nil {
+			continue
+		}
+
+		tips = append(tips, revision.Tip{Hash: hash})
+	}
+
+	return tips, nil
+}
+
+var RevListCommand = &cli.Command{
+	Name:      "rev-list",
+	HelpName:  "rev-list",
+	Usage:     "Lists commit objects in reverse chronological order",
+	ArgsUsage: "<commit>... [--] [<path>...]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:    "max-count",
+			Aliases: []string{"n"},
+			Value:   -1,
+			Usage:   "Limit the number of commits to output.",
+		},
+		&cli.BoolFlag{
+			Name:  "count",
+			Value: false,
+			Usage: "Print the number of commits that would be listed instead.",
+		},
+		&cli.BoolFlag{
+			Name:  "objects",
+			Value: false,
+			Usage: "Also list the trees and blobs reachable from the commits listed.",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Value: false,
+			Usage: "Walk from HEAD and all the refs in refs/.",
+		},
+		&cli.BoolFlag{
+			Name:  "topo-order",
+			Value: false,
+			Usage: "Show no parents before all of their children, and avoid mixing lines of history.",
+		},
+		&cli.BoolFlag{
+			Name:  "date-order",
+			Value: false,
+			Usage: "Show no parents before all of their children, but otherwise in commit date order.",
+		},
+		&cli.BoolFlag{
+			Name:  "left-right",
+			Value: false,
+			Usage: "Mark which side of a symmetric difference each commit is reachable from.",
+		},
+		&cli.BoolFlag{
+			Name:  "ancestry-path",
+			Value: false,
+			Usage: "Only list commits that are descendants of the excluded commits, and ancestors of the included ones.",
+		},
+		&cli.IntFlag{
+			Name:  "min-parents",
+			Value: 0,
+			Usage: "Show only commits with at least that many parents.",
+		},
+		&cli.IntFlag{
+			Name:  "max-parents",
+			Value: -1,
+			Usage: "Show only commits with at most that many parents.",
+		},
+		&cli.BoolFlag{
+			Name:  "merges",
+			Value: false,
+			Usage: "Show only merge commits, like --min-parents=2.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-merges",
+			Value: false,
+			Usage: "Show no merge commits, like --max-parents=1.",
+		},
+		&cli.BoolFlag{
+			Name:  "first-parent",
+			Value: false,
+			Usage: "Follow only the first parent commit upon seeing a merge commit.",
+		},
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Value: false,
+			Usage: "Output the commits chosen to be shown in reverse order.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for rev-list command.")
+
+		args, err := argsWithSeparator(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+		resolver := revision.NewResolver(git)
+		tips, paths, err := splitRevisionsAndPaths(c, git, resolver, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if c.Bool("all") {
+			all, err := allTips(git, resolver)
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			tips = append(tips, all...)
+		}
+
+		if len(tips) == 0 && !c.Bool("all") {
+			return cli.Exit("usage: git rev-list [<options>] <commit>... [--] [<path>...]", 129)
+		}
+
+		opts := revision.WalkOptions{
+			FirstParent:  c.Bool("first-parent"),
+			Paths:        paths,
+			MinParents:   c.Int("min-parents"),
+			MaxParents:   c.Int("max-parents"),
+			TopoOrder:    c.Bool("topo-order"),
+			DateOrder:    c.Bool("date-order"),
+			AncestryPath: c.Bool("ancestry-path"),
+			SkipParsing:  true,
+		}
+
+		if c.Bool("merges") {
+			opts.MinParents = 2
+		}
+
+		if c.Bool("no-merges") {
+			opts.MaxParents = 1
+		}
+
+		walker := revision.NewWalker(c.Context, git, opts)
+
+		for _, tip := range tips {
+			if err := walker.Add(tip); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		limit := c.Int("max-count")
+		entries := []*revision.Entry{}
+
+		for limit < 0 || len(entries) < limit {
+			entry, err := walker.Next()
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			if entry == nil {
+				break
+			}
+
+			entries = append(entries, entry)
+		}
+
+		if c.Bool("reverse") {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
+
+		out := bufio.NewWriter(c.App.Writer)
+
+		if c.Bool("count") {
+			if !c.Bool("left-right") {
+				fmt.Fprintln(out, len(entries))
+
+				return out.Flush()
+			}
+
+			left := 0
+
+			for _, entry := range entries {
+				if entry.Left {
+					left++
+				}
+			}
+
+			fmt.Fprintf(out, "%d\t%d\n", left, len(entries)-left)
+
+			return out.Flush()
+		}
+
+		for _, entry := range entries {
+			if c.Bool("left-right") {
+				if entry.Left {
+					out.WriteString("<")
+				} else {
+					out.WriteString(">")
+				}
+			}
+
+			fmt.Fprintln(out, entry.Hash)
+		}
+
+		if c.Bool("objects") {
+			err := walker.Objects(entries, func(hash plumbing.Hash, path string) error {
+				_, err := fmt.Fprintf(out, "%s %s\n", hash, path)
+
+				return err
+			})
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		return out.Flush()
+	},
+}
@@ -0,0 +1,407 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// The repository layout information asked for by the --git-dir,
+// --show-toplevel and similar flags.
+type repoInfo struct {
+	repo     *ditto.Repository
+	cwd      string
+	gitDir   string
+	workTree string
+	prefix   string
+	inside   bool
+}
+
+func newRepoInfo(c *cli.Context, repo *ditto.Repository) (*repoInfo, error) {
+	cwd, err := filepath.Abs(c.String("C"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := &repoInfo{repo: repo, cwd: cwd, gitDir: repo.GitDir()}
+
+	if w, err := repo.Worktree(); err == nil {
+		info.workTree = w.Root()
+	}
+
+	// Outside of the working tree, and in bare repositories, the prefix is
+	// left empty.
+	info.prefix = "."
+
+	if !repo.IsBare() {
+		if info.prefix, err = filepath.Rel(info.workTree, cwd); err != nil {
+			return nil, err
+		}
+
+		info.prefix = filepath.ToSlash(info.prefix)
+	}
+
+	// The git directory is not part of the working tree, even inside it.
+	inGitDir := cwd == info.gitDir || strings.HasPrefix(cwd, info.gitDir+string(filepath.Separator))
+	info.inside = !repo.IsBare() && !inGitDir && !strings.HasPrefix(info.prefix, "..")
+
+	if !info.inside {
+		info.prefix = "."
+	}
+
+	return info, nil
+}
+
+// Print the answer to a flag asking for repository information. Other
+// flags print nothing.
+func (info *repoInfo) print(out io.Writer, flag string) error {
+	switch flag {
+	case "git-dir":
+		switch info.gitDir {
+		case info.cwd:
+			fmt.Fprintln(out, ".")
+		case filepath.Join(info.cwd, ".git"):
+			fmt.Fprintln(out, ".git")
+		default:
+			fmt.Fprintln(out, info.gitDir)
+		}
+	case "absolute-git-dir":
+		fmt.Fprintln(out, info.gitDir)
+	case "show-toplevel":
+		if info.repo.IsBare() {
+			return errors.GitError{Message: "this operation must be run in a work tree"}
+		}
+
+		fmt.Fprintln(out, info.workTree)
+	case "is-inside-work-tree":
+		fmt.Fprintln(out, info.inside)
+	case "is-bare-repository":
+		fmt.Fprintln(out, info.repo.IsBare())
+	case "show-prefix":
+		if info.prefix == "." {
+			fmt.Fprintln(out)
+		} else {
+			fmt.Fprintln(out, info.prefix+"/")
+		}
+	case "show-cdup":
+		if info.prefix == "." {
+			fmt.Fprintln(out)
+		} else {
+			fmt.Fprintln(out, strings.Repeat("../", strings.Count(info.prefix, "/")+1))
+		}
+	case "show-object-format":
+		fmt.Fprintln(out, info.repo.ObjectFormat())
+	}
+
+	return nil
+}
+
+// Print the objects a revision argument names. A..B stands for B ^A, and
+// ^A for commits not reachable from A.
+func printRevision(c *cli.Context, repo *ditto.Repository, arg string) error {
+	revs := []string{arg}
+
+	if i := strings.Index(arg, ".."); i >= 0 && !strings.Contains(arg, "...") {
+		from, to := arg[:i], arg[i+2:]
+
+		if from == "" {
+			from = refs.Head
+		}
+
+		if to == "" {
+			to = refs.Head
+		}
+
+		revs = []string{to, "^" + from}
+	} else if rev, shorthand, ok := revision.ParentShorthand(arg); ok {
+		var err error
+
+		if revs, err = expandParentShorthand(c, repo, rev, shorthand, arg); err != nil {
+			return err
+		}
+	}
+
+	for _, rev := range revs {
+		negated := strings.HasPrefix(rev, "^")
+		rev = strings.TrimPrefix(rev, "^")
+
+		hash, err := repo.Resolve(c.Context, rev)
+
+		if err != nil {
+			if _, ok := err.(ditto.RevisionNotFoundError); ok {
+				return errors.GitError{Message: fmt.Sprintf("ambiguous argument '%s': unknown revision or path not in the working tree.", arg)}
+			}
+
+			return err
+		}
+
+		out, err := formatRevision(c, repo, rev, hash)
+
+		if err != nil {
+			return err
+		}
+
+		if negated {
+			out = "^" + out
+		}
+
+		fmt.Fprintln(c.App.Writer, out)
+	}
+
+	return nil
+}
+
+// The revisions a parent shorthand stands for: the parents of rev for ^@,
+// rev and its negated parents for ^!, and rev with its n-th parent negated
+// for ^-<n>.
+func expandParentShorthand(c *cli.Context, repo *ditto.Repository, rev, shorthand, arg string) ([]string, error) {
+	if strings.HasPrefix(shorthand, "-") {
+		n := strings.TrimPrefix(shorthand, "-")
+
+		if n == "" {
+			n = "1"
+		}
+
+		return []string{rev, "^" + rev + "^" + n}, nil
+	}
+
+	hash, err := repo.Resolve(c.Context, rev)
+
+	if err == nil {
+		hash, err = repo.Peel(c.Context, hash, ditto.CommitObject, rev)
+	}
+
+	if err != nil {
+		if _, ok := err.(ditto.RevisionNotFoundError); ok {
+			return nil, errors.GitError{Message: fmt.Sprintf("ambiguous argument '%s': unknown revision or path not in the working tree.", arg)}
+		}
+
+		return nil, err
+	}
+
+	commit, err := repo.Commit(c.Context, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	revs := []string{}
+
+	if shorthand == "!" {
+		revs = append(revs, rev)
+	}
+
+	for i := range commit.Parents {
+		parent := fmt.Sprintf("%s^%d", rev, i+1)
+
+		if shorthand == "!" {
+			parent = "^" + parent
+		}
+
+		revs = append(revs, parent)
+	}
+
+	return revs, nil
+}
+
+// Format a resolved revision as asked for by --short, --abbrev-ref and
+// --symbolic-full-name.
+func formatRevision(c *cli.Context, repo *ditto.Repository, rev string, hash ditto.Hash) (string, error) {
+	if c.Bool("symbolic-full-name") || c.Bool("abbrev-ref") {
+		name, err := repo.SymbolicFullName(c.Context, rev)
+
+		if err != nil || name == "" {
+			return "", err
+		}
+
+		if c.Bool("abbrev-ref") && name != refs.Head {
+			name = refs.ShortName(name)
+		}
+
+		return name, nil
+	}
+
+	if c.IsSet("short") {
+		length, err := strconv.Atoi(c.Generic("short").(*optionalValue).value)
+
+		if err != nil {
+			return "", err
+		}
+
+		return repo.Abbreviate(c.Context, hash, length)
+	}
+
+	return hash.String(), nil
+}
+
+var RevParseCommand = &cli.Command{
+	Name:      "rev-parse",
+	HelpName:  "rev-parse",
+	Usage:     "Pick out and massage parameters",
+	ArgsUsage: "[<options>] <args>...",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "verify",
+			Value: false,
+			Usage: "Verify that exactly one parameter is provided, and that it can be turned into an object name.",
+		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Value:   false,
+			Usage:   "Only meaningful in --verify mode. Do not output an error message if the first argument is not a valid object name.",
+		},
+		&cli.GenericFlag{
+			Name:  "short",
+			Value: &optionalValue{implied: strconv.Itoa(ditto.DefaultAbbrev)},
+			Usage: "Shorten the object name to a unique prefix with at least the given length (7 by default).",
+		},
+		&cli.BoolFlag{
+			Name:  "abbrev-ref",
+			Value: false,
+			Usage: "A non-ambiguous short name of the object's name.",
+		},
+		&cli.BoolFlag{
+			Name:  "symbolic-full-name",
+			Value: false,
+			Usage: "Print the full ref name of the revision, if it is a ref.",
+		},
+		&cli.BoolFlag{
+			Name:  "git-dir",
+			Value: false,
+			Usage: "Show $GIT_DIR if defined. Otherwise show the path to the .git directory.",
+		},
+		&cli.BoolFlag{
+			Name:  "absolute-git-dir",
+			Value: false,
+			Usage: "Like --git-dir, but its output is always the canonicalized absolute path.",
+		},
+		&cli.BoolFlag{
+			Name:  "show-toplevel",
+			Value: false,
+			Usage: "Show the absolute path of the top-level directory of the working tree.",
+		},
+		&cli.BoolFlag{
+			Name:  "is-inside-work-tree",
+			Value: false,
+			Usage: "When the current working directory is inside the work tree of the repository print \"true\", otherwise \"false\".",
+		},
+		&cli.BoolFlag{
+			Name:  "is-bare-repository",
+			Value: false,
+			Usage: "When the repository is bare print \"true\", otherwise \"false\".",
+		},
+		&cli.BoolFlag{
+			Name:  "show-prefix",
+			Value: false,
+			Usage: "Show the path of the current directory relative to the top-level directory.",
+		},
+		&cli.BoolFlag{
+			Name:  "show-cdup",
+			Value: false,
+			Usage: "Show the path of the top-level directory relative to the current directory.",
+		},
+		&cli.BoolFlag{
+			Name:  "show-object-format",
+			Value: false,
+			Usage: "Show the hash algorithm naming the objects of the repository.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for rev-parse command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		info, err := newRepoInfo(c, repo)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		line, err := commandLine(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		// Flags and revisions are answered in the order given. Under
+		// --verify, the revision is checked after all of them.
+		separated := false
+
+		for _, item := range line {
+			switch {
+			case item.flag != "":
+				err = info.print(c.App.Writer, item.flag)
+			case item.value == "--" && !separated:
+				separated = true
+			case !c.Bool("verify"):
+				err = printRevision(c, repo, item.value)
+			}
+
+			if err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if !c.Bool("verify") {
+			return nil
+		}
+
+		if len(args) != 1 {
+			if c.Bool("quiet") {
+				return cli.Exit("", 1)
+			}
+
+			return cli.Exit("Needed a single revision", 128)
+		}
+
+		hash, err := repo.Resolve(c.Context, args[0])
+
+		if err == nil {
+			_, err = repo.Stat(c.Context, hash)
+		}
+
+		if err != nil {
+			if c.Bool("quiet") {
+				return cli.Exit("", 1)
+			}
+
+			return cli.Exit("Needed a single revision", 128)
+		}
+
+		out, err := formatRevision(c, repo, args[0], hash)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		fmt.Fprintln(c.App.Writer, out)
+
+		return nil
+	},
+}
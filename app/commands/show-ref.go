@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Follow annotated tags down to the object they finally point at. Returns
+// ok=false if hash is not a tag.
+func peelTag(ctx context.Context, repo *ditto.Repository, hash ditto.Hash) (ditto.Hash, bool, error) {
+	peeled := false
+
+	for {
+		obj, err := repo.Object(ctx, hash)
+
+		if err != nil {
+			return ditto.ZeroHash, false, err
+		}
+
+		if obj.Type != ditto.TagObject {
+			return hash, peeled, nil
+		}
+
+		decoded, err := tag.Decode(obj.Data)
+
+		if err != nil {
+			return ditto.ZeroHash, false, err
+		}
+
+		hash = decoded.Object
+		peeled = true
+	}
+}
+
+// A pattern matches a ref if it names it fully or names its last
+// components, e.g. master matches refs/heads/master and
+// refs/remotes/origin/master.
+func refMatches(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if name == pattern || strings.HasSuffix(name, "/"+pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func printRef(c *cli.Context, repo *ditto.Repository, ref *ditto.Ref) error {
+	if c.Bool("quiet") {
+		return nil
+	}
+
+	if c.Bool("hash") {
+		fmt.Fprintln(c.App.Writer, ref.Hash)
+	} else {
+		fmt.Fprintf(c.App.Writer, "%s %s\n", ref.Hash, ref.Name)
+	}
+
+	if !c.Bool("dereference") {
+		return nil
+	}
+
+	peeled, ok := ref.Peeled, !ref.Peeled.IsZero()
+
+	if !ok {
+		var err error
+
+		if peeled, ok, err = peelTag(c.Context, repo, ref.Hash); err != nil {
+			return err
+		}
+	}
+
+	if !ok {
+		return nil
+	}
+
+	if c.Bool("hash") {
+		fmt.Fprintln(c.App.Writer, peeled)
+	} else {
+		fmt.Fprintf(c.App.Writer, "%s %s^{}\n", peeled, ref.Name)
+	}
+
+	return nil
+}
+
+var ShowRefCommand = &cli.Command{
+	Name:      "show-ref",
+	HelpName:  "show-ref",
+	Usage:     "List references in a local repository",
+	ArgsUsage: "[--head] [-d] [-s] [--tags] [--heads] [<pattern>...] | --verify [-q] [-d] [-s] <ref>...",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "head",
+			Value: false,
+			Usage: "Show the HEAD reference, even if it would normally be filtered out.",
+		},
+		&cli.BoolFlag{
+			Name:  "heads",
+			Value: false,
+			Usage: "Limit to refs/heads.",
+		},
+		&cli.BoolFlag{
+			Name:  "tags",
+			Value: false,
+			Usage: "Limit to refs/tags.",
+		},
+		&cli.BoolFlag{
+			Name:    "dereference",
+			Aliases: []string{"d"},
+			Value:   false,
+			Usage:   "Dereference tags into object IDs as well, shown with a ^{} suffix.",
+		},
+		&cli.BoolFlag{
+			Name:    "hash",
+			Aliases: []string{"s"},
+			Value:   false,
+			Usage:   "Only show the object ID, not the reference name.",
+		},
+		&cli.BoolFlag{
+			Name:  "verify",
+			Value: false,
+			Usage: "Enable stricter reference checking by requiring an exact ref path.",
+		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Value:   false,
+			Usage:   "Do not print any results to stdout.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for show-ref command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		if c.Bool("verify") {
+			for _, name := range args {
+				var ref *ditto.Ref
+
+				if name == refs.Head || strings.HasPrefix(name, "refs/") {
+					var hash ditto.Hash
+
+					if hash, err = repo.Refs().Resolve(c.Context, name); err == nil {
+						ref = &ditto.Ref{Name: name, Hash: hash}
+					}
+				}
+
+				if ref == nil {
+					if c.Bool("quiet") {
+						return cli.Exit("", 1)
+					}
+
+					return cli.Exit(fmt.Sprintf("'%s' - not a valid ref", name), 128)
+				}
+
+				if err = printRef(c, repo, ref); err != nil {
+					return cli.Exit(err.Error(), 128)
+				}
+			}
+
+			return nil
+		}
+
+		prefix := "refs/"
+
+		switch {
+		case c.Bool("heads") && !c.Bool("tags"):
+			prefix = refs.HeadsPrefix
+		case c.Bool("tags") && !c.Bool("heads"):
+			prefix = refs.TagsPrefix
+		}
+
+		all, err := repo.Refs().List(c.Context, prefix)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if c.Bool("head") {
+			if hash, err := repo.Refs().Resolve(c.Context, refs.Head); err == nil {
+				all = append([]*ditto.Ref{{Name: refs.Head, Hash: hash}}, all...)
+			}
+		}
+
+		patterns := args
+		found := false
+
+		for _, ref := range all {
+			if c.Bool("tags") && c.Bool("heads") && !strings.HasPrefix(ref.Name, refs.HeadsPrefix) && !strings.HasPrefix(ref.Name, refs.TagsPrefix) {
+				continue
+			}
+
+			if ref.Name != refs.Head && !refMatches(ref.Name, patterns) {
+				continue
+			}
+
+			found = true
+
+			if err = printRef(c, repo, ref); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if !found {
+			return cli.Exit("", 1)
+		}
+
+		return nil
+	},
+}
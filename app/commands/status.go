@@ -0,0 +1,819 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/ignore"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pathspec"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// The status of a tracked path: X compares HEAD with the index, Y the index
+// with the working tree, using the letters of `git status --short`.
+type pathStatus struct {
+	path string
+	x, y byte
+
+	headMode, indexMode, workTreeMode uint32
+	headHash, indexHash               plumbing.Hash
+
+	// Where a renamed or copied file comes from, and how similar it is,
+	// as a percentage.
+	origPath string
+	score    int
+
+	// Conflict stages (1 to 3) of unmerged paths.
+	stages [4]*index.Entry
+}
+
+func (s *pathStatus) unmerged() bool {
+	return s.x == 'U' || s.y == 'U' || (s.x == 'A' && s.y == 'A') || (s.x == 'D' && s.y == 'D')
+}
+
+type repoStatus struct {
+	branch   string
+	head     plumbing.Hash
+	tracked  []*pathStatus
+	untrack  []string
+	ignored  []string
+	merging  bool
+	relative func(string) string
+}
+
+// Code of an unmerged path, from the conflict stages it has.
+func unmergedCode(stages [4]*index.Entry) (byte, byte) {
+	base, ours, theirs := stages[index.Base] != nil, stages[index.Ours] != nil, stages[index.Theirs] != nil
+
+	switch {
+	case base && ours && theirs:
+		return 'U', 'U'
+	case ours && theirs:
+		return 'A', 'A'
+	case base && theirs:
+		return 'D', 'U'
+	case base && ours:
+		return 'U', 'D'
+	case ours:
+		return 'A', 'U'
+	case theirs:
+		return 'U', 'A'
+	}
+
+	return 'D', 'D'
+}
+
+// How status finds renames: status.renames and status.renameLimit, falling
+// back to their diff counterparts. Nil when renames are not looked for.
+func statusRenameOptions(cfg *config.Config) (*diff.RenameOptions, error) {
+	limit, err := cfg.Int("diff.renameLimit", diff.DefaultRenameLimit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if limit, err = cfg.Int("status.renameLimit", limit); err != nil {
+		return nil, err
+	}
+
+	opts := &diff.RenameOptions{Limit: int(limit)}
+	detect := true
+
+	for _, name := range []string{"diff.renames", "status.renames"} {
+		value, ok := cfg.Get(name)
+
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(value) {
+		case "copy", "copies":
+			detect, opts.Copies = true, true
+		default:
+			if detect, err = cfg.Bool(name, true); err != nil {
+				return nil, err
+			}
+
+			opts.Copies = false
+		}
+	}
+
+	if !detect {
+		return nil, nil
+	}
+
+	return opts, nil
+}
+
+// Compare HEAD, the index and the working tree.
+func collectStatus(git *fs.Git, idx *index.Index, spec pathspec.Pathspec, renames *diff.RenameOptions, matcher *ignore.Matcher, untrackedMode string, showIgnored bool) (*repoStatus, bool, error) {
+	status := &repoStatus{}
+
+	var err error
+
+	if status.branch, status.head, err = refs.NewStore(git.GitDir()).Head(); err != nil {
+		return nil, false, err
+	}
+
+	status.merging = utils.PathExists(filepath.Join(git.GitDir(), "MERGE_HEAD"))
+
+	headTree := plumbing.ZeroHash
+
+	if !status.head.IsZero() {
+		if headTree, err = revision.NewResolver(git).Peel(status.head, objfile.Tree, refs.Head); err != nil {
+			return nil, false, err
+		}
+	}
+
+	opts := diff.CompareOptions{Pathspec: spec}
+	staged, err := diff.TreeToIndex(git, headTree, idx, opts)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if renames != nil {
+		if staged, err = diff.DetectRenames(staged, *renames); err != nil {
+			return nil, false, err
+		}
+	}
+
+	unstaged, refreshed, err := diff.IndexToWorkTree(git, idx, opts)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	byPath := make(map[string]*pathStatus)
+
+	get := func(path string) *pathStatus {
+		s, ok := byPath[path]
+
+		if !ok {
+			s = &pathStatus{path: path, x: ' ', y: ' '}
+			byPath[path] = s
+		}
+
+		return s
+	}
+
+	for _, e := range idx.Entries {
+		if e.Stage == index.Merged || !spec.Match(e.Name) {
+			continue
+		}
+
+		s := get(e.Name)
+		s.stages[e.Stage] = e
+		s.x, s.y = unmergedCode(s.stages)
+
+		if info, err := git.StatWorkTree(e.Name); err == nil {
+			s.workTreeMode = tree.ModeFromFileInfo(info)
+		}
+	}
+
+	for _, c := range staged {
+		if c.Status == diff.Unmerged {
+			continue
+		}
+
+		// Files added with intent to add are not staged yet.
+		if e, ok := idx.Entry(c.Path(), index.Merged); ok && e.IntentToAdd {
+			continue
+		}
+
+		s := get(c.Path())
+		s.x = c.Status
+		s.headMode, s.headHash = c.Old.Mode, c.Old.Hash
+		s.indexMode, s.indexHash = c.New.Mode, c.New.Hash
+		s.workTreeMode = c.New.Mode
+
+		if c.Status == diff.Renamed || c.Status == diff.Copied {
+			s.origPath, s.score = c.Old.Path, c.Similarity()
+		}
+	}
+
+	// Conflicts compare their stages with the working tree instead.
+	for _, c := range unstaged {
+		s, ok := byPath[c.Path()]
+
+		switch {
+		case c.Status == diff.Unmerged || ok && s.unmerged():
+			continue
+		case !ok:
+			s = get(c.Path())
+			s.headMode, s.headHash = c.Old.Mode, c.Old.Hash
+			s.indexMode, s.indexHash = c.Old.Mode, c.Old.Hash
+		}
+
+		s.y = c.Status
+		s.workTreeMode = c.New.Mode
+	}
+
+	for _, s := range byPath {
+		status.tracked = append(status.tracked, s)
+	}
+
+	sort.Slice(status.tracked, func(i, j int) bool {
+		return status.tracked[i].path < status.tracked[j].path
+	})
+
+	// Like Git, ignored files are only shown along with untracked ones.
+	if untrackedMode != "no" {
+		if err = collectUntracked(git, idx, spec, matcher, untrackedMode, showIgnored, status); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return status, refreshed, nil
+}
+
+// Find untracked and ignored files. Unless every file is asked for,
+// directories without tracked files are reported once, as "dir/", and so
+// are directories holding only ignored files among the ignored ones.
+func collectUntracked(git *fs.Git, idx *index.Index, spec pathspec.Pathspec, matcher *ignore.Matcher, untrackedMode string, showIgnored bool, status *repoStatus) error {
+	tracked := make(map[string]bool)
+	trackedDirs := make(map[string]bool)
+
+	for _, e := range idx.Entries {
+		tracked[e.Name] = true
+
+		for dir := path.Dir(e.Name); dir != "."; dir = path.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	}
+
+	// The shallowest directory containing name that keep does not stop at,
+	// or name itself.
+	collapse := func(name string, keep func(dir string) bool) string {
+		if untrackedMode == "all" {
+			return name
+		}
+
+		result := name
+		components := strings.Split(strings.TrimSuffix(name, "/"), "/")
+
+		for i := len(components) - 1; i > 0; i-- {
+			dir := strings.Join(components[:i], "/")
+
+			if keep(dir) {
+				break
+			}
+
+			result = dir + "/"
+		}
+
+		return result
+	}
+
+	seen := make(map[string]bool)
+	untrackedDirs := make(map[string]bool)
+	ignored := []string{}
+
+	// Every file is listed when asked for, even in ignored directories.
+	ignoredDirs := make(map[string]bool)
+
+	err := git.WalkWorkTree(func(name string, info os.FileInfo) error {
+		if info.IsDir() {
+			if !spec.MatchesDirectory(name) {
+				return filepath.SkipDir
+			}
+
+			if ignoredDirs[path.Dir(name)] {
+				ignoredDirs[name] = true
+
+				return nil
+			}
+
+			if !trackedDirs[name] && matcher.Ignored(name, true) {
+				if showIgnored && untrackedMode == "all" {
+					ignoredDirs[name] = true
+
+					return nil
+				}
+
+				if showIgnored && spec.Match(name) {
+					ignored = append(ignored, name+"/")
+				}
+
+				return filepath.SkipDir
+			}
+
+			matcher.EnterDir(name)
+
+			return nil
+		}
+
+		if tracked[name] || !spec.Match(name) {
+			return nil
+		}
+
+		if ignoredDirs[path.Dir(name)] || matcher.Ignored(name, false) {
+			if showIgnored {
+				ignored = append(ignored, name)
+			}
+
+			return nil
+		}
+
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			untrackedDirs[dir] = true
+		}
+
+		reported := collapse(name, func(dir string) bool { return trackedDirs[dir] })
+
+		if !seen[reported] {
+			seen[reported] = true
+			status.untrack = append(status.untrack, reported)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Untracked files are only known once the walk is done.
+	for _, name := range ignored {
+		reported := collapse(name, func(dir string) bool { return trackedDirs[dir] || untrackedDirs[dir] })
+
+		if !seen[reported] {
+			seen[reported] = true
+			status.ignored = append(status.ignored, reported)
+		}
+	}
+
+	return nil
+}
+
+// Make a path relative to the directory the command runs in.
+func relativePathFunc(git *fs.Git, c *cli.Context) func(string) string {
+	cwd, err := filepath.Abs(c.String("C"))
+
+	if err != nil {
+		return func(p string) string { return p }
+	}
+
+	prefix, err := filepath.Rel(git.WorkTree(), cwd)
+
+	if err != nil || prefix == "." || strings.HasPrefix(prefix, "..") {
+		return func(p string) string { return p }
+	}
+
+	prefix = filepath.ToSlash(prefix)
+
+	return func(p string) string {
+		rel, err := filepath.Rel(prefix, p)
+
+		if err != nil {
+			return p
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if strings.HasSuffix(p, "/") {
+			rel += "/"
+		}
+
+		return rel
+	}
+}
+
+// The path of a tracked file as listed, "old -> new" for renames and
+// copies.
+func (status *repoStatus) shortPath(s *pathStatus) string {
+	if s.origPath != "" {
+		return status.relative(s.origPath) + " -> " + status.relative(s.path)
+	}
+
+	return status.relative(s.path)
+}
+
+func shortBranchName(ref string) string {
+	return strings.TrimPrefix(ref, refs.HeadsPrefix)
+}
+
+var statusLabels = map[byte]string{
+	'A': "new file:",
+	'M': "modified:",
+	'D': "deleted:",
+	'T': "typechange:",
+	'R': "renamed:",
+	'C': "copied:",
+}
+
+var unmergedLabels = map[string]string{
+	"DD": "both deleted:",
+	"AU": "added by us:",
+	"UD": "deleted by them:",
+	"UA": "added by them:",
+	"DU": "deleted by us:",
+	"AA": "both added:",
+	"UU": "both modified:",
+}
+
+func printLongStatus(w io.Writer, status *repoStatus, untrackedMode string) {
+	if status.branch != "" {
+		fmt.Fprintf(w, "On branch %s\n", shortBranchName(status.branch))
+	} else {
+		fmt.Fprintf(w, "HEAD detached at %s\n", status.head.String()[:7])
+	}
+
+	var staged, unstaged, unmerged []*pathStatus
+
+	for _, s := range status.tracked {
+		switch {
+		case s.unmerged():
+			unmerged = append(unmerged, s)
+		default:
+			if s.x != ' ' {
+				staged = append(staged, s)
+			}
+
+			if s.y != ' ' {
+				unstaged = append(unstaged, s)
+			}
+		}
+	}
+
+	if status.merging {
+		if len(unmerged) > 0 {
+			fmt.Fprint(w, "You have unmerged paths.\n")
+			fmt.Fprint(w, "  (fix conflicts and run \"git commit\")\n")
+			fmt.Fprint(w, "  (use \"git merge --abort\" to abort the merge)\n\n")
+		} else {
+			fmt.Fprint(w, "All conflicts fixed but you are still merging.\n")
+			fmt.Fprint(w, "  (use \"git commit\" to conclude merge)\n\n")
+		}
+	}
+
+	if status.head.IsZero() {
+		fmt.Fprint(w, "\nNo commits yet\n\n")
+	}
+
+	// Outside of a merge, the staged changes come from HEAD and can be
+	// unstaged again.
+	unstageHint := func() {
+		switch {
+		case status.merging:
+		case status.head.IsZero():
+			fmt.Fprint(w, "  (use \"git rm --cached <file>...\" to unstage)\n")
+		default:
+			fmt.Fprint(w, "  (use \"git restore --staged <file>...\" to unstage)\n")
+		}
+	}
+
+	if len(staged) > 0 {
+		fmt.Fprint(w, "Changes to be committed:\n")
+		unstageHint()
+
+		for _, s := range staged {
+			fmt.Fprintf(w, "\t%-12s%s\n", statusLabels[s.x], status.shortPath(s))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if len(unmerged) > 0 {
+		hasDeleted := false
+
+		for _, s := range unmerged {
+			hasDeleted = hasDeleted || s.x == 'D' || s.y == 'D'
+		}
+
+		fmt.Fprint(w, "Unmerged paths:\n")
+		unstageHint()
+
+		if hasDeleted {
+			fmt.Fprint(w, "  (use \"git add/rm <file>...\" as appropriate to mark resolution)\n")
+		} else {
+			fmt.Fprint(w, "  (use \"git add <file>...\" to mark resolution)\n")
+		}
+
+		for _, s := range unmerged {
+			fmt.Fprintf(w, "\t%-17s%s\n", unmergedLabels[string([]byte{s.x, s.y})], status.relative(s.path))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if len(unstaged) > 0 {
+		hasDeleted := false
+
+		for _, s := range unstaged {
+			hasDeleted = hasDeleted || s.y == 'D'
+		}
+
+		fmt.Fprint(w, "Changes not staged for commit:\n")
+
+		if hasDeleted {
+			fmt.Fprint(w, "  (use \"git add/rm <file>...\" to update what will be committed)\n")
+		} else {
+			fmt.Fprint(w, "  (use \"git add <file>...\" to update what will be committed)\n")
+		}
+
+		fmt.Fprint(w, "  (use \"git restore <file>...\" to discard changes in working directory)\n")
+
+		for _, s := range unstaged {
+			fmt.Fprintf(w, "\t%-12s%s\n", statusLabels[s.y], status.relative(s.path))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if len(status.untrack) > 0 {
+		fmt.Fprint(w, "Untracked files:\n")
+		fmt.Fprint(w, "  (use \"git add <file>...\" to include in what will be committed)\n")
+
+		for _, p := range status.untrack {
+			fmt.Fprintf(w, "\t%s\n", status.relative(p))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if len(status.ignored) > 0 {
+		fmt.Fprint(w, "Ignored files:\n")
+		fmt.Fprint(w, "  (use \"git add -f <file>...\" to include in what will be committed)\n")
+
+		for _, p := range status.ignored {
+			fmt.Fprintf(w, "\t%s\n", status.relative(p))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	committable := len(staged) > 0
+	dirty := len(unstaged) > 0 || len(unmerged) > 0
+
+	switch {
+	case committable:
+		if untrackedMode == "no" {
+			fmt.Fprintln(w, "Untracked files not listed (use -u option to show untracked files)")
+		}
+	case dirty:
+		fmt.Fprintln(w, "no changes added to commit (use \"git add\" and/or \"git commit -a\")")
+	case len(status.untrack) > 0:
+		fmt.Fprintln(w, "nothing added to commit but untracked files present (use \"git add\" to track)")
+	case status.head.IsZero():
+		fmt.Fprintln(w, "nothing to commit (create/copy files and use \"git add\" to track)")
+	case untrackedMode == "no":
+		fmt.Fprintln(w, "nothing to commit (use -u to show untracked files)")
+	default:
+		fmt.Fprintln(w, "nothing to commit, working tree clean")
+	}
+}
+
+func printShortStatus(w io.Writer, status *repoStatus, showBranch bool) {
+	if showBranch {
+		switch {
+		case status.branch == "":
+			fmt.Fprintln(w, "## HEAD (no branch)")
+		case status.head.IsZero():
+			fmt.Fprintf(w, "## No commits yet on %s\n", shortBranchName(status.branch))
+		default:
+			fmt.Fprintf(w, "## %s\n", shortBranchName(status.branch))
+		}
+	}
+
+	for _, s := range status.tracked {
+		fmt.Fprintf(w, "%c%c %s\n", s.x, s.y, status.shortPath(s))
+	}
+
+	for _, p := range status.untrack {
+		fmt.Fprintf(w, "?? %s\n", status.relative(p))
+	}
+
+	for _, p := range status.ignored {
+		fmt.Fprintf(w, "!! %s\n", status.relative(p))
+	}
+}
+
+func v2Code(c byte) byte {
+	if c == ' ' {
+		return '.'
+	}
+
+	return c
+}
+
+func printPorcelainV2(w io.Writer, status *repoStatus, showBranch bool) {
+	if showBranch {
+		if status.head.IsZero() {
+			fmt.Fprintln(w, "# branch.oid (initial)")
+		} else {
+			fmt.Fprintf(w, "# branch.oid %s\n", status.head)
+		}
+
+		if status.branch == "" {
+			fmt.Fprintln(w, "# branch.head (detached)")
+		} else {
+			fmt.Fprintf(w, "# branch.head %s\n", shortBranchName(status.branch))
+		}
+	}
+
+	// Changed entries come first, then unmerged ones.
+	for _, s := range status.tracked {
+		switch {
+		case s.unmerged():
+		case s.origPath != "":
+			fmt.Fprintf(w, "2 %c%c N... %06o %06o %06o %s %s %c%d %s\t%s\n", v2Code(s.x), v2Code(s.y),
+				s.headMode, s.indexMode, s.workTreeMode, s.headHash, s.indexHash, s.x, s.score, s.path, s.origPath)
+		default:
+			fmt.Fprintf(w, "1 %c%c N... %06o %06o %06o %s %s %s\n", v2Code(s.x), v2Code(s.y),
+				s.headMode, s.indexMode, s.workTreeMode, s.headHash, s.indexHash, s.path)
+		}
+	}
+
+	for _, s := range status.tracked {
+		if !s.unmerged() {
+			continue
+		}
+
+		modes := [4]uint32{}
+		hashes := [4]plumbing.Hash{}
+
+		for stage := index.Base; stage <= index.Theirs; stage++ {
+			if e := s.stages[stage]; e != nil {
+				modes[stage], hashes[stage] = e.Mode, e.Hash
+			}
+		}
+
+		fmt.Fprintf(w, "u %c%c N... %06o %06o %06o %06o %s %s %s %s\n", s.x, s.y,
+			modes[1], modes[2], modes[3], s.workTreeMode, hashes[1], hashes[2], hashes[3], s.path)
+	}
+
+	for _, p := range status.untrack {
+		fmt.Fprintf(w, "? %s\n", p)
+	}
+
+	for _, p := range status.ignored {
+		fmt.Fprintf(w, "! %s\n", p)
+	}
+}
+
+var StatusCommand = &cli.Command{
+	Name:      "status",
+	HelpName:  "status",
+	Usage:     "Show the working tree status",
+	ArgsUsage: "[<pathspec>...]",
+
+	UseShortOptionHandling: true,
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "short",
+			Aliases: []string{"s"},
+			Value:   false,
+			Usage:   "Give the output in the short-format.",
+		},
+		&cli.BoolFlag{
+			Name:    "branch",
+			Aliases: []string{"b"},
+			Value:   false,
+			Usage:   "Show the branch and tracking info even in short-format.",
+		},
+		&cli.GenericFlag{
+			Name:  "porcelain",
+			Value: &optionalValue{implied: "v1"},
+			Usage: "Give the output in an easy-to-parse format for scripts, v1 (the default) or v2.",
+		},
+		&cli.GenericFlag{
+			Name:    "untracked-files",
+			Aliases: []string{"u"},
+			Value:   &optionalValue{implied: "all"},
+			Usage:   "Show untracked files: no, normal (the default) or all.",
+		},
+		&cli.BoolFlag{
+			Name:  "ignored",
+			Value: false,
+			Usage: "Show ignored files as well.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for status command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		untrackedMode := "normal"
+
+		if c.IsSet("untracked-files") {
+			untrackedMode = c.Generic("untracked-files").(*optionalValue).value
+		}
+
+		if untrackedMode != "no" && untrackedMode != "normal" && untrackedMode != "all" {
+			return cli.Exit(fmt.Sprintf("Invalid untracked files mode '%s'", untrackedMode), 128)
+		}
+
+		format := "long"
+
+		if c.Bool("short") {
+			format = "short"
+		}
+
+		if c.IsSet("porcelain") {
+			format = c.Generic("porcelain").(*optionalValue).value
+
+			if format != "v1" && format != "v2" {
+				return cli.Exit(fmt.Sprintf("Unsupported porcelain version '%s'", format), 128)
+			}
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		if _, err = repo.Worktree(); err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		git := internalGit(repo)
+
+		spec, err := getPathspec(git, c, args)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		idx, err := git.ReadIndex()
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		cfg, err := repo.Config(c.Context)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		renames, err := statusRenameOptions(cfg)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		matcher, err := ignoreMatcher(git, cfg)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		status, refreshed, err := collectStatus(git, idx, spec, renames, matcher, untrackedMode, c.Bool("ignored"))
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		// Refreshing the index is opportunistic, like in Git.
+		if refreshed {
+			if err := git.WriteIndex(idx); err != nil {
+				utils.WarningLogger.Printf("Could not refresh the index: %s\n", err.Error())
+			}
+		}
+
+		status.relative = func(p string) string { return p }
+
+		switch format {
+		case "long":
+			status.relative = relativePathFunc(git, c)
+			printLongStatus(c.App.Writer, status, untrackedMode)
+		case "short":
+			status.relative = relativePathFunc(git, c)
+			printShortStatus(c.App.Writer, status, c.Bool("branch"))
+		case "v1":
+			printShortStatus(c.App.Writer, status, c.Bool("branch"))
+		case "v2":
+			printPorcelainV2(c.App.Writer, status, c.Bool("branch"))
+		}
+
+		return nil
+	},
+}
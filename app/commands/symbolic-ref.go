@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+var SymbolicRefCommand = &cli.Command{
+	Name:      "symbolic-ref",
+	HelpName:  "symbolic-ref",
+	Usage:     "Read, modify and delete symbolic refs",
+	ArgsUsage: "[-q] [--short] <name> | [-m <reason>] <name> <ref> | -d [-q] <name>",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Value:   false,
+			Usage:   "Do not issue an error message if <name> is not a symbolic ref but a detached HEAD.",
+		},
+		&cli.BoolFlag{
+			Name:  "short",
+			Value: false,
+			Usage: "Try to shorten the ref name when showing it, e.g. refs/heads/master to master.",
+		},
+		&cli.BoolFlag{
+			Name:    "delete",
+			Aliases: []string{"d"},
+			Value:   false,
+			Usage:   "Delete the symbolic ref <name>.",
+		},
+		&cli.StringFlag{
+			Name:  "m",
+			Usage: "Record <reason> in the reflog of <name>.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for symbolic-ref command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		if len(args) < 1 || len(args) > 2 || (c.Bool("delete") && len(args) != 1) {
+			return cli.Exit("usage: git symbolic-ref "+c.Command.ArgsUsage, 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		name := args[0]
+
+		if len(args) == 2 {
+			if c.IsSet("m") && c.String("m") == "" {
+				return cli.Exit("Refusing to perform update with empty message", 128)
+			}
+
+			if err = repo.Refs().SetSymbolic(c.Context, name, args[1], c.String("m")); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			return nil
+		}
+
+		ref, err := repo.Refs().Read(c.Context, name)
+
+		if err != nil && !ditto.IsNotFound(err) {
+			return cli.Exit(err.Error(), 128)
+		}
+
+		if ref == nil || !ref.IsSymbolic() {
+			if c.Bool("quiet") {
+				return cli.Exit("", 1)
+			}
+
+			return cli.Exit(fmt.Sprintf("ref %s is not a symbolic ref", name), 128)
+		}
+
+		if c.Bool("delete") {
+			if err = repo.Refs().Delete(c.Context, name, nil, true, ""); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+
+			return nil
+		}
+
+		target := ref.Target
+
+		if c.Bool("short") {
+			target = refs.ShortName(target)
+		}
+
+		fmt.Fprintln(c.App.Writer, target)
+
+		return nil
+	},
+}
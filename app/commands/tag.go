@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// List the names of the tags matching pattern (all tags if it is empty).
+func listTags(ctx context.Context, repo *ditto.Repository, pattern string) ([]string, error) {
+	tags, err := repo.Refs().List(ctx, refs.TagsPrefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+
+	for _, ref := range tags {
+		name := strings.TrimPrefix(ref.Name, refs.TagsPrefix)
+
+		if pattern != "" {
+			if matched, _ := filepath.Match(pattern, name); !matched {
+				continue
+			}
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func deleteTags(repo *ditto.Repository, c *cli.Context, args []string) error {
+	for _, name := range args {
+		ref, err := repo.Refs().Read(c.Context, refs.TagsPrefix+name)
+
+		if ditto.IsNotFound(err) {
+			return errors.GitError{Message: fmt.Sprintf("tag '%s' not found.", name)}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = repo.Refs().Delete(c.Context, ref.Name, &ref.Hash, true, ""); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "Deleted tag '%s' (was %s)\n", name, ref.Hash.String()[:7])
+	}
+
+	return nil
+}
+
+// Get the message of an annotated tag, or ok=false for lightweight tags.
+func getTagMessage(c *cli.Context) (message string, ok bool, err error) {
+	if c.IsSet("m") || c.IsSet("F") {
+		message, err = getCommitMessage(c)
+
+		return message, true, err
+	}
+
+	if c.Bool("a") {
+		return "", true, errors.GitError{Message: "No tag message given, use -m or -F."}
+	}
+
+	return "", false, nil
+}
+
+func createTag(repo *ditto.Repository, c *cli.Context, args []string) error {
+	name := args[0]
+
+	if !tag.ValidName(name) {
+		return errors.GitError{Message: fmt.Sprintf("'%s' is not a valid tag name.", name)}
+	}
+
+	_, err := repo.Refs().Read(c.Context, refs.TagsPrefix+name)
+
+	if err != nil && !ditto.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil && !c.Bool("f") {
+		return errors.GitError{Message: fmt.Sprintf("tag '%s' already exists", name)}
+	}
+
+	var target ditto.Hash
+
+	if len(args) > 1 {
+		target, err = resolveObjectName(c.Context, repo, args[1])
+	} else {
+		target, err = resolveObjectName(c.Context, repo, refs.Head)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	info, err := repo.Stat(c.Context, target)
+
+	if err != nil {
+		return err
+	}
+
+	message, annotated, err := getTagMessage(c)
+
+	if err != nil {
+		return err
+	}
+
+	if annotated {
+		tagger, err := repo.Identity(c.Context, ditto.CommitterRole)
+
+		if err != nil {
+			return err
+		}
+
+		newTag := &ditto.Tag{
+			Object:  target,
+			Type:    info.Type,
+			Name:    name,
+			Tagger:  &tagger,
+			Message: message,
+		}
+
+		if target, err = repo.WriteTag(c.Context, newTag); err != nil {
+			return err
+		}
+	}
+
+	return repo.Refs().Update(c.Context, refs.TagsPrefix+name, target, nil, true, "")
+}
+
+var TagCommand = &cli.Command{
+	Name:      "tag",
+	HelpName:  "tag",
+	Usage:     "Create, list or delete tags",
+	ArgsUsage: "[-a | -m <msg> | -F <file>] [-f] <tagname> [<object>] | -d <tagname>... | [-l] [<pattern>]",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "a",
+			Value: false,
+			Usage: "Make an unsigned, annotated tag object.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "m",
+			Usage: "Use the given tag message (instead of prompting). Implies -a.",
+		},
+		&cli.StringFlag{
+			Name:  "F",
+			Usage: "Take the tag message from the given file. Use - to read from the standard input. Implies -a.",
+		},
+		&cli.BoolFlag{
+			Name:  "f",
+			Value: false,
+			Usage: "Replace an existing tag with the given name (instead of failing).",
+		},
+		&cli.BoolFlag{
+			Name:  "d",
+			Value: false,
+			Usage: "Delete existing tags with the given names.",
+		},
+		&cli.BoolFlag{
+			Name:  "l",
+			Value: false,
+			Usage: "List tags, optionally only those matching the given pattern.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for tag command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		switch {
+		case c.Bool("d"):
+			err = deleteTags(repo, c, args)
+		case c.Bool("l") || len(args) == 0:
+			var names []string
+
+			pattern := ""
+
+			if len(args) > 0 {
+				pattern = args[0]
+			}
+
+			names, err = listTags(c.Context, repo, pattern)
+
+			for _, name := range names {
+				fmt.Fprintln(c.App.Writer, name)
+			}
+		default:
+			err = createTag(repo, c, args)
+		}
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		return nil
+	},
+}
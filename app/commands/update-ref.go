@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Resolve a revision, checking that the object it names exists.
+func resolveObjectName(ctx context.Context, repo *ditto.Repository, rev string) (ditto.Hash, error) {
+	hash, err := repo.Resolve(ctx, rev)
+
+	if err != nil {
+		return ditto.ZeroHash, err
+	}
+
+	if _, err = repo.Stat(ctx, hash); err != nil {
+		return ditto.ZeroHash, errors.GitError{Message: fmt.Sprintf("%s: not a valid SHA1", rev)}
+	}
+
+	return hash, nil
+}
+
+// Parse the expected old value of a ref. An empty value or the zero hash
+// mean that the ref must not exist yet.
+func parseOldValue(ctx context.Context, repo *ditto.Repository, value string) (*ditto.Hash, error) {
+	if value == "" {
+		return &ditto.ZeroHash, nil
+	}
+
+	if hash, err := ditto.NewHash(value); err == nil && hash.IsZero() {
+		return &hash, nil
+	}
+
+	hash, err := resolveObjectName(ctx, repo, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &hash, nil
+}
+
+var UpdateRefCommand = &cli.Command{
+	Name:      "update-ref",
+	HelpName:  "update-ref",
+	Usage:     "Update the object name stored in a ref safely",
+	ArgsUsage: "[-m <reason>] [--no-deref] (-d <ref> [<oldvalue>] | <ref> <newvalue> [<oldvalue>])",
+
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "d",
+			Value: false,
+			Usage: "Delete the named ref after verifying it still contains <oldvalue>.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-deref",
+			Value: false,
+			Usage: "Update the ref itself rather than the result of following symbolic refs.",
+		},
+		&cli.StringFlag{
+			Name:  "m",
+			Usage: "Record <reason> in the reflog of the updated ref.",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		utils.InfoLogger.Println("Validating preconditions for update-ref command.")
+
+		args, err := commandArgs(c)
+
+		if err != nil {
+			return cli.Exit(err.Error(), 129)
+		}
+
+		deleting := c.Bool("d")
+
+		if (deleting && (len(args) < 1 || len(args) > 2)) || (!deleting && (len(args) < 2 || len(args) > 3)) {
+			return cli.Exit("usage: git update-ref "+c.Command.ArgsUsage, 129)
+		}
+
+		message := c.String("m")
+
+		if c.IsSet("m") && message == "" {
+			return cli.Exit("Refusing to perform update with empty message.", 128)
+		}
+
+		repo, err := openRepository(c)
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 1)
+		}
+
+		name := args[0]
+
+		var old *ditto.Hash
+		oldIndex := 2
+
+		if deleting {
+			oldIndex = 1
+		}
+
+		if len(args) > oldIndex {
+			if old, err = parseOldValue(c.Context, repo, args[oldIndex]); err != nil {
+				return cli.Exit(err.Error(), 128)
+			}
+		}
+
+		if deleting {
+			err = repo.Refs().Delete(c.Context, name, old, c.Bool("no-deref"), message)
+		} else {
+			var hash ditto.Hash
+
+			if hash, err = resolveObjectName(c.Context, repo, args[1]); err == nil {
+				err = repo.Refs().Update(c.Context, name, hash, old, c.Bool("no-deref"), message)
+			}
+		}
+
+		if err != nil {
+			utils.ErrorLogger.Println(err.Error())
+
+			return cli.Exit(err.Error(), 128)
+		}
+
+		return nil
+	},
+}
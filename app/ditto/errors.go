@@ -0,0 +1,136 @@
+package ditto
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/discover"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+)
+
+// RepositoryNotFoundError is returned when no repository contains a path.
+// Reason says why, when it is not that there is no .git up to the root.
+type RepositoryNotFoundError struct {
+	Path   string
+	Reason string
+}
+
+func (e RepositoryNotFoundError) Error() string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+
+	return "Not a git repository (or any of the parent directories): .git"
+}
+
+// UnsafeRepositoryError is returned when opening a repository owned by
+// someone else, which safe.directory does not list.
+type UnsafeRepositoryError struct {
+	Path string
+}
+
+func (e UnsafeRepositoryError) Error() string {
+	return discover.DubiousOwnershipError{Path: e.Path}.Error()
+}
+
+// RepositoryExistsError is returned when initializing a repository where one
+// already exists.
+type RepositoryExistsError struct {
+	Path string
+}
+
+func (e RepositoryExistsError) Error() string {
+	return fmt.Sprintf("AlreadyExists %s", e.Path)
+}
+
+// BareRepositoryError is returned for working tree operations on a
+// repository without one.
+type BareRepositoryError struct {
+	GitDir string
+}
+
+func (e BareRepositoryError) Error() string {
+	return "this operation must be run in a work tree"
+}
+
+// ObjectNotFoundError is returned for objects missing from the repository.
+type ObjectNotFoundError struct {
+	Hash Hash
+}
+
+func (e ObjectNotFoundError) Error() string {
+	return fmt.Sprintf("Object %s not found", e.Hash)
+}
+
+// RevisionNotFoundError is returned for revisions that do not name any
+// object.
+type RevisionNotFoundError struct {
+	Rev string
+}
+
+func (e RevisionNotFoundError) Error() string {
+	return fmt.Sprintf("Not a valid object name %s", e.Rev)
+}
+
+// AmbiguousRevisionError is returned for abbreviated object names matching
+// more than one object.
+type AmbiguousRevisionError struct {
+	Prefix string
+}
+
+func (e AmbiguousRevisionError) Error() string {
+	return fmt.Sprintf("short object ID %s is ambiguous", e.Prefix)
+}
+
+// ReferenceNotFoundError is returned for refs that do not exist.
+type ReferenceNotFoundError struct {
+	Name string
+}
+
+func (e ReferenceNotFoundError) Error() string {
+	return fmt.Sprintf("ref %s does not exist", e.Name)
+}
+
+// UnexpectedTypeError is returned when an object is not of the type asked
+// for.
+type UnexpectedTypeError struct {
+	Hash     Hash
+	Type     ObjectType
+	Expected ObjectType
+}
+
+func (e UnexpectedTypeError) Error() string {
+	return fmt.Sprintf("%s is a %s, not a %s", e.Hash, e.Type, e.Expected)
+}
+
+// IsNotFound reports whether err means that a repository, object, revision
+// or reference does not exist.
+func IsNotFound(err error) bool {
+	var (
+		repository RepositoryNotFoundError
+		object     ObjectNotFoundError
+		rev        RevisionNotFoundError
+		ref        ReferenceNotFoundError
+	)
+
+	return errors.As(err, &repository) || errors.As(err, &object) || errors.As(err, &rev) || errors.As(err, &ref)
+}
+
+// Translate the errors of the internal packages into the public ones.
+func publicError(err error) error {
+	switch e := err.(type) {
+	case discover.NotFoundError:
+		return RepositoryNotFoundError{Path: e.Path, Reason: e.Reason}
+	case discover.DubiousOwnershipError:
+		return UnsafeRepositoryError{Path: e.Path}
+	case odb.NotFoundError:
+		return ObjectNotFoundError{Hash: e.Hash}
+	case revision.NotFoundError:
+		return RevisionNotFoundError{Rev: e.Rev}
+	case revision.AmbiguousError:
+		return AmbiguousRevisionError{Prefix: e.Prefix}
+	}
+
+	return err
+}
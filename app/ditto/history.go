@@ -0,0 +1,56 @@
+package ditto
+
+import (
+	"context"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+)
+
+// MergeBases finds the best common ancestors of one commit and any of the
+// others, newest first: the common ancestors that are not ancestors of
+// another one.
+func (r *Repository) MergeBases(ctx context.Context, one Hash, others ...Hash) ([]Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bases, err := revision.MergeBases(ctx, r.git, one, others...)
+
+	return bases, publicError(err)
+}
+
+// OctopusMergeBases finds the best common ancestors of all the commits
+// together, for merging them all at once.
+func (r *Repository) OctopusMergeBases(ctx context.Context, commits ...Hash) ([]Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bases, err := revision.OctopusMergeBases(ctx, r.git, commits...)
+
+	return bases, publicError(err)
+}
+
+// IndependentCommits keeps the commits that cannot be reached from any
+// other of them, in the order given.
+func (r *Repository) IndependentCommits(ctx context.Context, commits ...Hash) ([]Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	independent, err := revision.Independent(ctx, r.git, commits...)
+
+	return independent, publicError(err)
+}
+
+// IsAncestor reports whether ancestor can be reached from descendant, which
+// counts as its own ancestor.
+func (r *Repository) IsAncestor(ctx context.Context, ancestor, descendant Hash) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	found, err := revision.IsAncestor(ctx, r.git, ancestor, descendant)
+
+	return found, publicError(err)
+}
@@ -0,0 +1,356 @@
+package ditto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/discover"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Directories of a new repository, relative to its git directory.
+var repositoryDirs = []string{"objects/info", "objects/pack", "refs/heads", "refs/tags"}
+
+// Where templates are taken from when nothing else says so.
+const systemTemplateDir = "/usr/share/git-core/templates"
+
+// InitOptions tunes how InitWithOptions creates a repository.
+type InitOptions struct {
+	// Bare creates a repository without a working tree, whose git
+	// directory is the path given.
+	Bare bool
+
+	// InitialBranch is the branch HEAD points at, instead of the one named
+	// by init.defaultBranch.
+	InitialBranch string
+
+	// TemplateDir holds files to copy into the git directory, such as hooks
+	// and info/exclude. It defaults to $GIT_TEMPLATE_DIR, init.templateDir
+	// or the templates installed with git.
+	TemplateDir string
+
+	// ObjectFormat is the name of the hash algorithm naming objects, sha1
+	// or sha256. It defaults to $GIT_DEFAULT_HASH, or else sha1.
+	ObjectFormat string
+
+	// SeparateGitDir keeps the git directory there instead of in .git,
+	// which becomes a file pointing at it.
+	SeparateGitDir string
+}
+
+// The branch HEAD of a new repository points at: the one asked for, or the
+// one named by init.defaultBranch, master by default.
+func initialBranch(cfg *config.Config, branch string) (string, error) {
+	if branch == "" {
+		branch, _ = cfg.Get("init.defaultBranch")
+	}
+
+	if branch == "" {
+		return "master", nil
+	}
+
+	if !refs.ValidName(refs.HeadsPrefix + branch) {
+		return "", errors.GitError{Message: fmt.Sprintf("invalid initial branch name: '%s'", branch)}
+	}
+
+	return branch, nil
+}
+
+// The directory templates are copied from.
+func templateDir(cfg *config.Config, dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+
+	if dir = os.Getenv("GIT_TEMPLATE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	if dir, ok := cfg.Get("init.templateDir"); ok {
+		return config.ExpandPath(dir)
+	}
+
+	return systemTemplateDir, nil
+}
+
+// Copy the files of a template directory into a git directory, leaving
+// files that already exist alone. A missing template directory is skipped.
+func copyTemplates(templates, gitDir string) error {
+	if !utils.PathExists(templates) {
+		return nil
+	}
+
+	return filepath.Walk(templates, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(templates, path)
+
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(gitDir, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case utils.PathExists(target):
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		}
+
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+// The object format of a new repository: the one asked for, or the one
+// named by $GIT_DEFAULT_HASH.
+func objectFormat(name string) (plumbing.ObjectFormat, error) {
+	if name == "" {
+		name = os.Getenv("GIT_DEFAULT_HASH")
+	}
+
+	return plumbing.ParseObjectFormat(name)
+}
+
+// Fill in the settings every repository has, keeping the ones already set.
+// Repositories of any object format but SHA1 need format version 1 and the
+// objectFormat extension.
+func writeDefaultConfig(gitDir string, bare bool, format plumbing.ObjectFormat) error {
+	path := filepath.Join(gitDir, "config")
+
+	cfg, err := config.Load(path)
+
+	if err != nil {
+		return err
+	}
+
+	version := "0"
+
+	if format != plumbing.SHA1 {
+		version = "1"
+	}
+
+	defaults := [][2]string{
+		{"core.repositoryformatversion", version},
+		{"core.filemode", "true"},
+		{"core.bare", strconv.FormatBool(bare)},
+	}
+
+	if !bare {
+		defaults = append(defaults, [2]string{"core.logallrefupdates", "true"})
+	}
+
+	if format != plumbing.SHA1 {
+		defaults = append(defaults, [2]string{"extensions.objectformat", format.String()})
+	}
+
+	for _, setting := range defaults {
+		if _, ok := cfg.Get(setting[0]); ok {
+			continue
+		}
+
+		if err = config.Set(path, setting[0], setting[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Work out where the git directory of the repository at path goes. An
+// existing .git file is followed, and with a separate git directory an
+// existing .git directory is moved there.
+func initGitDir(path string, opts InitOptions) (string, error) {
+	if opts.Bare {
+		return path, nil
+	}
+
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	exists := err == nil
+
+	if opts.SeparateGitDir == "" {
+		if exists && !info.IsDir() {
+			return fs.ReadGitFile(dotGit)
+		}
+
+		return dotGit, nil
+	}
+
+	gitDir, err := filepath.Abs(opts.SeparateGitDir)
+
+	if err != nil {
+		return "", err
+	}
+
+	if exists && info.IsDir() {
+		if utils.PathExists(gitDir) {
+			return "", errors.GitError{Message: fmt.Sprintf("%s already exists", gitDir)}
+		}
+
+		if err = os.Rename(dotGit, gitDir); err != nil {
+			return "", err
+		}
+	}
+
+	return gitDir, nil
+}
+
+// InitWithOptions creates a repository at path, creating path if needed,
+// and reports whether there was one already. Like git init, running it on
+// an existing repository is safe: it only adds what is missing, such as new
+// templates, and leaves HEAD alone.
+func InitWithOptions(ctx context.Context, path string, opts InitOptions) (*Repository, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	format, err := objectFormat(opts.ObjectFormat)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if opts.Bare && opts.SeparateGitDir != "" {
+		return nil, false, errors.GitError{Message: "options '--separate-git-dir' and '--bare' cannot be used together"}
+	}
+
+	// Without a repository yet, only the system and global config apply.
+	cfg, err := config.LoadAll("")
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	gitDir, err := initGitDir(path, opts)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	reinit := utils.PathExists(filepath.Join(gitDir, "HEAD"))
+	branch := ""
+
+	if reinit {
+		existing, err := discover.ObjectFormat(gitDir)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if opts.ObjectFormat != "" && existing != format {
+			return nil, false, errors.GitError{Message: "attempt to reinitialize repository with different hash"}
+		}
+
+		format = existing
+	} else {
+		if branch, err = initialBranch(cfg, opts.InitialBranch); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err = os.MkdirAll(gitDir, 0755); err != nil {
+		return nil, false, err
+	}
+
+	templates, err := templateDir(cfg, opts.TemplateDir)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = copyTemplates(templates, gitDir); err != nil {
+		return nil, false, err
+	}
+
+	for _, dir := range repositoryDirs {
+		if err = os.MkdirAll(filepath.Join(gitDir, filepath.FromSlash(dir)), 0755); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if !reinit {
+		head := []byte("ref: " + refs.HeadsPrefix + branch + "\n")
+
+		if err = ioutil.WriteFile(filepath.Join(gitDir, "HEAD"), head, 0644); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err = writeDefaultConfig(gitDir, opts.Bare, format); err != nil {
+		return nil, false, err
+	}
+
+	if opts.SeparateGitDir != "" {
+		gitFile := []byte("gitdir: " + gitDir + "\n")
+
+		if err = os.MkdirAll(path, 0755); err != nil {
+			return nil, false, err
+		}
+
+		if err = ioutil.WriteFile(filepath.Join(path, ".git"), gitFile, 0644); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return newRepository(fs.NewGit(gitDir, format, nil)), reinit, nil
+}
+
+// Init creates an empty repository with its working tree at path, creating
+// path if needed. Unlike InitWithOptions, it fails with
+// RepositoryExistsError if there is a repository there already.
+func Init(ctx context.Context, path string) (*Repository, error) {
+	gitDir := filepath.Join(path, ".git")
+
+	if utils.PathExists(gitDir) {
+		return nil, RepositoryExistsError{Path: gitDir}
+	}
+
+	repo, _, err := InitWithOptions(ctx, path, InitOptions{})
+
+	return repo, err
+}
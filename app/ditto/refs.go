@@ -0,0 +1,105 @@
+package ditto
+
+import (
+	"context"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+)
+
+// Refs reads and updates the references of a repository, loose and packed.
+type Refs struct {
+	store *refs.Store
+}
+
+func newRefs(gitDir string) *Refs {
+	return &Refs{store: refs.NewStore(gitDir)}
+}
+
+// Read a ref without following symbolic refs.
+func (r *Refs) Read(ctx context.Context, name string) (*Ref, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ref, err := r.store.Read(name)
+
+	if err == nil && ref == nil {
+		err = ReferenceNotFoundError{Name: name}
+	}
+
+	return ref, publicError(err)
+}
+
+// Resolve a ref to the object it points at, following symbolic refs.
+func (r *Refs) Resolve(ctx context.Context, name string) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return ZeroHash, err
+	}
+
+	_, ref, err := r.store.Follow(name)
+
+	if err == nil && ref == nil {
+		err = ReferenceNotFoundError{Name: name}
+	}
+
+	if err != nil {
+		return ZeroHash, publicError(err)
+	}
+
+	return ref.Hash, nil
+}
+
+// Head reads HEAD, returning the branch it points at ("" when HEAD is
+// detached) and the commit it resolves to (zero when the branch is unborn).
+func (r *Refs) Head(ctx context.Context) (string, Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return "", ZeroHash, err
+	}
+
+	branch, head, err := r.store.Head()
+
+	return branch, head, publicError(err)
+}
+
+// List the refs under prefix (like refs/tags/) sorted by name.
+func (r *Refs) List(ctx context.Context, prefix string) ([]*Ref, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	refs, err := r.store.List(prefix)
+
+	return refs, publicError(err)
+}
+
+// Update points a ref at hash, following symbolic refs unless noDeref is
+// set. If old is not nil, the ref must currently point at it, or not exist
+// if it is zero. The update is logged in the ref's reflog with message.
+func (r *Refs) Update(ctx context.Context, name string, hash Hash, old *Hash, noDeref bool, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return publicError(r.store.Update(name, hash, old, noDeref, message))
+}
+
+// SetSymbolic points the symbolic ref name at the ref target, logging the
+// change with message.
+func (r *Refs) SetSymbolic(ctx context.Context, name, target, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return publicError(r.store.SetSymbolic(name, target, message))
+}
+
+// Delete removes a ref, loose and packed, with the same old value and
+// noDeref rules as Update. Its reflog is removed, and message is logged for
+// HEAD if it pointed at the ref.
+func (r *Refs) Delete(ctx context.Context, name string, old *Hash, noDeref bool, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return publicError(r.store.Delete(name, old, noDeref, message))
+}
@@ -0,0 +1,311 @@
+// Package ditto is the public Go API of git-ditto, for programs embedding it
+// instead of running the git-ditto command. The git-ditto commands are built
+// on it too.
+//
+// All operations doing I/O take a context.Context and give up once it is
+// done. Errors are of the types in errors.go where callers may want to tell
+// them apart, such as ObjectNotFoundError.
+package ditto
+
+import (
+	"context"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/discover"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// Repository is a git repository: its objects, refs and working tree.
+type Repository struct {
+	git *fs.Git
+
+	refs     *Refs
+	resolver *revision.Resolver
+}
+
+func newRepository(git *fs.Git) *Repository {
+	return &Repository{
+		git:      git,
+		refs:     newRefs(git.GitDir()),
+		resolver: revision.NewResolver(git),
+	}
+}
+
+// Open the repository containing path the way git finds it: the one named
+// by $GIT_DIR if it is set, or else the first .git directory or file, or
+// bare repository, in path and its parents.
+func Open(ctx context.Context, path string) (*Repository, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	git, err := discover.Find(path)
+
+	if err != nil {
+		return nil, publicError(err)
+	}
+
+	return newRepository(git), nil
+}
+
+// NewRepository opens the repository whose git directory is gitDir, keeping
+// its objects in store instead of the object directory, e.g. in memory. The
+// store should use the object format of the repository, SHA1 unless its
+// config says otherwise.
+func NewRepository(gitDir string, store ObjectStore) *Repository {
+	format, err := discover.ObjectFormat(gitDir)
+
+	if err != nil {
+		format = SHA1
+	}
+
+	return newRepository(fs.NewGit(gitDir, format, store))
+}
+
+// GitDir is the path of the git directory of the repository.
+func (r *Repository) GitDir() string {
+	return r.git.GitDir()
+}
+
+// ObjectFormat is the hash algorithm naming the objects of the repository.
+func (r *Repository) ObjectFormat() ObjectFormat {
+	return r.git.ObjectFormat()
+}
+
+// IsBare tells whether the repository has no working tree.
+func (r *Repository) IsBare() bool {
+	return r.git.IsBare()
+}
+
+// PackDir is the directory holding the packfiles of the repository.
+func (r *Repository) PackDir() string {
+	return r.git.PackDir()
+}
+
+// Config reads the configuration of the repository: its own config file
+// layered over the system and global ones.
+func (r *Repository) Config(ctx context.Context) (*Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadAll(r.git.GitDir())
+
+	return cfg, publicError(err)
+}
+
+// Identity builds the signature for new objects in the given role from the
+// environment, then the configuration, then the login and host names.
+func (r *Repository) Identity(ctx context.Context, role IdentityRole) (Signature, error) {
+	cfg, err := r.Config(ctx)
+
+	if err != nil {
+		return Signature{}, err
+	}
+
+	sig, err := commit.Ident(role, cfg)
+
+	return sig, publicError(err)
+}
+
+// Objects is the object store of the repository.
+func (r *Repository) Objects() ObjectStore {
+	return r.git.Objects()
+}
+
+// Refs gives access to the references of the repository.
+func (r *Repository) Refs() *Refs {
+	return r.refs
+}
+
+// Worktree gives access to the working tree and index of the repository.
+func (r *Repository) Worktree() (*Worktree, error) {
+	if r.git.IsBare() {
+		return nil, BareRepositoryError{GitDir: r.git.GitDir()}
+	}
+
+	return &Worktree{repo: r}, nil
+}
+
+// Resolve a revision, as described in gitrevisions(7), to an object id.
+func (r *Repository) Resolve(ctx context.Context, rev string) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return ZeroHash, err
+	}
+
+	hash, err := r.resolver.WithContext(ctx).Resolve(rev)
+
+	return hash, publicError(err)
+}
+
+// SymbolicFullName gives the full name of the ref a revision refers to,
+// like refs/heads/master for master, or "" if it does not name a ref.
+func (r *Repository) SymbolicFullName(ctx context.Context, rev string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	name, err := r.resolver.SymbolicFullName(rev)
+
+	return name, publicError(err)
+}
+
+// DefaultAbbrev is the length object names are abbreviated to by default.
+const DefaultAbbrev = revision.DefaultAbbrev
+
+// Abbreviate gives the shortest prefix of hash, at least minLen digits
+// long, that names no other object in the repository.
+func (r *Repository) Abbreviate(ctx context.Context, hash Hash, minLen int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	abbrev, err := revision.Abbreviate(r.git, hash, minLen)
+
+	return abbrev, publicError(err)
+}
+
+// Peel follows tags, and commits to their trees, until an object of type
+// want is reached. rev names the object in errors.
+func (r *Repository) Peel(ctx context.Context, hash Hash, want ObjectType, rev string) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return ZeroHash, err
+	}
+
+	hash, err := r.resolver.Peel(hash, want, rev)
+
+	return hash, publicError(err)
+}
+
+// Object reads an object fully into memory.
+func (r *Repository) Object(ctx context.Context, hash Hash) (*Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t, data, err := r.git.ReadObjectByHash(hash)
+
+	if err != nil {
+		return nil, publicError(err)
+	}
+
+	return &Object{Hash: hash, Type: t, Data: data}, nil
+}
+
+// Stat describes an object without reading all of it when possible.
+func (r *Repository) Stat(ctx context.Context, hash Hash) (ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := r.git.StatObject(hash)
+
+	return info, publicError(err)
+}
+
+// WriteObject stores an object, returning its id.
+func (r *Repository) WriteObject(ctx context.Context, t ObjectType, data []byte) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return ZeroHash, err
+	}
+
+	hash, err := r.git.WriteObject(t, data)
+
+	return hash, publicError(err)
+}
+
+// WriteLiteralObject stores an object under any type name, even one git does
+// not know, without checking its content. Only objects kept on disk can be of
+// unknown types.
+func (r *Repository) WriteLiteralObject(ctx context.Context, typeName string, data []byte) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return ZeroHash, err
+	}
+
+	hash, err := r.git.WriteLiteralObject(typeName, data)
+
+	return hash, publicError(err)
+}
+
+// ListObjects lists the ids of all objects in the repository, sorted.
+func (r *Repository) ListObjects(ctx context.Context) ([]Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hashes, err := r.git.ListObjects(ctx)
+
+	return hashes, publicError(err)
+}
+
+// Read an object, checking that it has the expected type.
+func (r *Repository) typedObject(ctx context.Context, hash Hash, expected ObjectType) (*Object, error) {
+	obj, err := r.Object(ctx, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Type != expected {
+		return nil, UnexpectedTypeError{Hash: hash, Type: obj.Type, Expected: expected}
+	}
+
+	return obj, nil
+}
+
+// Commit reads and parses a commit object.
+func (r *Repository) Commit(ctx context.Context, hash Hash) (*Commit, error) {
+	obj, err := r.typedObject(ctx, hash, CommitObject)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := commit.Decode(obj.Data)
+
+	return c, publicError(err)
+}
+
+// WriteCommit stores a commit object, returning its id.
+func (r *Repository) WriteCommit(ctx context.Context, c *Commit) (Hash, error) {
+	return r.WriteObject(ctx, CommitObject, c.Bytes())
+}
+
+// Tree reads and parses a tree object.
+func (r *Repository) Tree(ctx context.Context, hash Hash) (*Tree, error) {
+	obj, err := r.typedObject(ctx, hash, TreeObject)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := tree.Decode(obj.Data, hash.Format())
+
+	if err != nil {
+		return nil, publicError(err)
+	}
+
+	return &Tree{Hash: hash, Entries: entries}, nil
+}
+
+// Tag reads and parses an annotated tag object.
+func (r *Repository) Tag(ctx context.Context, hash Hash) (*Tag, error) {
+	obj, err := r.typedObject(ctx, hash, TagObject)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := tag.Decode(obj.Data)
+
+	return t, publicError(err)
+}
+
+// WriteTag stores an annotated tag object, returning its id.
+func (r *Repository) WriteTag(ctx context.Context, t *Tag) (Hash, error) {
+	return r.WriteObject(ctx, TagObject, t.Bytes())
+}
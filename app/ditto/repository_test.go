@@ -0,0 +1,362 @@
+package ditto_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/ditto"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+const (
+	emptyTree   = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	firstCommit = "07aa2d0808984a15395272a831194def44801887"
+)
+
+func newRepository(t *testing.T) *ditto.Repository {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "git_ditto_repo")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	repo, err := ditto.Init(context.Background(), filepath.Join(dir, "repo"))
+	utils.Expect(t, err, nil)
+
+	return repo
+}
+
+func signature(t *testing.T, line string) ditto.Signature {
+	t.Helper()
+
+	sig, err := ditto.ParseSignature(line)
+	utils.Expect(t, err, nil)
+
+	return sig
+}
+
+// Write the empty tree and a commit of it, returning the commit.
+func writeFirstCommit(t *testing.T, repo *ditto.Repository) ditto.Hash {
+	t.Helper()
+
+	ctx := context.Background()
+
+	tree, err := repo.WriteObject(ctx, ditto.TreeObject, nil)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, tree.String(), emptyTree)
+
+	hash, err := repo.WriteCommit(ctx, &ditto.Commit{
+		Tree:      tree,
+		Author:    signature(t, "A U Thor <author@example.com> 1112911993 -0700"),
+		Committer: signature(t, "C O Mitter <committer@example.com> 1112912053 -0700"),
+		Message:   "Initial commit\n",
+	})
+	utils.Expect(t, err, nil)
+	utils.Expect(t, hash.String(), firstCommit)
+
+	return hash
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+	hash := writeFirstCommit(t, repo)
+
+	c, err := repo.Commit(ctx, hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, c.Message, "Initial commit\n")
+
+	tree, err := repo.Tree(ctx, c.Tree)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(tree.Entries), 0)
+
+	_, err = repo.Tree(ctx, hash)
+	_, ok := err.(ditto.UnexpectedTypeError)
+	utils.Expect(t, ok, true)
+
+	utils.Expect(t, repo.Refs().Update(ctx, "refs/heads/master", hash, &ditto.ZeroHash, false, ""), nil)
+
+	branch, head, err := repo.Refs().Head(ctx)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, branch, "refs/heads/master")
+	utils.Expect(t, head, hash)
+
+	resolved, err := repo.Resolve(ctx, "HEAD^{tree}")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, resolved.String(), emptyTree)
+
+	// Opening from a subdirectory of the working tree finds the repository.
+	worktree, err := repo.Worktree()
+	utils.Expect(t, err, nil)
+
+	sub := filepath.Join(worktree.Root(), "sub")
+	utils.Expect(t, os.Mkdir(sub, 0755), nil)
+
+	reopened, err := ditto.Open(ctx, sub)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, reopened.GitDir(), repo.GitDir())
+
+	_, err = ditto.Init(ctx, worktree.Root())
+	_, ok = err.(ditto.RepositoryExistsError)
+	utils.Expect(t, ok, true)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+
+	missing, _ := ditto.NewHash(firstCommit)
+
+	_, err := repo.Object(ctx, missing)
+	utils.Expect(t, err, ditto.ObjectNotFoundError{Hash: missing})
+	utils.Expect(t, ditto.IsNotFound(err), true)
+
+	_, err = repo.Resolve(ctx, "nope")
+	utils.Expect(t, err, ditto.RevisionNotFoundError{Rev: "nope"})
+
+	_, err = repo.Refs().Read(ctx, "refs/heads/nope")
+	utils.Expect(t, err, ditto.ReferenceNotFoundError{Name: "refs/heads/nope"})
+
+	dir, err := ioutil.TempDir("", "git_ditto_norepo")
+	utils.Expect(t, err, nil)
+
+	defer os.RemoveAll(dir)
+
+	_, err = ditto.Open(ctx, dir)
+	utils.Expect(t, ditto.IsNotFound(err), true)
+
+	// Wrapped errors are recognized too.
+	utils.Expect(t, ditto.IsNotFound(fmt.Errorf("opening: %w", err)), true)
+	utils.Expect(t, ditto.IsNotFound(context.Canceled), false)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = repo.Object(cancelled, missing)
+	utils.Expect(t, err, context.Canceled)
+}
+
+func TestMalformedDates(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+
+	data := "tree " + emptyTree + "\n" +
+		"author A <a@example.com> 1234567890 +05\n" +
+		"committer C <c@example.com> soon +0000\n" +
+		"\nMalformed\n"
+
+	hash, err := repo.WriteObject(ctx, ditto.CommitObject, []byte(data))
+	utils.Expect(t, err, nil)
+
+	// The commit is read anyway, like git does, "+05" being five minutes
+	// east and an unreadable timestamp the epoch.
+	c, err := repo.Commit(ctx, hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, c.Author.When.Format("2006-01-02 15:04:05 -0700"), "2009-02-13 23:36:30 +0005")
+	utils.Expect(t, c.Committer.When.Unix(), int64(0))
+
+	// Writing it back keeps the dates as they were.
+	rewritten, err := repo.WriteCommit(ctx, c)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, rewritten, hash)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+	first := writeFirstCommit(t, repo)
+
+	commit, err := repo.Commit(ctx, first)
+	utils.Expect(t, err, nil)
+
+	// Two children of the first commit.
+	children := []ditto.Hash{}
+
+	for _, message := range []string{"One\n", "Two\n"} {
+		hash, err := repo.WriteCommit(ctx, &ditto.Commit{
+			Tree:      commit.Tree,
+			Parents:   []ditto.Hash{first},
+			Author:    commit.Author,
+			Committer: commit.Committer,
+			Message:   message,
+		})
+		utils.Expect(t, err, nil)
+
+		children = append(children, hash)
+	}
+
+	bases, err := repo.MergeBases(ctx, children[0], children[1])
+	utils.Expect(t, err, nil)
+	utils.Expect(t, bases, []ditto.Hash{first})
+
+	ancestor, err := repo.IsAncestor(ctx, first, children[1])
+	utils.Expect(t, err, nil)
+	utils.Expect(t, ancestor, true)
+
+	independent, err := repo.IndependentCommits(ctx, first, children[0], children[1])
+	utils.Expect(t, err, nil)
+	utils.Expect(t, independent, children)
+
+	short, err := repo.Abbreviate(ctx, first, ditto.DefaultAbbrev)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, short, firstCommit[:7])
+}
+
+// A context that is done once Err has been asked about a number of times,
+// to cancel operations part-way through.
+type countdownContext struct {
+	context.Context
+	left int
+}
+
+func (c *countdownContext) Err() error {
+	if c.left == 0 {
+		return context.Canceled
+	}
+
+	c.left--
+
+	return nil
+}
+
+func TestCancel(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepository(t)
+	first := writeFirstCommit(t, repo)
+
+	commit, err := repo.Commit(ctx, first)
+	utils.Expect(t, err, nil)
+
+	// A line of commits on top of the first one.
+	tip := first
+
+	for i := 0; i < 10; i++ {
+		tip, err = repo.WriteCommit(ctx, &ditto.Commit{
+			Tree:      commit.Tree,
+			Parents:   []ditto.Hash{tip},
+			Author:    commit.Author,
+			Committer: commit.Committer,
+			Message:   fmt.Sprintf("Commit %d\n", i),
+		})
+		utils.Expect(t, err, nil)
+	}
+
+	utils.Expect(t, repo.Refs().Update(ctx, "refs/heads/master", tip, nil, true, ""), nil)
+
+	// Each one gets past the check on the way in and stops in its loop.
+	_, err = repo.MergeBases(&countdownContext{Context: ctx, left: 3}, tip, first)
+	utils.Expect(t, err, context.Canceled)
+
+	_, err = repo.IsAncestor(&countdownContext{Context: ctx, left: 3}, first, tip)
+	utils.Expect(t, err, context.Canceled)
+
+	_, err = repo.Resolve(&countdownContext{Context: ctx, left: 3}, ":/Initial")
+	utils.Expect(t, err, context.Canceled)
+
+	_, err = repo.ListObjects(&countdownContext{Context: ctx, left: 3})
+	utils.Expect(t, err, context.Canceled)
+
+	found, err := repo.IsAncestor(ctx, first, tip)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, found, true)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := ditto.NewMemoryStore(ditto.SHA1)
+	repo := ditto.NewRepository(newRepository(t).GitDir(), store)
+
+	hash := writeFirstCommit(t, repo)
+
+	ok, err := store.Has(hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, ok, true)
+
+	// Nothing was written to the object directory.
+	_, err = os.Stat(filepath.Join(repo.GitDir(), "objects", firstCommit[:2]))
+	utils.Expect(t, os.IsNotExist(err), true)
+
+	hashes, err := repo.ListObjects(ctx)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(hashes), 2)
+}
+
+func TestInitWithOptions(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "git_ditto_init")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "bare.git")
+	opts := ditto.InitOptions{Bare: true, InitialBranch: "main"}
+
+	repo, reinit, err := ditto.InitWithOptions(ctx, path, opts)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, reinit, false)
+	utils.Expect(t, repo.GitDir(), path)
+
+	cfg, err := repo.Config(ctx)
+	utils.Expect(t, err, nil)
+
+	isBare, err := cfg.Bool("core.bare", false)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, isBare, true)
+
+	utils.ExpectFileContent(t, filepath.Join(path, "HEAD"), "ref: refs/heads/main\n")
+
+	// Running it again keeps the repository as it is.
+	_, reinit, err = ditto.InitWithOptions(ctx, path, ditto.InitOptions{Bare: true, InitialBranch: "other"})
+	utils.Expect(t, err, nil)
+	utils.Expect(t, reinit, true)
+
+	utils.ExpectFileContent(t, filepath.Join(path, "HEAD"), "ref: refs/heads/main\n")
+
+	_, _, err = ditto.InitWithOptions(ctx, filepath.Join(dir, "other"), ditto.InitOptions{ObjectFormat: "md5"})
+	utils.Expect(t, err != nil, true)
+}
+
+func TestSHA256(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "git_ditto_sha256")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "repo")
+
+	repo, _, err := ditto.InitWithOptions(ctx, path, ditto.InitOptions{ObjectFormat: "sha256"})
+	utils.Expect(t, err, nil)
+	utils.Expect(t, repo.ObjectFormat(), ditto.SHA256)
+
+	blob, err := repo.WriteObject(ctx, ditto.BlobObject, []byte("hello\n"))
+	utils.Expect(t, err, nil)
+	utils.Expect(t, blob.String(), "2cf8d83d9ee29543b34a87727421fdecb7e3f3a183d337639025de576db9ebb4")
+
+	tree, err := repo.WriteObject(ctx, ditto.TreeObject, append([]byte("100644 hello\x00"), blob.Bytes()...))
+	utils.Expect(t, err, nil)
+
+	// The format is read back from extensions.objectFormat.
+	repo, err = ditto.Open(ctx, path)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, repo.ObjectFormat(), ditto.SHA256)
+
+	read, err := repo.Tree(ctx, tree)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, read.Entries[0].Hash(), blob)
+
+	// Reinitializing cannot change the format.
+	_, _, err = ditto.InitWithOptions(ctx, path, ditto.InitOptions{ObjectFormat: "sha1"})
+	utils.Expect(t, err != nil, true)
+
+	hash, err := ditto.NewHash(blob.String())
+	utils.Expect(t, err, nil)
+	utils.Expect(t, hash, blob)
+}
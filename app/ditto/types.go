@@ -0,0 +1,119 @@
+package ditto
+
+import (
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// Hash is the id of an object.
+type Hash = plumbing.Hash
+
+// ObjectFormat is the hash algorithm naming the objects of a repository.
+type ObjectFormat = plumbing.ObjectFormat
+
+const (
+	SHA1   = plumbing.SHA1
+	SHA256 = plumbing.SHA256
+)
+
+// ParseObjectFormat maps an algorithm name such as "sha256" to its
+// ObjectFormat.
+func ParseObjectFormat(name string) (ObjectFormat, error) {
+	return plumbing.ParseObjectFormat(name)
+}
+
+// ZeroHash is the all-zero SHA-1 object id, used to denote a missing
+// object. Use Hash.IsZero to test for missing objects of any format.
+var ZeroHash = plumbing.ZeroHash
+
+// NewHash parses a full hex object id, of either format.
+func NewHash(s string) (Hash, error) {
+	return plumbing.NewHash(s)
+}
+
+// ObjectType is the type of an object: blob, tree, commit or tag.
+type ObjectType = objfile.GitObjectType
+
+const (
+	BlobObject   = objfile.Blob
+	TreeObject   = objfile.Tree
+	CommitObject = objfile.Commit
+	TagObject    = objfile.Tag
+)
+
+// ParseObjectType maps a type name such as "blob" to its ObjectType.
+func ParseObjectType(name string) (ObjectType, error) {
+	return objfile.DetectObjectType(name)
+}
+
+// Object is an object read fully into memory.
+type Object struct {
+	Hash Hash
+	Type ObjectType
+	Data []byte
+}
+
+type (
+	// Commit is a parsed commit object.
+	Commit = commit.Commit
+
+	// Signature is the author, committer or tagger line of an object.
+	Signature = commit.Signature
+
+	// Tag is a parsed annotated tag object.
+	Tag = tag.Tag
+
+	// TreeEntry is a single entry of a tree object.
+	TreeEntry = tree.Entry
+
+	// Ref is a reference, either direct or symbolic.
+	Ref = refs.Ref
+
+	// Index is the staging area of a working tree.
+	Index = index.Index
+
+	// ObjectStore stores objects by their id. Implement it to keep the
+	// objects of a repository anywhere.
+	ObjectStore = odb.ObjectStore
+
+	// ObjectInfo describes how an object is stored, without its content.
+	ObjectInfo = odb.ObjectInfo
+
+	// Config is the configuration in effect, read from every config file
+	// that applies, in order.
+	Config = config.Config
+)
+
+// IdentityRole selects whether an identity is that of an author or of a
+// committer, which taggers use too.
+type IdentityRole = commit.Role
+
+const (
+	AuthorRole    = commit.Author
+	CommitterRole = commit.Committer
+)
+
+// ParseSignature parses a signature line like
+// "A U Thor <author@example.com> 1112911993 -0700".
+func ParseSignature(line string) (Signature, error) {
+	return commit.ParseSignature([]byte(line))
+}
+
+// Tree is a parsed tree object.
+type Tree struct {
+	Hash    Hash
+	Entries []TreeEntry
+}
+
+// NewMemoryStore creates an object store that keeps objects in memory only,
+// named in the given format.
+func NewMemoryStore(format ObjectFormat) ObjectStore {
+	return odb.NewMemory(format)
+}
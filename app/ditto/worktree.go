@@ -0,0 +1,70 @@
+package ditto
+
+import (
+	"context"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Worktree is the working tree of a repository along with its index.
+type Worktree struct {
+	repo *Repository
+}
+
+// Root is the top-level directory of the working tree.
+func (w *Worktree) Root() string {
+	return w.repo.git.WorkTree()
+}
+
+// Index reads the index, which is empty if it does not exist yet.
+func (w *Worktree) Index(ctx context.Context) (*Index, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	idx, err := w.repo.git.ReadIndex()
+
+	return idx, publicError(err)
+}
+
+// WriteIndex replaces the index.
+func (w *Worktree) WriteIndex(ctx context.Context, idx *Index) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return publicError(w.repo.git.WriteIndex(idx))
+}
+
+// WriteTree writes the tree of the staged content, returning its id.
+// Without an index, the whole working tree is written instead, as if every
+// file had been staged.
+func (w *Worktree) WriteTree(ctx context.Context) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return ZeroHash, err
+	}
+
+	git := w.repo.git
+
+	if !utils.PathExists(git.IndexPath()) {
+		hash, err := tree.WriteFromDirectory(git.WorkTree(), git.WriteObject)
+
+		return hash, publicError(err)
+	}
+
+	idx, err := git.ReadIndex()
+
+	if err != nil {
+		return ZeroHash, publicError(err)
+	}
+
+	hash, err := tree.WriteFromIndex(idx, git.WriteObject)
+
+	if err != nil {
+		return hash, publicError(err)
+	}
+
+	// Persist the refreshed TREE extension for the next write-tree.
+	return hash, publicError(git.WriteIndex(idx))
+}
@@ -0,0 +1,331 @@
+package checkout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// Options tells how to update the working tree.
+type Options struct {
+	// Force throws local changes away, the way reset --hard does, rather
+	// than refusing to overwrite them.
+	Force bool
+
+	// What the update is for, like "merge", naming it in errors.
+	Action string
+}
+
+// OverwriteError lists the local changes an update would throw away.
+type OverwriteError struct {
+	Action string
+
+	// Tracked files with changes, staged or not.
+	Modified []string
+
+	// Files not tracked where the update puts a file.
+	Untracked []string
+}
+
+func (e OverwriteError) Error() string {
+	var parts []string
+
+	if len(e.Modified) > 0 {
+		parts = append(parts, "Your local changes to the following files would be overwritten by "+e.Action+":\n\t"+
+			strings.Join(e.Modified, "\n\t")+"\nPlease commit your changes or stash them before you "+e.Action+".")
+	}
+
+	if len(e.Untracked) > 0 {
+		parts = append(parts, "The following untracked working tree files would be overwritten by "+e.Action+":\n\t"+
+			strings.Join(e.Untracked, "\n\t")+"\nPlease move or remove them before you "+e.Action+".")
+	}
+
+	return strings.Join(parts, "\nerror: ")
+}
+
+// Update moves the index and the working tree from one tree to another,
+// where a zero hash stands for the empty tree. Only the paths that differ
+// between the trees are touched, so that local changes to others are kept;
+// local changes to those that differ make it fail with an OverwriteError
+// unless forced, which rewrites every path.
+func Update(git *fs.Git, idx *index.Index, from, to plumbing.Hash, opts Options) error {
+	fromFiles, err := ReadFiles(git, from)
+
+	if err != nil {
+		return err
+	}
+
+	toFiles, err := ReadFiles(git, to)
+
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[string]bool)
+
+	for path, f := range fromFiles {
+		if t, ok := toFiles[path]; !ok || t.Mode != f.Mode || t.Hash() != f.Hash() {
+			changed[path] = true
+		}
+	}
+
+	for path := range toFiles {
+		if _, ok := fromFiles[path]; !ok || opts.Force {
+			changed[path] = true
+		}
+	}
+
+	if opts.Force {
+		for _, e := range idx.Entries {
+			changed[e.Name] = true
+		}
+	}
+
+	paths := make([]string, 0, len(changed))
+
+	for path := range changed {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	if !opts.Force {
+		if err := checkLocalChanges(git, idx, from, paths, toFiles, opts.Action); err != nil {
+			return err
+		}
+	}
+
+	// Remove files first, deepest first, to make room for the directories
+	// of the new ones.
+	for i := len(paths) - 1; i >= 0; i-- {
+		if _, ok := toFiles[paths[i]]; !ok {
+			idx.Remove(paths[i])
+
+			if err := removeFile(git, paths[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, path := range paths {
+		if f, ok := toFiles[path]; ok {
+			e, err := writeFile(git, path, f)
+
+			if err != nil {
+				return err
+			}
+
+			idx.Add(e)
+		}
+	}
+
+	return nil
+}
+
+// ReadFiles lists the files of a tree, by path; none for the zero hash.
+func ReadFiles(git *fs.Git, hash plumbing.Hash) (map[string]tree.Entry, error) {
+	files := make(map[string]tree.Entry)
+
+	if hash.IsZero() {
+		return files, nil
+	}
+
+	entries, err := tree.ReadRecursive(git.ReadObjectByHash, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		files[e.Name] = e
+	}
+
+	return files, nil
+}
+
+// Refuse to touch paths changed in the index or the working tree, and to
+// put files where untracked ones are.
+func checkLocalChanges(git *fs.Git, idx *index.Index, from plumbing.Hash, paths []string, toFiles map[string]tree.Entry, action string) error {
+	staged, err := diff.TreeToIndex(git, from, idx, diff.CompareOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	unstaged, _, err := diff.IndexToWorkTree(git, idx, diff.CompareOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	local := make(map[string]bool)
+
+	for _, changes := range [][]*diff.Change{staged, unstaged} {
+		for _, c := range changes {
+			local[c.Path()] = true
+		}
+	}
+
+	tracked := make(map[string]bool)
+
+	for _, e := range idx.Entries {
+		for path := e.Name; ; path = path[:strings.LastIndexByte(path, '/')] {
+			tracked[path] = true
+
+			if !strings.Contains(path, "/") {
+				break
+			}
+		}
+	}
+
+	overwrite := OverwriteError{Action: action}
+
+	for _, path := range paths {
+		if local[path] {
+			overwrite.Modified = append(overwrite.Modified, path)
+
+			continue
+		}
+
+		if _, ok := toFiles[path]; !ok {
+			continue
+		}
+
+		if _, ok := idx.Entry(path, index.Merged); ok {
+			continue
+		}
+
+		untracked, err := untrackedFiles(git, path, tracked)
+
+		if err != nil {
+			return err
+		}
+
+		overwrite.Untracked = append(overwrite.Untracked, untracked...)
+	}
+
+	if len(overwrite.Modified) > 0 || len(overwrite.Untracked) > 0 {
+		return overwrite
+	}
+
+	return nil
+}
+
+// The untracked files of the working tree at a path, or under it if it is
+// a directory.
+func untrackedFiles(git *fs.Git, path string, tracked map[string]bool) ([]string, error) {
+	info, err := git.StatWorkTree(path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := []string{}
+	root := filepath.Join(git.WorkTree(), filepath.FromSlash(path))
+
+	err = filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(git.WorkTree(), name)
+
+		if err == nil && !tracked[filepath.ToSlash(rel)] {
+			files = append(files, filepath.ToSlash(rel))
+		}
+
+		return err
+	})
+
+	return files, err
+}
+
+// Remove a file of the working tree, along with the directories it leaves
+// empty.
+func removeFile(git *fs.Git, path string) error {
+	full := filepath.Join(git.WorkTree(), filepath.FromSlash(path))
+
+	// A submodule is left in place unless it is empty.
+	if info, err := os.Lstat(full); err == nil && info.IsDir() {
+		os.Remove(full)
+	} else if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for dir := filepath.Dir(full); dir != git.WorkTree(); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Write a file of a tree to the working tree, returning its index entry.
+func writeFile(git *fs.Git, path string, f tree.Entry) (*index.Entry, error) {
+	full := filepath.Join(git.WorkTree(), filepath.FromSlash(path))
+	e := &index.Entry{Name: path, Mode: f.Mode, Hash: f.Hash()}
+
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Lstat(full); err == nil {
+		if info.IsDir() && f.Mode == tree.ModeGitlink {
+			return e, nil
+		}
+
+		if err := os.RemoveAll(full); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Mode == tree.ModeGitlink {
+		return e, os.Mkdir(full, 0755)
+	}
+
+	_, data, err := git.ReadObjectByHash(e.Hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch f.Mode {
+	case tree.ModeSymlink:
+		err = os.Symlink(string(data), full)
+	case tree.ModeExecutable:
+		err = ioutil.WriteFile(full, data, 0755)
+	default:
+		err = ioutil.WriteFile(full, data, 0644)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(full)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.FillStat(info)
+
+	return e, nil
+}
@@ -0,0 +1,193 @@
+package commit
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Commit is the parsed form of a commit object.
+type Commit struct {
+	Tree      plumbing.Hash
+	Parents   []plumbing.Hash
+	Author    Signature
+	Committer Signature
+
+	// Encoding is the value of the optional encoding header.
+	Encoding string
+
+	// GPGSig holds the armored signature, without the continuation spaces.
+	GPGSig string
+
+	// ExtraHeaders are headers git-ditto does not interpret (e.g. mergetag),
+	// kept so that decoding and re-encoding a commit is lossless.
+	ExtraHeaders []ExtraHeader
+
+	Message string
+}
+
+type ExtraHeader struct {
+	Key   string
+	Value string
+}
+
+// Header is a single, possibly multi-line, header of a commit or tag object.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// ParseHeaders splits the content of a commit or tag object into its headers
+// and its message. Continuation lines (starting with a space) are folded into
+// the value of the preceding header.
+func ParseHeaders(data []byte) ([]Header, []byte, error) {
+	headers := []Header{}
+
+	for len(data) > 0 {
+		eol := bytes.IndexByte(data, '\n')
+
+		if eol < 0 {
+			eol = len(data)
+		}
+
+		line := data[:eol]
+
+		if eol < len(data) {
+			data = data[eol+1:]
+		} else {
+			data = data[eol:]
+		}
+
+		if len(line) == 0 {
+			return headers, data, nil
+		}
+
+		if line[0] == ' ' {
+			if len(headers) == 0 {
+				return nil, nil, errors.GitError{Message: "Malformed object: continuation line without header"}
+			}
+
+			last := &headers[len(headers)-1]
+			last.Value = append(append(last.Value, '\n'), line[1:]...)
+
+			continue
+		}
+
+		space := bytes.IndexByte(line, ' ')
+
+		if space < 0 {
+			return nil, nil, errors.GitError{Message: fmt.Sprintf("Malformed object header: '%s'", line)}
+		}
+
+		headers = append(headers, Header{
+			Key:   string(line[:space]),
+			Value: append([]byte{}, line[space+1:]...),
+		})
+	}
+
+	return headers, nil, nil
+}
+
+// WriteHeader writes a header, indenting continuation lines of multi-line
+// values.
+func WriteHeader(buf *bytes.Buffer, key string, value string) {
+	buf.WriteString(key)
+	buf.WriteByte(' ')
+
+	for i := 0; i < len(value); i++ {
+		buf.WriteByte(value[i])
+
+		if value[i] == '\n' {
+			buf.WriteByte(' ')
+		}
+	}
+
+	buf.WriteByte('\n')
+}
+
+// Decode parses the content of a commit object (without the object header).
+func Decode(data []byte) (*Commit, error) {
+	headers, message, err := ParseHeaders(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Commit{Message: string(message)}
+	seenTree := false
+
+	for _, h := range headers {
+		switch h.Key {
+		case "tree":
+			c.Tree, err = plumbing.NewHash(string(h.Value))
+			seenTree = true
+		case "parent":
+			var parent plumbing.Hash
+			parent, err = plumbing.NewHash(string(h.Value))
+			c.Parents = append(c.Parents, parent)
+		case "author":
+			c.Author, err = ParseSignature(h.Value)
+		case "committer":
+			c.Committer, err = ParseSignature(h.Value)
+		case "encoding":
+			c.Encoding = string(h.Value)
+		case "gpgsig":
+			c.GPGSig = string(h.Value)
+		default:
+			c.ExtraHeaders = append(c.ExtraHeaders, ExtraHeader{Key: h.Key, Value: string(h.Value)})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !seenTree {
+		return nil, errors.GitError{Message: "Malformed commit: missing tree header"}
+	}
+
+	return c, nil
+}
+
+// Bytes serializes the commit into the content of a commit object.
+func (c *Commit) Bytes() []byte {
+	buf := bytes.NewBufferString("")
+
+	WriteHeader(buf, "tree", c.Tree.String())
+
+	for _, parent := range c.Parents {
+		WriteHeader(buf, "parent", parent.String())
+	}
+
+	WriteHeader(buf, "author", c.Author.String())
+	WriteHeader(buf, "committer", c.Committer.String())
+
+	if c.Encoding != "" {
+		WriteHeader(buf, "encoding", c.Encoding)
+	}
+
+	for _, h := range c.ExtraHeaders {
+		WriteHeader(buf, h.Key, h.Value)
+	}
+
+	if c.GPGSig != "" {
+		WriteHeader(buf, "gpgsig", c.GPGSig)
+	}
+
+	buf.WriteByte('\n')
+	buf.WriteString(c.Message)
+
+	return buf.Bytes()
+}
+
+// Summary returns the first line of the commit message.
+func (c *Commit) Summary() string {
+	if i := strings.IndexByte(c.Message, '\n'); i >= 0 {
+		return c.Message[:i]
+	}
+
+	return c.Message
+}
@@ -0,0 +1,192 @@
+package commit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// Date styles, as named by git's --date option.
+const (
+	DateDefault   = "default"
+	DateRelative  = "relative"
+	DateISO       = "iso"
+	DateISOStrict = "iso-strict"
+	DateRFC       = "rfc"
+	DateShort     = "short"
+	DateUnix      = "unix"
+	DateRaw       = "raw"
+)
+
+// FormatDate formats a date in one of the Date* styles, in its own
+// timezone. Relative dates are relative to now.
+func FormatDate(when time.Time, style string, now time.Time) string {
+	switch style {
+	case DateRelative:
+		return relativeDate(when, now)
+	case DateISO:
+		return when.Format("2006-01-02 15:04:05 -0700")
+	case DateISOStrict:
+		if _, offset := when.Zone(); offset == 0 {
+			return when.Format("2006-01-02T15:04:05Z")
+		}
+
+		return when.Format("2006-01-02T15:04:05-07:00")
+	case DateRFC:
+		return when.Format("Mon, 2 Jan 2006 15:04:05 -0700")
+	case DateShort:
+		return when.Format("2006-01-02")
+	case DateUnix:
+		return strconv.FormatInt(when.Unix(), 10)
+	case DateRaw:
+		return strconv.FormatInt(when.Unix(), 10) + " " + when.Format("-0700")
+	}
+
+	return when.Format("Mon Jan 2 15:04:05 2006 -0700")
+}
+
+func plural(n int64, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// The date relative to now, rounded the way git does it.
+func relativeDate(when, now time.Time) string {
+	if when.After(now) {
+		return "in the future"
+	}
+
+	diff := int64(now.Sub(when) / time.Second)
+
+	if diff < 90 {
+		return plural(diff, "second") + " ago"
+	}
+
+	// Minutes, hours, days, weeks and months.
+	diff = (diff + 30) / 60
+
+	if diff < 90 {
+		return plural(diff, "minute") + " ago"
+	}
+
+	diff = (diff + 30) / 60
+
+	if diff < 36 {
+		return plural(diff, "hour") + " ago"
+	}
+
+	diff = (diff + 12) / 24
+
+	if diff < 14 {
+		return plural(diff, "day") + " ago"
+	}
+
+	if diff < 70 {
+		return plural((diff+3)/7, "week") + " ago"
+	}
+
+	if diff < 365 {
+		return plural((diff+15)/30, "month") + " ago"
+	}
+
+	// Years and months, up to five years.
+	if diff < 1825 {
+		totalMonths := (diff*12*2 + 365) / (365 * 2)
+		years, months := totalMonths/12, totalMonths%12
+
+		if months == 0 {
+			return plural(years, "year") + " ago"
+		}
+
+		return plural(years, "year") + ", " + plural(months, "month") + " ago"
+	}
+
+	return plural((diff+183)/365, "year") + " ago"
+}
+
+var approxLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"2006-01-02 15:04",
+	"Jan 2 2006",
+	"2 Jan 2006",
+	"Mon Jan 2 15:04:05 2006 -0700",
+}
+
+var approxUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// ParseApproxDate parses the dates taken by options like --since: anything
+// ParseDate accepts, plain dates, and dates relative to now such as
+// "2 weeks ago", "yesterday" or "now".
+func ParseApproxDate(date string, now time.Time) (time.Time, error) {
+	if when, err := ParseDate(date); err == nil {
+		return when, nil
+	}
+
+	date = strings.ToLower(strings.TrimSpace(date))
+
+	switch date {
+	case "now", "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	for _, layout := range approxLayouts {
+		if when, err := time.ParseInLocation(layout, date, time.Local); err == nil {
+			return when, nil
+		}
+	}
+
+	// "<n> <unit>[s] [ago]", possibly repeated as in "1 year 2 months ago".
+	fields := strings.Fields(strings.ReplaceAll(date, ".", " "))
+
+	if len(fields) > 0 && fields[len(fields)-1] == "ago" {
+		fields = fields[:len(fields)-1]
+	}
+
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return time.Time{}, errors.GitError{Message: fmt.Sprintf("Invalid date format: %s", date)}
+	}
+
+	when := now
+
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.Atoi(fields[i])
+
+		if err != nil {
+			return time.Time{}, errors.GitError{Message: fmt.Sprintf("Invalid date format: %s", date)}
+		}
+
+		unit := strings.TrimSuffix(fields[i+1], "s")
+
+		switch unit {
+		case "month":
+			when = when.AddDate(0, -n, 0)
+		case "year":
+			when = when.AddDate(-n, 0, 0)
+		default:
+			duration, ok := approxUnits[unit]
+
+			if !ok {
+				return time.Time{}, errors.GitError{Message: fmt.Sprintf("Invalid date format: %s", date)}
+			}
+
+			when = when.Add(-time.Duration(n) * duration)
+		}
+	}
+
+	return when, nil
+}
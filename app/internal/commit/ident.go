@@ -0,0 +1,126 @@
+package commit
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+)
+
+// Role selects which set of GIT_<ROLE>_{NAME,EMAIL,DATE} environment
+// variables an identity is read from.
+type Role string
+
+const (
+	Author    Role = "AUTHOR"
+	Committer Role = "COMMITTER"
+)
+
+// Ident builds the signature for the given role the way git does: from the
+// environment, then <role>.name and <role>.email of cfg, then user.name and
+// user.email, and last the login name and host name. cfg may be nil.
+func Ident(role Role, cfg *config.Config) (Signature, error) {
+	sig := Signature{
+		Name:  os.Getenv("GIT_" + string(role) + "_NAME"),
+		Email: os.Getenv("GIT_" + string(role) + "_EMAIL"),
+		When:  time.Now(),
+	}
+
+	if cfg != nil {
+		section := strings.ToLower(string(role))
+
+		for _, prefix := range []string{section, "user"} {
+			if name, ok := cfg.Get(prefix + ".name"); ok && sig.Name == "" {
+				sig.Name = name
+			}
+
+			if email, ok := cfg.Get(prefix + ".email"); ok && sig.Email == "" {
+				sig.Email = email
+			}
+		}
+	}
+
+	if sig.Name == "" || sig.Email == "" {
+		login := "unknown"
+
+		if u, err := user.Current(); err == nil {
+			login = u.Username
+
+			if sig.Name == "" && u.Name != "" {
+				sig.Name = u.Name
+			}
+		}
+
+		if sig.Name == "" {
+			sig.Name = login
+		}
+
+		if sig.Email == "" {
+			host, err := os.Hostname()
+
+			if err != nil {
+				host = "localhost"
+			}
+
+			sig.Email = login + "@" + host
+		}
+	}
+
+	if date := os.Getenv("GIT_" + string(role) + "_DATE"); date != "" {
+		when, err := ParseDate(date)
+
+		if err != nil {
+			return sig, err
+		}
+
+		sig.When = when
+	}
+
+	return sig, nil
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+}
+
+// ParseDate parses the date formats Git accepts in GIT_AUTHOR_DATE and
+// GIT_COMMITTER_DATE: the internal "<unix> <tz>" format (optionally prefixed
+// with '@'), RFC 2822 and ISO 8601.
+func ParseDate(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	fields := strings.Fields(strings.TrimPrefix(date, "@"))
+
+	if len(fields) > 0 && len(fields) <= 2 {
+		if seconds, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			location := time.UTC
+
+			if len(fields) == 2 {
+				location, err = ParseTimezone(fields[1])
+
+				if err != nil {
+					return time.Time{}, err
+				}
+			}
+
+			return time.Unix(seconds, 0).In(location), nil
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if when, err := time.ParseInLocation(layout, date, time.Local); err == nil {
+			return when, nil
+		}
+	}
+
+	return time.Time{}, errors.GitError{Message: fmt.Sprintf("Invalid date format: %s", date)}
+}
@@ -0,0 +1,124 @@
+package commit
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// Signature identifies who authored or committed an object and when, as
+// recorded in the author, committer and tagger headers.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+
+	// The timestamp and timezone as recorded, when they are malformed.
+	// When holds what could be read of them, and they are written back as
+	// they were.
+	RawDate string
+}
+
+// ParseSignature parses a signature of the form
+//
+//	Name <email> 1234567890 +0530
+//
+// Like in git, a malformed date does not keep the object from being read:
+// timezones are taken as hours and minutes however many digits they have,
+// and what cannot be read at all is taken as UTC, or as the epoch.
+func ParseSignature(line []byte) (Signature, error) {
+	var sig Signature
+
+	openIdx := bytes.LastIndexByte(line, '<')
+	closeIdx := bytes.LastIndexByte(line, '>')
+
+	if openIdx < 0 || closeIdx < openIdx {
+		return sig, errors.GitError{Message: fmt.Sprintf("Malformed signature: '%s'", line)}
+	}
+
+	sig.Name = string(bytes.TrimSpace(line[:openIdx]))
+	sig.Email = string(line[openIdx+1 : closeIdx])
+
+	fields := bytes.Fields(line[closeIdx+1:])
+
+	if len(fields) == 0 {
+		return sig, nil
+	}
+
+	seconds, err := strconv.ParseInt(string(fields[0]), 10, 64)
+	location, ok := time.UTC, err == nil
+
+	if len(fields) > 1 {
+		location, ok = readTimezone(string(fields[1]))
+		ok = ok && err == nil
+	}
+
+	if len(fields) != 2 || !ok || len(fields[1]) != 5 {
+		sig.RawDate = string(bytes.Join(fields, []byte(" ")))
+	}
+
+	sig.When = time.Unix(seconds, 0).In(location)
+
+	return sig, nil
+}
+
+// Read a recorded timezone the way git does, as a sign and a number of
+// hours and minutes, "+05" being five minutes east. Anything else is UTC.
+func readTimezone(tz string) (*time.Location, bool) {
+	if len(tz) < 2 || (tz[0] != '+' && tz[0] != '-') {
+		return time.UTC, false
+	}
+
+	hhmm, err := strconv.Atoi(tz[1:])
+
+	if err != nil || tz[1] == '+' || tz[1] == '-' {
+		return time.UTC, false
+	}
+
+	offset := hhmm/100*3600 + hhmm%100*60
+
+	if tz[0] == '-' {
+		offset = -offset
+	}
+
+	return time.FixedZone("", offset), true
+}
+
+// ParseTimezone converts a Git timezone offset such as "-0700" into a fixed
+// location. Unlike reading one, only well-formed offsets are accepted.
+func ParseTimezone(tz string) (*time.Location, error) {
+	if len(tz) != 5 || (tz[0] != '+' && tz[0] != '-') {
+		return nil, errors.GitError{Message: fmt.Sprintf("Malformed timezone: '%s'", tz)}
+	}
+
+	hours, err := strconv.Atoi(tz[1:3])
+
+	if err != nil {
+		return nil, errors.GitError{Message: fmt.Sprintf("Malformed timezone: '%s'", tz)}
+	}
+
+	minutes, err := strconv.Atoi(tz[3:5])
+
+	if err != nil {
+		return nil, errors.GitError{Message: fmt.Sprintf("Malformed timezone: '%s'", tz)}
+	}
+
+	offset := hours*3600 + minutes*60
+
+	if tz[0] == '-' {
+		offset = -offset
+	}
+
+	return time.FixedZone("", offset), nil
+}
+
+func (s Signature) String() string {
+	if s.RawDate != "" {
+		return fmt.Sprintf("%s <%s> %s", s.Name, s.Email, s.RawDate)
+	}
+
+	return fmt.Sprintf("%s <%s> %d %s", s.Name, s.Email, s.When.Unix(), s.When.Format("-0700"))
+}
@@ -0,0 +1,167 @@
+package commitgraph
+
+import (
+	"strings"
+)
+
+// Settings of the changed-path Bloom filters, as Git writes them.
+const (
+	bloomVersion         = 1
+	bloomNumHashes       = 7
+	bloomBitsPerEntry    = 10
+	bloomMaxChangedPaths = 512
+
+	bloomSeed0 = 0x293ae76f
+	bloomSeed1 = 0x7e646e2c
+)
+
+// BloomSettings describe how the filters of a commit-graph are built.
+type BloomSettings struct {
+	Version      uint32
+	NumHashes    uint32
+	BitsPerEntry uint32
+}
+
+var defaultBloomSettings = BloomSettings{Version: bloomVersion, NumHashes: bloomNumHashes, BitsPerEntry: bloomBitsPerEntry}
+
+// BloomFilter records the paths a commit changed from its first parent,
+// and the directories holding them. It can only tell for sure that a path
+// did not change.
+type BloomFilter struct {
+	settings BloomSettings
+	data     []byte
+}
+
+func rotl32(x uint32, r uint) uint32 {
+	return x<<r | x>>(32-r)
+}
+
+// Murmur3 as Git computes it for version 1 filters, reading bytes as
+// signed chars.
+func murmur3(seed uint32, data []byte) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	signed := func(b byte) uint32 {
+		return uint32(int32(int8(b)))
+	}
+
+	n := len(data) / 4
+
+	for i := 0; i < n; i++ {
+		b := data[4*i:]
+		k := signed(b[0]) | signed(b[1])<<8 | signed(b[2])<<16 | signed(b[3])<<24
+		k *= c1
+		k = rotl32(k, 15)
+		k *= c2
+
+		seed ^= k
+		seed = rotl32(seed, 13)*5 + 0xe6546b64
+	}
+
+	tail := data[4*n:]
+	k := uint32(0)
+
+	switch len(tail) {
+	case 3:
+		k ^= signed(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= signed(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= signed(tail[0])
+		k *= c1
+		k = rotl32(k, 15)
+		k *= c2
+		seed ^= k
+	}
+
+	seed ^= uint32(len(data))
+	seed ^= seed >> 16
+	seed *= 0x85ebca6b
+	seed ^= seed >> 13
+	seed *= 0xc2b2ae35
+	seed ^= seed >> 16
+
+	return seed
+}
+
+// The bit positions of a path, by double hashing.
+func bloomKey(settings BloomSettings, path string) []uint32 {
+	hash0 := murmur3(bloomSeed0, []byte(path))
+	hash1 := murmur3(bloomSeed1, []byte(path))
+	key := make([]uint32, settings.NumHashes)
+
+	for i := range key {
+		key[i] = hash0 + uint32(i)*hash1
+	}
+
+	return key
+}
+
+// Build the filter of a set of changed paths. Too many changes make a
+// filter with every bit set.
+func newBloomFilter(settings BloomSettings, paths []string) *BloomFilter {
+	if len(paths) > bloomMaxChangedPaths {
+		return &BloomFilter{settings: settings, data: []byte{0xff}}
+	}
+
+	// Each path counts along with the directories leading to it.
+	entries := make(map[string]bool)
+
+	for _, path := range paths {
+		for {
+			entries[path] = true
+
+			i := strings.LastIndex(path, "/")
+
+			if i < 0 {
+				break
+			}
+
+			path = path[:i]
+		}
+	}
+
+	size := (len(entries)*int(settings.BitsPerEntry) + 7) / 8
+
+	if size == 0 {
+		size = 1
+	}
+
+	f := &BloomFilter{settings: settings, data: make([]byte, size)}
+
+	for path := range entries {
+		for _, bit := range f.bits(path) {
+			f.data[bit/8] |= 1 << (bit % 8)
+		}
+	}
+
+	return f
+}
+
+func (f *BloomFilter) bits(path string) []uint64 {
+	bits := []uint64{}
+	size := uint64(len(f.data)) * 8
+
+	for _, hash := range bloomKey(f.settings, path) {
+		bits = append(bits, uint64(hash)%size)
+	}
+
+	return bits
+}
+
+// MaybeChanged reports whether the commit may have changed the path, or
+// anything below it. False answers are always right.
+func (f *BloomFilter) MaybeChanged(path string) bool {
+	for _, bit := range f.bits(path) {
+		if f.data[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
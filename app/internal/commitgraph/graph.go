@@ -0,0 +1,572 @@
+// Package commitgraph reads and writes commit-graph files, which record
+// the parents, root tree, date and generation of commits so that history
+// walks need not parse them, along with Bloom filters of the paths they
+// changed. A repository has either a single objects/info/commit-graph file
+// or a chain of them under objects/info/commit-graphs, each layer adding
+// commits to the ones below it.
+package commitgraph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+var graphMagic = []byte("CGPH")
+
+const (
+	graphVersion = 1
+
+	headerSize     = 8
+	chunkEntrySize = 12
+
+	// Parent positions in the commit data.
+	parentNone     = 0x70000000
+	parentOctopus  = 0x80000000
+	lastEdge       = 0x80000000
+	offsetOverflow = 0x80000000
+
+	// Topological levels take the upper 30 bits of a 32-bit word.
+	maxLevel = 0x3fffffff
+)
+
+// Chunk ids.
+const (
+	chunkFanout         = 0x4f494446 // "OIDF"
+	chunkOIDs           = 0x4f49444c // "OIDL"
+	chunkData           = 0x43444154 // "CDAT"
+	chunkGeneration     = 0x47444132 // "GDA2"
+	chunkGenOverflow    = 0x47444f32 // "GDO2"
+	chunkExtraEdges     = 0x45444745 // "EDGE"
+	chunkBloomIndexes   = 0x42494458 // "BIDX"
+	chunkBloomData      = 0x42444154 // "BDAT"
+	chunkBaseGraphs     = 0x42415345 // "BASE"
+	bloomDataHeaderSize = 12
+)
+
+const (
+	graphFile  = "commit-graph"
+	chainDir   = "commit-graphs"
+	chainFile  = "commit-graph-chain"
+	infoDir    = "info"
+	layerExt   = ".graph"
+	layerStart = "graph-"
+)
+
+// The path of the single commit-graph file of an object directory.
+func GraphPath(objectDir string) string {
+	return filepath.Join(objectDir, infoDir, graphFile)
+}
+
+// The path of the file listing the layers of a chain, base first.
+func ChainPath(objectDir string) string {
+	return filepath.Join(objectDir, infoDir, chainDir, chainFile)
+}
+
+// The path of a layer of a chain, named after its checksum.
+func LayerPath(objectDir string, checksum plumbing.Hash) string {
+	return filepath.Join(objectDir, infoDir, chainDir, layerStart+checksum.String()+layerExt)
+}
+
+// Layer is a single commit-graph file.
+type Layer struct {
+	Path     string
+	Checksum plumbing.Hash
+
+	// The checksums of the layers below it, base first.
+	Bases []plumbing.Hash
+
+	format plumbing.ObjectFormat
+	data   []byte
+	fanout [256]uint32
+
+	oids, commits, edges            []byte
+	generations, generationOverflow []byte
+	bloomIndexes, bloomData         []byte
+	bloom                           *BloomSettings
+}
+
+// Commit is what a commit-graph knows about a commit.
+type Commit struct {
+	Tree    plumbing.Hash
+	Parents []plumbing.Hash
+
+	// The committer date, in seconds since the epoch.
+	Date int64
+
+	// The topological level: 1 for root commits, one more than the highest
+	// of its parents otherwise.
+	Level uint32
+
+	// The corrected commit date: its date, or one more than the highest of
+	// its parents if that is later. Zero when the file does not have them.
+	CorrectedDate uint64
+}
+
+// ReadLayer parses a commit-graph file of the given object format.
+func ReadLayer(path string, format plumbing.ObjectFormat) (*Layer, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLayer(path, data, format)
+}
+
+func parseLayer(path string, data []byte, format plumbing.ObjectFormat) (*Layer, error) {
+	hashSize := format.Size()
+	corrupt := func(message string) error {
+		return errors.GitError{Message: fmt.Sprintf("commit-graph file %s is corrupt: %s", path, message)}
+	}
+
+	if len(data) < headerSize+chunkEntrySize+hashSize {
+		return nil, corrupt("file is too small")
+	}
+
+	if !bytes.Equal(data[:4], graphMagic) {
+		return nil, errors.GitError{Message: fmt.Sprintf("commit-graph signature %X does not match signature %X", data[:4], graphMagic)}
+	}
+
+	if data[4] != graphVersion {
+		return nil, errors.GitError{Message: fmt.Sprintf("commit-graph version %X does not match version %X", data[4], graphVersion)}
+	}
+
+	if data[5] != hashVersion(format) {
+		return nil, errors.GitError{Message: fmt.Sprintf("commit-graph hash version %X does not match version %X", data[5], hashVersion(format))}
+	}
+
+	l := &Layer{Path: path, format: format, data: data}
+	l.Checksum, _ = format.HashFromBytes(data[len(data)-hashSize:])
+
+	numChunks := int(data[6])
+	numBases := int(data[7])
+	end := len(data) - hashSize
+
+	if headerSize+(numChunks+1)*chunkEntrySize > end {
+		return nil, corrupt("chunk lookup table is truncated")
+	}
+
+	for i := 0; i < numChunks; i++ {
+		entry := data[headerSize+i*chunkEntrySize:]
+		id := binary.BigEndian.Uint32(entry)
+		start := binary.BigEndian.Uint64(entry[4:])
+		next := binary.BigEndian.Uint64(entry[4+chunkEntrySize:])
+
+		if start > next || next > uint64(end) {
+			return nil, corrupt(fmt.Sprintf("improper chunk offset %08x", next))
+		}
+
+		chunk := data[start:next]
+
+		switch id {
+		case chunkFanout:
+			if len(chunk) != 256*4 {
+				return nil, corrupt("commit-graph oid fanout chunk is wrong size")
+			}
+
+			for j := range l.fanout {
+				l.fanout[j] = binary.BigEndian.Uint32(chunk[j*4:])
+			}
+		case chunkOIDs:
+			l.oids = chunk
+		case chunkData:
+			l.commits = chunk
+		case chunkGeneration:
+			l.generations = chunk
+		case chunkGenOverflow:
+			l.generationOverflow = chunk
+		case chunkExtraEdges:
+			l.edges = chunk
+		case chunkBloomIndexes:
+			l.bloomIndexes = chunk
+		case chunkBloomData:
+			if len(chunk) < bloomDataHeaderSize {
+				return nil, corrupt("bloom data chunk is too small")
+			}
+
+			l.bloomData = chunk
+			l.bloom = &BloomSettings{
+				Version:      binary.BigEndian.Uint32(chunk),
+				NumHashes:    binary.BigEndian.Uint32(chunk[4:]),
+				BitsPerEntry: binary.BigEndian.Uint32(chunk[8:]),
+			}
+		case chunkBaseGraphs:
+			if len(chunk) != numBases*hashSize {
+				return nil, corrupt("commit-graph base graphs chunk is wrong size")
+			}
+
+			for j := 0; j < numBases; j++ {
+				base, _ := format.HashFromBytes(chunk[j*hashSize:])
+				l.Bases = append(l.Bases, base)
+			}
+		}
+	}
+
+	count := int(l.fanout[255])
+
+	if l.oids == nil || l.commits == nil {
+		return nil, corrupt("missing required chunk")
+	}
+
+	if len(l.oids) != count*hashSize || len(l.commits) != count*(hashSize+16) {
+		return nil, corrupt("commit data does not match the fanout")
+	}
+
+	if l.generations != nil && len(l.generations) != count*4 {
+		l.generations = nil
+	}
+
+	if l.bloomIndexes != nil && len(l.bloomIndexes) != count*4 || l.bloom != nil && l.bloom.Version != bloomVersion {
+		l.bloomIndexes, l.bloomData, l.bloom = nil, nil, nil
+	}
+
+	if len(l.Bases) != numBases {
+		return nil, corrupt("missing base graphs chunk")
+	}
+
+	return l, nil
+}
+
+// The hash version byte of the header.
+func hashVersion(format plumbing.ObjectFormat) byte {
+	if format == plumbing.SHA256 {
+		return 2
+	}
+
+	return 1
+}
+
+// Count is the number of commits in the layer.
+func (l *Layer) Count() int {
+	return int(l.fanout[255])
+}
+
+func (l *Layer) oid(i int) plumbing.Hash {
+	size := l.format.Size()
+	hash, _ := l.format.HashFromBytes(l.oids[i*size : (i+1)*size])
+
+	return hash
+}
+
+// Find the position of a commit in the layer.
+func (l *Layer) find(hash plumbing.Hash) (int, bool) {
+	first := hash.Bytes()[0]
+	lo := 0
+
+	if first > 0 {
+		lo = int(l.fanout[first-1])
+	}
+
+	hi := int(l.fanout[first])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return l.oid(lo+i).Compare(hash) >= 0
+	})
+
+	return i, i < hi && l.oid(i) == hash
+}
+
+// Graph is the commit-graph of a repository: a single file, or a chain of
+// layers, base first. Positions of commits count from the base.
+type Graph struct {
+	format plumbing.ObjectFormat
+	Layers []*Layer
+}
+
+// Open the commit-graph of an object directory, preferring the single
+// file to a chain. Returns nil when there is neither.
+func Open(objectDir string, format plumbing.ObjectFormat) (*Graph, error) {
+	layer, err := ReadLayer(GraphPath(objectDir), format)
+
+	if err == nil {
+		return &Graph{format: format, Layers: []*Layer{layer}}, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	checksums, err := ReadChain(objectDir, format)
+
+	if err != nil || checksums == nil {
+		return nil, err
+	}
+
+	g := &Graph{format: format}
+
+	for i, checksum := range checksums {
+		layer, err := ReadLayer(LayerPath(objectDir, checksum), format)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if layer.Checksum != checksum {
+			return nil, errors.GitError{Message: fmt.Sprintf("commit-graph chain does not match %s", layer.Path)}
+		}
+
+		if len(layer.Bases) != i {
+			return nil, errors.GitError{Message: fmt.Sprintf("commit-graph file %s has an incorrect number of base graphs", layer.Path)}
+		}
+
+		for j, base := range layer.Bases {
+			if base != checksums[j] {
+				return nil, errors.GitError{Message: fmt.Sprintf("commit-graph chain does not match %s", layer.Path)}
+			}
+		}
+
+		g.Layers = append(g.Layers, layer)
+	}
+
+	return g, nil
+}
+
+// ReadChain reads the checksums of the layers of a chain, base first, or
+// nil if there is no chain.
+func ReadChain(objectDir string, format plumbing.ObjectFormat) ([]plumbing.Hash, error) {
+	f, err := os.Open(ChainPath(objectDir))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	defer f.Close()
+
+	checksums := []plumbing.Hash{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		checksum, err := plumbing.NewHash(line)
+
+		if err != nil || checksum.Format() != format {
+			return nil, errors.GitError{Message: fmt.Sprintf("invalid commit-graph chain: line '%s' not a hash", line)}
+		}
+
+		checksums = append(checksums, checksum)
+	}
+
+	return checksums, scanner.Err()
+}
+
+// Count is the number of commits in the whole graph.
+func (g *Graph) Count() int {
+	count := 0
+
+	for _, l := range g.Layers {
+		count += l.Count()
+	}
+
+	return count
+}
+
+// Find the layer holding a commit, and the position of the commit in it.
+func (g *Graph) find(hash plumbing.Hash) (*Layer, int, bool) {
+	if g == nil {
+		return nil, 0, false
+	}
+
+	for i := len(g.Layers) - 1; i >= 0; i-- {
+		if pos, ok := g.Layers[i].find(hash); ok {
+			return g.Layers[i], pos, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// The commit at a position counted from the base of the chain.
+func (g *Graph) oidAt(pos uint32) (plumbing.Hash, error) {
+	for _, l := range g.Layers {
+		if int(pos) < l.Count() {
+			return l.oid(int(pos)), nil
+		}
+
+		pos -= uint32(l.Count())
+	}
+
+	return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("invalid commit position %d in commit-graph", pos)}
+}
+
+// Has reports whether the graph knows about a commit.
+func (g *Graph) Has(hash plumbing.Hash) bool {
+	_, _, ok := g.find(hash)
+
+	return ok
+}
+
+// Lookup reads what the graph knows about a commit.
+func (g *Graph) Lookup(hash plumbing.Hash) (*Commit, bool, error) {
+	l, i, ok := g.find(hash)
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	c, err := g.commitAt(l, i)
+
+	return c, err == nil, err
+}
+
+func (g *Graph) commitAt(l *Layer, i int) (*Commit, error) {
+	hashSize := l.format.Size()
+	record := l.commits[i*(hashSize+16) : (i+1)*(hashSize+16)]
+	c := &Commit{}
+	c.Tree, _ = l.format.HashFromBytes(record[:hashSize])
+
+	first := binary.BigEndian.Uint32(record[hashSize:])
+	second := binary.BigEndian.Uint32(record[hashSize+4:])
+	positions := []uint32{}
+
+	if first != parentNone {
+		positions = append(positions, first)
+	}
+
+	switch {
+	case second == parentNone:
+	case second&parentOctopus == 0:
+		positions = append(positions, second)
+	default:
+		for j := int(second &^ parentOctopus); ; j++ {
+			if (j+1)*4 > len(l.edges) {
+				return nil, errors.GitError{Message: "commit-graph extra-edges pointer out of bounds"}
+			}
+
+			edge := binary.BigEndian.Uint32(l.edges[j*4:])
+			positions = append(positions, edge&^lastEdge)
+
+			if edge&lastEdge != 0 {
+				break
+			}
+		}
+	}
+
+	for _, pos := range positions {
+		parent, err := g.oidAt(pos)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.Parents = append(c.Parents, parent)
+	}
+
+	levelAndDate := binary.BigEndian.Uint32(record[hashSize+8:])
+	c.Level = levelAndDate >> 2
+	c.Date = int64(levelAndDate&3)<<32 | int64(binary.BigEndian.Uint32(record[hashSize+12:]))
+
+	if l.generations != nil {
+		offset := uint64(binary.BigEndian.Uint32(l.generations[i*4:]))
+
+		if offset&offsetOverflow != 0 {
+			j := int(offset &^ offsetOverflow)
+
+			if (j+1)*8 > len(l.generationOverflow) {
+				return nil, errors.GitError{Message: "commit-graph requires overflow generation data but has none"}
+			}
+
+			offset = binary.BigEndian.Uint64(l.generationOverflow[j*8:])
+		}
+
+		c.CorrectedDate = uint64(c.Date) + offset
+	}
+
+	return c, nil
+}
+
+// Whether every layer has corrected commit dates, which can only be
+// compared with each other.
+func (g *Graph) hasCorrectedDates() bool {
+	for _, l := range g.Layers {
+		if l.generations == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Generation returns a number that is higher for a commit than for any of
+// its ancestors: its corrected commit date when the whole graph has them,
+// its topological level otherwise.
+func (g *Graph) Generation(hash plumbing.Hash) (uint64, bool, error) {
+	c, ok, err := g.Lookup(hash)
+
+	if !ok {
+		return 0, false, err
+	}
+
+	if g.hasCorrectedDates() {
+		return c.CorrectedDate, true, nil
+	}
+
+	return uint64(c.Level), true, nil
+}
+
+// HasGenerations reports whether walks can rely on the generation numbers
+// of the graph. Graphs written before Git computed them have zero levels.
+func (g *Graph) HasGenerations() bool {
+	if g == nil || len(g.Layers) == 0 {
+		return false
+	}
+
+	top := g.Layers[len(g.Layers)-1]
+
+	if top.Count() == 0 {
+		return false
+	}
+
+	c, err := g.commitAt(top, 0)
+
+	return err == nil && c.Level > 0
+}
+
+// HasBloomFilters reports whether any layer has changed-path filters.
+func (g *Graph) HasBloomFilters() bool {
+	for _, l := range g.Layers {
+		if l.bloom != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BloomFilter returns the changed-path filter of a commit, when the graph
+// has one for it.
+func (g *Graph) BloomFilter(hash plumbing.Hash) (*BloomFilter, bool) {
+	l, i, ok := g.find(hash)
+
+	if !ok || l.bloom == nil {
+		return nil, false
+	}
+
+	start := uint32(0)
+
+	if i > 0 {
+		start = binary.BigEndian.Uint32(l.bloomIndexes[(i-1)*4:])
+	}
+
+	end := binary.BigEndian.Uint32(l.bloomIndexes[i*4:])
+	data := l.bloomData[bloomDataHeaderSize:]
+
+	if start >= end || int(end) > len(data) {
+		return nil, false
+	}
+
+	return &BloomFilter{settings: *l.bloom, data: data[start:end]}, true
+}
@@ -0,0 +1,130 @@
+package commitgraph
+
+import (
+	"fmt"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Verify checks the layers of the graph against their checksums and the
+// commits they describe, returning the problems found. Only the top layer
+// is checked if shallow is set.
+func (g *Graph) Verify(read objfile.ObjectReader, shallow bool) []error {
+	problems := []error{}
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, errors.GitError{Message: fmt.Sprintf(format, args...)})
+	}
+
+	layers := g.Layers
+
+	if shallow && len(layers) > 0 {
+		layers = layers[len(layers)-1:]
+	}
+
+	for _, l := range layers {
+		hashSize := l.format.Size()
+
+		if l.format.NewHasher(l.data[:len(l.data)-hashSize]).Sum() != l.Checksum {
+			report("the commit-graph file has incorrect checksum and is likely corrupt")
+		}
+
+		previous := plumbing.ZeroHash
+		counts := [256]uint32{}
+
+		for i := 0; i < l.Count(); i++ {
+			hash := l.oid(i)
+
+			if i > 0 && previous.Compare(hash) >= 0 {
+				report("commit-graph has incorrect OID order: %s then %s", previous, hash)
+			}
+
+			previous = hash
+			counts[hash.Bytes()[0]]++
+		}
+
+		total := uint32(0)
+
+		for i, n := range counts {
+			total += n
+
+			if l.fanout[i] != total {
+				report("commit-graph has incorrect fanout value: fanout[%d] = %d != %d", i, l.fanout[i], total)
+			}
+		}
+
+		for i := 0; i < l.Count(); i++ {
+			g.verifyCommit(l, i, read, report)
+		}
+	}
+
+	return problems
+}
+
+func (g *Graph) verifyCommit(l *Layer, i int, read objfile.ObjectReader, report func(string, ...interface{})) {
+	hash := l.oid(i)
+	recorded, err := g.commitAt(l, i)
+
+	if err != nil {
+		report("%s", err.Error())
+
+		return
+	}
+
+	t, data, err := read(hash)
+
+	var c *commit.Commit
+
+	if err == nil && t == objfile.Commit {
+		c, err = commit.Decode(data)
+	}
+
+	if err != nil || c == nil {
+		report("failed to parse commit %s from commit-graph", hash)
+
+		return
+	}
+
+	if recorded.Tree != c.Tree {
+		report("root tree OID for commit %s in commit-graph is %s != %s", hash, recorded.Tree, c.Tree)
+	}
+
+	for j, parent := range recorded.Parents {
+		if j >= len(c.Parents) {
+			report("commit-graph parent list for commit %s is too long", hash)
+
+			break
+		}
+
+		if parent != c.Parents[j] {
+			report("commit-graph parent for %s is %s != %s", hash, parent, c.Parents[j])
+		}
+	}
+
+	if len(recorded.Parents) < len(c.Parents) {
+		report("commit-graph parent list for commit %s terminates early", hash)
+	}
+
+	generation, _, _ := g.Generation(hash)
+	highest := uint64(0)
+
+	for _, parent := range recorded.Parents {
+		if parentGeneration, ok, _ := g.Generation(parent); ok && parentGeneration > highest {
+			highest = parentGeneration
+		}
+	}
+
+	if !g.hasCorrectedDates() && highest >= maxLevel {
+		highest = maxLevel - 1
+	}
+
+	if generation < highest+1 {
+		report("commit-graph generation for commit %s is %d < %d", hash, generation, highest+1)
+	}
+
+	if recorded.Date != c.Committer.When.Unix() {
+		report("commit date for commit %s in commit-graph is %d != %d", hash, recorded.Date, c.Committer.When.Unix())
+	}
+}
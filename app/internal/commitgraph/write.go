@@ -0,0 +1,699 @@
+package commitgraph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// How a split write treats the layers already in the chain.
+const (
+	// Merge the top layers into the new one while they are not much
+	// bigger than it.
+	SplitMerge = ""
+
+	// Always add a new layer.
+	SplitNoMerge = "no-merge"
+
+	// Merge the whole chain into a single layer.
+	SplitReplace = "replace"
+)
+
+// DefaultSizeMultiple is how many times bigger than the new layer a layer
+// has to be to not get merged into it.
+const DefaultSizeMultiple = 2
+
+// WriteOptions control how a commit-graph is written.
+type WriteOptions struct {
+	// Compute the changed-path Bloom filters of the commits written.
+	ChangedPaths bool
+
+	// Keep the commits of the current graph in a non-split write.
+	Append bool
+
+	// Write a new layer of a chain instead of a single file, merging
+	// existing layers into it as SplitStrategy says.
+	Split         bool
+	SplitStrategy string
+	SizeMultiple  int
+}
+
+// The data of a commit written to a layer.
+type pending struct {
+	hash          plumbing.Hash
+	commit        *commit.Commit
+	level         uint32
+	correctedDate uint64
+	filter        *BloomFilter
+}
+
+// Writer writes the commit-graph of a repository.
+type Writer struct {
+	git       *fs.Git
+	objectDir string
+	format    plumbing.ObjectFormat
+	opts      WriteOptions
+
+	// The current graph, nil if there is none.
+	current *Graph
+}
+
+func NewWriter(git *fs.Git, opts WriteOptions) (*Writer, error) {
+	objectDir := git.ObjectDir()
+	current, err := Open(objectDir, git.ObjectFormat())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SizeMultiple <= 0 {
+		opts.SizeMultiple = DefaultSizeMultiple
+	}
+
+	return &Writer{git: git, objectDir: objectDir, format: git.ObjectFormat(), opts: opts, current: current}, nil
+}
+
+// EnableChangedPaths makes the writer compute Bloom filters, like Git does
+// when the current graph has them.
+func (w *Writer) EnableChangedPaths() {
+	w.opts.ChangedPaths = true
+}
+
+// Current returns the graph the writer started from, nil if there was none.
+func (w *Writer) Current() *Graph {
+	return w.current
+}
+
+func (w *Writer) readCommit(hash plumbing.Hash) (*commit.Commit, error) {
+	t, data, err := w.git.ReadObjectByHash(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t != objfile.Commit {
+		return nil, errors.GitError{Message: fmt.Sprintf("%s is a %s, not a commit", hash, t)}
+	}
+
+	return commit.Decode(data)
+}
+
+// Write a graph of the given commits and all their ancestors. Commits
+// already in layers a split write keeps are left to them.
+func (w *Writer) Write(hashes []plumbing.Hash) error {
+	// The layers kept below the new one, base first.
+	kept := []*Layer{}
+
+	if w.opts.Split && w.current != nil {
+		kept = w.current.Layers
+	}
+
+	inKept := func(hash plumbing.Hash) bool {
+		g := &Graph{format: w.format, Layers: kept}
+
+		return g.Has(hash)
+	}
+
+	commits := make(map[plumbing.Hash]*pending)
+	stack := append([]plumbing.Hash{}, hashes...)
+
+	if w.opts.Append && !w.opts.Split && w.current != nil {
+		for _, l := range w.current.Layers {
+			for i := 0; i < l.Count(); i++ {
+				stack = append(stack, l.oid(i))
+			}
+		}
+	}
+
+	// The commits not in the graph yet, and their ancestors.
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if commits[hash] != nil || inKept(hash) {
+			continue
+		}
+
+		c, err := w.readCommit(hash)
+
+		if err != nil {
+			return err
+		}
+
+		commits[hash] = &pending{hash: hash, commit: c}
+		stack = append(stack, c.Parents...)
+	}
+
+	if w.opts.Split {
+		if len(commits) == 0 && w.opts.SplitStrategy != SplitReplace {
+			return nil
+		}
+
+		// Merge the layers the strategy says into the new one.
+		count := len(commits)
+		merged := 0
+
+		for i := len(kept) - 1; i >= 0; i-- {
+			switch w.opts.SplitStrategy {
+			case SplitNoMerge:
+			case SplitReplace:
+				merged++
+
+				continue
+			default:
+				if kept[i].Count() <= w.opts.SizeMultiple*count {
+					count += kept[i].Count()
+					merged++
+
+					continue
+				}
+			}
+
+			break
+		}
+
+		for _, l := range kept[len(kept)-merged:] {
+			for i := 0; i < l.Count(); i++ {
+				c, err := w.readCommit(l.oid(i))
+
+				if err != nil {
+					return err
+				}
+
+				commits[l.oid(i)] = &pending{hash: l.oid(i), commit: c}
+			}
+		}
+
+		kept = kept[:len(kept)-merged]
+	}
+
+	if len(commits) == 0 {
+		return nil
+	}
+
+	base := &Graph{format: w.format, Layers: kept}
+	list := make([]*pending, 0, len(commits))
+
+	for _, p := range commits {
+		list = append(list, p)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].hash.Compare(list[j].hash) < 0
+	})
+
+	if err := w.computeGenerations(commits, base); err != nil {
+		return err
+	}
+
+	if w.opts.ChangedPaths {
+		for _, p := range list {
+			filter, err := w.bloomFilter(p.commit)
+
+			if err != nil {
+				return err
+			}
+
+			p.filter = filter
+		}
+	}
+
+	data, err := w.encode(list, base)
+
+	if err != nil {
+		return err
+	}
+
+	if w.opts.Split {
+		return w.writeChain(data, kept)
+	}
+
+	return w.writeSingle(data)
+}
+
+// Compute the topological levels and corrected commit dates of the
+// commits written, parents first.
+func (w *Writer) computeGenerations(commits map[plumbing.Hash]*pending, base *Graph) error {
+	generation := func(hash plumbing.Hash) (uint32, uint64, bool, error) {
+		if p := commits[hash]; p != nil {
+			return p.level, p.correctedDate, p.level != 0, nil
+		}
+
+		c, ok, err := base.Lookup(hash)
+
+		if !ok {
+			return 0, 0, false, err
+		}
+
+		return c.Level, c.CorrectedDate, true, nil
+	}
+
+	for _, start := range commits {
+		stack := []*pending{start}
+
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+
+			if p.level != 0 {
+				stack = stack[:len(stack)-1]
+
+				continue
+			}
+
+			level, corrected := uint32(0), uint64(0)
+			ready := true
+
+			for _, parent := range p.commit.Parents {
+				parentLevel, parentCorrected, ok, err := generation(parent)
+
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					stack = append(stack, commits[parent])
+					ready = false
+
+					continue
+				}
+
+				if parentLevel > level {
+					level = parentLevel
+				}
+
+				if parentCorrected > corrected {
+					corrected = parentCorrected
+				}
+			}
+
+			if !ready {
+				continue
+			}
+
+			stack = stack[:len(stack)-1]
+
+			if level < maxLevel {
+				level++
+			}
+
+			p.level = level
+			p.correctedDate = uint64(p.commit.Committer.When.Unix())
+
+			if corrected+1 > p.correctedDate {
+				p.correctedDate = corrected + 1
+			}
+		}
+	}
+
+	return nil
+}
+
+// The paths of the files that differ between two trees, the zero hash
+// standing for the empty tree. Stops once more than limit are found.
+func (w *Writer) changedPaths(from, to plumbing.Hash, prefix string, limit int, paths *[]string) error {
+	if from == to || len(*paths) > limit {
+		return nil
+	}
+
+	read := func(hash plumbing.Hash) (map[string]tree.Entry, []string, error) {
+		entries := make(map[string]tree.Entry)
+
+		if hash.IsZero() {
+			return entries, nil, nil
+		}
+
+		list, err := tree.ReadTree(w.git.ReadObjectByHash, hash)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		names := []string{}
+
+		for _, e := range list {
+			entries[e.Name] = e
+			names = append(names, e.Name)
+		}
+
+		return entries, names, nil
+	}
+
+	old, oldNames, err := read(from)
+
+	if err != nil {
+		return err
+	}
+
+	cur, names, err := read(to)
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range oldNames {
+		if _, ok := cur[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		a, inOld := old[name]
+		b, inNew := cur[name]
+
+		if inOld && inNew && a.Mode == b.Mode && a.Hash() == b.Hash() {
+			continue
+		}
+
+		subtree := func(e tree.Entry, ok bool) plumbing.Hash {
+			if ok && e.IsTree() {
+				return e.Hash()
+			}
+
+			return plumbing.ZeroHash
+		}
+
+		if (inOld && !a.IsTree()) || (inNew && !b.IsTree()) {
+			*paths = append(*paths, prefix+name)
+		}
+
+		if (inOld && a.IsTree()) || (inNew && b.IsTree()) {
+			if err := w.changedPaths(subtree(a, inOld), subtree(b, inNew), prefix+name+"/", limit, paths); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// The Bloom filter of the paths a commit changed from its first parent.
+func (w *Writer) bloomFilter(c *commit.Commit) (*BloomFilter, error) {
+	from := plumbing.ZeroHash
+
+	if len(c.Parents) > 0 {
+		parent, err := w.readCommit(c.Parents[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		from = parent.Tree
+	}
+
+	paths := []string{}
+
+	if err := w.changedPaths(from, c.Tree, "", bloomMaxChangedPaths, &paths); err != nil {
+		return nil, err
+	}
+
+	return newBloomFilter(defaultBloomSettings, paths), nil
+}
+
+type chunk struct {
+	id   uint32
+	data []byte
+}
+
+// Encode a layer holding the commits of list, sorted by id, on top of the
+// layers of base.
+func (w *Writer) encode(list []*pending, base *Graph) ([]byte, error) {
+	hashSize := w.format.Size()
+	offset := uint32(base.Count())
+	positions := make(map[plumbing.Hash]uint32)
+
+	for i, p := range list {
+		positions[p.hash] = offset + uint32(i)
+	}
+
+	position := func(hash plumbing.Hash) (uint32, error) {
+		if pos, ok := positions[hash]; ok {
+			return pos, nil
+		}
+
+		for i, l := range base.Layers {
+			if pos, ok := l.find(hash); ok {
+				for _, below := range base.Layers[:i] {
+					pos += below.Count()
+				}
+
+				return uint32(pos), nil
+			}
+		}
+
+		return 0, errors.GitError{Message: fmt.Sprintf("missing parent %s for commit-graph", hash)}
+	}
+
+	var fanout, oids, data, edges, generations, overflow, bloomIndexes, bloomData bytes.Buffer
+
+	counts := [256]uint32{}
+
+	for _, p := range list {
+		counts[p.hash.Bytes()[0]]++
+		oids.Write(p.hash.Bytes())
+	}
+
+	total := uint32(0)
+
+	for _, n := range counts {
+		total += n
+		binary.Write(&fanout, binary.BigEndian, total)
+	}
+
+	// Corrected commit dates are only written if every layer below has
+	// them, so that they can be compared.
+	writeGenerations := base.hasCorrectedDates()
+
+	binary.Write(&bloomData, binary.BigEndian, []uint32{bloomVersion, bloomNumHashes, bloomBitsPerEntry})
+
+	for _, p := range list {
+		data.Write(p.commit.Tree.Bytes())
+
+		parents := make([]uint32, 0, len(p.commit.Parents))
+
+		for _, parent := range p.commit.Parents {
+			pos, err := position(parent)
+
+			if err != nil {
+				return nil, err
+			}
+
+			parents = append(parents, pos)
+		}
+
+		words := []uint32{parentNone, parentNone}
+
+		for i, pos := range parents {
+			if i < 2 {
+				words[i] = pos
+			}
+		}
+
+		if len(parents) > 2 {
+			words[1] = parentOctopus | uint32(edges.Len()/4)
+
+			for i, pos := range parents[1:] {
+				if i == len(parents)-2 {
+					pos |= lastEdge
+				}
+
+				binary.Write(&edges, binary.BigEndian, pos)
+			}
+		}
+
+		date := p.commit.Committer.When.Unix()
+		words = append(words, p.level<<2|uint32(date>>32)&3, uint32(date))
+		binary.Write(&data, binary.BigEndian, words)
+
+		offset := p.correctedDate - uint64(date)
+
+		if offset > offsetOverflow-1 {
+			binary.Write(&generations, binary.BigEndian, offsetOverflow|uint32(overflow.Len()/8))
+			binary.Write(&overflow, binary.BigEndian, offset)
+		} else {
+			binary.Write(&generations, binary.BigEndian, uint32(offset))
+		}
+
+		if p.filter != nil {
+			bloomData.Write(p.filter.data)
+			binary.Write(&bloomIndexes, binary.BigEndian, uint32(bloomData.Len()-bloomDataHeaderSize))
+		}
+	}
+
+	chunks := []chunk{
+		{chunkFanout, fanout.Bytes()},
+		{chunkOIDs, oids.Bytes()},
+		{chunkData, data.Bytes()},
+	}
+
+	if writeGenerations {
+		chunks = append(chunks, chunk{chunkGeneration, generations.Bytes()})
+
+		if overflow.Len() > 0 {
+			chunks = append(chunks, chunk{chunkGenOverflow, overflow.Bytes()})
+		}
+	}
+
+	if edges.Len() > 0 {
+		chunks = append(chunks, chunk{chunkExtraEdges, edges.Bytes()})
+	}
+
+	if w.opts.ChangedPaths {
+		chunks = append(chunks, chunk{chunkBloomIndexes, bloomIndexes.Bytes()}, chunk{chunkBloomData, bloomData.Bytes()})
+	}
+
+	if len(base.Layers) > 0 {
+		var bases bytes.Buffer
+
+		for _, l := range base.Layers {
+			bases.Write(l.Checksum.Bytes())
+		}
+
+		chunks = append(chunks, chunk{chunkBaseGraphs, bases.Bytes()})
+	}
+
+	var out bytes.Buffer
+
+	out.Write(graphMagic)
+	out.Write([]byte{graphVersion, hashVersion(w.format), byte(len(chunks)), byte(len(base.Layers))})
+
+	start := uint64(headerSize + (len(chunks)+1)*chunkEntrySize)
+
+	for _, c := range chunks {
+		binary.Write(&out, binary.BigEndian, c.id)
+		binary.Write(&out, binary.BigEndian, start)
+		start += uint64(len(c.data))
+	}
+
+	binary.Write(&out, binary.BigEndian, uint32(0))
+	binary.Write(&out, binary.BigEndian, start)
+
+	for _, c := range chunks {
+		out.Write(c.data)
+	}
+
+	sum := w.format.NewHasher(out.Bytes()).Sum()
+	out.Write(sum.Bytes()[:hashSize])
+
+	return out.Bytes(), nil
+}
+
+// Replace a file with new content through its lock file, leaving it
+// read-only like Git does.
+func writeFile(path string, data []byte) error {
+	lock, err := fs.Lock(path)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := lock.Write(data); err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	if err := lock.Chmod(0444); err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	return lock.Commit()
+}
+
+// Remove the layers of a chain that are not in it anymore, and the chain
+// file too when keep is empty.
+func (w *Writer) removeLayers(keep map[string]bool) error {
+	dir := filepath.Join(w.objectDir, infoDir, chainDir)
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, f := range files {
+		name := f.Name()
+		isLayer := strings.HasPrefix(name, layerStart) && strings.HasSuffix(name, layerExt)
+
+		if keep[name] || !isLayer && (name != chainFile || len(keep) > 0) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) writeSingle(data []byte) error {
+	if err := os.MkdirAll(filepath.Join(w.objectDir, infoDir), 0755); err != nil {
+		return err
+	}
+
+	if err := writeFile(GraphPath(w.objectDir), data); err != nil {
+		return err
+	}
+
+	return w.removeLayers(nil)
+}
+
+// Write a new layer on top of the kept ones, turning a single file kept
+// into the base of the chain.
+func (w *Writer) writeChain(data []byte, kept []*Layer) error {
+	if err := os.MkdirAll(filepath.Join(w.objectDir, infoDir, chainDir), 0755); err != nil {
+		return err
+	}
+
+	checksum, _ := w.format.HashFromBytes(data[len(data)-w.format.Size():])
+
+	if err := writeFile(LayerPath(w.objectDir, checksum), data); err != nil {
+		return err
+	}
+
+	keep := map[string]bool{chainFile: true}
+
+	var chain strings.Builder
+
+	for _, l := range append(kept, &Layer{Checksum: checksum}) {
+		path := LayerPath(w.objectDir, l.Checksum)
+
+		if l.Path == GraphPath(w.objectDir) {
+			if err := os.Rename(l.Path, path); err != nil {
+				return err
+			}
+		}
+
+		keep[filepath.Base(path)] = true
+		chain.WriteString(l.Checksum.String() + "\n")
+	}
+
+	if err := writeFile(ChainPath(w.objectDir), []byte(chain.String())); err != nil {
+		return err
+	}
+
+	if err := os.Remove(GraphPath(w.objectDir)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return w.removeLayers(keep)
+}
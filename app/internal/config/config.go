@@ -0,0 +1,467 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// Entry is a single variable set in a config file. Section and Key are
+// lowercased, as they are case-insensitive; Subsection is kept as is.
+type Entry struct {
+	Section    string
+	Subsection string
+	Key        string
+	Value      string
+
+	// A key given without "=" has no value at all, which is not the same as
+	// an empty one: booleans read it as true, and it is listed bare.
+	NoValue bool
+
+	// The file the entry was read from ("" for the command line) and the
+	// scope that file belongs to.
+	Origin string
+	Scope  Scope
+}
+
+// The dotted name of the variable, e.g. branch.master.remote.
+func (e Entry) Name() string {
+	if e.Subsection == "" {
+		return e.Section + "." + e.Key
+	}
+
+	return e.Section + "." + e.Subsection + "." + e.Key
+}
+
+// Config holds the entries of one or more config files in the order they
+// are read, so that later entries override earlier ones.
+type Config struct {
+	Entries []Entry
+}
+
+// Load a config file and the files it includes. A missing file is an empty
+// config.
+func Load(path string) (*Config, error) {
+	return LoadFile(path, ScopeUnknown, "", true)
+}
+
+// LoadFile loads a config file, marking its entries as being of the given
+// scope, and following its includes if asked to. includeIf conditions are
+// checked against the repository at gitDir, if there is one.
+func LoadFile(path string, scope Scope, gitDir string, includes bool) (*Config, error) {
+	c := &Config{}
+	l := &loader{gitDir: gitDir, skipIncludes: !includes}
+
+	if err := l.load(c, path, scope, 0); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Read a config file, treating a missing one as empty.
+func readFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+// InvalidKeyError is returned for variable names that are not of the form
+// section[.subsection].key with a valid key.
+type InvalidKeyError struct {
+	Name      string
+	NoSection bool
+}
+
+func (e InvalidKeyError) Error() string {
+	if e.NoSection {
+		return fmt.Sprintf("key does not contain a section: %s", e.Name)
+	}
+
+	return fmt.Sprintf("invalid key: %s", e.Name)
+}
+
+// ParseName splits a variable name into its section, subsection and key,
+// keeping the case they are given in.
+func ParseName(name string) (string, string, string, error) {
+	first := strings.Index(name, ".")
+	last := strings.LastIndex(name, ".")
+
+	if first <= 0 || last == len(name)-1 {
+		return "", "", "", InvalidKeyError{Name: name, NoSection: true}
+	}
+
+	section, key := name[:first], name[last+1:]
+
+	if !validKey(key) || !validSection(section) {
+		return "", "", "", InvalidKeyError{Name: name}
+	}
+
+	if first == last {
+		return section, "", key, nil
+	}
+
+	return section, name[first+1 : last], key, nil
+}
+
+// Split a variable name into its section, subsection and key, normalising
+// the case of the parts that are case-insensitive.
+func splitName(name string) (string, string, string) {
+	first := strings.Index(name, ".")
+	last := strings.LastIndex(name, ".")
+
+	if first < 0 {
+		return strings.ToLower(name), "", ""
+	}
+
+	section := strings.ToLower(name[:first])
+	key := strings.ToLower(name[last+1:])
+
+	if first == last {
+		return section, "", key
+	}
+
+	return section, name[first+1 : last], key
+}
+
+// Find the entries setting a variable, by its dotted name, in the order
+// they are read.
+func (c *Config) Find(name string) []Entry {
+	section, subsection, key := splitName(name)
+
+	var found []Entry
+
+	for _, e := range c.Entries {
+		if e.Section == section && e.Subsection == subsection && e.Key == key {
+			found = append(found, e)
+		}
+	}
+
+	return found
+}
+
+// Get the value of a variable by its dotted name. The last one set wins.
+func (c *Config) Get(name string) (string, bool) {
+	found := c.Find(name)
+
+	if len(found) == 0 {
+		return "", false
+	}
+
+	return found[len(found)-1].Value, true
+}
+
+// GetAll gets every value of a multi-valued variable.
+func (c *Config) GetAll(name string) []string {
+	var values []string
+
+	for _, e := range c.Find(name) {
+		values = append(values, e.Value)
+	}
+
+	return values
+}
+
+// Bool gets a boolean variable, or def if it is not set.
+func (c *Config) Bool(name string, def bool) (bool, error) {
+	found := c.Find(name)
+
+	if len(found) == 0 {
+		return def, nil
+	}
+
+	last := found[len(found)-1]
+
+	if last.NoValue {
+		return true, nil
+	}
+
+	value := last.Value
+	b, err := ParseBool(value)
+
+	if err != nil {
+		return false, errors.GitError{Message: fmt.Sprintf("bad boolean config value '%s' for '%s'", value, name)}
+	}
+
+	return b, nil
+}
+
+// Int gets an integer variable, or def if it is not set.
+func (c *Config) Int(name string, def int64) (int64, error) {
+	value, ok := c.Get(name)
+
+	if !ok {
+		return def, nil
+	}
+
+	n, err := ParseInt(value)
+
+	if err != nil {
+		return 0, errors.GitError{Message: fmt.Sprintf("bad numeric config value '%s' for '%s': %s", value, name, err.Error())}
+	}
+
+	return n, nil
+}
+
+// Parse the content of a config file.
+func Parse(data []byte) (*Config, error) {
+	d, err := parseDocument(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{Entries: d.entries}, nil
+}
+
+// A config file as lines, with where each entry and section lies in it, so
+// that it can be edited without disturbing the rest of the file.
+type document struct {
+	// The lines of the file, each ending in a newline. Lines removed by an
+	// edit are left empty.
+	lines    []string
+	entries  []Entry
+	spans    []span
+	sections []section
+}
+
+// The lines [start, end) an entry was read from. offset is where the entry
+// starts in the first line, which is not 0 when it follows a section header.
+type span struct {
+	start, end int
+	offset     int
+	section    int
+}
+
+// A section header and the last line of the entries following it, and
+// whether the header line has a comment.
+type section struct {
+	name, subsection string
+	header, last     int
+	comment          bool
+}
+
+func parseDocument(data []byte) (*document, error) {
+	text := string(data)
+
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	d := &document{lines: strings.SplitAfter(text, "\n")}
+	d.lines = d.lines[:len(d.lines)-1]
+
+	for i := 0; i < len(d.lines); i++ {
+		start := i
+		line := strings.TrimSpace(d.lines[i])
+
+		// Values can continue on the next line after a trailing backslash.
+		for strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") && i+1 < len(d.lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimRight(d.lines[i], "\r\n")
+		}
+
+		lineNo := i + 1
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		offset := 0
+
+		if line[0] == '[' {
+			name, subsection, rest, err := parseSectionHeader(line)
+
+			if err != nil {
+				return nil, errors.GitError{Message: fmt.Sprintf("bad config line %d: %s", lineNo, err.Error())}
+			}
+
+			d.sections = append(d.sections, section{name: name, subsection: subsection, header: start, last: start})
+
+			rest = strings.TrimSpace(rest)
+
+			if rest == "" {
+				continue
+			}
+
+			if rest[0] == '#' || rest[0] == ';' {
+				d.sections[len(d.sections)-1].comment = true
+
+				continue
+			}
+
+			raw := d.lines[start]
+			offset = len(raw) - len(strings.TrimLeft(raw, " \t")) + len(line) - len(rest)
+			line = rest
+		}
+
+		if len(d.sections) == 0 {
+			return nil, errors.GitError{Message: fmt.Sprintf("bad config line %d: variable outside of a section", lineNo)}
+		}
+
+		key, value, noValue := line, "", true
+
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			key = strings.TrimSpace(line[:i])
+
+			parsed, err := parseValue(line[i+1:])
+
+			if err != nil {
+				return nil, errors.GitError{Message: fmt.Sprintf("bad config line %d: %s", lineNo, err.Error())}
+			}
+
+			value, noValue = parsed, false
+		} else if i := strings.IndexAny(line, "#;"); i >= 0 {
+			key = strings.TrimSpace(line[:i])
+		}
+
+		if !validKey(key) {
+			return nil, errors.GitError{Message: fmt.Sprintf("bad config line %d: invalid key '%s'", lineNo, key)}
+		}
+
+		current := len(d.sections) - 1
+		d.sections[current].last = i
+
+		d.entries = append(d.entries, Entry{
+			Section:    d.sections[current].name,
+			Subsection: d.sections[current].subsection,
+			Key:        strings.ToLower(key),
+			Value:      value,
+			NoValue:    noValue,
+		})
+		d.spans = append(d.spans, span{start: start, end: i + 1, offset: offset, section: current})
+	}
+
+	return d, nil
+}
+
+// Parse [section], [section "subsection"] or the legacy [section.subsection],
+// returning what follows the closing bracket on the line.
+func parseSectionHeader(line string) (string, string, string, error) {
+	if i := strings.IndexByte(line, '"'); i >= 0 {
+		section := strings.TrimSpace(line[1:i])
+		var sub strings.Builder
+
+		for j := i + 1; j < len(line); j++ {
+			switch line[j] {
+			case '\\':
+				j++
+
+				if j < len(line) {
+					sub.WriteByte(line[j])
+				}
+			case '"':
+				if j+1 >= len(line) || line[j+1] != ']' {
+					return "", "", "", errors.GitError{Message: "malformed section header"}
+				}
+
+				return strings.ToLower(section), sub.String(), line[j+2:], nil
+			default:
+				sub.WriteByte(line[j])
+			}
+		}
+
+		return "", "", "", errors.GitError{Message: "malformed section header"}
+	}
+
+	end := strings.IndexByte(line, ']')
+
+	if end < 0 {
+		return "", "", "", errors.GitError{Message: "malformed section header"}
+	}
+
+	name := line[1:end]
+
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		return strings.ToLower(name[:dot]), strings.ToLower(name[dot+1:]), line[end+1:], nil
+	}
+
+	return strings.ToLower(name), "", line[end+1:], nil
+}
+
+// Unquote a value, dropping comments and surrounding whitespace.
+func parseValue(raw string) (string, error) {
+	var value strings.Builder
+
+	quoted := false
+	pendingSpace := 0
+
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+
+		switch {
+		case ch == '\\':
+			i++
+
+			if i >= len(raw) {
+				return "", errors.GitError{Message: "bad escape at end of value"}
+			}
+
+			escapes := map[byte]byte{'n': '\n', 't': '\t', 'b': '\b', '\\': '\\', '"': '"'}
+			escaped, ok := escapes[raw[i]]
+
+			if !ok {
+				return "", errors.GitError{Message: fmt.Sprintf("bad escape '\\%c' in value", raw[i])}
+			}
+
+			value.WriteString(strings.Repeat(" ", pendingSpace))
+			pendingSpace = 0
+			value.WriteByte(escaped)
+		case ch == '"':
+			value.WriteString(strings.Repeat(" ", pendingSpace))
+			pendingSpace = 0
+			quoted = !quoted
+		case !quoted && (ch == '#' || ch == ';'):
+			i = len(raw)
+		case !quoted && (ch == ' ' || ch == '\t' || ch == '\r'):
+			if value.Len() > 0 {
+				pendingSpace++
+			}
+		default:
+			value.WriteString(strings.Repeat(" ", pendingSpace))
+			pendingSpace = 0
+			value.WriteByte(ch)
+		}
+	}
+
+	if quoted {
+		return "", errors.GitError{Message: "unterminated quote in value"}
+	}
+
+	return value.String(), nil
+}
+
+func validKey(key string) bool {
+	if key == "" || !isAlpha(key[0]) {
+		return false
+	}
+
+	for i := 1; i < len(key); i++ {
+		if !isAlpha(key[i]) && (key[i] < '0' || key[i] > '9') && key[i] != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validSection(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !isAlpha(name[i]) && (name[i] < '0' || name[i] > '9') && name[i] != '-' {
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,307 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "git_ditto_config")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	utils.Expect(t, os.MkdirAll(filepath.Dir(path), 0755), nil)
+	utils.Expect(t, ioutil.WriteFile(path, []byte(content), 0644), nil)
+}
+
+func TestParse(t *testing.T) {
+	c, err := config.Parse([]byte("# comment\n" +
+		"[Core]\n" +
+		"\tBare = false ; trailing\n" +
+		"\tquoted = \" a;b \" \n" +
+		"\tflag\n" +
+		"[branch \"Main\"]\n" +
+		"\tmerge = refs/heads/main\n" +
+		"[remote.Origin] url = one \\\n" +
+		"two\n" +
+		"[core]\n" +
+		"\tbare = true\n"))
+	utils.Expect(t, err, nil)
+
+	value, ok := c.Get("core.bare")
+	utils.Expect(t, ok, true)
+	utils.Expect(t, value, "true")
+
+	utils.Expect(t, c.GetAll("CORE.BARE"), []string{"false", "true"})
+
+	value, _ = c.Get("core.quoted")
+	utils.Expect(t, value, " a;b ")
+
+	flag, err := c.Bool("core.flag", false)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, flag, true)
+
+	// A key without a value has none, rather than "true".
+	value, ok = c.Get("core.flag")
+	utils.Expect(t, ok, true)
+	utils.Expect(t, value, "")
+	utils.Expect(t, c.Find("core.flag")[0].NoValue, true)
+
+	value, _ = c.Get("branch.Main.merge")
+	utils.Expect(t, value, "refs/heads/main")
+
+	_, ok = c.Get("branch.main.merge")
+	utils.Expect(t, ok, false)
+
+	value, _ = c.Get("remote.origin.url")
+	utils.Expect(t, value, "one two")
+
+	_, err = config.Parse([]byte("key = value\n"))
+	utils.Expect(t, err != nil, true)
+}
+
+func TestTypes(t *testing.T) {
+	for value, expected := range map[string]bool{"yes": true, "On": true, "1": true, "0": false, "": false, "off": false, "2k": true} {
+		b, err := config.ParseBool(value)
+		utils.Expect(t, err, nil)
+		utils.Expect(t, b, expected)
+	}
+
+	_, err := config.ParseBool("maybe")
+	utils.Expect(t, err != nil, true)
+
+	for value, expected := range map[string]int64{"12": 12, "1k": 1024, "3M": 3 << 20, "1g": 1 << 30, "-2": -2, "0x10": 16} {
+		n, err := config.ParseInt(value)
+		utils.Expect(t, err, nil)
+		utils.Expect(t, n, expected)
+	}
+
+	_, err = config.ParseInt("1x")
+	utils.Expect(t, err != nil, true)
+
+	for value, expected := range map[string]string{
+		"bold red blue": "\033[1;31;44m",
+		"reset":         "\033[m",
+		"normal":        "",
+		"brightgreen":   "\033[92m",
+		"196 #ff0000":   "\033[38;5;196;48;2;255;0;0m",
+		"no-bold ul":    "\033[4;22m",
+		"default":       "\033[39m",
+	} {
+		color, err := config.ParseColor(value)
+		utils.Expect(t, err, nil)
+		utils.Expect(t, color, expected)
+	}
+
+	_, err = config.ParseColor("red green blue")
+	utils.Expect(t, err != nil, true)
+}
+
+func TestIncludes(t *testing.T) {
+	dir := tempDir(t)
+	gitDir := filepath.Join(dir, "work", ".git")
+
+	writeFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/topic/x\n")
+	writeFile(t, filepath.Join(gitDir, "config"), "[user]\n"+
+		"\tname = Local\n"+
+		"[include]\n"+
+		"\tpath = extra.cfg\n"+
+		"[includeIf \"gitdir:work/\"]\n"+
+		"\tpath = ../../work.cfg\n"+
+		"[includeIf \"gitdir:other/\"]\n"+
+		"\tpath = ../../other.cfg\n"+
+		"[includeIf \"onbranch:topic/\"]\n"+
+		"\tpath = ../../topic.cfg\n"+
+		"[user]\n"+
+		"\temail = local@example.com\n")
+	writeFile(t, filepath.Join(gitDir, "extra.cfg"), "[user]\n\tname = Extra\n")
+	writeFile(t, filepath.Join(dir, "work.cfg"), "[user]\n\temail = work@example.com\n")
+	writeFile(t, filepath.Join(dir, "other.cfg"), "[user]\n\temail = other@example.com\n")
+	writeFile(t, filepath.Join(dir, "topic.cfg"), "[topic]\n\tseen = true\n")
+
+	c, err := config.LoadFile(filepath.Join(gitDir, "config"), config.ScopeLocal, gitDir, true)
+	utils.Expect(t, err, nil)
+
+	utils.Expect(t, c.GetAll("user.name"), []string{"Local", "Extra"})
+	utils.Expect(t, c.GetAll("user.email"), []string{"work@example.com", "local@example.com"})
+
+	seen := c.Find("topic.seen")
+	utils.Expect(t, len(seen), 1)
+	utils.Expect(t, seen[0].Origin, filepath.Join(dir, "topic.cfg"))
+	utils.Expect(t, seen[0].Scope, config.ScopeLocal)
+
+	// Without includes only the file itself is read.
+	c, err = config.LoadFile(filepath.Join(gitDir, "config"), config.ScopeLocal, gitDir, false)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, c.GetAll("user.name"), []string{"Local"})
+
+	// A file including itself gives up at some depth.
+	writeFile(t, filepath.Join(dir, "loop.cfg"), "[include]\n\tpath = loop.cfg\n")
+
+	_, err = config.Load(filepath.Join(dir, "loop.cfg"))
+	utils.Expect(t, err != nil, true)
+}
+
+func TestLoadAll(t *testing.T) {
+	dir := tempDir(t)
+	gitDir := filepath.Join(dir, ".git")
+
+	writeFile(t, filepath.Join(dir, "system"), "[core]\n\teditor = vi\n\tpager = less\n")
+	writeFile(t, filepath.Join(dir, "global"), "[core]\n\teditor = emacs\n")
+	writeFile(t, filepath.Join(gitDir, "config"), "[extensions]\n\tworktreeConfig = true\n[core]\n\tpager = more\n")
+	writeFile(t, filepath.Join(gitDir, "config.worktree"), "[core]\n\tpager = most\n")
+
+	env := map[string]string{
+		"GIT_CONFIG_SYSTEM":  filepath.Join(dir, "system"),
+		"GIT_CONFIG_GLOBAL":  filepath.Join(dir, "global"),
+		"GIT_CONFIG_COUNT":   "1",
+		"GIT_CONFIG_KEY_0":   "core.editor",
+		"GIT_CONFIG_VALUE_0": "ed",
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+
+	t.Cleanup(func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	})
+
+	c, err := config.LoadAll(gitDir)
+	utils.Expect(t, err, nil)
+
+	var scopes []string
+
+	for _, e := range c.Find("core.editor") {
+		scopes = append(scopes, e.Scope.String()+"="+e.Value)
+	}
+
+	for _, e := range c.Find("core.pager") {
+		scopes = append(scopes, e.Scope.String()+"="+e.Value)
+	}
+
+	utils.Expect(t, scopes, []string{"system=vi", "global=emacs", "command=ed", "system=less", "local=more", "worktree=most"})
+
+	// Outside of a repository only the system and global files apply.
+	os.Unsetenv("GIT_CONFIG_COUNT")
+
+	c, err = config.LoadAll("")
+	utils.Expect(t, err, nil)
+
+	value, _ := c.Get("core.pager")
+	utils.Expect(t, value, "less")
+}
+
+func TestExcludesFile(t *testing.T) {
+	home, err := os.UserHomeDir()
+	utils.Expect(t, err, nil)
+
+	xdg, had := os.LookupEnv("XDG_CONFIG_HOME")
+
+	t.Cleanup(func() {
+		if had {
+			os.Setenv("XDG_CONFIG_HOME", xdg)
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	})
+
+	c, err := config.Parse([]byte("[core]\n\texcludesFile = ~/ignored\n"))
+	utils.Expect(t, err, nil)
+
+	path, err := config.ExcludesFile(c)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, path, filepath.Join(home, "ignored"))
+
+	// Without the setting, the ignore file of the XDG config directory.
+	c, err = config.Parse(nil)
+	utils.Expect(t, err, nil)
+
+	os.Setenv("XDG_CONFIG_HOME", "/xdg")
+
+	path, err = config.ExcludesFile(c)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, path, "/xdg/git/ignore")
+
+	os.Unsetenv("XDG_CONFIG_HOME")
+
+	path, err = config.ExcludesFile(c)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, path, filepath.Join(home, ".config", "git", "ignore"))
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(tempDir(t), "config")
+
+	writeFile(t, path, "# Keep me\n"+
+		"[core]\n"+
+		"\tbare = false # and me\n"+
+		"[remote \"origin\"]\n"+
+		"\tfetch = a\n"+
+		"\tfetch = b\n"+
+		"[alias] co = checkout\n")
+
+	utils.Expect(t, config.Set(path, "core.bare", "true"), nil)
+	utils.Expect(t, config.Set(path, "core.Editor", "vim -f"), nil)
+	utils.Expect(t, config.Set(path, "alias.co", "commit"), nil)
+	utils.Expect(t, config.Add(path, "remote.origin.fetch", "c"), nil)
+	utils.Expect(t, config.Set(path, "user.name", "A \"Q\" #1\n"), nil)
+
+	_, ok := config.Set(path, "remote.origin.fetch", "d").(config.MultipleValuesError)
+	utils.Expect(t, ok, true)
+
+	_, ok = config.Unset(path, "remote.origin.fetch").(config.MultipleValuesError)
+	utils.Expect(t, ok, true)
+
+	_, ok = config.Unset(path, "core.missing").(config.NotSetError)
+	utils.Expect(t, ok, true)
+
+	_, ok = config.Set(path, "nosection", "x").(config.InvalidKeyError)
+	utils.Expect(t, ok, true)
+
+	utils.ExpectFileContent(t, path, "# Keep me\n"+
+		"[core]\n"+
+		"\tbare = true\n"+
+		"\tEditor = vim -f\n"+
+		"[remote \"origin\"]\n"+
+		"\tfetch = a\n"+
+		"\tfetch = b\n"+
+		"\tfetch = c\n"+
+		"[alias]\n"+
+		"\tco = commit\n"+
+		"[user]\n"+
+		"\tname = \"A \\\"Q\\\" #1\\n\"\n")
+
+	c, err := config.Load(path)
+	utils.Expect(t, err, nil)
+
+	value, _ := c.Get("user.name")
+	utils.Expect(t, value, "A \"Q\" #1\n")
+
+	// Sections left empty go away.
+	utils.Expect(t, config.UnsetAll(path, "remote.origin.fetch"), nil)
+	utils.Expect(t, config.Unset(path, "alias.co"), nil)
+
+	utils.ExpectFileContent(t, path, "# Keep me\n"+
+		"[core]\n"+
+		"\tbare = true\n"+
+		"\tEditor = vim -f\n"+
+		"[user]\n"+
+		"\tname = \"A \\\"Q\\\" #1\\n\"\n")
+}
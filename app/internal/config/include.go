@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/ignore"
+)
+
+// How deep includes may nest, so that a file including itself fails
+// instead of recursing forever.
+const maxIncludeDepth = 10
+
+// Reads config files into a Config, splicing in the files they include
+// where the include appears, unless skipIncludes is set. gitDir is the
+// repository includeIf conditions are checked against, if any.
+type loader struct {
+	gitDir       string
+	skipIncludes bool
+}
+
+func (l *loader) load(c *Config, path string, scope Scope, depth int) error {
+	data, err := readFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	d, err := parseDocument(data)
+
+	if err != nil {
+		return errors.GitError{Message: fmt.Sprintf("%s in file %s", err.Error(), path)}
+	}
+
+	for _, e := range d.entries {
+		e.Origin, e.Scope = path, scope
+		c.Entries = append(c.Entries, e)
+
+		if l.skipIncludes || e.Key != "path" || !l.includes(e, path) {
+			continue
+		}
+
+		if depth >= maxIncludeDepth {
+			return errors.GitError{Message: fmt.Sprintf("exceeded maximum include depth (%d) while including %s from %s", maxIncludeDepth, e.Value, path)}
+		}
+
+		included, err := ExpandPath(e.Value)
+
+		if err != nil {
+			return err
+		}
+
+		if !filepath.IsAbs(included) {
+			included = filepath.Join(filepath.Dir(path), included)
+		}
+
+		if err = l.load(c, included, scope, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Whether e, an include.path or includeIf.<condition>.path entry read from
+// the file at from, takes effect.
+func (l *loader) includes(e Entry, from string) bool {
+	switch e.Section {
+	case "include":
+		return e.Subsection == ""
+	case "includeif":
+		return l.conditionHolds(e.Subsection, from)
+	}
+
+	return false
+}
+
+// Check an includeIf condition: gitdir:, gitdir/i: or onbranch:. Unknown
+// conditions never hold.
+func (l *loader) conditionHolds(condition, from string) bool {
+	if l.gitDir == "" {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(condition, "gitdir:"):
+		return l.gitDirMatches(strings.TrimPrefix(condition, "gitdir:"), from, false)
+	case strings.HasPrefix(condition, "gitdir/i:"):
+		return l.gitDirMatches(strings.TrimPrefix(condition, "gitdir/i:"), from, true)
+	case strings.HasPrefix(condition, "onbranch:"):
+		head, err := ioutil.ReadFile(filepath.Join(l.gitDir, "HEAD"))
+
+		if err != nil {
+			return false
+		}
+
+		ref := strings.TrimSpace(string(head))
+
+		if !strings.HasPrefix(ref, "ref: refs/heads/") {
+			return false
+		}
+
+		pattern := strings.TrimPrefix(condition, "onbranch:")
+
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+
+		return ignore.Wildmatch(pattern, strings.TrimPrefix(ref, "ref: refs/heads/"))
+	}
+
+	return false
+}
+
+// Match the git directory against the pattern of a gitdir: condition. The
+// pattern may start with ~/ or with ./ for the directory of the including
+// file; other relative patterns match at any depth, and a trailing slash
+// matches everything below.
+func (l *loader) gitDirMatches(pattern, from string, foldCase bool) bool {
+	pattern, err := ExpandPath(pattern)
+
+	if err != nil {
+		return false
+	}
+
+	if strings.HasPrefix(pattern, "./") {
+		trailingSlash := strings.HasSuffix(pattern, "/")
+		pattern = filepath.Join(filepath.Dir(from), pattern[2:])
+
+		if trailingSlash {
+			pattern += "/"
+		}
+	}
+
+	pattern = filepath.ToSlash(pattern)
+
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	candidates := []string{l.gitDir}
+
+	if real, err := filepath.EvalSymlinks(l.gitDir); err == nil && real != l.gitDir {
+		candidates = append(candidates, real)
+	}
+
+	for _, dir := range candidates {
+		dir = filepath.ToSlash(dir)
+
+		if foldCase {
+			pattern, dir = strings.ToLower(pattern), strings.ToLower(dir)
+		}
+
+		if ignore.Wildmatch(pattern, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpandPath expands a leading ~/ in a path to the home directory.
+func ExpandPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", errors.GitError{Message: fmt.Sprintf("failed to expand user dir in: '%s'", path)}
+	}
+
+	return home + path[1:], nil
+}
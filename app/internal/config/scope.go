@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// Scope is where a config file applies, from the whole system down to a
+// single worktree. Files of narrower scopes are read later and so override
+// the broader ones.
+type Scope int
+
+const (
+	ScopeUnknown Scope = iota
+	ScopeSystem
+	ScopeGlobal
+	ScopeLocal
+	ScopeWorktree
+	ScopeCommand
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeSystem:
+		return "system"
+	case ScopeGlobal:
+		return "global"
+	case ScopeLocal:
+		return "local"
+	case ScopeWorktree:
+		return "worktree"
+	case ScopeCommand:
+		return "command"
+	}
+
+	return "unknown"
+}
+
+const systemConfig = "/etc/gitconfig"
+
+// The system config file, unless GIT_CONFIG_NOSYSTEM turns it off.
+func systemPaths() []string {
+	if off, err := ParseBool(os.Getenv("GIT_CONFIG_NOSYSTEM")); err == nil && off {
+		return nil
+	}
+
+	if path := os.Getenv("GIT_CONFIG_SYSTEM"); path != "" {
+		return []string{path}
+	}
+
+	return []string{systemConfig}
+}
+
+// The global config files: the XDG one, then ~/.gitconfig, unless
+// GIT_CONFIG_GLOBAL names a single one instead.
+func globalPaths() []string {
+	if path := os.Getenv("GIT_CONFIG_GLOBAL"); path != "" {
+		return []string{path}
+	}
+
+	var paths []string
+
+	if dir := xdgDir(); dir != "" {
+		paths = append(paths, filepath.Join(dir, "config"))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	return paths
+}
+
+// The directory of git's files under $XDG_CONFIG_HOME, or ~/.config when it
+// is not set; "" if neither is known.
+func xdgDir() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git")
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git")
+	}
+
+	return ""
+}
+
+// ExcludesFile is the file of patterns ignored in every repository:
+// core.excludesFile, or by default the ignore file next to the XDG config
+// file. It is "" if there is none.
+func ExcludesFile(c *Config) (string, error) {
+	if path, ok := c.Get("core.excludesFile"); ok {
+		return ExpandPath(path)
+	}
+
+	if dir := xdgDir(); dir != "" {
+		return filepath.Join(dir, "ignore"), nil
+	}
+
+	return "", nil
+}
+
+// Path is the file holding the config of a scope, the one written to when
+// changing it. gitDir is only needed for the local and worktree scopes.
+func Path(scope Scope, gitDir string) (string, error) {
+	switch scope {
+	case ScopeSystem:
+		if path := os.Getenv("GIT_CONFIG_SYSTEM"); path != "" {
+			return path, nil
+		}
+
+		return systemConfig, nil
+	case ScopeGlobal:
+		paths := globalPaths()
+
+		if len(paths) == 0 {
+			return "", errors.GitError{Message: "$HOME not set"}
+		}
+
+		// The XDG file is only written to if it is the one in use.
+		path := paths[len(paths)-1]
+
+		if _, err := os.Stat(path); os.IsNotExist(err) && len(paths) > 1 {
+			if _, err = os.Stat(paths[0]); err == nil {
+				return paths[0], nil
+			}
+		}
+
+		return path, nil
+	case ScopeLocal, ScopeWorktree:
+		if gitDir == "" {
+			return "", errors.GitError{Message: "not in a git directory"}
+		}
+
+		if scope == ScopeWorktree {
+			return filepath.Join(gitDir, "config.worktree"), nil
+		}
+
+		return filepath.Join(gitDir, "config"), nil
+	}
+
+	return "", errors.GitError{Message: fmt.Sprintf("no config file for the %s scope", scope)}
+}
+
+// LoadAll reads the config in effect for the repository at gitDir: the
+// system, global, local and worktree files, then variables set in the
+// environment. Without a repository, gitDir is "" and only the system and
+// global files and the environment are read.
+func LoadAll(gitDir string) (*Config, error) {
+	c := &Config{}
+	l := &loader{gitDir: gitDir}
+
+	for _, path := range systemPaths() {
+		if err := l.load(c, path, ScopeSystem, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range globalPaths() {
+		if err := l.load(c, path, ScopeGlobal, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if gitDir != "" {
+		if err := l.load(c, filepath.Join(gitDir, "config"), ScopeLocal, 0); err != nil {
+			return nil, err
+		}
+
+		worktreeConfig, err := c.Bool("extensions.worktreeConfig", false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if worktreeConfig {
+			if err = l.load(c, filepath.Join(gitDir, "config.worktree"), ScopeWorktree, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := loadEnvironment(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Add the variables set by GIT_CONFIG_COUNT and the GIT_CONFIG_KEY_<n> and
+// GIT_CONFIG_VALUE_<n> pairs.
+func loadEnvironment(c *Config) error {
+	count := os.Getenv("GIT_CONFIG_COUNT")
+
+	if count == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(count)
+
+	if err != nil || n < 0 {
+		return errors.GitError{Message: "bogus count in GIT_CONFIG_COUNT"}
+	}
+
+	for i := 0; i < n; i++ {
+		key, ok := os.LookupEnv(fmt.Sprintf("GIT_CONFIG_KEY_%d", i))
+
+		if !ok {
+			return errors.GitError{Message: fmt.Sprintf("missing config key GIT_CONFIG_KEY_%d", i)}
+		}
+
+		value, ok := os.LookupEnv(fmt.Sprintf("GIT_CONFIG_VALUE_%d", i))
+
+		if !ok {
+			return errors.GitError{Message: fmt.Sprintf("missing config value GIT_CONFIG_VALUE_%d", i)}
+		}
+
+		section, subsection, name, err := ParseName(key)
+
+		if err != nil {
+			return err
+		}
+
+		c.Entries = append(c.Entries, Entry{
+			Section:    strings.ToLower(section),
+			Subsection: subsection,
+			Key:        strings.ToLower(name),
+			Value:      value,
+			Scope:      ScopeCommand,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// ParseBool parses a boolean value: true, yes, on or a non-zero number for
+// true, and false, no, off, 0 or nothing at all for false.
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off", "":
+		return false, nil
+	}
+
+	n, err := ParseInt(value)
+
+	if err != nil {
+		return false, errors.GitError{Message: fmt.Sprintf("invalid boolean value '%s'", value)}
+	}
+
+	return n != 0, nil
+}
+
+// ParseInt parses an integer value, which may be scaled by 1024, 1024^2 or
+// 1024^3 with a k, m or g suffix.
+func ParseInt(value string) (int64, error) {
+	number, scale := value, int64(1)
+
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'k', 'K':
+			scale = 1 << 10
+		case 'm', 'M':
+			scale = 1 << 20
+		case 'g', 'G':
+			scale = 1 << 30
+		}
+
+		if scale > 1 {
+			number = value[:len(value)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 0, 64)
+
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		return 0, errors.GitError{Message: "out of range"}
+	}
+
+	if err != nil {
+		return 0, errors.GitError{Message: "invalid unit"}
+	}
+
+	if n > math.MaxInt64/scale || n < math.MinInt64/scale {
+		return 0, errors.GitError{Message: "out of range"}
+	}
+
+	return n * scale, nil
+}
+
+// Color attributes and their ANSI codes; turning one off is 20 more, except
+// for bold which shares 22 with dim.
+var colorAttributes = []struct {
+	name string
+	code int
+}{
+	{"bold", 1},
+	{"dim", 2},
+	{"italic", 3},
+	{"ul", 4},
+	{"blink", 5},
+	{"reverse", 7},
+	{"strike", 9},
+}
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// Parse a single color word to the parameters of its ANSI escape, given the
+// base of the color range: 30 for foreground colors and 40 for background.
+func parseColorWord(word string, base int) (string, bool) {
+	switch word {
+	case "normal":
+		return "", true
+	case "default":
+		return strconv.Itoa(base + 9), true
+	}
+
+	bright := strings.HasPrefix(word, "bright")
+
+	for i, name := range colorNames {
+		if word == name {
+			return strconv.Itoa(base + i), true
+		}
+
+		if bright && word[len("bright"):] == name {
+			return strconv.Itoa(base + 60 + i), true
+		}
+	}
+
+	if len(word) == 7 && word[0] == '#' {
+		rgb, err := strconv.ParseUint(word[1:], 16, 32)
+
+		if err != nil {
+			return "", false
+		}
+
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, rgb>>16, (rgb>>8)&0xff, rgb&0xff), true
+	}
+
+	n, err := strconv.Atoi(word)
+
+	if err != nil || n < -1 || n > 255 {
+		return "", false
+	}
+
+	switch {
+	case n == -1:
+		return "", true
+	case n < 8:
+		return strconv.Itoa(base + n), true
+	}
+
+	return fmt.Sprintf("%d;5;%d", base+8, n), true
+}
+
+// ParseColor parses a color value, such as "bold red blue", to its ANSI
+// escape sequence. The first color is the foreground and the second the
+// background; attributes can be turned off with a no or no- prefix.
+func ParseColor(value string) (string, error) {
+	invalid := errors.GitError{Message: fmt.Sprintf("invalid color value: %s", value)}
+	words := strings.Fields(strings.ToLower(value))
+
+	if len(words) == 0 {
+		return "", nil
+	}
+
+	if len(words) == 1 && words[0] == "reset" {
+		return "\033[m", nil
+	}
+
+	var set, unset []int
+	var colors []string
+	seenColors := 0
+
+	for _, word := range words {
+		base := 30
+
+		if seenColors > 0 {
+			base = 40
+		}
+
+		if color, ok := parseColorWord(word, base); ok {
+			if seenColors == 2 {
+				return "", invalid
+			}
+
+			seenColors++
+
+			if color != "" {
+				colors = append(colors, color)
+			}
+
+			continue
+		}
+
+		negate := false
+
+		if strings.HasPrefix(word, "no") {
+			negate = true
+			word = strings.TrimPrefix(strings.TrimPrefix(word, "no"), "-")
+		}
+
+		if word == "underline" {
+			word = "ul"
+		}
+
+		found := false
+
+		for _, attr := range colorAttributes {
+			if attr.name != word {
+				continue
+			}
+
+			found = true
+
+			if negate {
+				code := 20 + attr.code
+
+				if attr.code == 1 {
+					code = 22
+				}
+
+				unset = append(unset, code)
+			} else {
+				set = append(set, attr.code)
+			}
+		}
+
+		if !found {
+			return "", invalid
+		}
+	}
+
+	var params []string
+
+	for _, code := range sortedCodes(set) {
+		params = append(params, strconv.Itoa(code))
+	}
+
+	for _, code := range sortedCodes(unset) {
+		params = append(params, strconv.Itoa(code))
+	}
+
+	params = append(params, colors...)
+
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	return "\033[" + strings.Join(params, ";") + "m", nil
+}
+
+// Sort and dedupe attribute codes, as the order they are given in does not
+// matter.
+func sortedCodes(codes []int) []int {
+	var sorted []int
+
+	for code := 0; code < 30; code++ {
+		for _, c := range codes {
+			if c == code {
+				sorted = append(sorted, code)
+
+				break
+			}
+		}
+	}
+
+	return sorted
+}
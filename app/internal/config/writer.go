@@ -0,0 +1,257 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+)
+
+// MultipleValuesError is returned when a change meant for a single value
+// finds a variable set more than once.
+type MultipleValuesError struct {
+	Name string
+}
+
+func (e MultipleValuesError) Error() string {
+	return fmt.Sprintf("%s has multiple values", e.Name)
+}
+
+// NotSetError is returned when removing a variable that is not set.
+type NotSetError struct {
+	Name string
+}
+
+func (e NotSetError) Error() string {
+	return fmt.Sprintf("%s is not set", e.Name)
+}
+
+// Set a variable in the config file at path, replacing its value if it is
+// already set there. It is an error if the variable has several values.
+func Set(path, name, value string) error {
+	return edit(path, name, func(d *document, key string, matches []int) error {
+		switch len(matches) {
+		case 0:
+			d.insert(name, key, value)
+		case 1:
+			d.replace(matches[0], key, value)
+		default:
+			return MultipleValuesError{Name: name}
+		}
+
+		return nil
+	})
+}
+
+// Add a value to a variable in the config file at path, keeping the values
+// it already has.
+func Add(path, name, value string) error {
+	return edit(path, name, func(d *document, key string, matches []int) error {
+		d.insert(name, key, value)
+
+		return nil
+	})
+}
+
+// Unset removes a variable from the config file at path. It is an error if
+// the variable has several values.
+func Unset(path, name string) error {
+	return edit(path, name, func(d *document, key string, matches []int) error {
+		switch len(matches) {
+		case 0:
+			return NotSetError{Name: name}
+		case 1:
+			d.remove(matches)
+		default:
+			return MultipleValuesError{Name: name}
+		}
+
+		return nil
+	})
+}
+
+// UnsetAll removes every value of a variable from the config file at path.
+func UnsetAll(path, name string) error {
+	return edit(path, name, func(d *document, key string, matches []int) error {
+		if len(matches) == 0 {
+			return NotSetError{Name: name}
+		}
+
+		d.remove(matches)
+
+		return nil
+	})
+}
+
+// Apply a change to the entries of a variable in the config file at path,
+// holding its lock while it is read and rewritten. change gets the key as
+// written in name and the indexes of the entries for the variable.
+func edit(path, name string, change func(d *document, key string, matches []int) error) error {
+	section, subsection, key, err := ParseName(name)
+
+	if err != nil {
+		return err
+	}
+
+	lock, err := fs.Lock(path)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil && !os.IsNotExist(err) {
+		lock.Rollback()
+
+		return err
+	}
+
+	d, err := parseDocument(data)
+
+	if err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	var matches []int
+
+	for i, e := range d.entries {
+		if e.Section == strings.ToLower(section) && e.Subsection == subsection && e.Key == strings.ToLower(key) {
+			matches = append(matches, i)
+		}
+	}
+
+	if err = change(d, key, matches); err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	if _, err = lock.WriteString(strings.Join(d.lines, "")); err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	return lock.Commit()
+}
+
+// Add an entry at the end of the last section it belongs in, or in a new
+// section at the end of the file.
+func (d *document) insert(name, key, value string) {
+	section, subsection, _, _ := ParseName(name)
+	line := formatEntry(key, value)
+
+	for i := len(d.sections) - 1; i >= 0; i-- {
+		s := d.sections[i]
+
+		if s.name != strings.ToLower(section) || s.subsection != subsection {
+			continue
+		}
+
+		d.lines = append(d.lines[:s.last+1], append([]string{line}, d.lines[s.last+1:]...)...)
+
+		return
+	}
+
+	d.lines = append(d.lines, formatSectionHeader(section, subsection), line)
+}
+
+// Replace the entry at index i with a new value.
+func (d *document) replace(i int, key, value string) {
+	s := d.spans[i]
+	line := formatEntry(key, value)
+
+	// An entry on the line of its section header keeps the header.
+	if s.offset > 0 {
+		line = strings.TrimRight(d.lines[s.start][:s.offset], " \t") + "\n" + line
+	}
+
+	d.lines[s.start] = line
+
+	for l := s.start + 1; l < s.end; l++ {
+		d.lines[l] = ""
+	}
+}
+
+// Remove the entries at the given indexes, and the headers of the sections
+// they leave with nothing in them.
+func (d *document) remove(indexes []int) {
+	removed := make(map[int]bool)
+
+	for _, i := range indexes {
+		s := d.spans[i]
+		removed[i] = true
+
+		for l := s.start; l < s.end; l++ {
+			d.lines[l] = ""
+		}
+
+		if s.offset > 0 {
+			d.lines[s.start] = strings.TrimRight(d.lines[s.start][:s.offset], " \t") + "\n"
+		}
+	}
+
+	for i, s := range d.sections {
+		end := len(d.lines)
+
+		if i+1 < len(d.sections) {
+			end = d.sections[i+1].header
+		}
+
+		empty, touched := true, false
+
+		for j, sp := range d.spans {
+			if sp.section != i {
+				continue
+			}
+
+			if removed[j] {
+				touched = true
+			} else {
+				empty = false
+			}
+		}
+
+		// Sections also stay if they hold comments.
+		empty = empty && !s.comment
+
+		for l := s.header + 1; l < end && empty; l++ {
+			empty = strings.TrimSpace(d.lines[l]) == ""
+		}
+
+		if !touched || !empty {
+			continue
+		}
+
+		for l := s.header; l < end; l++ {
+			d.lines[l] = ""
+		}
+	}
+}
+
+func formatSectionHeader(section, subsection string) string {
+	if subsection == "" {
+		return "[" + section + "]\n"
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(subsection)
+
+	return fmt.Sprintf("[%s \"%s\"]\n", section, escaped)
+}
+
+// Format an entry line, quoting the value if it would otherwise lose
+// leading or trailing spaces or be cut short by a comment character.
+func formatEntry(key, value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\b", `\b`).Replace(value)
+
+	if value != "" && (value[0] == ' ' || value[len(value)-1] == ' ' || strings.ContainsAny(value, "#;")) {
+		escaped = `"` + escaped + `"`
+	}
+
+	return fmt.Sprintf("\t%s = %s\n", key, escaped)
+}
@@ -0,0 +1,60 @@
+package diff
+
+import (
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// Algorithm finds the lines of two sides that changed: those left out of
+// the common subsequence it picks.
+type Algorithm interface {
+	// The name of the algorithm, as --diff-algorithm takes it.
+	String() string
+
+	// Mark the lines of both sides that changed.
+	mark(old, new *side)
+}
+
+var (
+	// Myers finds a short edit script fast, giving up on the shortest for
+	// costly inputs, like git's default.
+	Myers Algorithm = myers{}
+
+	// Minimal is Myers always finding the shortest edit script.
+	Minimal Algorithm = myers{minimal: true}
+
+	// Patience lines up the lines unique to both sides first.
+	Patience Algorithm = patience{}
+
+	// Histogram extends patience to lines that are rare rather than
+	// unique.
+	Histogram Algorithm = histogram{}
+)
+
+// ParseAlgorithm reads the name of a diff algorithm, as git's
+// diff.algorithm setting and --diff-algorithm option take it.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "myers", "default":
+		return Myers, nil
+	case "minimal":
+		return Minimal, nil
+	case "patience":
+		return Patience, nil
+	case "histogram":
+		return Histogram, nil
+	}
+
+	return nil, errors.GitError{Message: "option diff-algorithm accepts \"myers\", \"minimal\", \"patience\" and \"histogram\""}
+}
+
+// Compare parts of two sides with Myers, when a smarter algorithm finds no
+// lines to line up there.
+func fallBack(old, new *side, start1, count1, start2, count2 int) {
+	a, b := old.slice(start1, count1), new.slice(start2, count2)
+
+	Myers.mark(a, b)
+	copy(old.changed[start1:], a.changed)
+	copy(new.changed[start2:], b.changed)
+}
@@ -0,0 +1,380 @@
+package diff
+
+import (
+	"os"
+	"sort"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pathspec"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// CompareOptions limits a comparison to some paths.
+type CompareOptions struct {
+	Pathspec pathspec.Pathspec
+
+	// Also list the files that did not change, as sources to find copies
+	// harder.
+	Unmodified bool
+}
+
+// Trees compares two trees. A zero hash stands for the empty tree.
+func Trees(git *fs.Git, from, to plumbing.Hash, opts CompareOptions) ([]*Change, error) {
+	old, err := treeFiles(git, from, opts.Pathspec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	new, err := treeFiles(git, to, opts.Pathspec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return compareFiles(old, new, opts.Unmodified), nil
+}
+
+// TreeToIndex compares a tree with the index, as staged for the next
+// commit.
+func TreeToIndex(git *fs.Git, from plumbing.Hash, idx *index.Index, opts CompareOptions) ([]*Change, error) {
+	old, err := treeFiles(git, from, opts.Pathspec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	new, unmerged := indexFiles(git, idx, opts.Pathspec)
+	old, conflicts := splitUnmerged(old, unmerged)
+
+	return withUnmerged(compareFiles(old, new, opts.Unmodified), conflicts), nil
+}
+
+// IndexToWorkTree compares the index with the files of the working tree.
+// Files whose stat data went stale without their content changing get it
+// refreshed in the index, reported through refreshed.
+func IndexToWorkTree(git *fs.Git, idx *index.Index, opts CompareOptions) (changes []*Change, refreshed bool, err error) {
+	old, unmerged := indexFiles(git, idx, opts.Pathspec)
+	indexTime := indexModTime(git)
+
+	for _, f := range old {
+		e, _ := idx.Entry(f.Path, index.Merged)
+
+		if e.IntentToAdd {
+			f.Mode, f.Hash = 0, plumbing.ZeroHash
+		}
+
+		new, same, err := workTreeFile(git, e, indexTime, &refreshed)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if same {
+			if opts.Unmodified {
+				changes = append(changes, &Change{Status: Unmodified, Old: f, New: f})
+			}
+
+			continue
+		}
+
+		if change := newChange(f, new, false); change != nil {
+			changes = append(changes, change)
+		}
+	}
+
+	// Conflicts show the mode of the working tree file, then how it differs
+	// from our side.
+	conflicts := []*Change{}
+
+	for _, p := range unmerged {
+		current, err := workTreeOnly(git, p)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		conflicts = append(conflicts, &Change{Status: Unmerged, Old: &File{Path: p}, New: &File{Path: p, Mode: current.Mode}})
+
+		if e, ok := idx.Entry(p, index.Ours); ok {
+			ours := &File{Path: p, Mode: e.Mode, Hash: e.Hash, git: git}
+
+			if change := newChange(ours, current, false); change != nil {
+				conflicts = append(conflicts, change)
+			}
+		}
+	}
+
+	return withUnmerged(changes, conflicts), refreshed, nil
+}
+
+// TreeToWorkTree compares a tree with the files of the working tree the
+// index tracks, refreshing stale stat data like IndexToWorkTree.
+func TreeToWorkTree(git *fs.Git, from plumbing.Hash, idx *index.Index, opts CompareOptions) (changes []*Change, refreshed bool, err error) {
+	old, err := treeFiles(git, from, opts.Pathspec)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	tracked, unmerged := indexFiles(git, idx, opts.Pathspec)
+	indexTime := indexModTime(git)
+	new := make([]*File, 0, len(tracked)+len(unmerged))
+
+	for _, f := range tracked {
+		e, _ := idx.Entry(f.Path, index.Merged)
+		current, same, err := workTreeFile(git, e, indexTime, &refreshed)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch {
+		case same:
+			new = append(new, f)
+		case current.Exists():
+			new = append(new, current)
+		}
+	}
+
+	// Paths with conflicts compare the tree with the working tree as is.
+	for _, p := range unmerged {
+		current, err := workTreeOnly(git, p)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if current.Exists() {
+			new = append(new, current)
+		}
+	}
+
+	sort.SliceStable(new, func(i, j int) bool {
+		return new[i].Path < new[j].Path
+	})
+
+	changes = compareFiles(old, new, opts.Unmodified)
+	kept := changes[:0]
+
+	// Files changed since they were staged may be back to what the tree
+	// holds.
+	for _, c := range changes {
+		if c.Status == Modified && c.New.inWorkTree && c.Old.Mode == c.New.Mode {
+			hash, err := c.New.ObjectHash()
+
+			if err != nil {
+				return nil, false, err
+			}
+
+			if hash == c.Old.Hash {
+				if opts.Unmodified {
+					c.Status = Unmodified
+					kept = append(kept, c)
+				}
+
+				continue
+			}
+		}
+
+		kept = append(kept, c)
+	}
+
+	return kept, refreshed, nil
+}
+
+// Pair up the files of two sides, both sorted by path.
+func compareFiles(old, new []*File, unmodified bool) []*Change {
+	changes := []*Change{}
+	i, j := 0, 0
+
+	for i < len(old) || j < len(new) {
+		var change *Change
+
+		switch {
+		case j == len(new) || i < len(old) && old[i].Path < new[j].Path:
+			change = newChange(old[i], &File{Path: old[i].Path}, false)
+			i++
+		case i == len(old) || new[j].Path < old[i].Path:
+			change = newChange(&File{Path: new[j].Path}, new[j], false)
+			j++
+		default:
+			change = newChange(old[i], new[j], unmodified)
+			i, j = i+1, j+1
+		}
+
+		if change != nil {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+func treeFiles(git *fs.Git, hash plumbing.Hash, spec pathspec.Pathspec) ([]*File, error) {
+	files := []*File{}
+
+	if hash.IsZero() {
+		return files, nil
+	}
+
+	entries, err := tree.ReadRecursive(git.ReadObjectByHash, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if spec.Match(e.Name) {
+			files = append(files, &File{Path: e.Name, Mode: e.Mode, Hash: e.Hash(), git: git})
+		}
+	}
+
+	return files, nil
+}
+
+// The merged files of the index, and the paths left with conflicts.
+func indexFiles(git *fs.Git, idx *index.Index, spec pathspec.Pathspec) ([]*File, []string) {
+	files := []*File{}
+	unmerged := []string{}
+
+	for _, e := range idx.Entries {
+		if !spec.Match(e.Name) {
+			continue
+		}
+
+		if e.Stage != index.Merged {
+			if len(unmerged) == 0 || unmerged[len(unmerged)-1] != e.Name {
+				unmerged = append(unmerged, e.Name)
+			}
+
+			continue
+		}
+
+		files = append(files, &File{Path: e.Name, Mode: e.Mode, Hash: e.Hash, git: git})
+	}
+
+	return files, unmerged
+}
+
+// Take the files of paths with conflicts out of a side, making them the old
+// side of unmerged changes.
+func splitUnmerged(files []*File, paths []string) ([]*File, []*Change) {
+	if len(paths) == 0 {
+		return files, nil
+	}
+
+	byPath := make(map[string]*File, len(paths))
+
+	for _, p := range paths {
+		byPath[p] = &File{Path: p}
+	}
+
+	kept := files[:0]
+
+	for _, f := range files {
+		if _, ok := byPath[f.Path]; ok {
+			byPath[f.Path] = f
+		} else {
+			kept = append(kept, f)
+		}
+	}
+
+	conflicts := make([]*Change, 0, len(paths))
+
+	for _, p := range paths {
+		conflicts = append(conflicts, &Change{Status: Unmerged, Old: byPath[p], New: &File{Path: p}})
+	}
+
+	return kept, conflicts
+}
+
+// Add the changes of paths with conflicts, keeping the order of changes to
+// the same path.
+func withUnmerged(changes, conflicts []*Change) []*Change {
+	if len(conflicts) == 0 {
+		return changes
+	}
+
+	changes = append(changes, conflicts...)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path() < changes[j].Path()
+	})
+
+	return changes
+}
+
+func indexModTime(git *fs.Git) int64 {
+	if info, err := os.Stat(git.IndexPath()); err == nil {
+		return info.ModTime().Unix()
+	}
+
+	return 0
+}
+
+// The working tree file of an index entry, and whether it is the same as
+// the entry. Entries found the same by content get their stat data
+// refreshed, reported through refreshed.
+func workTreeFile(git *fs.Git, e *index.Entry, indexTime int64, refreshed *bool) (*File, bool, error) {
+	f, info, err := statWorkTree(git, e.Name)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !f.Exists() || f.Mode != e.Mode || e.IntentToAdd {
+		return f, false, nil
+	}
+
+	// Racily clean entries were modified in the same second the index was
+	// written, so their stat data cannot tell whether they changed since.
+	if e.StatMatches(info) && e.MTime.Unix() < indexTime {
+		return f, true, nil
+	}
+
+	hash, err := f.ObjectHash()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if hash != e.Hash {
+		return f, false, nil
+	}
+
+	e.FillStat(info)
+	*refreshed = true
+
+	return f, true, nil
+}
+
+// The working tree file at a path, without an index entry to compare with.
+func workTreeOnly(git *fs.Git, name string) (*File, error) {
+	f, _, err := statWorkTree(git, name)
+
+	return f, err
+}
+
+// A file of the working tree, missing if there is none or a directory is in
+// its place.
+func statWorkTree(git *fs.Git, name string) (*File, os.FileInfo, error) {
+	f := &File{Path: name, git: git, inWorkTree: true}
+	info, err := git.StatWorkTree(name)
+
+	if os.IsNotExist(err) {
+		return f, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if f.Mode = tree.ModeFromFileInfo(info); f.Mode == tree.ModeTree {
+		f.Mode = 0
+	}
+
+	return f, info, nil
+}
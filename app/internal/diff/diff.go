@@ -0,0 +1,177 @@
+// Package diff compares trees, the index and the working tree, pairs up
+// renamed and copied files, and shows the changes as patches or summaries
+// of them, the way git diff does.
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Status letters of a change, as git shows them.
+const (
+	Added       byte = 'A'
+	Copied      byte = 'C'
+	Deleted     byte = 'D'
+	Modified    byte = 'M'
+	Renamed     byte = 'R'
+	TypeChanged byte = 'T'
+	Unmerged    byte = 'U'
+
+	// Unmodified files are only compared to find the sources of copies.
+	Unmodified byte = ' '
+)
+
+// MaxScore is the similarity of identical files.
+const MaxScore = 60000
+
+const modeTypeMask uint32 = 0170000
+
+// File is one side of a change: what a tree, the index or the working tree
+// holds at a path. A file with a zero mode does not exist on that side.
+type File struct {
+	Path string
+	Mode uint32
+
+	// The blob of the file, zero when it is only in the working tree and
+	// has not been hashed.
+	Hash plumbing.Hash
+
+	git        *fs.Git
+	inWorkTree bool
+	hash       plumbing.Hash
+	data       []byte
+	loaded     bool
+	spans      map[uint32]int
+}
+
+// Exists tells whether the file is on its side of the change at all.
+func (f *File) Exists() bool {
+	return f.Mode != 0
+}
+
+// IsRegular tells whether the file is a regular file, rather than a
+// symbolic link or a submodule.
+func (f *File) IsRegular() bool {
+	return f.Mode&modeTypeMask == 0100000
+}
+
+// Content reads the file from the object store or the working tree.
+func (f *File) Content() ([]byte, error) {
+	if f.loaded || !f.Exists() {
+		return f.data, nil
+	}
+
+	var err error
+
+	switch {
+	case !f.inWorkTree:
+		_, f.data, err = f.git.ReadObjectByHash(f.Hash)
+	case f.Mode&modeTypeMask == 0120000:
+		var target string
+
+		target, err = os.Readlink(f.workTreePath())
+		f.data = []byte(target)
+	default:
+		f.data, err = ioutil.ReadFile(f.workTreePath())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.loaded = true
+
+	return f.data, nil
+}
+
+// ObjectHash returns the blob the file would be stored as, hashing it from
+// the working tree if need be. It is zero when the file does not exist.
+func (f *File) ObjectHash() (plumbing.Hash, error) {
+	if !f.Hash.IsZero() || !f.Exists() {
+		return f.Hash, nil
+	}
+
+	if f.hash.IsZero() {
+		data, err := f.Content()
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		f.hash = objfile.HashObject(f.git.ObjectFormat(), objfile.Blob, data)
+	}
+
+	return f.hash, nil
+}
+
+func (f *File) workTreePath() string {
+	return filepath.Join(f.git.WorkTree(), filepath.FromSlash(f.Path))
+}
+
+// How far into a file to look for a NUL byte, which makes it binary.
+const binaryCheckLength = 8000
+
+// IsBinary tells binary content from text the way git does, by a NUL byte
+// near its start.
+func IsBinary(data []byte) bool {
+	if len(data) > binaryCheckLength {
+		data = data[:binaryCheckLength]
+	}
+
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// Change is a file that differs between the two sides of a comparison. The
+// old and new sides have different paths once renames and copies are
+// paired up.
+type Change struct {
+	Status byte
+	Old    *File
+	New    *File
+
+	// How similar the old and new files of a rename or copy are, out of
+	// MaxScore.
+	Score int
+}
+
+// Path is the path a change is listed under: its new path, or the old one
+// for a deletion.
+func (c *Change) Path() string {
+	if c.New.Exists() || c.Old.Path == "" {
+		return c.New.Path
+	}
+
+	return c.Old.Path
+}
+
+// Similarity is the score of a rename or copy as a percentage.
+func (c *Change) Similarity() int {
+	return c.Score * 100 / MaxScore
+}
+
+// Pair files at a path into a change, or nil if they are the same.
+func newChange(old, new *File, unmodified bool) *Change {
+	switch {
+	case !old.Exists() && !new.Exists():
+		return nil
+	case !old.Exists():
+		return &Change{Status: Added, Old: old, New: new}
+	case !new.Exists():
+		return &Change{Status: Deleted, Old: old, New: new}
+	case old.Mode&modeTypeMask != new.Mode&modeTypeMask:
+		return &Change{Status: TypeChanged, Old: old, New: new}
+	case old.Mode != new.Mode || old.Hash != new.Hash || new.Hash.IsZero():
+		return &Change{Status: Modified, Old: old, New: new}
+	case unmodified:
+		return &Change{Status: Unmodified, Old: old, New: new}
+	}
+
+	return nil
+}
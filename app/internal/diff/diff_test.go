@@ -0,0 +1,148 @@
+package diff_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func TestParseScore(t *testing.T) {
+	cases := []struct {
+		score    string
+		expected int
+	}{
+		{score: "50%", expected: 30000},
+		{score: "5", expected: 30000},
+		{score: "75", expected: 45000},
+		{score: "0.9", expected: 54000},
+		{score: "12.5%", expected: 7500},
+		{score: "100%", expected: diff.MaxScore},
+		{score: "2", expected: 12000},
+	}
+
+	for _, c := range cases {
+		score, err := diff.ParseScore(c.score)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, score, c.expected)
+	}
+
+	_, err := diff.ParseScore("50x")
+	utils.Expect(t, err.Error(), "invalid similarity score '50x'")
+}
+
+func TestQuotePath(t *testing.T) {
+	utils.Expect(t, diff.QuotePath("dir/a b.txt"), "dir/a b.txt")
+	utils.Expect(t, diff.QuotePath("tab\there"), "\"tab\\there\"")
+	utils.Expect(t, diff.QuotePath("caf\xc3\xa9"), "\"caf\\303\\251\"")
+	utils.Expect(t, diff.QuotePath("say \"hi\""), "\"say \\\"hi\\\"\"")
+}
+
+func TestWriteStat(t *testing.T) {
+	change := func(status byte, old, new string) *diff.Change {
+		return &diff.Change{
+			Status: status,
+			Old:    &diff.File{Path: old, Mode: 0100644},
+			New:    &diff.File{Path: new, Mode: 0100644},
+			Score:  diff.MaxScore,
+		}
+	}
+
+	stats := []diff.FileStat{
+		{Change: change(diff.Modified, "a.txt", "a.txt"), Added: 120, Deleted: 30},
+		{Change: change(diff.Renamed, "src/old/name.go", "src/new/name.go")},
+		{Change: change(diff.Unmerged, "c.txt", "c.txt")},
+	}
+
+	var out bytes.Buffer
+
+	diff.WriteStat(&out, stats, diff.StatOptions{Width: 60})
+	utils.Expect(t, out.String(), ""+
+		" a.txt                    | 150 +++++++++++++++++++++------\n"+
+		" src/{old => new}/name.go |   0\n"+
+		" c.txt                    | Unmerged\n"+
+		" 2 files changed, 120 insertions(+), 30 deletions(-)\n")
+
+	out.Reset()
+
+	diff.WriteNumstat(&out, stats[:2])
+	utils.Expect(t, out.String(), "120\t30\ta.txt\n0\t0\tsrc/{old => new}/name.go\n")
+
+	// Binary files show their sizes.
+	stats = []diff.FileStat{
+		{Change: change(diff.Modified, "a.txt", "a.txt"), Added: 1},
+		{Change: change(diff.Modified, "b.bin", "b.bin"), Added: 5, Deleted: 3, Binary: true},
+	}
+
+	out.Reset()
+
+	diff.WriteStat(&out, stats, diff.StatOptions{})
+	diff.WriteNumstat(&out, stats)
+	utils.Expect(t, out.String(), ""+
+		" a.txt |   1 +\n"+
+		" b.bin | Bin 3 -> 5 bytes\n"+
+		" 2 files changed, 1 insertion(+)\n"+
+		"1\t0\ta.txt\n"+
+		"-\t-\tb.bin\n")
+}
+
+func lines(s ...string) [][]byte {
+	result := make([][]byte, len(s))
+
+	for i := range s {
+		result[i] = []byte(s[i] + "\n")
+	}
+
+	return result
+}
+
+func TestLines(t *testing.T) {
+	a, b := lines("c", "b", "a", "a"), lines("a", "b", "b", "c")
+
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{}), []diff.Edit{
+		{Old: 0, OldLines: 3, New: 0, NewLines: 0},
+		{Old: 4, OldLines: 0, New: 1, NewLines: 3},
+	})
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{Algorithm: diff.Patience}), []diff.Edit{
+		{Old: 0, OldLines: 0, New: 0, NewLines: 3},
+		{Old: 1, OldLines: 3, New: 4, NewLines: 0},
+	})
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{Algorithm: diff.Histogram}), []diff.Edit{
+		{Old: 0, OldLines: 1, New: 0, NewLines: 1},
+		{Old: 2, OldLines: 2, New: 2, NewLines: 2},
+	})
+
+	// The indent heuristic keeps the new block whole.
+	a, b = lines("\tfoo();", "if (x) {"), lines("\tfoo();", "if (x) {", "\tbar();", "if (x) {")
+
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{}), []diff.Edit{{Old: 2, OldLines: 0, New: 2, NewLines: 2}})
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{IndentHeuristic: true}), []diff.Edit{{Old: 1, OldLines: 0, New: 1, NewLines: 2}})
+
+	a, b = lines("a b", "c ", "d\r"), lines("a  b", "c", "d")
+
+	utils.Expect(t, len(diff.Lines(a, b, diff.LineOptions{})), 1)
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{IgnoreSpaceAtEOL: true}), []diff.Edit{{Old: 0, OldLines: 1, New: 0, NewLines: 1}})
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{IgnoreCRAtEOL: true}), []diff.Edit{{Old: 0, OldLines: 2, New: 0, NewLines: 2}})
+	utils.Expect(t, diff.Lines(a, b, diff.LineOptions{IgnoreSpaceChange: true}), []diff.Edit{})
+	utils.Expect(t, diff.Lines(lines("ab"), lines("a b"), diff.LineOptions{IgnoreSpaceChange: true}), []diff.Edit{{Old: 0, OldLines: 1, New: 0, NewLines: 1}})
+	utils.Expect(t, diff.Lines(lines("ab"), lines("a b"), diff.LineOptions{IgnoreAllSpace: true}), []diff.Edit{})
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, name := range []string{"myers", "minimal", "patience", "histogram"} {
+		algorithm, err := diff.ParseAlgorithm(name)
+
+		utils.Expect(t, err, nil)
+		utils.Expect(t, algorithm.String(), name)
+	}
+
+	algorithm, err := diff.ParseAlgorithm("Default")
+
+	utils.Expect(t, err, nil)
+	utils.Expect(t, algorithm, diff.Myers)
+
+	_, err = diff.ParseAlgorithm("fast")
+	utils.Expect(t, err.Error(), "option diff-algorithm accepts \"myers\", \"minimal\", \"patience\" and \"histogram\"")
+}
@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+)
+
+// The status of a change with its similarity, like R086.
+func statusWithScore(c *Change) string {
+	if c.Score > 0 {
+		return fmt.Sprintf("%c%03d", c.Status, c.Similarity())
+	}
+
+	return string(c.Status)
+}
+
+// The paths of a change, tab separated: both for renames and copies.
+func changePaths(c *Change) string {
+	if c.Status == Renamed || c.Status == Copied {
+		return QuotePath(c.Old.Path) + "\t" + QuotePath(c.New.Path)
+	}
+
+	return QuotePath(c.Path())
+}
+
+// WriteRaw lists changes with the modes and object names of both sides,
+// like git diff --raw. Files of the working tree show a zero name.
+func WriteRaw(w io.Writer, changes []*Change, opts *Options) error {
+	for _, c := range changes {
+		oldHash, newHash := c.Old.Hash, c.New.Hash
+
+		if oldHash.IsZero() {
+			oldHash = newHash.Format().ZeroHash()
+		}
+
+		if newHash.IsZero() {
+			newHash = oldHash.Format().ZeroHash()
+		}
+
+		oldName, err := opts.abbrev(oldHash)
+
+		if err != nil {
+			return err
+		}
+
+		newName, err := opts.abbrev(newHash)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, ":%06o %06o %s %s %s\t%s\n", c.Old.Mode, c.New.Mode, oldName, newName, statusWithScore(c), changePaths(c))
+	}
+
+	return nil
+}
+
+// WriteNameStatus lists the status and paths of changes.
+func WriteNameStatus(w io.Writer, changes []*Change) {
+	for _, c := range changes {
+		fmt.Fprintf(w, "%s\t%s\n", statusWithScore(c), changePaths(c))
+	}
+}
+
+// WriteNameOnly lists the paths changed, the new ones of renames.
+func WriteNameOnly(w io.Writer, changes []*Change) {
+	for _, c := range changes {
+		fmt.Fprintln(w, QuotePath(c.Path()))
+	}
+}
+
+// WriteSummary tells of files created, deleted, renamed or copied, and of
+// mode changes.
+func WriteSummary(w io.Writer, changes []*Change) {
+	for _, c := range changes {
+		switch c.Status {
+		case Added:
+			fmt.Fprintf(w, " create mode %06o %s\n", c.New.Mode, QuotePath(c.New.Path))
+		case Deleted:
+			fmt.Fprintf(w, " delete mode %06o %s\n", c.Old.Mode, QuotePath(c.Old.Path))
+		case Renamed, Copied:
+			verb := "rename"
+
+			if c.Status == Copied {
+				verb = "copy"
+			}
+
+			fmt.Fprintf(w, " %s %s (%d%%)\n", verb, renameName(c.Old.Path, c.New.Path), c.Similarity())
+
+			if c.Old.Mode != c.New.Mode {
+				fmt.Fprintf(w, " mode change %06o => %06o\n", c.Old.Mode, c.New.Mode)
+			}
+		case Unmerged:
+		default:
+			if c.Old.Mode != c.New.Mode {
+				fmt.Fprintf(w, " mode change %06o => %06o %s\n", c.Old.Mode, c.New.Mode, QuotePath(c.New.Path))
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package diff
+
+// Histogram diff, as in xdiff: the lines of the old side are counted, and
+// the comparison split around the longest run of equal lines holding the
+// rarest of them, recursively. Lines are counted from one here, like in
+// xdiff, so that zero can stand for none. Where the rarest lines are too
+// common, it falls back to Myers.
+
+type histogram struct{}
+
+func (histogram) String() string {
+	return "histogram"
+}
+
+func (histogram) mark(old, new *side) {
+	histogramDiff(old, new, 1, len(old.records), 1, len(new.records))
+}
+
+// Lines occurring more often than this are not used to split at.
+const maxChainLength = 64
+
+// The occurrences of a record on the old side: the first of them, chained
+// on by next, and how many there are.
+type histogramRecord struct {
+	ptr int
+	cnt int
+}
+
+// A run of equal lines, [begin1, end1] on the old side and [begin2, end2]
+// on the new.
+type region struct {
+	begin1, end1 int
+	begin2, end2 int
+}
+
+type histogramIndex struct {
+	old, new *side
+
+	records map[int]*histogramRecord
+	lineMap []*histogramRecord
+	next    []int
+
+	// The first line of the range compared.
+	ptrShift int
+
+	// The fewest occurrences of the lines of the best region yet.
+	cnt       int
+	hasCommon bool
+}
+
+func (ix *histogramIndex) equal(line1, line2 int) bool {
+	return ix.old.records[line1-1] == ix.new.records[line2-1]
+}
+
+// Count the old lines of the range, from its end so that each chain of
+// occurrences starts with the first.
+func (ix *histogramIndex) scanA(line1, count1 int) {
+	for ptr := line1 + count1 - 1; ptr >= line1; ptr-- {
+		record := ix.old.records[ptr-1]
+		rec, ok := ix.records[record]
+
+		if ok {
+			ix.next[ptr-ix.ptrShift] = rec.ptr
+			rec.ptr = ptr
+			rec.cnt++
+		} else {
+			rec = &histogramRecord{ptr: ptr, cnt: 1}
+			ix.records[record] = rec
+		}
+
+		ix.lineMap[ptr-ix.ptrShift] = rec
+	}
+}
+
+// Grow runs of equal lines around each occurrence on the old side of the
+// new line bPtr, keeping the longest of the rarest in lcs. It returns the
+// next new line worth trying.
+func (ix *histogramIndex) tryLCS(lcs *region, bPtr, line1, count1, line2, count2 int) int {
+	bNext := bPtr + 1
+	rec, ok := ix.records[ix.new.records[bPtr-1]]
+
+	if !ok {
+		return bNext
+	}
+
+	if rec.cnt > ix.cnt {
+		ix.hasCommon = true
+
+		return bNext
+	}
+
+	as := rec.ptr
+	ix.hasCommon = true
+
+	for {
+		np := ix.next[as-ix.ptrShift]
+		bs := bPtr
+		ae, be := as, bs
+		rc := rec.cnt
+
+		for line1 < as && line2 < bs && ix.equal(as-1, bs-1) {
+			as, bs = as-1, bs-1
+
+			if 1 < rc {
+				rc = minInt(rc, ix.lineMap[as-ix.ptrShift].cnt)
+			}
+		}
+
+		for ae < line1+count1-1 && be < line2+count2-1 && ix.equal(ae+1, be+1) {
+			ae, be = ae+1, be+1
+
+			if 1 < rc {
+				rc = minInt(rc, ix.lineMap[ae-ix.ptrShift].cnt)
+			}
+		}
+
+		if bNext <= be {
+			bNext = be + 1
+		}
+
+		if lcs.end1-lcs.begin1 < ae-as || rc < ix.cnt {
+			*lcs = region{begin1: as, end1: ae, begin2: bs, end2: be}
+			ix.cnt = rc
+		}
+
+		if np == 0 {
+			return bNext
+		}
+
+		for np <= ae {
+			if np = ix.next[np-ix.ptrShift]; np == 0 {
+				return bNext
+			}
+		}
+
+		as = np
+	}
+}
+
+// Find the region to split a range at, returning whether to fall back to
+// Myers instead.
+func findLCS(old, new *side, lcs *region, line1, count1, line2, count2 int) bool {
+	ix := &histogramIndex{
+		old:      old,
+		new:      new,
+		records:  make(map[int]*histogramRecord),
+		lineMap:  make([]*histogramRecord, count1),
+		next:     make([]int, count1),
+		ptrShift: line1,
+	}
+
+	ix.scanA(line1, count1)
+	ix.cnt = maxChainLength + 1
+
+	for bPtr := line2; bPtr <= line2+count2-1; {
+		bPtr = ix.tryLCS(lcs, bPtr, line1, count1, line2, count2)
+	}
+
+	return ix.hasCommon && maxChainLength < ix.cnt
+}
+
+func histogramDiff(old, new *side, line1, count1, line2, count2 int) {
+	for {
+		if count1 <= 0 && count2 <= 0 {
+			return
+		}
+
+		if count1 == 0 {
+			new.markChanged(line2-1, count2)
+
+			return
+		}
+
+		if count2 == 0 {
+			old.markChanged(line1-1, count1)
+
+			return
+		}
+
+		var lcs region
+
+		if findLCS(old, new, &lcs, line1, count1, line2, count2) {
+			fallBack(old, new, line1-1, count1, line2-1, count2)
+
+			return
+		}
+
+		if lcs.begin1 == 0 && lcs.begin2 == 0 {
+			old.markChanged(line1-1, count1)
+			new.markChanged(line2-1, count2)
+
+			return
+		}
+
+		histogramDiff(old, new, line1, lcs.begin1-line1, line2, lcs.begin2-line2)
+
+		count1 = line1 + count1 - 1 - lcs.end1
+		line1 = lcs.end1 + 1
+		count2 = line2 + count2 - 1 - lcs.end2
+		line2 = lcs.end2 + 1
+	}
+}
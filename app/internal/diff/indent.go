@@ -0,0 +1,203 @@
+package diff
+
+// The indent heuristic of xdiff: of the places a group of changes can slide
+// to, pick the one whose first and last lines sit best with the lines
+// around them, judging by blank lines and indentation.
+
+const (
+	// How far up from its lowest place a group is tried.
+	indentMaxSliding = 100
+
+	// Indentation beyond this, and blank lines beyond this many, count as
+	// no more.
+	maxIndent = 200
+	maxBlanks = 20
+
+	startOfFilePenalty              = 1
+	endOfFilePenalty                = 21
+	totalBlankWeight                = -30
+	postBlankWeight                 = 6
+	relativeIndentPenalty           = -4
+	relativeIndentWithBlankPenalty  = 10
+	relativeOutdentPenalty          = 24
+	relativeOutdentWithBlankPenalty = 17
+	relativeDedentPenalty           = 23
+	relativeDedentWithBlankPenalty  = 17
+
+	// How much the indentation of a split outweighs its penalties.
+	indentWeight = 60
+)
+
+// The indentation of a line, tabs reaching the next multiple of eight, or
+// -1 for a line of only whitespace.
+func lineIndent(line []byte) int {
+	indent := 0
+
+	for _, c := range line {
+		if !isSpace(c) {
+			return indent
+		}
+
+		if c == ' ' {
+			indent++
+		} else if c == '\t' {
+			indent += 8 - indent%8
+		}
+
+		if indent >= maxIndent {
+			return maxIndent
+		}
+	}
+
+	return -1
+}
+
+// What surrounds a split just before a line.
+type splitMeasurement struct {
+	endOfFile bool
+
+	// The indentation of the line after the split, -1 if blank.
+	indent int
+
+	// The blank lines just before the split and the indentation of the
+	// line above them, -1 if there is none.
+	preBlank  int
+	preIndent int
+
+	// The blank lines after the line after the split, and the indentation
+	// of the line below them, -1 if there is none.
+	postBlank  int
+	postIndent int
+}
+
+func measureSplit(s *side, split int) splitMeasurement {
+	m := splitMeasurement{indent: -1, preIndent: -1, postIndent: -1}
+
+	if split >= len(s.lines) {
+		m.endOfFile = true
+	} else {
+		m.indent = lineIndent(s.lines[split])
+	}
+
+	for i := split - 1; i >= 0; i-- {
+		if m.preIndent = lineIndent(s.lines[i]); m.preIndent != -1 {
+			break
+		}
+
+		if m.preBlank++; m.preBlank == maxBlanks {
+			m.preIndent = 0
+
+			break
+		}
+	}
+
+	for i := split + 1; i < len(s.lines); i++ {
+		if m.postIndent = lineIndent(s.lines[i]); m.postIndent != -1 {
+			break
+		}
+
+		if m.postBlank++; m.postBlank == maxBlanks {
+			m.postIndent = 0
+
+			break
+		}
+	}
+
+	return m
+}
+
+// The score of the splits around a group: lower is better.
+type splitScore struct {
+	effectiveIndent int
+	penalty         int
+}
+
+func (s *splitScore) add(m splitMeasurement) {
+	if m.preIndent == -1 && m.preBlank == 0 {
+		s.penalty += startOfFilePenalty
+	}
+
+	if m.endOfFile {
+		s.penalty += endOfFilePenalty
+	}
+
+	postBlank := 0
+
+	if m.indent == -1 {
+		postBlank = 1 + m.postBlank
+	}
+
+	totalBlank := m.preBlank + postBlank
+	s.penalty += totalBlankWeight*totalBlank + postBlankWeight*postBlank
+
+	indent := m.indent
+
+	if indent == -1 {
+		indent = m.postIndent
+	}
+
+	anyBlanks := totalBlank != 0
+	s.effectiveIndent += indent
+
+	switch {
+	case indent == -1 || m.preIndent == -1 || indent == m.preIndent:
+	case indent > m.preIndent:
+		s.penalty += pickPenalty(anyBlanks, relativeIndentWithBlankPenalty, relativeIndentPenalty)
+	case m.postIndent != -1 && m.postIndent > indent:
+		s.penalty += pickPenalty(anyBlanks, relativeOutdentWithBlankPenalty, relativeOutdentPenalty)
+	default:
+		s.penalty += pickPenalty(anyBlanks, relativeDedentWithBlankPenalty, relativeDedentPenalty)
+	}
+}
+
+func pickPenalty(anyBlanks bool, withBlank, without int) int {
+	if anyBlanks {
+		return withBlank
+	}
+
+	return without
+}
+
+// Below zero if s is better than t, zero if they are as good.
+func (s splitScore) compare(t splitScore) int {
+	cmp := 0
+
+	if s.effectiveIndent > t.effectiveIndent {
+		cmp = 1
+	} else if s.effectiveIndent < t.effectiveIndent {
+		cmp = -1
+	}
+
+	return indentWeight*cmp + s.penalty - t.penalty
+}
+
+// Where a group of size lines ending at end, which can slide up until it
+// ends at earliestEnd, is best ended. Of equally good places the lowest
+// wins.
+func bestShift(s *side, end, earliestEnd, size int) int {
+	shift := earliestEnd
+
+	if end-size-1 > shift {
+		shift = end - size - 1
+	}
+
+	if end-indentMaxSliding > shift {
+		shift = end - indentMaxSliding
+	}
+
+	best := -1
+	var bestScore splitScore
+
+	for ; shift <= end; shift++ {
+		var score splitScore
+
+		score.add(measureSplit(s, shift))
+		score.add(measureSplit(s, shift-size))
+
+		if best == -1 || score.compare(bestScore) <= 0 {
+			best, bestScore = shift, score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,289 @@
+package diff
+
+import "bytes"
+
+// Edit replaces a run of lines of the old side by a run of the new one,
+// either possibly empty. Lines are counted from zero.
+type Edit struct {
+	Old      int
+	OldLines int
+	New      int
+	NewLines int
+}
+
+// SplitLines splits content into lines, each keeping its newline. The
+// last line may lack one.
+func SplitLines(data []byte) [][]byte {
+	lines := [][]byte{}
+
+	for len(data) > 0 {
+		n := bytes.IndexByte(data, '\n') + 1
+
+		if n == 0 {
+			n = len(data)
+		}
+
+		lines = append(lines, data[:n])
+		data = data[n:]
+	}
+
+	return lines
+}
+
+// LineOptions tells how lines are compared.
+type LineOptions struct {
+	// The algorithm finding the lines changed; Myers unless set.
+	Algorithm Algorithm
+
+	// Slide groups of changes to where the indentation of the lines around
+	// them makes them easiest to read.
+	IndentHeuristic bool
+
+	// Ignore whitespace when comparing lines: all of it, changes in its
+	// amount, that at the end of lines, or only a carriage return before
+	// the newline.
+	IgnoreAllSpace    bool
+	IgnoreSpaceChange bool
+	IgnoreSpaceAtEOL  bool
+	IgnoreCRAtEOL     bool
+}
+
+func (o *LineOptions) ignoresWhitespace() bool {
+	return o.IgnoreAllSpace || o.IgnoreSpaceChange || o.IgnoreSpaceAtEOL || o.IgnoreCRAtEOL
+}
+
+// Lines compares two lists of lines into the edits turning one into the
+// other, for diffs, blame and merges alike.
+func Lines(a, b [][]byte, opts LineOptions) []Edit {
+	old, new := newSides(a, b, &opts)
+	algorithm := opts.Algorithm
+
+	if algorithm == nil {
+		algorithm = Myers
+	}
+
+	algorithm.mark(old, new)
+	compact(old, new, opts.IndentHeuristic)
+	compact(new, old, opts.IndentHeuristic)
+
+	return buildEdits(old, new)
+}
+
+// A side of a comparison: its lines, their records as numbers equal for
+// lines compared equal, and which of them changed.
+type side struct {
+	lines   [][]byte
+	records []int
+	changed []bool
+}
+
+func (s *side) isChanged(i int) bool {
+	return i >= 0 && i < len(s.records) && s.changed[i]
+}
+
+// Number the lines of both sides so that lines compared equal get equal
+// numbers, in the order they first appear.
+func newSides(a, b [][]byte, opts *LineOptions) (*side, *side) {
+	ids := make(map[string]int)
+	number := func(lines [][]byte) *side {
+		s := &side{lines: lines, records: make([]int, len(lines)), changed: make([]bool, len(lines))}
+
+		for i, line := range lines {
+			key := lineKey(line, opts)
+			id, ok := ids[key]
+
+			if !ok {
+				id = len(ids)
+				ids[key] = id
+			}
+
+			s.records[i] = id
+		}
+
+		return s
+	}
+
+	return number(a), number(b)
+}
+
+// A part of a side, to be compared on its own.
+func (s *side) slice(start, count int) *side {
+	return &side{
+		lines:   s.lines[start : start+count],
+		records: s.records[start : start+count],
+		changed: make([]bool, count),
+	}
+}
+
+// Mark every record of a part of a side changed.
+func (s *side) markChanged(start, count int) {
+	for i := start; i < start+count; i++ {
+		s.changed[i] = true
+	}
+}
+
+// Collect the runs of changed records of both sides into edits.
+func buildEdits(old, new *side) []Edit {
+	edits := []Edit{}
+	i, j := 0, 0
+
+	for i < len(old.records) || j < len(new.records) {
+		if !old.isChanged(i) && !new.isChanged(j) {
+			i, j = i+1, j+1
+
+			continue
+		}
+
+		e := Edit{Old: i, New: j}
+
+		for old.isChanged(i) {
+			i++
+		}
+
+		for new.isChanged(j) {
+			j++
+		}
+
+		e.OldLines, e.NewLines = i-e.Old, j-e.New
+		edits = append(edits, e)
+	}
+
+	return edits
+}
+
+// Slide the groups of changed records of a side as far down as they go,
+// merging groups that meet, then back up to line up with a group of
+// changes of the other side where one can, or else to where the indent
+// heuristic likes them best, like xdiff does.
+func compact(s, other *side, indentHeuristic bool) {
+	g := newGroup(s)
+	og := newGroup(other)
+
+	for {
+		if g.end != g.start {
+			var earliestEnd, size int
+			endMatchingOther := -1
+
+			for {
+				size = g.end - g.start
+
+				for g.slideUp(s) {
+					og.previous(other)
+				}
+
+				earliestEnd = g.end
+				endMatchingOther = -1
+
+				if og.end > og.start {
+					endMatchingOther = g.end
+				}
+
+				for g.slideDown(s) {
+					og.next(other)
+
+					if og.end > og.start {
+						endMatchingOther = g.end
+					}
+				}
+
+				if size == g.end-g.start {
+					break
+				}
+			}
+
+			if g.end == earliestEnd {
+				// The group cannot slide.
+			} else if endMatchingOther != -1 {
+				for og.end == og.start {
+					g.slideUp(s)
+					og.previous(other)
+				}
+			} else if indentHeuristic {
+				shift := bestShift(s, g.end, earliestEnd, size)
+
+				for g.end > shift {
+					g.slideUp(s)
+					og.previous(other)
+				}
+			}
+		}
+
+		if !g.next(s) {
+			break
+		}
+
+		og.next(other)
+	}
+}
+
+// A group of changed records, [start, end), or the empty group between
+// two unchanged records.
+type group struct {
+	start, end int
+}
+
+func newGroup(s *side) *group {
+	g := &group{}
+
+	for s.isChanged(g.end) {
+		g.end++
+	}
+
+	return g
+}
+
+func (g *group) next(s *side) bool {
+	if g.end == len(s.records) {
+		return false
+	}
+
+	g.start = g.end + 1
+
+	for g.end = g.start; s.isChanged(g.end); g.end++ {
+	}
+
+	return true
+}
+
+func (g *group) previous(s *side) bool {
+	if g.start == 0 {
+		return false
+	}
+
+	g.end = g.start - 1
+
+	for g.start = g.end; s.isChanged(g.start - 1); g.start-- {
+	}
+
+	return true
+}
+
+func (g *group) slideDown(s *side) bool {
+	if g.end >= len(s.records) || s.records[g.start] != s.records[g.end] {
+		return false
+	}
+
+	s.changed[g.start], s.changed[g.end] = false, true
+	g.start, g.end = g.start+1, g.end+1
+
+	for s.isChanged(g.end) {
+		g.end++
+	}
+
+	return true
+}
+
+func (g *group) slideUp(s *side) bool {
+	if g.start == 0 || s.records[g.start-1] != s.records[g.end-1] {
+		return false
+	}
+
+	g.start, g.end = g.start-1, g.end-1
+	s.changed[g.start], s.changed[g.end] = true, false
+
+	for s.isChanged(g.start - 1) {
+		g.start--
+	}
+
+	return true
+}
@@ -0,0 +1,443 @@
+package diff
+
+// Myers' algorithm as xdiff has it: the common head and tail set aside,
+// lines without a match on the other side discarded, and the rest split in
+// the middle of their edit script recursively, in linear space. Unless a
+// minimal script is asked for, costly comparisons settle for a good split
+// rather than the best one.
+
+const (
+	// Lines matching more lines than this, or than the square root of the
+	// lines of the other side, may be discarded.
+	maxEqualLimit = 1024
+
+	// How far around a line to look for other lines without a match.
+	simScanWindow = 100
+
+	// How many lines without a match there need to be for each line of
+	// many matches for the latter to be discarded too.
+	keepDiscardedRun = 4
+
+	// A snake this long is good enough to split at, once the cost passes
+	// heurMinCost.
+	snakeCount  = 20
+	heurMinCost = 256
+	heurFactor  = 4
+
+	// The least cost before giving up on the best split.
+	maxCostMin = 256
+
+	maxLine = int(^uint(0) >> 1)
+)
+
+type myers struct {
+	minimal bool
+}
+
+func (m myers) String() string {
+	if m.minimal {
+		return "minimal"
+	}
+
+	return "myers"
+}
+
+// A rough square root: the power of two with half the bits of n.
+func bogoSqrt(n int) int {
+	i := 1
+
+	for ; n > 0; n >>= 2 {
+		i <<= 1
+	}
+
+	return i
+}
+
+// The records of a side left to compare, and which records they are.
+type myersSide struct {
+	side    *side
+	records []int
+	index   []int
+}
+
+func (m myers) mark(old, new *side) {
+	a, b := old.records, new.records
+	start := 0
+
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+
+	tail := 0
+
+	for tail < len(a)-start && tail < len(b)-start && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+
+	counts := make(map[int][2]int)
+
+	for _, r := range a {
+		c := counts[r]
+		c[0]++
+		counts[r] = c
+	}
+
+	for _, r := range b {
+		c := counts[r]
+		c[1]++
+		counts[r] = c
+	}
+
+	s1 := m.discard(old, start, len(a)-tail, counts, 1)
+	s2 := m.discard(new, start, len(b)-tail, counts, 0)
+
+	e := &myersEnv{s1: s1, s2: s2, offset: len(s2.records) + 1}
+	diagonals := len(s1.records) + len(s2.records) + 3
+	e.forward = make([]int, diagonals)
+	e.backward = make([]int, diagonals)
+	e.maxCost = bogoSqrt(diagonals)
+
+	if e.maxCost < maxCostMin {
+		e.maxCost = maxCostMin
+	}
+
+	e.compare(0, len(s1.records), 0, len(s2.records), m.minimal)
+}
+
+// Mark changed the lines of [start, end) of a side without a match on the
+// other one, and lines of many matches among them, keeping the rest to be
+// compared. The other side is the one counted at counts[r][other].
+func (m myers) discard(s *side, start, end int, counts map[int][2]int, other int) *myersSide {
+	limit := bogoSqrt(len(s.records))
+
+	if limit > maxEqualLimit {
+		limit = maxEqualLimit
+	}
+
+	discards := make([]byte, len(s.records))
+
+	for i := start; i < end; i++ {
+		n := counts[s.records[i]][other]
+
+		switch {
+		case n == 0:
+			discards[i] = 0
+		case n >= limit && !m.minimal:
+			discards[i] = 2
+		default:
+			discards[i] = 1
+		}
+	}
+
+	kept := &myersSide{side: s}
+
+	for i := start; i < end; i++ {
+		if discards[i] == 1 || discards[i] == 2 && keepAmongMatched(discards, i, start, end-1) {
+			kept.index = append(kept.index, i)
+			kept.records = append(kept.records, s.records[i])
+		} else {
+			s.changed[i] = true
+		}
+	}
+
+	return kept
+}
+
+// Whether a line of many matches is to be kept: unless it sits among many
+// more lines without a match, within [s, e].
+func keepAmongMatched(discards []byte, i, s, e int) bool {
+	if i-s > simScanWindow {
+		s = i - simScanWindow
+	}
+
+	if e-i > simScanWindow {
+		e = i + simScanWindow
+	}
+
+	before, manyBefore := 0, 1
+
+	for r := 1; i-r >= s; r++ {
+		if discards[i-r] == 0 {
+			before++
+		} else if discards[i-r] == 2 {
+			manyBefore++
+		} else {
+			break
+		}
+	}
+
+	if before == 0 {
+		return true
+	}
+
+	after, manyAfter := 0, 1
+
+	for r := 1; i+r <= e; r++ {
+		if discards[i+r] == 0 {
+			after++
+		} else if discards[i+r] == 2 {
+			manyAfter++
+		} else {
+			break
+		}
+	}
+
+	if after == 0 {
+		return true
+	}
+
+	without, many := before+after, manyBefore+manyAfter
+
+	return many*keepDiscardedRun >= many+without
+}
+
+type myersEnv struct {
+	s1, s2 *myersSide
+
+	// The furthest reaching paths forward and backward by diagonal, at
+	// offset.
+	forward, backward []int
+	offset            int
+
+	maxCost int
+}
+
+// Compare the kept records [off1, lim1) and [off2, lim2), marking those
+// changed.
+func (e *myersEnv) compare(off1, lim1, off2, lim2 int, minimal bool) {
+	a, b := e.s1.records, e.s2.records
+
+	for off1 < lim1 && off2 < lim2 && a[off1] == b[off2] {
+		off1, off2 = off1+1, off2+1
+	}
+
+	for off1 < lim1 && off2 < lim2 && a[lim1-1] == b[lim2-1] {
+		lim1, lim2 = lim1-1, lim2-1
+	}
+
+	switch {
+	case off1 == lim1:
+		for ; off2 < lim2; off2++ {
+			e.s2.side.changed[e.s2.index[off2]] = true
+		}
+	case off2 == lim2:
+		for ; off1 < lim1; off1++ {
+			e.s1.side.changed[e.s1.index[off1]] = true
+		}
+	default:
+		spl := e.split(off1, lim1, off2, lim2, minimal)
+
+		e.compare(off1, spl.i1, off2, spl.i2, spl.minLow)
+		e.compare(spl.i1, lim1, spl.i2, lim2, spl.minHigh)
+	}
+}
+
+// Where to split a comparison, and whether either half needs its
+// shortest edit script.
+type myersSplit struct {
+	i1, i2          int
+	minLow, minHigh bool
+}
+
+// Find the middle snake of the edit script between [off1, lim1) and
+// [off2, lim2), searching from both ends at once.
+func (e *myersEnv) split(off1, lim1, off2, lim2 int, minimal bool) myersSplit {
+	a, b := e.s1.records, e.s2.records
+	kf, kb, o := e.forward, e.backward, e.offset
+
+	dmin, dmax := off1-lim2, lim1-off2
+	fmid, bmid := off1-off2, lim1-lim2
+	odd := (fmid-bmid)&1 != 0
+	fmin, fmax := fmid, fmid
+	bmin, bmax := bmid, bmid
+
+	kf[o+fmid] = off1
+	kb[o+bmid] = lim1
+
+	for cost := 1; ; cost++ {
+		gotSnake := false
+
+		if fmin > dmin {
+			fmin--
+			kf[o+fmin-1] = -1
+		} else {
+			fmin++
+		}
+
+		if fmax < dmax {
+			fmax++
+			kf[o+fmax+1] = -1
+		} else {
+			fmax--
+		}
+
+		for d := fmax; d >= fmin; d -= 2 {
+			var i1 int
+
+			if kf[o+d-1] >= kf[o+d+1] {
+				i1 = kf[o+d-1] + 1
+			} else {
+				i1 = kf[o+d+1]
+			}
+
+			prev := i1
+			i2 := i1 - d
+
+			for i1 < lim1 && i2 < lim2 && a[i1] == b[i2] {
+				i1, i2 = i1+1, i2+1
+			}
+
+			if i1-prev > snakeCount {
+				gotSnake = true
+			}
+
+			kf[o+d] = i1
+
+			if odd && bmin <= d && d <= bmax && kb[o+d] <= i1 {
+				return myersSplit{i1: i1, i2: i2, minLow: true, minHigh: true}
+			}
+		}
+
+		if bmin > dmin {
+			bmin--
+			kb[o+bmin-1] = maxLine
+		} else {
+			bmin++
+		}
+
+		if bmax < dmax {
+			bmax++
+			kb[o+bmax+1] = maxLine
+		} else {
+			bmax--
+		}
+
+		for d := bmax; d >= bmin; d -= 2 {
+			var i1 int
+
+			if kb[o+d-1] < kb[o+d+1] {
+				i1 = kb[o+d-1]
+			} else {
+				i1 = kb[o+d+1] - 1
+			}
+
+			prev := i1
+			i2 := i1 - d
+
+			for i1 > off1 && i2 > off2 && a[i1-1] == b[i2-1] {
+				i1, i2 = i1-1, i2-1
+			}
+
+			if prev-i1 > snakeCount {
+				gotSnake = true
+			}
+
+			kb[o+d] = i1
+
+			if !odd && fmin <= d && d <= fmax && i1 <= kf[o+d] {
+				return myersSplit{i1: i1, i2: i2, minLow: true, minHigh: true}
+			}
+		}
+
+		if minimal {
+			continue
+		}
+
+		// Past a certain cost, a long enough snake will do.
+		if gotSnake && cost > heurMinCost {
+			best := 0
+			var spl myersSplit
+
+			for d := fmax; d >= fmin; d -= 2 {
+				dd := absInt(d - fmid)
+				i1 := kf[o+d]
+				i2 := i1 - d
+				v := (i1 - off1) + (i2 - off2) - dd
+
+				if v > heurFactor*cost && v > best && off1+snakeCount <= i1 && i1 < lim1 && off2+snakeCount <= i2 && i2 < lim2 {
+					for k := 1; a[i1-k] == b[i2-k]; k++ {
+						if k == snakeCount {
+							best = v
+							spl = myersSplit{i1: i1, i2: i2, minLow: true}
+
+							break
+						}
+					}
+				}
+			}
+
+			if best > 0 {
+				return spl
+			}
+
+			for d := bmax; d >= bmin; d -= 2 {
+				dd := absInt(d - bmid)
+				i1 := kb[o+d]
+				i2 := i1 - d
+				v := (lim1 - i1) + (lim2 - i2) - dd
+
+				if v > heurFactor*cost && v > best && off1 < i1 && i1 <= lim1-snakeCount && off2 < i2 && i2 <= lim2-snakeCount {
+					for k := 0; a[i1+k] == b[i2+k]; k++ {
+						if k == snakeCount-1 {
+							best = v
+							spl = myersSplit{i1: i1, i2: i2, minHigh: true}
+
+							break
+						}
+					}
+				}
+			}
+
+			if best > 0 {
+				return spl
+			}
+		}
+
+		// Too costly: split where either search got furthest.
+		if cost >= e.maxCost {
+			fbest, fbest1 := -1, -1
+
+			for d := fmax; d >= fmin; d -= 2 {
+				i1 := minInt(kf[o+d], lim1)
+				i2 := i1 - d
+
+				if lim2 < i2 {
+					i1, i2 = lim2+d, lim2
+				}
+
+				if fbest < i1+i2 {
+					fbest, fbest1 = i1+i2, i1
+				}
+			}
+
+			bbest, bbest1 := maxLine, maxLine
+
+			for d := bmax; d >= bmin; d -= 2 {
+				i1 := maxInt(off1, kb[o+d])
+				i2 := i1 - d
+
+				if i2 < off2 {
+					i1, i2 = off2+d, off2
+				}
+
+				if i1+i2 < bbest {
+					bbest, bbest1 = i1+i2, i1
+				}
+			}
+
+			if (lim1+lim2)-bbest < fbest-(off1+off2) {
+				return myersSplit{i1: fbest1, i2: fbest - fbest1, minLow: true}
+			}
+
+			return myersSplit{i1: bbest1, i2: bbest - bbest1, minHigh: true}
+		}
+	}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
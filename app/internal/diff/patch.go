@@ -0,0 +1,528 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Options tells how changes are shown.
+type Options struct {
+	// Lines of context shown around changes, and up to how many more lines
+	// between two hunks merge them.
+	Context          int
+	InterHunkContext int
+
+	// The prefixes of the old and new paths of patches, like a/ and b/.
+	SrcPrefix string
+	DstPrefix string
+
+	// Abbrev shortens object names. Without it they are shown whole.
+	Abbrev func(plumbing.Hash) (string, error)
+
+	// WordDiff shows the words changed within lines rather than whole
+	// lines, as "plain" text or in a "porcelain" format for scripts.
+	WordDiff string
+
+	// How lines are compared.
+	Lines LineOptions
+
+	// Leave out changes of only blank lines, unless they are close to
+	// other changes.
+	IgnoreBlankLines bool
+
+	// Compare binary files line by line too, rather than only telling
+	// that they differ.
+	Text bool
+
+	Stat StatOptions
+}
+
+func (o *Options) abbrev(hash plumbing.Hash) (string, error) {
+	if o.Abbrev == nil {
+		return hash.String(), nil
+	}
+
+	return o.Abbrev(hash)
+}
+
+// The longest function name shown in hunk headers.
+const maxFuncName = 80
+
+// WritePatch shows changes as a unified diff, like git diff -p.
+func WritePatch(w io.Writer, changes []*Change, opts *Options) error {
+	out := bufio.NewWriter(w)
+
+	for _, c := range changes {
+		if err := writeFilePatch(out, c, opts); err != nil {
+			return err
+		}
+	}
+
+	return out.Flush()
+}
+
+func writeFilePatch(w *bufio.Writer, c *Change, opts *Options) error {
+	switch c.Status {
+	case Unmerged:
+		fmt.Fprintf(w, "* Unmerged path %s\n", c.New.Path)
+
+		return nil
+	case TypeChanged:
+		// Files and symbolic links are not compared: one is deleted and the
+		// other added.
+		if err := writeFilePatch(w, &Change{Status: Deleted, Old: c.Old, New: &File{Path: c.New.Path}}, opts); err != nil {
+			return err
+		}
+
+		return writeFilePatch(w, &Change{Status: Added, Old: &File{Path: c.Old.Path}, New: c.New}, opts)
+	}
+
+	oldName := quotePrefixed(opts.SrcPrefix, c.Old.Path)
+	newName := quotePrefixed(opts.DstPrefix, c.New.Path)
+
+	header, mustShow, err := patchHeader(c, oldName, newName, opts)
+
+	if err != nil {
+		return err
+	}
+
+	d, err := compareFile(c, opts)
+
+	if err != nil {
+		return err
+	}
+
+	if !c.Old.Exists() {
+		oldName = "/dev/null"
+	}
+
+	if !c.New.Exists() {
+		newName = "/dev/null"
+	}
+
+	if d.binary {
+		if d.same {
+			if mustShow {
+				w.WriteString(header)
+			}
+
+			return nil
+		}
+
+		w.WriteString(header)
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+
+		return nil
+	}
+
+	if len(d.hunks) == 0 {
+		if mustShow {
+			w.WriteString(header)
+		}
+
+		return nil
+	}
+
+	w.WriteString(header)
+	fmt.Fprintf(w, "--- %s%s\n", oldName, labelTab(oldName))
+	fmt.Fprintf(w, "+++ %s%s\n", newName, labelTab(newName))
+
+	var sink hunkSink = &plainSink{w: w}
+
+	if opts.WordDiff == "plain" || opts.WordDiff == "porcelain" {
+		sink = newWordSink(w, opts.WordDiff)
+	}
+
+	writeHunks(sink, d, opts)
+	sink.flush()
+
+	return nil
+}
+
+// HasChanges tells whether there are changes. When whitespace or blank
+// lines are ignored, only changes whose patches show lines or binary files
+// differing count, like for git diff --exit-code.
+func HasChanges(changes []*Change, opts *Options) (bool, error) {
+	if !opts.Lines.ignoresWhitespace() && !opts.IgnoreBlankLines {
+		return len(changes) > 0, nil
+	}
+
+	for _, c := range changes {
+		if c.Status == Unmerged || c.Status == TypeChanged {
+			return true, nil
+		}
+
+		d, err := compareFile(c, opts)
+
+		if err != nil {
+			return false, err
+		}
+
+		if d.binary && !d.same || len(d.hunks) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// A file compared: whether it is binary, and if so whether both sides are
+// the same, or else the lines of both sides and the hunks of edits between
+// them.
+type fileDiff struct {
+	binary bool
+	same   bool
+
+	oldSize, newSize int
+
+	oldLines, newLines [][]byte
+	hunks              [][]Edit
+}
+
+func compareFile(c *Change, opts *Options) (*fileDiff, error) {
+	oldData, err := c.Old.Content()
+
+	if err != nil {
+		return nil, err
+	}
+
+	newData, err := c.New.Content()
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := &fileDiff{oldSize: len(oldData), newSize: len(newData)}
+
+	if !opts.Text && (IsBinary(oldData) || IsBinary(newData)) {
+		d.binary = true
+		d.same = bytes.Equal(oldData, newData)
+
+		return d, nil
+	}
+
+	d.oldLines, d.newLines = SplitLines(oldData), SplitLines(newData)
+	d.hunks = groupHunks(Lines(d.oldLines, d.newLines, opts.Lines), d.oldLines, d.newLines, opts)
+
+	return d, nil
+}
+
+// Labels with spaces end in a tab, for patch to tell where they end.
+func labelTab(label string) string {
+	if strings.Contains(label, " ") {
+		return "\t"
+	}
+
+	return ""
+}
+
+// The extended header of a file's patch, and whether it has to be shown
+// even if the contents are the same.
+func patchHeader(c *Change, oldName, newName string, opts *Options) (string, bool, error) {
+	var b strings.Builder
+	mustShow := true
+
+	fmt.Fprintf(&b, "diff --git %s %s\n", oldName, newName)
+
+	switch {
+	case !c.Old.Exists():
+		fmt.Fprintf(&b, "new file mode %06o\n", c.New.Mode)
+	case !c.New.Exists():
+		fmt.Fprintf(&b, "deleted file mode %06o\n", c.Old.Mode)
+	case c.Old.Mode != c.New.Mode:
+		fmt.Fprintf(&b, "old mode %06o\nnew mode %06o\n", c.Old.Mode, c.New.Mode)
+	default:
+		mustShow = false
+	}
+
+	switch c.Status {
+	case Copied:
+		fmt.Fprintf(&b, "similarity index %d%%\ncopy from %s\ncopy to %s\n", c.Similarity(), QuotePath(c.Old.Path), QuotePath(c.New.Path))
+		mustShow = true
+	case Renamed:
+		fmt.Fprintf(&b, "similarity index %d%%\nrename from %s\nrename to %s\n", c.Similarity(), QuotePath(c.Old.Path), QuotePath(c.New.Path))
+		mustShow = true
+	}
+
+	oldHash, err := c.Old.ObjectHash()
+
+	if err != nil {
+		return "", false, err
+	}
+
+	newHash, err := c.New.ObjectHash()
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if oldHash.IsZero() {
+		oldHash = newHash.Format().ZeroHash()
+	}
+
+	if newHash.IsZero() {
+		newHash = oldHash.Format().ZeroHash()
+	}
+
+	if oldHash != newHash {
+		oldName, err := opts.abbrev(oldHash)
+
+		if err != nil {
+			return "", false, err
+		}
+
+		newName, err := opts.abbrev(newHash)
+
+		if err != nil {
+			return "", false, err
+		}
+
+		fmt.Fprintf(&b, "index %s..%s", oldName, newName)
+
+		if c.Old.Mode == c.New.Mode {
+			fmt.Fprintf(&b, " %06o", c.Old.Mode)
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String(), mustShow, nil
+}
+
+// Where the lines of hunks go: straight out, or to be compared word by
+// word.
+type hunkSink interface {
+	header(text string)
+	line(prefix byte, text []byte)
+	flush()
+}
+
+type plainSink struct {
+	w *bufio.Writer
+}
+
+func (s *plainSink) header(text string) {
+	s.w.WriteString(text)
+}
+
+func (s *plainSink) line(prefix byte, text []byte) {
+	s.w.WriteByte(prefix)
+	s.w.Write(text)
+
+	if len(text) > 0 && text[len(text)-1] != '\n' {
+		s.w.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func (s *plainSink) flush() {}
+
+// Group edits into hunks: edits with no more than twice the context lines
+// and the extra inter-hunk context between them go together. Edits of only
+// blank lines, when those are ignored, are left out unless they are within
+// the context of other edits, like xdiff does.
+func groupHunks(edits []Edit, oldLines, newLines [][]byte, opts *Options) [][]Edit {
+	ignorable := make([]bool, len(edits))
+
+	if opts.IgnoreBlankLines {
+		for i, e := range edits {
+			ignorable[i] = blankEdit(e, oldLines, newLines, &opts.Lines)
+		}
+	}
+
+	maxCommon := 2*opts.Context + opts.InterHunkContext
+	maxIgnorable := opts.Context
+	gap := func(i int) int {
+		return edits[i].Old - (edits[i-1].Old + edits[i-1].OldLines)
+	}
+
+	hunks := [][]Edit{}
+
+	for first := 0; first < len(edits); {
+		for i := first; i < len(edits) && ignorable[i]; i++ {
+			if i+1 == len(edits) || gap(i+1) >= maxIgnorable {
+				first = i + 1
+			}
+		}
+
+		if first == len(edits) {
+			break
+		}
+
+		last, ignored := first, 0
+
+		for i := first + 1; i < len(edits); i++ {
+			distance := gap(i)
+
+			if distance > maxCommon {
+				break
+			}
+
+			if distance < maxIgnorable && (!ignorable[i] || last == i-1) {
+				last, ignored = i, 0
+			} else if distance < maxIgnorable {
+				ignored += edits[i].NewLines
+			} else if last != i-1 && edits[i].Old+ignored-(edits[last].Old+edits[last].OldLines) > maxCommon {
+				break
+			} else if !ignorable[i] {
+				last, ignored = i, 0
+			} else {
+				ignored += edits[i].NewLines
+			}
+		}
+
+		hunks = append(hunks, edits[first:last+1])
+		first = last + 1
+	}
+
+	return hunks
+}
+
+// Whether an edit only deletes and adds blank lines.
+func blankEdit(e Edit, oldLines, newLines [][]byte, opts *LineOptions) bool {
+	for i := e.Old; i < e.Old+e.OldLines; i++ {
+		if !isBlankLine(oldLines[i], opts) {
+			return false
+		}
+	}
+
+	for j := e.New; j < e.New+e.NewLines; j++ {
+		if !isBlankLine(newLines[j], opts) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Write the hunks of a file with context, headed by the line numbers they
+// cover and the last function name above them.
+func writeHunks(sink hunkSink, d *fileDiff, opts *Options) {
+	oldLines, newLines := d.oldLines, d.newLines
+	funcName := ""
+	funcSearched := -1
+
+	for _, edits := range d.hunks {
+		start, end := edits[0], edits[len(edits)-1]
+
+		s1 := maxInt(start.Old-opts.Context, 0)
+		s2 := maxInt(start.New-opts.Context, 0)
+
+		after := opts.Context
+		after = minInt(after, len(oldLines)-(end.Old+end.OldLines))
+		after = minInt(after, len(newLines)-(end.New+end.NewLines))
+
+		e1 := end.Old + end.OldLines + after
+		e2 := end.New + end.NewLines + after
+
+		for i := s1 - 1; i > funcSearched && i >= 0; i-- {
+			if name, ok := functionName(oldLines[i]); ok {
+				funcName = name
+
+				break
+			}
+		}
+
+		funcSearched = s1 - 1
+
+		sink.header(hunkHeader(s1+1, e1-s1, s2+1, e2-s2, funcName))
+
+		for ; s2 < start.New; s2++ {
+			sink.line(' ', newLines[s2])
+		}
+
+		for k, e := range edits {
+			if k > 0 {
+				prev := edits[k-1]
+
+				for j := prev.New + prev.NewLines; j < e.New; j++ {
+					sink.line(' ', newLines[j])
+				}
+			}
+
+			for i := e.Old; i < e.Old+e.OldLines; i++ {
+				sink.line('-', oldLines[i])
+			}
+
+			for j := e.New; j < e.New+e.NewLines; j++ {
+				sink.line('+', newLines[j])
+			}
+		}
+
+		for j := end.New + end.NewLines; j < e2; j++ {
+			sink.line(' ', newLines[j])
+		}
+	}
+}
+
+// The "@@ -<start>,<count> +<start>,<count> @@" header of a hunk. Counts
+// of one are left out, and empty ranges start at the line before.
+func hunkHeader(s1, c1, s2, c2 int, funcName string) string {
+	var b strings.Builder
+
+	b.WriteString("@@ -")
+	writeRange(&b, s1, c1)
+	b.WriteString(" +")
+	writeRange(&b, s2, c2)
+	b.WriteString(" @@")
+
+	if funcName != "" {
+		b.WriteString(" " + funcName)
+	}
+
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func writeRange(b *strings.Builder, start, count int) {
+	if count == 0 {
+		start--
+	}
+
+	fmt.Fprintf(b, "%d", start)
+
+	if count != 1 {
+		fmt.Fprintf(b, ",%d", count)
+	}
+}
+
+// Lines starting with a letter, '_' or '$' name the function following
+// them, as far as hunk headers go.
+func functionName(line []byte) (string, bool) {
+	if len(line) == 0 {
+		return "", false
+	}
+
+	c := line[0]
+
+	if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$') {
+		return "", false
+	}
+
+	if len(line) > maxFuncName {
+		line = line[:maxFuncName]
+	}
+
+	return strings.TrimRight(string(line), " \t\n\r"), true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,195 @@
+package diff
+
+// Patience diff, as in xdiff: the lines that appear exactly once on both
+// sides are lined up along their longest common subsequence, and the gaps
+// between them compared the same way, falling back to Myers where no such
+// lines are left.
+
+type patience struct{}
+
+func (patience) String() string {
+	return "patience"
+}
+
+func (patience) mark(old, new *side) {
+	patienceDiff(old, new, 0, len(old.records), 0, len(new.records))
+}
+
+// Where a line of the new side is not yet known, or is not unique.
+const (
+	patienceUnseen    = -1
+	patienceNotUnique = -2
+)
+
+// A line of the old side unique within the range compared, and its match
+// on the new side.
+type patienceEntry struct {
+	line1, line2   int
+	next, previous *patienceEntry
+}
+
+func patienceDiff(old, new *side, line1, count1, line2, count2 int) {
+	if count1 == 0 {
+		new.markChanged(line2, count2)
+
+		return
+	}
+
+	if count2 == 0 {
+		old.markChanged(line1, count1)
+
+		return
+	}
+
+	byRecord := make(map[int]*patienceEntry)
+	var first, last *patienceEntry
+	entries := 0
+
+	for i := line1; i < line1+count1; i++ {
+		if e, ok := byRecord[old.records[i]]; ok {
+			e.line2 = patienceNotUnique
+
+			continue
+		}
+
+		e := &patienceEntry{line1: i, line2: patienceUnseen, previous: last}
+		byRecord[old.records[i]] = e
+		entries++
+
+		if last != nil {
+			last.next = e
+		} else {
+			first = e
+		}
+
+		last = e
+	}
+
+	hasMatches := false
+
+	for j := line2; j < line2+count2; j++ {
+		e, ok := byRecord[new.records[j]]
+
+		if !ok {
+			continue
+		}
+
+		hasMatches = true
+
+		if e.line2 != patienceUnseen {
+			e.line2 = patienceNotUnique
+		} else {
+			e.line2 = j
+		}
+	}
+
+	if !hasMatches {
+		old.markChanged(line1, count1)
+		new.markChanged(line2, count2)
+
+		return
+	}
+
+	if common := longestCommonSequence(first, entries); common != nil {
+		walkCommonSequence(old, new, common, line1, count1, line2, count2)
+	} else {
+		fallBack(old, new, line1, count1, line2, count2)
+	}
+}
+
+// Link the longest run of unique lines in the same order on both sides,
+// found by patience sorting, returning its first.
+func longestCommonSequence(first *patienceEntry, entries int) *patienceEntry {
+	sequence := make([]*patienceEntry, entries)
+	longest := 0
+
+	for e := first; e != nil; e = e.next {
+		if e.line2 < 0 {
+			continue
+		}
+
+		i := searchSequence(sequence, longest, e.line2)
+
+		if i < 0 {
+			e.previous = nil
+		} else {
+			e.previous = sequence[i]
+		}
+
+		i++
+		sequence[i] = e
+
+		if i == longest {
+			longest++
+		}
+	}
+
+	if longest == 0 {
+		return nil
+	}
+
+	e := sequence[longest-1]
+	e.next = nil
+
+	for e.previous != nil {
+		e.previous.next = e
+		e = e.previous
+	}
+
+	return e
+}
+
+// Where in the sequence the last entry with a new line before line2 is, or
+// -1 if there is none.
+func searchSequence(sequence []*patienceEntry, longest, line2 int) int {
+	left, right := -1, longest
+
+	for left+1 < right {
+		middle := left + (right-left)/2
+
+		if sequence[middle].line2 > line2 {
+			right = middle
+		} else {
+			left = middle
+		}
+	}
+
+	return left
+}
+
+// Compare the gaps before, between and after the lines of a common
+// sequence, growing each match over equal lines next to it.
+func walkCommonSequence(old, new *side, first *patienceEntry, line1, count1, line2, count2 int) {
+	end1, end2 := line1+count1, line2+count2
+
+	for {
+		next1, next2 := end1, end2
+
+		if first != nil {
+			next1, next2 = first.line1, first.line2
+
+			for next1 > line1 && next2 > line2 && old.records[next1-1] == new.records[next2-1] {
+				next1, next2 = next1-1, next2-1
+			}
+		}
+
+		for line1 < next1 && line2 < next2 && old.records[line1] == new.records[line2] {
+			line1, line2 = line1+1, line2+1
+		}
+
+		if next1 > line1 || next2 > line2 {
+			patienceDiff(old, new, line1, next1-line1, line2, next2-line2)
+		}
+
+		if first == nil {
+			return
+		}
+
+		for first.next != nil && first.next.line1 == first.line1+1 && first.next.line2 == first.line2+1 {
+			first = first.next
+		}
+
+		line1, line2 = first.line1+1, first.line2+1
+		first = first.next
+	}
+}
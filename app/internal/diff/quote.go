@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Escapes of the characters git quotes in paths by name rather than in
+// octal.
+var namedEscapes = map[byte]byte{
+	'\a': 'a', '\b': 'b', '\t': 't', '\n': 'n', '\v': 'v', '\f': 'f', '\r': 'r',
+	'"': '"', '\\': '\\',
+}
+
+func needsQuoting(c byte) bool {
+	return c < 0x20 || c == '"' || c == '\\' || c >= 0x7f
+}
+
+// QuotePath quotes a path the way git shows it, C-style within double
+// quotes, when it holds control characters, quotes, backslashes or bytes
+// outside ASCII.
+func QuotePath(name string) string {
+	for i := 0; i < len(name); i++ {
+		if needsQuoting(name[i]) {
+			return `"` + escapePath(name) + `"`
+		}
+	}
+
+	return name
+}
+
+// Quote a path along with the prefix it is shown with, as a whole.
+func quotePrefixed(prefix, name string) string {
+	if QuotePath(prefix) == prefix && QuotePath(name) == name {
+		return prefix + name
+	}
+
+	return `"` + escapePath(prefix) + escapePath(name) + `"`
+}
+
+func escapePath(name string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+
+		switch {
+		case !needsQuoting(c):
+			b.WriteByte(c)
+		case namedEscapes[c] != 0:
+			b.WriteByte('\\')
+			b.WriteByte(namedEscapes[c])
+		default:
+			fmt.Fprintf(&b, "\\%03o", c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,529 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// DefaultRenameScore is the similarity renames and copies need unless told
+// otherwise, half of MaxScore.
+const DefaultRenameScore = MaxScore / 2
+
+// DefaultRenameLimit bounds the sources times destinations compared to
+// find inexact renames, as its square.
+const DefaultRenameLimit = 1000
+
+// How many of the most similar sources are kept for each destination.
+const candidatesPerDestination = 4
+
+// RenameOptions tells how to pair deleted and added files into renames.
+type RenameOptions struct {
+	// Also find files copied from files that still exist.
+	Copies bool
+
+	// The similarity a pair of files needs, out of MaxScore.
+	MinScore int
+
+	// Skip inexact renames when there are more candidate pairs than the
+	// square of this; zero does not limit them.
+	Limit int
+}
+
+// ParseScore reads a similarity given to -M or -C: a percentage like
+// "75%", or the digits of a fraction, "75" or "0.75", as git takes them.
+func ParseScore(s string) (int, error) {
+	num, scale := int64(0), int64(1)
+	dot := false
+	i := 0
+
+	for ; i < len(s); i++ {
+		c := s[i]
+
+		if c == '.' && !dot {
+			scale, dot = 1, true
+		} else if c == '%' {
+			if dot {
+				scale *= 100
+			} else {
+				scale = 100
+			}
+
+			i++
+
+			break
+		} else if c >= '0' && c <= '9' {
+			if scale < 100000 {
+				scale *= 10
+				num = num*10 + int64(c-'0')
+			}
+		} else {
+			break
+		}
+	}
+
+	if i != len(s) {
+		return 0, errors.GitError{Message: fmt.Sprintf("invalid similarity score '%s'", s)}
+	}
+
+	if num >= scale {
+		return MaxScore, nil
+	}
+
+	return int(MaxScore * num / scale), nil
+}
+
+type renameSource struct {
+	file *File
+
+	// How many destinations use the source, plus one when it is kept.
+	used int
+}
+
+type renameDestination struct {
+	change  *Change
+	renamed *Change
+}
+
+type candidate struct {
+	destination int
+	source      int
+	score       int
+	nameScore   int
+}
+
+// DetectRenames pairs the files deleted or, with copies, kept in a list of
+// changes with the files added into renames and copies, like git's
+// diffcore-rename. The changes keep their order, each rename or copy taking
+// the place of its destination. Unmodified changes are dropped.
+func DetectRenames(changes []*Change, opts RenameOptions) ([]*Change, error) {
+	if opts.MinScore <= 0 {
+		opts.MinScore = DefaultRenameScore
+	}
+
+	sources := []*renameSource{}
+	destinations := []*renameDestination{}
+	sourceOf := make(map[*File]*renameSource)
+	destinationOf := make(map[*Change]*renameDestination)
+
+	for _, change := range changes {
+		switch change.Status {
+		case Unmerged:
+		case Added:
+			d := &renameDestination{change: change}
+			destinations = append(destinations, d)
+			destinationOf[change] = d
+		case Deleted:
+			s := &renameSource{file: change.Old}
+			sources = append(sources, s)
+			sourceOf[change.Old] = s
+		default:
+			if opts.Copies {
+				s := &renameSource{file: change.Old, used: 1}
+				sources = append(sources, s)
+				sourceOf[change.Old] = s
+			}
+		}
+	}
+
+	if len(destinations) > 0 && len(sources) > 0 {
+		r := &renamer{sources: sources, destinations: destinations, opts: opts}
+
+		if err := r.detect(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]*Change, 0, len(changes))
+
+	for _, change := range changes {
+		switch change.Status {
+		case Added:
+			if d := destinationOf[change]; d.renamed != nil {
+				change = d.renamed
+			}
+		case Deleted:
+			if sourceOf[change.Old].used > 0 {
+				continue
+			}
+		case Unmodified:
+			continue
+		}
+
+		result = append(result, change)
+	}
+
+	// A source used more than once is copied to all but the last of its
+	// destinations, unless it is kept.
+	for _, change := range result {
+		if change.Status != Renamed {
+			continue
+		}
+
+		s := sourceOf[change.Old]
+
+		if s.used--; s.used > 0 {
+			change.Status = Copied
+		}
+	}
+
+	return result, nil
+}
+
+type renamer struct {
+	sources      []*renameSource
+	destinations []*renameDestination
+	opts         RenameOptions
+}
+
+func (r *renamer) detect() error {
+	if err := r.findExact(); err != nil {
+		return err
+	}
+
+	if !r.opts.Copies {
+		r.dropUsedSources()
+
+		// Half way between the minimum score and identical files.
+		if err := r.findByBasename(r.opts.MinScore + (MaxScore-r.opts.MinScore)/2); err != nil {
+			return err
+		}
+
+		r.dropUsedSources()
+	}
+
+	left := 0
+
+	for _, d := range r.destinations {
+		if d.renamed == nil {
+			left++
+		}
+	}
+
+	if left == 0 || len(r.sources) == 0 {
+		return nil
+	}
+
+	if r.opts.Limit > 0 && left*len(r.sources) > r.opts.Limit*r.opts.Limit {
+		return nil
+	}
+
+	return r.findInexact()
+}
+
+func (r *renamer) record(d *renameDestination, s *renameSource, score int) {
+	s.used++
+	d.renamed = &Change{Status: Renamed, Old: s.file, New: d.change.New, Score: score}
+}
+
+func (r *renamer) dropUsedSources() {
+	kept := r.sources[:0]
+
+	for _, s := range r.sources {
+		if s.used == 0 {
+			kept = append(kept, s)
+		}
+	}
+
+	r.sources = kept
+}
+
+func sameBasename(a, b string) bool {
+	return path.Base(a) == path.Base(b)
+}
+
+// Pair destinations with sources of the same content, preferring sources
+// not used yet and then ones of the same name.
+func (r *renamer) findExact() error {
+	byHash := make(map[string][]*renameSource)
+
+	for _, s := range r.sources {
+		key := s.file.Hash.String()
+		byHash[key] = append(byHash[key], s)
+	}
+
+	for _, d := range r.destinations {
+		hash, err := d.change.New.ObjectHash()
+
+		if err != nil {
+			return err
+		}
+
+		// Like in git, destinations in the working tree are named from here
+		// on.
+		d.change.New.Hash = hash
+
+		var best *renameSource
+		bestScore := -1
+
+		for _, s := range byHash[hash.String()] {
+			if (!s.file.IsRegular() || !d.change.New.IsRegular()) && s.file.Mode != d.change.New.Mode {
+				continue
+			}
+
+			if s.used > 0 && !r.opts.Copies {
+				continue
+			}
+
+			score := 0
+
+			if s.used == 0 {
+				score++
+			}
+
+			if sameBasename(s.file.Path, d.change.New.Path) {
+				score++
+			}
+
+			if score > bestScore {
+				best, bestScore = s, score
+
+				if score == 2 {
+					break
+				}
+			}
+		}
+
+		if best != nil {
+			r.record(d, best, MaxScore)
+		}
+	}
+
+	return nil
+}
+
+// Pair sources and destinations whose names are unique among them, if they
+// are similar enough.
+func (r *renamer) findByBasename(minScore int) error {
+	sourceByName := make(map[string]int)
+	destinationByName := make(map[string]int)
+
+	for i, s := range r.sources {
+		name := path.Base(s.file.Path)
+
+		if _, ok := sourceByName[name]; ok {
+			sourceByName[name] = -1
+		} else {
+			sourceByName[name] = i
+		}
+	}
+
+	for i, d := range r.destinations {
+		if d.renamed != nil {
+			continue
+		}
+
+		name := path.Base(d.change.New.Path)
+
+		if _, ok := destinationByName[name]; ok {
+			destinationByName[name] = -1
+		} else {
+			destinationByName[name] = i
+		}
+	}
+
+	for i, s := range r.sources {
+		name := path.Base(s.file.Path)
+		j, ok := destinationByName[name]
+
+		if !ok || j < 0 || sourceByName[name] != i {
+			continue
+		}
+
+		d := r.destinations[j]
+
+		if d.renamed != nil {
+			continue
+		}
+
+		score, err := similarity(s.file, d.change.New, minScore)
+
+		if err != nil {
+			return err
+		}
+
+		if score >= minScore {
+			r.record(d, s, score)
+		}
+	}
+
+	return nil
+}
+
+// Compare every destination left with every source, keeping the best
+// candidates of each, and pair them from the most similar down.
+func (r *renamer) findInexact() error {
+	candidates := []candidate{}
+
+	for i, d := range r.destinations {
+		if d.renamed != nil {
+			continue
+		}
+
+		best := make([]candidate, candidatesPerDestination)
+
+		for k := range best {
+			best[k].destination = -1
+		}
+
+		for j, s := range r.sources {
+			score, err := similarity(s.file, d.change.New, r.opts.MinScore)
+
+			if err != nil {
+				return err
+			}
+
+			c := candidate{destination: i, source: j, score: score}
+
+			if sameBasename(s.file.Path, d.change.New.Path) {
+				c.nameScore = 1
+			}
+
+			worst := 0
+
+			for k := 1; k < len(best); k++ {
+				if worseCandidate(best[k], best[worst]) {
+					worst = k
+				}
+			}
+
+			if worseCandidate(best[worst], c) {
+				best[worst] = c
+			}
+		}
+
+		candidates = append(candidates, best...)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return worseCandidate(candidates[j], candidates[i])
+	})
+
+	r.pairCandidates(candidates, false)
+
+	if r.opts.Copies {
+		r.pairCandidates(candidates, true)
+	}
+
+	return nil
+}
+
+// Whether candidate a is worse than b. Empty slots are worst of all.
+func worseCandidate(a, b candidate) bool {
+	if a.destination < 0 || b.destination < 0 {
+		return a.destination < 0 && b.destination >= 0
+	}
+
+	if a.score != b.score {
+		return a.score < b.score
+	}
+
+	return a.nameScore < b.nameScore
+}
+
+func (r *renamer) pairCandidates(candidates []candidate, copies bool) {
+	for _, c := range candidates {
+		if c.destination < 0 || c.score < r.opts.MinScore {
+			break
+		}
+
+		d := r.destinations[c.destination]
+		s := r.sources[c.source]
+
+		if d.renamed != nil || !copies && s.used > 0 {
+			continue
+		}
+
+		r.record(d, s, c.score)
+	}
+}
+
+// How much of the larger of two files is made of content of the source,
+// out of MaxScore. Only regular files are compared, and files whose sizes
+// are too far apart to reach the minimum score are not.
+func similarity(src, dst *File, minScore int) (int, error) {
+	if !src.IsRegular() || !dst.IsRegular() {
+		return 0, nil
+	}
+
+	srcData, err := src.Content()
+
+	if err != nil {
+		return 0, err
+	}
+
+	dstData, err := dst.Content()
+
+	if err != nil {
+		return 0, err
+	}
+
+	maxSize, baseSize := int64(len(srcData)), int64(len(dstData))
+
+	if maxSize < baseSize {
+		maxSize, baseSize = baseSize, maxSize
+	}
+
+	if maxSize*int64(MaxScore-minScore) < (maxSize-baseSize)*MaxScore {
+		return 0, nil
+	}
+
+	if len(dstData) == 0 {
+		return 0, nil
+	}
+
+	srcSpans, dstSpans := src.spanCounts(), dst.spanCounts()
+	copied := int64(0)
+
+	for hash, n := range srcSpans {
+		if m := dstSpans[hash]; m < n {
+			copied += int64(m)
+		} else {
+			copied += int64(n)
+		}
+	}
+
+	return int(copied * MaxScore / maxSize), nil
+}
+
+const spanHashBase = 107927
+
+// Count the bytes of the file by hash of the spans holding them: lines, or
+// 64 byte chunks of longer ones. Text files ignore the CR of CRLF, and like
+// in git, a last line without a newline is not counted.
+func (f *File) spanCounts() map[uint32]int {
+	if f.spans != nil {
+		return f.spans
+	}
+
+	data := f.data
+	text := !IsBinary(data)
+	f.spans = make(map[uint32]int)
+
+	var accum1, accum2 uint32
+	n := 0
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if text && c == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			continue
+		}
+
+		old := accum1
+		accum1 = (accum1 << 7) ^ (accum2 >> 25)
+		accum2 = (accum2 << 7) ^ (old >> 25)
+		accum1 += uint32(c)
+
+		if n++; n < 64 && c != '\n' {
+			continue
+		}
+
+		f.spans[(accum1+accum2*0x61)%spanHashBase] += n
+		n, accum1, accum2 = 0, 0, 0
+	}
+
+	return f.spans
+}
@@ -0,0 +1,363 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultStatWidth is how wide --stat output gets unless told otherwise.
+const DefaultStatWidth = 80
+
+// StatOptions bounds --stat output.
+type StatOptions struct {
+	// The columns of each line, DefaultStatWidth if zero, and at most how
+	// many of them go to the name and the graph, unbounded if zero.
+	Width      int
+	NameWidth  int
+	GraphWidth int
+
+	// How many files to list, all of them if zero.
+	Count int
+}
+
+// FileStat counts the lines a change adds and deletes. For binary files,
+// they are the sizes of both sides in bytes, or zero if both are the same.
+type FileStat struct {
+	Change  *Change
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+func (s *FileStat) unmerged() bool {
+	return s.Change.Status == Unmerged
+}
+
+// The name of the file, or of both for renames and copies, sharing their
+// common leading directories and trailing part, like "dir/{a => b}.c".
+func (s *FileStat) name() string {
+	old, new := s.Change.Old.Path, s.Change.New.Path
+
+	if !s.Change.Old.Exists() || !s.Change.New.Exists() || old == new {
+		return QuotePath(s.Change.Path())
+	}
+
+	return renameName(old, new)
+}
+
+func renameName(a, b string) string {
+	if QuotePath(a) != a || QuotePath(b) != b {
+		return QuotePath(a) + " => " + QuotePath(b)
+	}
+
+	prefix := 0
+
+	for i := 0; i < len(a) && i < len(b) && a[i] == b[i]; i++ {
+		if a[i] == '/' {
+			prefix = i + 1
+		}
+	}
+
+	// The suffix starts at a slash; with a common prefix, it may be the
+	// slash ending the prefix.
+	suffix := 0
+	limit := prefix
+
+	if prefix > 0 {
+		limit--
+	}
+
+	for i, j := len(a)-1, len(b)-1; i >= limit && j >= limit && a[i] == b[j]; i, j = i-1, j-1 {
+		if a[i] == '/' {
+			suffix = len(a) - i
+		}
+	}
+
+	aMid := maxInt(len(a)-prefix-suffix, 0)
+	bMid := maxInt(len(b)-prefix-suffix, 0)
+
+	if prefix+suffix == 0 {
+		return a[:aMid] + " => " + b[:bMid]
+	}
+
+	return a[:prefix] + "{" + a[prefix:prefix+aMid] + " => " + b[prefix:prefix+bMid] + "}" + a[len(a)-suffix:]
+}
+
+// Stats counts the lines each change adds and deletes, as they are shown in
+// its patch. Like in git, binary files are counted in bytes even when
+// patches treat them as text.
+func Stats(changes []*Change, opts *Options) ([]FileStat, error) {
+	stats := make([]FileStat, 0, len(changes))
+	statOpts := *opts
+	statOpts.Text = false
+
+	for _, c := range changes {
+		s := FileStat{Change: c}
+
+		if c.Status != Unmerged {
+			d, err := compareFile(c, &statOpts)
+
+			if err != nil {
+				return nil, err
+			}
+
+			s.Binary = d.binary
+
+			if d.binary && !d.same {
+				s.Added, s.Deleted = d.newSize, d.oldSize
+			}
+
+			for _, edits := range d.hunks {
+				for _, e := range edits {
+					s.Added += e.NewLines
+					s.Deleted += e.OldLines
+				}
+			}
+
+			// Like git, leave out files whose only changes are ignored,
+			// unless their mode or name changed too.
+			if !d.binary && s.Added == 0 && s.Deleted == 0 && c.Status == Modified && c.Old.Mode == c.New.Mode {
+				continue
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+// WriteNumstat lists the lines added and deleted in each file, tab
+// separated, like git diff --numstat. Binary files show dashes for both.
+func WriteNumstat(w io.Writer, stats []FileStat) {
+	for i := range stats {
+		if stats[i].Binary {
+			fmt.Fprintf(w, "-\t-\t%s\n", stats[i].name())
+		} else {
+			fmt.Fprintf(w, "%d\t%d\t%s\n", stats[i].Added, stats[i].Deleted, stats[i].name())
+		}
+	}
+}
+
+// WriteStat draws how much each file changed as a bar of pluses and
+// minuses, scaled to fit the width, followed by the totals.
+func WriteStat(w io.Writer, stats []FileStat, opts StatOptions) {
+	if len(stats) == 0 {
+		return
+	}
+
+	count := len(stats)
+
+	if opts.Count > 0 && opts.Count < count {
+		count = opts.Count
+	}
+
+	// Besides the graph, lines may need room for "Unmerged" or for
+	// "Bin <old> -> <new> bytes", binWidth columns.
+	maxLen, maxChange, binWidth, numberWidth := 0, 0, 0, 0
+
+	for i := 0; i < count; i++ {
+		s := &stats[i]
+
+		if n := len(s.name()); n > maxLen {
+			maxLen = n
+		}
+
+		if s.unmerged() {
+			binWidth = maxInt(binWidth, len("Unmerged"))
+
+			continue
+		}
+
+		if s.Binary {
+			binWidth = maxInt(binWidth, 14+len(strconv.Itoa(s.Added))+len(strconv.Itoa(s.Deleted)))
+			numberWidth = len("Bin")
+
+			continue
+		}
+
+		if change := s.Added + s.Deleted; change > maxChange {
+			maxChange = change
+		}
+	}
+
+	width := opts.Width
+
+	if width <= 0 {
+		width = DefaultStatWidth
+	}
+
+	numberWidth = maxInt(numberWidth, len(strconv.Itoa(maxChange)))
+
+	// Leave room for at least a 10 column name and a 6 column graph.
+	if width < 16+6+numberWidth {
+		width = 16 + 6 + numberWidth
+	}
+
+	graphWidth := maxChange
+
+	if maxChange+4 <= binWidth {
+		graphWidth = binWidth - 4
+	}
+
+	if opts.GraphWidth > 0 && opts.GraphWidth < graphWidth {
+		graphWidth = opts.GraphWidth
+	}
+
+	nameWidth := maxLen
+
+	if opts.NameWidth > 0 && opts.NameWidth < maxLen {
+		nameWidth = opts.NameWidth
+	}
+
+	// Past the width, the name gets at most 5/8 of it and the graph the
+	// rest.
+	if nameWidth+numberWidth+6+graphWidth > width {
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = maxInt(width*3/8-numberWidth-6, 6)
+		}
+
+		if opts.GraphWidth > 0 && graphWidth > opts.GraphWidth {
+			graphWidth = opts.GraphWidth
+		}
+
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		s := &stats[i]
+		name, prefix := s.name(), ""
+
+		// Names too long lose their head, up to a slash if they have one.
+		if len(name) > nameWidth {
+			prefix = "..."
+			name = name[len(name)-maxInt(nameWidth-3, 0):]
+
+			if slash := strings.IndexByte(name, '/'); slash >= 0 {
+				name = name[slash:]
+			}
+		}
+
+		padding := maxInt(nameWidth-len(prefix)-len(name), 0)
+
+		if s.unmerged() {
+			fmt.Fprintf(w, " %s%s%*s | %*s\n", prefix, name, padding, "", numberWidth, "Unmerged")
+
+			continue
+		}
+
+		if s.Binary {
+			fmt.Fprintf(w, " %s%s%*s | %*s", prefix, name, padding, "", numberWidth, "Bin")
+
+			if s.Added > 0 || s.Deleted > 0 {
+				fmt.Fprintf(w, " %d -> %d bytes", s.Deleted, s.Added)
+			}
+
+			fmt.Fprintln(w)
+
+			continue
+		}
+
+		added, deleted := s.Added, s.Deleted
+
+		if graphWidth <= maxChange {
+			total := scaleLinear(added+deleted, graphWidth, maxChange)
+
+			if total < 2 && added > 0 && deleted > 0 {
+				total = 2
+			}
+
+			if added < deleted {
+				added = scaleLinear(added, graphWidth, maxChange)
+				deleted = total - added
+			} else {
+				deleted = scaleLinear(deleted, graphWidth, maxChange)
+				added = total - deleted
+			}
+		}
+
+		separator := ""
+
+		if s.Added+s.Deleted > 0 {
+			separator = " "
+		}
+
+		fmt.Fprintf(w, " %s%s%*s | %*d%s%s%s\n", prefix, name, padding, "", numberWidth, s.Added+s.Deleted, separator,
+			strings.Repeat("+", added), strings.Repeat("-", deleted))
+	}
+
+	for i := count; i < len(stats); i++ {
+		if !stats[i].unmerged() {
+			fmt.Fprintln(w, " ...")
+
+			break
+		}
+	}
+
+	WriteShortstat(w, stats)
+}
+
+// Scale a count to the width of the graph, showing at least one column
+// for any change.
+func scaleLinear(n, width, max int) int {
+	if n == 0 {
+		return 0
+	}
+
+	return 1 + n*(width-1)/max
+}
+
+// WriteShortstat writes the totals of files changed, insertions and
+// deletions.
+func WriteShortstat(w io.Writer, stats []FileStat) {
+	if len(stats) == 0 {
+		return
+	}
+
+	files, insertions, deletions := 0, 0, 0
+
+	for i := range stats {
+		if stats[i].unmerged() {
+			continue
+		}
+
+		files++
+
+		if !stats[i].Binary {
+			insertions += stats[i].Added
+			deletions += stats[i].Deleted
+		}
+	}
+
+	if files == 0 {
+		fmt.Fprintln(w, " 0 files changed")
+
+		return
+	}
+
+	line := fmt.Sprintf(" %d %s changed", files, plural(files, "file", "files"))
+
+	if insertions > 0 || deletions == 0 {
+		line += fmt.Sprintf(", %d %s(+)", insertions, plural(insertions, "insertion", "insertions"))
+	}
+
+	if deletions > 0 || insertions == 0 {
+		line += fmt.Sprintf(", %d %s(-)", deletions, plural(deletions, "deletion", "deletions"))
+	}
+
+	fmt.Fprintln(w, line)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+
+	return many
+}
@@ -0,0 +1,77 @@
+package diff
+
+// The form of a line compared under the whitespace options: lines of the
+// same key are equal.
+func lineKey(line []byte, opts *LineOptions) string {
+	switch {
+	case opts.IgnoreAllSpace:
+		key := make([]byte, 0, len(line))
+
+		for _, c := range line {
+			if !isSpace(c) {
+				key = append(key, c)
+			}
+		}
+
+		return string(key)
+	case opts.IgnoreSpaceChange:
+		key := make([]byte, 0, len(line))
+
+		for i := 0; i < len(line); i++ {
+			if !isSpace(line[i]) {
+				key = append(key, line[i])
+
+				continue
+			}
+
+			for i+1 < len(line) && isSpace(line[i+1]) {
+				i++
+			}
+
+			// Whitespace at the end of the line does not count.
+			if i+1 < len(line) {
+				key = append(key, ' ')
+			}
+		}
+
+		return string(key)
+	case opts.IgnoreSpaceAtEOL:
+		return string(trimSpaceRight(line))
+	case opts.IgnoreCRAtEOL:
+		// The newline itself goes too, so that a last line missing it is
+		// equal to one that has it.
+		n := len(line)
+
+		if n > 0 && line[n-1] == '\n' {
+			n--
+
+			if n > 0 && line[n-1] == '\r' {
+				n--
+			}
+		}
+
+		return string(line[:n])
+	}
+
+	return string(line)
+}
+
+func trimSpaceRight(line []byte) []byte {
+	n := len(line)
+
+	for n > 0 && isSpace(line[n-1]) {
+		n--
+	}
+
+	return line[:n]
+}
+
+// Whether a line is blank: empty but for its newline, or only whitespace
+// when whitespace is ignored.
+func isBlankLine(line []byte, opts *LineOptions) bool {
+	if !opts.ignoresWhitespace() {
+		return len(line) <= 1
+	}
+
+	return len(trimSpaceRight(line)) == 0
+}
@@ -0,0 +1,202 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// How changed words are marked: text around them, and what ends a line.
+type wordStyle struct {
+	oldPrefix, oldSuffix string
+	newPrefix, newSuffix string
+	ctxPrefix, ctxSuffix string
+	newline              string
+
+	// Context lines keep their prefix, and end with the newline mark.
+	markContext bool
+}
+
+var wordStyles = map[string]wordStyle{
+	"plain": {
+		oldPrefix: "[-", oldSuffix: "-]",
+		newPrefix: "{+", newSuffix: "+}",
+		newline: "\n",
+	},
+	"porcelain": {
+		oldPrefix: "-", oldSuffix: "\n",
+		newPrefix: "+", newSuffix: "\n",
+		ctxPrefix: " ", ctxSuffix: "\n",
+		newline:     "~\n",
+		markContext: true,
+	},
+}
+
+// A word of a run of changed lines, by its offsets in their text.
+type word struct {
+	begin, end int
+}
+
+// wordSink gathers the removed and added lines of each run of changes and
+// shows the words that changed between them.
+type wordSink struct {
+	w     *bufio.Writer
+	style wordStyle
+	minus []byte
+	plus  []byte
+}
+
+func newWordSink(w *bufio.Writer, mode string) *wordSink {
+	return &wordSink{w: w, style: wordStyles[mode]}
+}
+
+func (s *wordSink) header(text string) {
+	s.flush()
+	s.w.WriteString(text)
+}
+
+func (s *wordSink) line(prefix byte, text []byte) {
+	// Lines missing their newline at the end of a file are compared as if
+	// they had one.
+	if len(text) == 0 || text[len(text)-1] != '\n' {
+		text = append(append([]byte(nil), text...), '\n')
+	}
+
+	switch prefix {
+	case '-':
+		s.minus = append(s.minus, text...)
+	case '+':
+		s.plus = append(s.plus, text...)
+	default:
+		s.flush()
+
+		if s.style.markContext {
+			s.w.WriteByte(prefix)
+			s.w.Write(text)
+			s.w.WriteString(s.style.newline)
+		} else {
+			s.w.Write(text)
+		}
+	}
+}
+
+func (s *wordSink) flush() {
+	if len(s.minus) == 0 && len(s.plus) == 0 {
+		return
+	}
+
+	minus, plus := s.minus, s.plus
+	s.minus, s.plus = nil, nil
+
+	if len(plus) == 0 {
+		s.write(s.style.oldPrefix, s.style.oldSuffix, minus)
+
+		return
+	}
+
+	minusWords, plusWords := splitWords(minus), splitWords(plus)
+	edits := Lines(wordRecords(minus, minusWords), wordRecords(plus, plusWords), LineOptions{})
+	current := 0
+
+	for _, e := range edits {
+		minusBegin, minusEnd := wordSpan(minusWords, e.Old, e.OldLines)
+		plusBegin, plusEnd := wordSpan(plusWords, e.New, e.NewLines)
+
+		if current != plusBegin {
+			s.write(s.style.ctxPrefix, s.style.ctxSuffix, plus[current:plusBegin])
+		}
+
+		if minusBegin != minusEnd {
+			s.write(s.style.oldPrefix, s.style.oldSuffix, minus[minusBegin:minusEnd])
+		}
+
+		if plusBegin != plusEnd {
+			s.write(s.style.newPrefix, s.style.newSuffix, plus[plusBegin:plusEnd])
+		}
+
+		current = plusEnd
+	}
+
+	if current != len(plus) {
+		s.write(s.style.ctxPrefix, s.style.ctxSuffix, plus[current:])
+	}
+}
+
+// Write text line by line, each part between newlines marked.
+func (s *wordSink) write(prefix, suffix string, text []byte) {
+	for len(text) > 0 {
+		n := bytes.IndexByte(text, '\n')
+
+		if n != 0 {
+			part := text
+
+			if n > 0 {
+				part = text[:n]
+			}
+
+			s.w.WriteString(prefix)
+			s.w.Write(part)
+			s.w.WriteString(suffix)
+		}
+
+		if n < 0 {
+			return
+		}
+
+		s.w.WriteString(s.style.newline)
+		text = text[n+1:]
+	}
+}
+
+// Words are runs of characters other than whitespace.
+func splitWords(text []byte) []word {
+	words := []word{}
+
+	for i := 0; i < len(text); {
+		for i < len(text) && isSpace(text[i]) {
+			i++
+		}
+
+		if i == len(text) {
+			break
+		}
+
+		j := i + 1
+
+		for j < len(text) && !isSpace(text[j]) {
+			j++
+		}
+
+		words = append(words, word{i, j})
+		i = j
+	}
+
+	return words
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func wordRecords(text []byte, words []word) [][]byte {
+	records := make([][]byte, len(words))
+
+	for i, w := range words {
+		records[i] = text[w.begin:w.end]
+	}
+
+	return records
+}
+
+// The text a run of words covers; for an empty run, where it would be:
+// right after the word before it.
+func wordSpan(words []word, first, count int) (int, int) {
+	if count > 0 {
+		return words[first].begin, words[first+count-1].end
+	}
+
+	if first == 0 {
+		return 0, 0
+	}
+
+	return words[first-1].end, words[first-1].end
+}
@@ -0,0 +1,435 @@
+// Package discover finds the repository a command works on, following the
+// rules of git: $GIT_DIR and $GIT_WORK_TREE when they are set, and otherwise
+// a walk up from the current directory looking for a .git directory or file,
+// or for a bare repository.
+package discover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// NotFoundError is returned when no repository is found. Reason says why
+// the search stopped when it was not at the root of the filesystem.
+type NotFoundError struct {
+	Path   string
+	Reason string
+}
+
+func (e NotFoundError) Error() string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+
+	return "not a git repository (or any of the parent directories): .git"
+}
+
+// DubiousOwnershipError is returned for repositories owned by someone else
+// and not listed in safe.directory.
+type DubiousOwnershipError struct {
+	Path string
+}
+
+func (e DubiousOwnershipError) Error() string {
+	return fmt.Sprintf("detected dubious ownership in repository at '%s'\n"+
+		"To add an exception for this directory, call:\n\n"+
+		"\tgit config --global --add safe.directory %s", e.Path, e.Path)
+}
+
+// Extensions a version 1 repository may use.
+var knownExtensions = map[string]bool{
+	"noop":            true,
+	"preciousobjects": true,
+	"partialclone":    true,
+	"worktreeconfig":  true,
+	"objectformat":    true,
+}
+
+// IsGitDir reports whether path looks like a git directory: it has a HEAD
+// file and objects and refs directories.
+func IsGitDir(path string) bool {
+	if info, err := os.Stat(filepath.Join(path, "HEAD")); err != nil || info.IsDir() {
+		return false
+	}
+
+	for _, dir := range []string{"objects", "refs"} {
+		if info, err := os.Stat(filepath.Join(path, dir)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Find the repository for a command run in dir.
+func Find(dir string) (*fs.Git, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		return explicit(dir, gitDir)
+	}
+
+	return walk(dir)
+}
+
+// Open the repository named by $GIT_DIR. Without $GIT_WORK_TREE or
+// core.worktree, the working tree is dir unless the repository is bare.
+func explicit(dir, env string) (*fs.Git, error) {
+	gitDir := env
+
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+
+	if info, err := os.Stat(gitDir); err == nil && !info.IsDir() {
+		if gitDir, err = fs.ReadGitFile(gitDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if !IsGitDir(gitDir) {
+		return nil, NotFoundError{Path: dir, Reason: fmt.Sprintf("not a git repository: '%s'", env)}
+	}
+
+	cfg, format, err := checkFormat(gitDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	worktree := configuredWorkTree(cfg, dir, gitDir)
+
+	if worktree == "" {
+		if bare, err := cfg.Bool("core.bare", false); err != nil {
+			return nil, err
+		} else if !bare {
+			worktree = dir
+		}
+	}
+
+	git := fs.NewGit(gitDir, format, nil)
+	git.SetWorkTree(worktree)
+
+	return git, nil
+}
+
+// Walk up from dir looking for a repository, stopping below the closest
+// of $GIT_CEILING_DIRECTORIES and at filesystem boundaries.
+func walk(dir string) (*fs.Git, error) {
+	ceiling := ceilingFor(dir)
+	acrossFilesystems, err := config.ParseBool(os.Getenv("GIT_DISCOVERY_ACROSS_FILESYSTEM"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	start, _ := device(dir)
+
+	for current := dir; ; {
+		git, err := findAt(current, dir)
+
+		if err != nil || git != nil {
+			return git, err
+		}
+
+		parent := filepath.Dir(current)
+
+		if parent == current || parent == ceiling {
+			break
+		}
+
+		if dev, ok := device(parent); ok && dev != start && !acrossFilesystems {
+			return nil, NotFoundError{Path: dir, Reason: fmt.Sprintf("not a git repository (or any parent up to mount point %s)\n"+
+				"Stopping at filesystem boundary (GIT_DISCOVERY_ACROSS_FILESYSTEM not set).", current)}
+		}
+
+		current = parent
+	}
+
+	return nil, NotFoundError{Path: dir}
+}
+
+// Look for a repository at dir: a .git directory or file in it, or dir
+// itself being a git directory. A nil repository means there is none.
+func findAt(dir, cwd string) (*fs.Git, error) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+
+	switch {
+	case err == nil && info.IsDir() && IsGitDir(dotGit):
+		return open(dotGit, dir, "", cwd)
+	case err == nil && !info.IsDir():
+		gitDir, err := fs.ReadGitFile(dotGit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !IsGitDir(gitDir) {
+			return nil, NotFoundError{Path: cwd, Reason: fmt.Sprintf("not a git repository: %s", gitDir)}
+		}
+
+		return open(gitDir, dir, dotGit, cwd)
+	case IsGitDir(dir):
+		// Inside a .git directory the working tree is its parent, but any
+		// other git directory is taken to be a bare repository.
+		worktree := ""
+
+		if filepath.Base(dir) == ".git" {
+			worktree = filepath.Dir(dir)
+		}
+
+		return open(dir, worktree, "", cwd)
+	}
+
+	return nil, nil
+}
+
+// Open a repository found by walking up, with worktree the working tree it
+// was found in, if any, and gitFile the .git file leading to it, if any.
+func open(gitDir, worktree, gitFile, cwd string) (*fs.Git, error) {
+	cfg, format, err := checkFormat(gitDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	configured := configuredWorkTree(cfg, cwd, gitDir)
+
+	if bare, err := cfg.Bool("core.bare", false); err != nil {
+		return nil, err
+	} else if bare && gitFile == "" {
+		worktree = ""
+	}
+
+	if configured != "" {
+		worktree = configured
+	}
+
+	if err = checkOwnership(gitDir, worktree, gitFile); err != nil {
+		return nil, err
+	}
+
+	git := fs.NewGit(gitDir, format, nil)
+	git.SetWorkTree(worktree)
+
+	return git, nil
+}
+
+// The working tree set by $GIT_WORK_TREE, relative to cwd, or by
+// core.worktree, relative to the git directory. It is "" if neither is set.
+func configuredWorkTree(cfg *config.Config, cwd, gitDir string) string {
+	base, worktree := cwd, os.Getenv("GIT_WORK_TREE")
+
+	if worktree == "" {
+		base, worktree = gitDir, ""
+
+		if value, ok := cfg.Get("core.worktree"); ok {
+			worktree = value
+		}
+	}
+
+	if worktree == "" {
+		return ""
+	}
+
+	if !filepath.IsAbs(worktree) {
+		worktree = filepath.Join(base, worktree)
+	}
+
+	return filepath.Clean(worktree)
+}
+
+// ObjectFormat reads the object format of the repository at gitDir from its
+// extensions.objectFormat, refusing repositories of a format this version
+// does not understand.
+func ObjectFormat(gitDir string) (plumbing.ObjectFormat, error) {
+	_, format, err := checkFormat(gitDir)
+
+	return format, err
+}
+
+// Read the config and object format of the repository at gitDir, refusing
+// repositories of a format this version does not understand.
+func checkFormat(gitDir string) (*config.Config, plumbing.ObjectFormat, error) {
+	format := plumbing.SHA1
+	cfg, err := config.Load(filepath.Join(gitDir, "config"))
+
+	if err != nil {
+		return nil, format, err
+	}
+
+	version, err := cfg.Int("core.repositoryformatversion", 0)
+
+	if err != nil {
+		return nil, format, err
+	}
+
+	if version > 1 {
+		return nil, format, errors.GitError{Message: fmt.Sprintf("Expected git repo version <= 1, found %d", version)}
+	}
+
+	// Extensions only mean something from version 1 on.
+	if version == 0 {
+		return cfg, format, nil
+	}
+
+	var unknown []string
+
+	for _, e := range cfg.Entries {
+		if e.Section != "extensions" || e.Subsection != "" {
+			continue
+		}
+
+		if !knownExtensions[e.Key] {
+			unknown = append(unknown, e.Key)
+
+			continue
+		}
+
+		if e.Key != "objectformat" {
+			continue
+		}
+
+		if format, err = plumbing.ParseObjectFormat(strings.ToLower(e.Value)); err != nil || e.Value == "" {
+			return nil, format, errors.GitError{Message: fmt.Sprintf("invalid value for 'extensions.objectformat': '%s'", e.Value)}
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, format, errors.GitError{Message: "unknown repository extension found:\n\t" + strings.Join(unknown, "\n\t")}
+	}
+
+	return cfg, format, nil
+}
+
+// The closest of $GIT_CEILING_DIRECTORIES above dir, "" if there is none.
+// Relative entries are ignored, like git does.
+func ceilingFor(dir string) string {
+	closest := ""
+
+	for _, ceiling := range filepath.SplitList(os.Getenv("GIT_CEILING_DIRECTORIES")) {
+		if ceiling == "" || !filepath.IsAbs(ceiling) {
+			continue
+		}
+
+		if resolved, err := filepath.EvalSymlinks(ceiling); err == nil {
+			ceiling = resolved
+		}
+
+		ceiling = filepath.Clean(ceiling)
+
+		if !isBelow(dir, ceiling) || len(ceiling) <= len(closest) {
+			continue
+		}
+
+		closest = ceiling
+	}
+
+	return closest
+}
+
+// Whether path is strictly below dir.
+func isBelow(path, dir string) bool {
+	if path == dir {
+		return false
+	}
+
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Refuse repositories owned by someone else unless safe.directory lists
+// them. Only the system and global config and the command line count, so
+// the repository cannot vouch for itself.
+func checkOwnership(gitDir, worktree, gitFile string) error {
+	owned := true
+
+	for _, path := range []string{worktree, gitDir, gitFile} {
+		if path != "" && utils.PathExists(path) && !ownedByUser(path) {
+			owned = false
+		}
+	}
+
+	if owned {
+		return nil
+	}
+
+	path := worktree
+
+	if path == "" {
+		path = gitDir
+	}
+
+	cfg, err := config.LoadAll("")
+
+	if err != nil {
+		return err
+	}
+
+	if isSafe(cfg.GetAll("safe.directory"), path) {
+		return nil
+	}
+
+	return DubiousOwnershipError{Path: path}
+}
+
+// Whether path is listed in the safe.directory values. "*" lists every
+// directory, a trailing "/*" every directory below a path, and an empty
+// value empties the list so far.
+func isSafe(values []string, path string) bool {
+	safe := false
+
+	for _, value := range values {
+		switch {
+		case value == "":
+			safe = false
+		case value == "*":
+			safe = true
+		default:
+			expanded, err := config.ExpandPath(value)
+
+			if err != nil {
+				continue
+			}
+
+			if strings.HasSuffix(expanded, "/*") {
+				if strings.HasPrefix(path+"/", strings.TrimSuffix(expanded, "*")) {
+					safe = true
+				}
+			} else if filepath.Clean(expanded) == path {
+				safe = true
+			}
+		}
+	}
+
+	return safe
+}
+
+// The user whose repositories are trusted: the one running git, or the one
+// who ran sudo when that is root.
+func trustedUID() int {
+	uid := os.Geteuid()
+
+	if uid != 0 {
+		return uid
+	}
+
+	if sudo, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil {
+		return sudo
+	}
+
+	return uid
+}
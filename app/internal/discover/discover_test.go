@@ -0,0 +1,141 @@
+package discover_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/discover"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "git_ditto_discover")
+	utils.Expect(t, err, nil)
+
+	// Symlinked temporary directories, like on macOS, would not match the
+	// paths found.
+	dir, err = filepath.EvalSymlinks(dir)
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
+// Create the bare bones of a git directory, with the given config.
+func makeGitDir(t *testing.T, gitDir, config string) {
+	t.Helper()
+
+	for _, dir := range []string{"objects", "refs"} {
+		utils.Expect(t, os.MkdirAll(filepath.Join(gitDir, dir), 0755), nil)
+	}
+
+	utils.Expect(t, ioutil.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/master\n"), 0644), nil)
+	utils.Expect(t, ioutil.WriteFile(filepath.Join(gitDir, "config"), []byte(config), 0644), nil)
+}
+
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+
+	os.Setenv(key, value)
+	t.Cleanup(func() { os.Unsetenv(key) })
+}
+
+func TestFind(t *testing.T) {
+	dir := tempDir(t)
+	work := filepath.Join(dir, "work")
+	sub := filepath.Join(work, "a", "b")
+
+	makeGitDir(t, filepath.Join(work, ".git"), "[core]\n\tbare = false\n")
+	utils.Expect(t, os.MkdirAll(sub, 0755), nil)
+
+	git, err := discover.Find(sub)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.GitDir(), filepath.Join(work, ".git"))
+	utils.Expect(t, git.WorkTree(), work)
+
+	// Inside the git directory, the working tree is still its parent.
+	git, err = discover.Find(filepath.Join(work, ".git", "refs"))
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.WorkTree(), work)
+
+	// A .git file points at a git directory elsewhere.
+	linked := filepath.Join(dir, "linked")
+	utils.Expect(t, os.MkdirAll(linked, 0755), nil)
+	utils.Expect(t, ioutil.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: ../work/.git\n"), 0644), nil)
+
+	git, err = discover.Find(linked)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.GitDir(), filepath.Join(work, ".git"))
+	utils.Expect(t, git.WorkTree(), linked)
+
+	// Any other git directory is a bare repository.
+	bare := filepath.Join(dir, "bare.git")
+	makeGitDir(t, bare, "[core]\n\tbare = true\n")
+
+	git, err = discover.Find(filepath.Join(bare, "objects"))
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.GitDir(), bare)
+	utils.Expect(t, git.IsBare(), true)
+
+	// The search stops below a ceiling directory.
+	setenv(t, "GIT_CEILING_DIRECTORIES", "relative:"+work)
+
+	_, err = discover.Find(sub)
+	_, ok := err.(discover.NotFoundError)
+	utils.Expect(t, ok, true)
+
+	git, err = discover.Find(work)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.WorkTree(), work)
+}
+
+func TestFindExplicit(t *testing.T) {
+	dir := tempDir(t)
+	gitDir := filepath.Join(dir, "repo.git")
+	other := filepath.Join(dir, "other")
+
+	makeGitDir(t, gitDir, "[core]\n\tbare = false\n")
+	utils.Expect(t, os.MkdirAll(other, 0755), nil)
+
+	// Without a working tree set, it is the current directory.
+	setenv(t, "GIT_DIR", "../repo.git")
+
+	git, err := discover.Find(other)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.GitDir(), gitDir)
+	utils.Expect(t, git.WorkTree(), other)
+
+	setenv(t, "GIT_WORK_TREE", dir)
+
+	git, err = discover.Find(other)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, git.WorkTree(), dir)
+
+	setenv(t, "GIT_DIR", filepath.Join(dir, "missing"))
+
+	_, err = discover.Find(other)
+	utils.Expect(t, err.Error(), "not a git repository: '"+filepath.Join(dir, "missing")+"'")
+}
+
+func TestFindFormat(t *testing.T) {
+	dir := tempDir(t)
+
+	makeGitDir(t, filepath.Join(dir, "v2", ".git"), "[core]\n\trepositoryformatversion = 2\n")
+	makeGitDir(t, filepath.Join(dir, "ext", ".git"), "[core]\n\trepositoryformatversion = 1\n[extensions]\n\tfoo = bar\n\tnoop = true\n")
+	makeGitDir(t, filepath.Join(dir, "v0", ".git"), "[core]\n\trepositoryformatversion = 0\n[extensions]\n\tfoo = bar\n")
+
+	_, err := discover.Find(filepath.Join(dir, "v2"))
+	utils.Expect(t, err.Error(), "Expected git repo version <= 1, found 2")
+
+	_, err = discover.Find(filepath.Join(dir, "ext"))
+	utils.Expect(t, err.Error(), "unknown repository extension found:\n\tfoo")
+
+	// Version 0 repositories predate extensions and ignore them.
+	_, err = discover.Find(filepath.Join(dir, "v0"))
+	utils.Expect(t, err, nil)
+}
@@ -0,0 +1,43 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package discover
+
+import (
+	"os"
+	"syscall"
+)
+
+// The device holding path, to tell where a filesystem ends.
+func device(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return 0, false
+	}
+
+	st, ok := info.Sys().(*syscall.Stat_t)
+
+	if !ok {
+		return 0, false
+	}
+
+	return uint64(st.Dev), true
+}
+
+// Whether path belongs to the user whose repositories are trusted.
+func ownedByUser(path string) bool {
+	info, err := os.Lstat(path)
+
+	if err != nil {
+		return false
+	}
+
+	st, ok := info.Sys().(*syscall.Stat_t)
+
+	if !ok {
+		return true
+	}
+
+	return int(st.Uid) == trustedUID()
+}
@@ -0,0 +1,104 @@
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+const (
+	suffix     = ".git"
+	objectPath = "objects"
+	packPath   = "pack"
+)
+
+type Git struct {
+	basedir  string
+	worktree string
+	format   plumbing.ObjectFormat
+
+	objects odb.ObjectStore
+	loose   *odb.Loose
+	packed  *odb.Packed
+}
+
+// Open the repository whose git directory is gitDir, with objects named in
+// the given format, reading and writing them through store. A nil store
+// stands for the loose objects and packfiles under the git directory, and
+// any other store should use the same format. The working tree is the
+// directory containing gitDir if it is named .git, and there is none
+// otherwise; use SetWorkTree to say where it is when it is elsewhere.
+func NewGit(gitDir string, format plumbing.ObjectFormat, store odb.ObjectStore) *Git {
+	g := &Git{basedir: gitDir, format: format, objects: store}
+
+	if filepath.Base(gitDir) == suffix {
+		g.worktree = filepath.Dir(gitDir)
+	}
+
+	if store == nil {
+		g.loose = odb.NewLoose(filepath.Join(gitDir, objectPath), format)
+		g.packed = odb.NewPacked(filepath.Join(gitDir, objectPath, packPath), format, nil)
+		g.objects = odb.NewLayered(g.loose, g.packed)
+
+		// Thin packs may have delta bases stored anywhere in the repository.
+		g.packed.Resolve = g.objects.Get
+	}
+
+	return g
+}
+
+// The top-level directory of the working tree, "" for a bare repository.
+func (g Git) WorkTree() string {
+	return g.worktree
+}
+
+// Set the top-level directory of the working tree, "" for a bare
+// repository.
+func (g *Git) SetWorkTree(dir string) {
+	g.worktree = dir
+}
+
+// Whether the repository has no working tree.
+func (g Git) IsBare() bool {
+	return g.worktree == ""
+}
+
+// The hash algorithm naming the objects of the repository.
+func (g Git) ObjectFormat() plumbing.ObjectFormat {
+	return g.format
+}
+
+// The .git directory itself.
+func (g Git) GitDir() string {
+	return g.basedir
+}
+
+// ReadGitFile reads a .git file, which stands in for a git directory kept
+// elsewhere with a "gitdir: <path>" line. A relative path is relative to
+// the directory of the file.
+func ReadGitFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimRight(string(data), "\r\n")
+
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", errors.GitError{Message: fmt.Sprintf("invalid gitfile format: %s", path)}
+	}
+
+	gitDir := strings.TrimPrefix(line, "gitdir: ")
+
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+
+	return gitDir, nil
+}
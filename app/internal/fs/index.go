@@ -2,133 +2,48 @@ package fs
 
 import (
 	"bufio"
-	"io"
-	"io/fs"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 
-	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
-	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
-	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
-	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
 )
 
-const (
-	suffix     = ".git"
-	objectPath = "objects"
-	packPath   = "pack"
-)
+const indexFile = "index"
 
-type Git struct {
-	basedir string
+func (g Git) IndexPath() string {
+	return filepath.Join(g.basedir, indexFile)
 }
 
-// Find the directory containing the Git index folder from a given path.
-// Currently, only finds the closest .git directory if it exists in the current tree.
-// TODO: Make this support all different ways.
-func FindGit(curDir string) (*Git, error) {
-	for marker := curDir; marker != "/"; {
-		utils.InfoLogger.Printf("Checking directory: %s\n", marker)
-		if filepath.Base(marker) == suffix {
-			return &Git{
-				basedir: marker,
-			}, nil
-		}
-
-		if utils.PathExists(filepath.Join(marker, suffix)) {
-			return &Git{
-				basedir: filepath.Join(marker, suffix),
-			}, nil
-		}
+// Read the index of the repository. A missing index is an empty one.
+func (g Git) ReadIndex() (*index.Index, error) {
+	f, err := os.Open(g.IndexPath())
 
-		marker = filepath.Dir(marker)
+	if os.IsNotExist(err) {
+		return index.New(g.format), nil
 	}
 
-	return nil, errors.GitError{
-		Message: "Not a git repository (or any of the parent directories): .git",
-	}
-}
-
-func (g Git) GetObjectReader(objectSha string) (io.Reader, error) {
-	blobPath, err := g.GetObjectPath(objectSha)
-
 	if err != nil {
 		return nil, err
 	}
 
-	f, err := os.Open(blobPath)
+	defer f.Close()
 
-	if err != nil {
-		return nil, err
-	}
+	return index.Decode(bufio.NewReader(f), g.format)
+}
 
-	fileReader := bufio.NewReader(f)
+// Write the index of the repository through its lock file.
+func (g Git) WriteIndex(idx *index.Index) error {
+	lock, err := Lock(g.IndexPath())
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return fileReader, nil
-}
-
-func (g Git) GetTempObjectFile() (*os.File, error) {
-	f, err := ioutil.TempFile(filepath.Join(g.basedir, objectPath, packPath), "tmp_obj_")
-
-	return f, err
-}
-
-func (g Git) GetTreeEntries() ([]tree.Entry, error) {
-    entries := []tree.Entry{}
-
-    utils.InfoLogger.Printf("Base dir: %s\n", g.basedir)
-
-    err := filepath.Walk(filepath.Dir(g.basedir),
-    func(path string, info fs.FileInfo, err error) error {
-        utils.InfoLogger.Printf("Visit path: %s\n", path)
-        if err != nil {
-            return err
-        }
-
-        // Skip the .git directory
-        if info.IsDir() && info.Name() == suffix {
-            return filepath.SkipDir
-        }
-
-        if !info.IsDir() {
-            rawWriter := ioutil.Discard
-            objWriter, err := objfile.NewWriter(rawWriter)
-
-            if err != nil {
-                return err
-            }
-            defer objWriter.Close()
+	if err = idx.Encode(lock); err != nil {
+		lock.Rollback()
 
-            err = objWriter.WriteHeader(objfile.Blob, info.Size())
-            if err != nil {
-                return err
-            }
-
-            f, err := os.Open(path)
-            if err != nil {
-                return err
-            }
-            defer f.Close()
-
-            io.Copy(objWriter, f)
-
-            entry := tree.Entry{
-                Mode: uint32(info.Mode().Perm()),
-                Name: path,
-                Sha: []byte(objWriter.Hash().String()),
-            }
-
-            entries = append(entries, entry)
-        }
-
-        return nil
-    })
+		return err
+	}
 
-    return entries, err
+	return lock.Commit()
 }
-
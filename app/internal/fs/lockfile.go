@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"os"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+const lockSuffix = ".lock"
+
+// LockFile guards updates of a file the way Git does: new content is written
+// to <path>.lock, created exclusively, and renamed over the file on Commit.
+type LockFile struct {
+	*os.File
+
+	path string
+}
+
+func Lock(path string) (*LockFile, error) {
+	f, err := os.OpenFile(path+lockSuffix, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, &errors.PathError{
+				Op:   "lock",
+				Path: path + lockSuffix,
+				Err:  errors.GitError{Message: "File exists; another git process seems to be running"},
+			}
+		}
+
+		return nil, err
+	}
+
+	return &LockFile{File: f, path: path}, nil
+}
+
+// Commit replaces the locked file with the written content.
+func (l *LockFile) Commit() error {
+	if err := l.File.Close(); err != nil {
+		os.Remove(l.Name())
+
+		return err
+	}
+
+	return os.Rename(l.Name(), l.path)
+}
+
+// Rollback discards the written content, leaving the locked file untouched.
+func (l *LockFile) Rollback() error {
+	l.File.Close()
+
+	return os.Remove(l.Name())
+}
@@ -1,27 +1,76 @@
 package fs
 
 import (
-	"path/filepath"
+	"context"
 
 	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
-	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
 )
 
-func (g Git) ComputeObjectPath(objectSha string) string {
-	return filepath.Join(g.basedir, objectPath, objectSha[:2], objectSha[2:])
+// The object store of the repository.
+func (g Git) Objects() odb.ObjectStore {
+	return g.objects
 }
 
-// Get the path of an object stored in Git, also checking if it exists.
-func (g Git) GetObjectPath(objectSha string) (string, error) {
-	objectPath := g.ComputeObjectPath(objectSha)
+// Find the objects whose hash starts with the given hex prefix, both loose
+// and packed.
+func (g Git) FindObjects(prefix string) ([]plumbing.Hash, error) {
+	return odb.FindPrefix(g.objects, prefix)
+}
+
+// Read an object fully into memory, returning its type and content.
+func (g Git) ReadObject(objectSha string) (objfile.GitObjectType, []byte, error) {
+	hash, err := plumbing.NewHash(objectSha)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return g.objects.Get(hash)
+}
+
+// Read an object fully into memory by its id.
+func (g Git) ReadObjectByHash(hash plumbing.Hash) (objfile.GitObjectType, []byte, error) {
+	return g.objects.Get(hash)
+}
+
+// Write an object with the given content into the object store, returning
+// its hash. Writing an object that already exists is a no-op.
+func (g Git) WriteObject(t objfile.GitObjectType, content []byte) (plumbing.Hash, error) {
+	if !t.Valid() {
+		return plumbing.ZeroHash, errors.GitError{Message: "Invalid object type"}
+	}
+
+	return g.objects.Put(t, content)
+}
+
+// Write an object of any type name, even one Git does not know about, into
+// the loose object store.
+func (g Git) WriteLiteralObject(typeName string, content []byte) (plumbing.Hash, error) {
+	if g.loose == nil {
+		return plumbing.ZeroHash, errors.GitError{Message: "Objects of unknown types can only be written as loose objects"}
+	}
+
+	return g.loose.PutLiteral(typeName, content)
+}
+
+// Describe an object, reading only its header when it is stored loose.
+func (g Git) StatObject(hash plumbing.Hash) (odb.ObjectInfo, error) {
+	return g.objects.Stat(hash)
+}
+
+// List the ids of all objects in the repository, loose and packed, sorted.
+func (g Git) ListObjects(ctx context.Context) ([]plumbing.Hash, error) {
+	return odb.List(ctx, g.objects)
+}
 
-	if !utils.PathExists(objectPath) {
-		return "", &errors.PathError{
-			Op:   "stat",
-			Path: objectPath,
-			Err:  nil,
-		}
+// List the ids of the packed objects of the repository, sorted.
+func (g Git) ListPackedObjects(ctx context.Context) ([]plumbing.Hash, error) {
+	if g.packed == nil {
+		return []plumbing.Hash{}, nil
 	}
 
-	return objectPath, nil
+	return odb.List(ctx, g.packed)
 }
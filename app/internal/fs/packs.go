@@ -0,0 +1,15 @@
+package fs
+
+import (
+	"path/filepath"
+)
+
+// The directory holding the objects of the repository.
+func (g Git) ObjectDir() string {
+	return filepath.Join(g.basedir, objectPath)
+}
+
+// The directory holding the packfiles of the repository.
+func (g Git) PackDir() string {
+	return filepath.Join(g.basedir, objectPath, packPath)
+}
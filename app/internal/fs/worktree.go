@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// WalkFunc is called with the path of each file and directory of the working
+// tree, relative to its top and using forward slashes. Returning
+// filepath.SkipDir for a directory skips its contents.
+type WalkFunc func(name string, info os.FileInfo) error
+
+// Walk the working tree in lexical order, skipping .git directories.
+func (g Git) WalkWorkTree(fn WalkFunc) error {
+	root := g.WorkTree()
+
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == root {
+			return nil
+		}
+
+		if info.IsDir() && info.Name() == suffix {
+			return filepath.SkipDir
+		}
+
+		name, err := filepath.Rel(root, path)
+
+		if err != nil {
+			return err
+		}
+
+		return fn(filepath.ToSlash(name), info)
+	})
+}
+
+// Stat a file of the working tree without following symlinks. A file in
+// the way of one of its directories means it does not exist either.
+func (g Git) StatWorkTree(name string) (os.FileInfo, error) {
+	path := filepath.Join(g.WorkTree(), filepath.FromSlash(name))
+	info, err := os.Lstat(path)
+
+	if errors.Is(err, syscall.ENOTDIR) {
+		return nil, &os.PathError{Op: "lstat", Path: path, Err: os.ErrNotExist}
+	}
+
+	return info, err
+}
@@ -0,0 +1,646 @@
+// Package graph draws the ASCII history graph of git log --graph, one
+// commit at a time, the way git lays it out: each line of history keeps a
+// column, merges open new columns with '\' and lines joining collapse
+// back with '/'.
+package graph
+
+import (
+	"io"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// What the next line of the graph shows.
+type state int
+
+const (
+	statePadding state = iota
+	stateSkip
+	statePreCommit
+	stateCommit
+	statePostMerge
+	stateCollapsing
+)
+
+var mergeChars = []byte{'/', '|', '\\'}
+
+// Graph is the state of a graph being drawn. Commits must be given in an
+// order where none comes before its children, with the parents that are
+// shown in the graph.
+type Graph struct {
+	commit  plumbing.Hash
+	parents []plumbing.Hash
+	started bool
+
+	// The character drawn for commits.
+	Mark string
+
+	width        int
+	expansionRow int
+
+	state, prevState state
+
+	commitIndex, prevCommitIndex int
+
+	// Whether the first parent of a merge is to the left (1) or right (0)
+	// of the merge, or -1 before it is known.
+	mergeLayout int
+
+	// Columns added to the right of the commit by a merge.
+	edgesAdded, prevEdgesAdded int
+
+	// The commit each column is waiting for, before and after this
+	// commit.
+	columns, newColumns []plumbing.Hash
+
+	// Which of newColumns each screen position of this line leads to, or
+	// -1 for none.
+	mapping, oldMapping []int
+	mappingSize         int
+}
+
+func New() *Graph {
+	return &Graph{Mark: "*"}
+}
+
+// Update the graph for the next commit to draw.
+func (g *Graph) Update(commit plumbing.Hash, parents []plumbing.Hash) {
+	g.commit = commit
+	g.parents = parents
+	g.started = true
+	g.prevCommitIndex = g.commitIndex
+
+	g.updateColumns()
+
+	g.expansionRow = 0
+
+	if g.state != statePadding {
+		g.state = stateSkip
+	} else if g.needsPreCommitLine() {
+		g.state = statePreCommit
+	} else {
+		g.state = stateCommit
+	}
+}
+
+func (g *Graph) setState(s state) {
+	g.prevState = g.state
+	g.state = s
+}
+
+func (g *Graph) findNewColumn(commit plumbing.Hash) int {
+	for i, c := range g.newColumns {
+		if c == commit {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (g *Graph) insertIntoNewColumns(commit plumbing.Hash, index int) {
+	i := g.findNewColumn(commit)
+
+	if i < 0 {
+		i = len(g.newColumns)
+		g.newColumns = append(g.newColumns, commit)
+	}
+
+	mappingIndex := 0
+
+	switch {
+	case len(g.parents) > 1 && index > -1 && g.mergeLayout == -1:
+		// The first parent of a merge: lay the merge out depending on
+		// whether it is to the left of the merge.
+		dist := index - i
+		shift := 1
+
+		if dist > 1 {
+			shift = 2*dist - 3
+		}
+
+		g.mergeLayout = 1
+
+		if dist > 0 {
+			g.mergeLayout = 0
+		}
+
+		g.edgesAdded = len(g.parents) + g.mergeLayout - 2
+
+		mappingIndex = g.width + (g.mergeLayout-1)*shift
+		g.width += 2 * g.mergeLayout
+	case g.edgesAdded > 0 && i == g.mapping[g.width-2]:
+		// A merge added columns, but this one was found in the last
+		// existing column: join the two edges right away.
+		mappingIndex = g.width - 2
+		g.edgesAdded = -1
+	default:
+		mappingIndex = g.width
+		g.width += 2
+	}
+
+	g.mapping[mappingIndex] = i
+}
+
+func (g *Graph) updateColumns() {
+	g.columns, g.newColumns = g.newColumns, g.columns[:0]
+
+	maxNewColumns := len(g.columns) + len(g.parents)
+	g.mappingSize = 2 * maxNewColumns
+
+	for len(g.mapping) < g.mappingSize {
+		g.mapping = append(g.mapping, -1)
+		g.oldMapping = append(g.oldMapping, -1)
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		g.mapping[i] = -1
+	}
+
+	g.width = 0
+	g.prevEdgesAdded = g.edgesAdded
+	g.edgesAdded = 0
+
+	seenThis := false
+
+	for i := 0; i <= len(g.columns); i++ {
+		var column plumbing.Hash
+
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+
+			column = g.commit
+		} else {
+			column = g.columns[i]
+		}
+
+		if column != g.commit {
+			g.insertIntoNewColumns(column, -1)
+
+			continue
+		}
+
+		seenThis = true
+		g.commitIndex = i
+		g.mergeLayout = -1
+
+		for _, parent := range g.parents {
+			g.insertIntoNewColumns(parent, i)
+		}
+
+		// The commit takes up at least two characters.
+		if len(g.parents) == 0 {
+			g.width += 2
+		}
+	}
+
+	for g.mappingSize > 1 && g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+}
+
+func (g *Graph) dashedParents() int {
+	return len(g.parents) + g.mergeLayout - 3
+}
+
+func (g *Graph) expansionRows() int {
+	return g.dashedParents() * 2
+}
+
+// Octopus merges need room around them before the commit line.
+func (g *Graph) needsPreCommitLine() bool {
+	return len(g.parents) >= 3 && g.commitIndex < len(g.columns)-1 && g.expansionRow < g.expansionRows()
+}
+
+// Whether every column is where it belongs, or one to its right, in which
+// case '/' takes it there.
+func (g *Graph) isMappingCorrect() bool {
+	for i := 0; i < g.mappingSize; i++ {
+		if target := g.mapping[i]; target >= 0 && target != i/2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Pad a line to the width of the graph, so that what follows it lines up.
+func (g *Graph) pad(line *strings.Builder) {
+	if line.Len() < g.width {
+		line.WriteString(strings.Repeat(" ", g.width-line.Len()))
+	}
+}
+
+func (g *Graph) paddingLine(line *strings.Builder) {
+	for range g.newColumns {
+		line.WriteString("| ")
+	}
+}
+
+func (g *Graph) skipLine(line *strings.Builder) {
+	line.WriteString("...")
+
+	if g.needsPreCommitLine() {
+		g.setState(statePreCommit)
+	} else {
+		g.setState(stateCommit)
+	}
+}
+
+func (g *Graph) preCommitLine(line *strings.Builder) {
+	seenThis := false
+
+	for i, column := range g.columns {
+		switch {
+		case column == g.commit:
+			seenThis = true
+			line.WriteByte('|')
+			line.WriteString(strings.Repeat(" ", g.expansionRow))
+		case seenThis && g.expansionRow == 0:
+			// Lines after a merge ending in a post-merge line were drawn
+			// as '\'; keep drawing them so.
+			if g.prevState == statePostMerge && g.prevCommitIndex < i {
+				line.WriteByte('\\')
+			} else {
+				line.WriteByte('|')
+			}
+		case seenThis && g.expansionRow > 0:
+			line.WriteByte('\\')
+		default:
+			line.WriteByte('|')
+		}
+
+		line.WriteByte(' ')
+	}
+
+	g.expansionRow++
+
+	if !g.needsPreCommitLine() {
+		g.setState(stateCommit)
+	}
+}
+
+// The dashes leading to the parents of an octopus merge.
+func (g *Graph) octopusMerge(line *strings.Builder) {
+	dashed := g.dashedParents()
+
+	for i := 0; i < dashed; i++ {
+		line.WriteByte('-')
+
+		if i == dashed-1 {
+			line.WriteByte('.')
+		} else {
+			line.WriteByte('-')
+		}
+	}
+}
+
+func (g *Graph) commitLine(line *strings.Builder) {
+	seenThis := false
+
+	for i := 0; i <= len(g.columns); i++ {
+		var column plumbing.Hash
+
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+
+			column = g.commit
+		} else {
+			column = g.columns[i]
+		}
+
+		switch {
+		case column == g.commit:
+			seenThis = true
+			line.WriteString(g.Mark)
+
+			if len(g.parents) > 2 {
+				g.octopusMerge(line)
+			}
+		case seenThis && g.edgesAdded > 1:
+			line.WriteByte('\\')
+		case seenThis && g.edgesAdded == 1:
+			// A merge skewed to the right has no pre-commit line: keep
+			// drawing '\' lines from a post-merge line before it.
+			if g.prevState == statePostMerge && g.prevEdgesAdded > 0 && g.prevCommitIndex < i {
+				line.WriteByte('\\')
+			} else {
+				line.WriteByte('|')
+			}
+		case g.prevState == stateCollapsing && g.oldMapping[2*i+1] == i && g.mapping[2*i] < i:
+			line.WriteByte('/')
+		default:
+			line.WriteByte('|')
+		}
+
+		line.WriteByte(' ')
+	}
+
+	switch {
+	case len(g.parents) > 1:
+		g.setState(statePostMerge)
+	case g.isMappingCorrect():
+		g.setState(statePadding)
+	default:
+		g.setState(stateCollapsing)
+	}
+}
+
+func (g *Graph) postMergeLine(line *strings.Builder) {
+	seenThis := false
+	parentColumn := false
+
+	for i := 0; i <= len(g.columns); i++ {
+		var column plumbing.Hash
+
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+
+			column = g.commit
+		} else {
+			column = g.columns[i]
+		}
+
+		switch {
+		case column == g.commit:
+			// Draw the edges to the parents, in the columns they have
+			// after this commit.
+			seenThis = true
+			index := g.mergeLayout
+
+			for j := range g.parents {
+				line.WriteByte(mergeChars[index])
+
+				if index == 2 {
+					if g.edgesAdded > 0 || j < len(g.parents)-1 {
+						line.WriteByte(' ')
+					}
+				} else {
+					index++
+				}
+			}
+
+			if g.edgesAdded == 0 {
+				line.WriteByte(' ')
+			}
+		case seenThis:
+			if g.edgesAdded > 0 {
+				line.WriteByte('\\')
+			} else {
+				line.WriteByte('|')
+			}
+
+			line.WriteByte(' ')
+		default:
+			line.WriteByte('|')
+
+			if g.mergeLayout != 0 || i != g.commitIndex-1 {
+				if parentColumn {
+					line.WriteByte('_')
+				} else {
+					line.WriteByte(' ')
+				}
+			}
+		}
+
+		if column == g.parents[0] {
+			parentColumn = true
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.setState(statePadding)
+	} else {
+		g.setState(stateCollapsing)
+	}
+}
+
+// Move each column one step towards where it belongs, crossing at most
+// one other line horizontally.
+func (g *Graph) collapsingLine(line *strings.Builder) {
+	usedHorizontal := false
+	horizontalEdge, horizontalEdgeTarget := -1, -1
+
+	g.mapping, g.oldMapping = g.oldMapping, g.mapping
+
+	for i := 0; i < g.mappingSize; i++ {
+		g.mapping[i] = -1
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.oldMapping[i]
+
+		if target < 0 {
+			continue
+		}
+
+		switch {
+		case target*2 == i:
+			g.mapping[i] = target
+		case g.mapping[i-1] < 0:
+			// Nothing to the left: move left by one.
+			g.mapping[i-1] = target
+
+			if horizontalEdge == -1 {
+				horizontalEdge, horizontalEdgeTarget = i, target
+
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		case g.mapping[i-1] == target:
+			// The line to the left shares our parent: join it.
+		default:
+			// Cross over the line to the left.
+			g.mapping[i-2] = target
+
+			if horizontalEdge == -1 {
+				horizontalEdge, horizontalEdgeTarget = i-1, target
+
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		}
+	}
+
+	copy(g.oldMapping, g.mapping[:g.mappingSize])
+
+	if g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+
+	for i := 0; i < g.mappingSize; i++ {
+		target := g.mapping[i]
+
+		switch {
+		case target < 0:
+			line.WriteByte(' ')
+		case target*2 == i:
+			line.WriteByte('|')
+		case target == horizontalEdgeTarget && i != horizontalEdge-1:
+			// Only the first segment of a horizontal line continues to
+			// the next line.
+			if i != target*2+3 {
+				g.mapping[i] = -1
+			}
+
+			usedHorizontal = true
+			line.WriteByte('_')
+		default:
+			if usedHorizontal && i < horizontalEdge {
+				g.mapping[i] = -1
+			}
+
+			line.WriteByte('/')
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.setState(statePadding)
+	}
+}
+
+// NextLine returns the next line of the graph for the current commit, and
+// whether it is the line of the commit itself.
+func (g *Graph) NextLine() (string, bool) {
+	if !g.started {
+		return "", false
+	}
+
+	var line strings.Builder
+
+	commitLine := false
+
+	switch g.state {
+	case statePadding:
+		g.paddingLine(&line)
+	case stateSkip:
+		g.skipLine(&line)
+	case statePreCommit:
+		g.preCommitLine(&line)
+	case stateCommit:
+		g.commitLine(&line)
+		commitLine = true
+	case statePostMerge:
+		g.postMergeLine(&line)
+	case stateCollapsing:
+		g.collapsingLine(&line)
+	}
+
+	g.pad(&line)
+
+	return line.String(), commitLine
+}
+
+// PaddingLine returns a line continuing every column, to go next to
+// output between commits. Before the commit line, it leaves room for the
+// dashes of octopus merges.
+func (g *Graph) PaddingLine() string {
+	if g.state != stateCommit {
+		line, _ := g.NextLine()
+
+		return line
+	}
+
+	var line strings.Builder
+
+	for _, column := range g.columns {
+		line.WriteByte('|')
+
+		if column == g.commit && len(g.parents) > 2 {
+			line.WriteString(strings.Repeat(" ", (len(g.parents)-2)*2))
+		} else {
+			line.WriteByte(' ')
+		}
+	}
+
+	g.pad(&line)
+	g.prevState = statePadding
+
+	return line.String()
+}
+
+// IsCommitFinished reports whether every line of the current commit has
+// been drawn.
+func (g *Graph) IsCommitFinished() bool {
+	return g.state == statePadding
+}
+
+// ShowCommit writes the lines of the graph up to and including the line of
+// the current commit, leaving the output after it.
+func (g *Graph) ShowCommit(w io.Writer) {
+	if g.IsCommitFinished() {
+		io.WriteString(w, g.PaddingLine())
+
+		return
+	}
+
+	for !g.IsCommitFinished() {
+		line, commitLine := g.NextLine()
+		io.WriteString(w, line)
+
+		if commitLine {
+			return
+		}
+
+		io.WriteString(w, "\n")
+	}
+}
+
+// ShowRemainder writes the lines of the graph left for the current commit,
+// without a final newline. It reports whether there were any.
+func (g *Graph) ShowRemainder(w io.Writer) bool {
+	shown := false
+
+	for !g.IsCommitFinished() {
+		if shown {
+			io.WriteString(w, "\n")
+		}
+
+		line, _ := g.NextLine()
+		io.WriteString(w, line)
+		shown = true
+	}
+
+	return shown
+}
+
+// ShowMessage writes the text shown for the current commit, putting the
+// next line of the graph before each of its lines but the first, then the
+// rest of the graph for the commit.
+func (g *Graph) ShowMessage(w io.Writer, message string) {
+	lines := strings.SplitAfter(message, "\n")
+
+	for i, text := range lines {
+		io.WriteString(w, text)
+
+		if strings.HasSuffix(text, "\n") && i+1 < len(lines) && lines[i+1] != "" {
+			line, _ := g.NextLine()
+			io.WriteString(w, line)
+		}
+	}
+
+	if g.IsCommitFinished() {
+		return
+	}
+
+	terminated := strings.HasSuffix(message, "\n")
+
+	if !terminated {
+		io.WriteString(w, "\n")
+	}
+
+	g.ShowRemainder(w)
+
+	if terminated {
+		io.WriteString(w, "\n")
+	}
+}
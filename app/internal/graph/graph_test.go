@@ -0,0 +1,60 @@
+package graph_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/graph"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func hash(t *testing.T, digit string) plumbing.Hash {
+	t.Helper()
+
+	h, err := plumbing.NewHash(strings.Repeat(digit, 40))
+	utils.Expect(t, err, nil)
+
+	return h
+}
+
+func TestGraph(t *testing.T) {
+	r, a, b, c, d, o := hash(t, "1"), hash(t, "2"), hash(t, "3"), hash(t, "4"), hash(t, "5"), hash(t, "6")
+
+	// An octopus merge of a, b and c, and d built on b, in the order
+	// git log --graph lists them.
+	commits := []struct {
+		name    string
+		hash    plumbing.Hash
+		parents []plumbing.Hash
+	}{
+		{"o", o, []plumbing.Hash{a, b, c}},
+		{"c", c, []plumbing.Hash{r}},
+		{"a", a, []plumbing.Hash{r}},
+		{"d", d, []plumbing.Hash{b}},
+		{"b", b, []plumbing.Hash{r}},
+		{"r", r, nil},
+	}
+
+	g := graph.New()
+
+	var out strings.Builder
+
+	for _, commit := range commits {
+		g.Update(commit.hash, commit.parents)
+		g.ShowCommit(&out)
+		g.ShowMessage(&out, commit.name+"\n")
+	}
+
+	utils.Expect(t, out.String(), "*-.   o\n"+
+		"|\\ \\  \n"+
+		"| | * c\n"+
+		"* | | a\n"+
+		"| |/  \n"+
+		"|/|   \n"+
+		"| | * d\n"+
+		"| |/  \n"+
+		"| * b\n"+
+		"|/  \n"+
+		"* r\n")
+}
@@ -0,0 +1,227 @@
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ignoreFile  = ".gitignore"
+	excludeFile = "info/exclude"
+)
+
+// Pattern is a single line of a .gitignore file.
+type Pattern struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+
+	// Directory of the .gitignore file the pattern comes from, relative to
+	// the top of the working tree ("" for the top).
+	base string
+}
+
+// ParsePatterns parses the lines of a .gitignore file located in the
+// directory base.
+func ParsePatterns(data []byte, base string) []Pattern {
+	patterns := []Pattern{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Trailing spaces are ignored unless escaped with a backslash.
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+			line = line[:len(line)-1]
+		}
+
+		p := Pattern{base: base}
+
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		p.anchored = strings.Contains(line, "/")
+		p.pattern = strings.TrimPrefix(line, "/")
+
+		patterns = append(patterns, p)
+	}
+
+	return patterns
+}
+
+// Match reports whether the pattern applies to path, a path relative to
+// the top of the working tree.
+func (p Pattern) Match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if p.base != "" {
+		if !strings.HasPrefix(path, p.base+"/") {
+			return false
+		}
+
+		path = path[len(p.base)+1:]
+	}
+
+	if !p.anchored {
+		path = path[strings.LastIndexByte(path, '/')+1:]
+	}
+
+	return Wildmatch(p.pattern, path)
+}
+
+// Matcher decides whether paths are ignored from the patterns of the
+// user's excludes file, .git/info/exclude and the .gitignore files of the
+// visited directories, which take precedence in that order.
+type Matcher struct {
+	workTree string
+	patterns []Pattern
+}
+
+// NewMatcher loads the excludes file, if excludesFile is not "", the
+// repository-wide exclude file and the top-level .gitignore of the working
+// tree.
+func NewMatcher(workTree, gitDir, excludesFile string) *Matcher {
+	m := &Matcher{workTree: workTree}
+
+	if excludesFile != "" {
+		if data, err := ioutil.ReadFile(excludesFile); err == nil {
+			m.Add(ParsePatterns(data, ""))
+		}
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(gitDir, excludeFile)); err == nil {
+		m.Add(ParsePatterns(data, ""))
+	}
+
+	m.EnterDir("")
+
+	return m
+}
+
+func (m *Matcher) Add(patterns []Pattern) {
+	m.patterns = append(m.patterns, patterns...)
+}
+
+// EnterDir loads the .gitignore file of dir, if any. Directories must be
+// entered from the top down for deeper files to take precedence.
+func (m *Matcher) EnterDir(dir string) {
+	data, err := ioutil.ReadFile(filepath.Join(m.workTree, filepath.FromSlash(dir), ignoreFile))
+
+	if err != nil {
+		return
+	}
+
+	m.Add(ParsePatterns(data, dir))
+}
+
+// Ignored reports whether path is ignored; the last matching pattern wins.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if m.patterns[i].Match(path, isDir) {
+			return !m.patterns[i].negate
+		}
+	}
+
+	return false
+}
+
+// Wildmatch matches a glob against a path where '*' and '?' do not match
+// '/', and "**" as a whole path component matches any number of directories.
+func Wildmatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch {
+		case strings.HasPrefix(pattern, "**/"):
+			for i := 0; i <= len(name); i++ {
+				if (i == 0 || name[i-1] == '/') && Wildmatch(pattern[3:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case pattern == "**":
+			return true
+		case strings.HasPrefix(pattern, "/**") && len(pattern) == 3:
+			return strings.HasPrefix(name, "/")
+		}
+
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+
+			for i := 0; i <= len(name); i++ {
+				if Wildmatch(pattern, name[i:]) {
+					return true
+				}
+
+				if i < len(name) && name[i] == '/' {
+					return false
+				}
+			}
+
+			return false
+		case '?':
+			if name == "" || name[0] == '/' {
+				return false
+			}
+		case '[':
+			end := strings.IndexByte(pattern[1:], ']')
+
+			if end < 0 || name == "" || name[0] == '/' {
+				return false
+			}
+
+			class := pattern[:end+2]
+
+			if strings.HasPrefix(class, "[!") {
+				class = "[^" + class[2:]
+			}
+
+			if matched, err := filepath.Match(class, name[:1]); err != nil || !matched {
+				return false
+			}
+
+			pattern = pattern[end+2:]
+			name = name[1:]
+
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+		default:
+			if name == "" || name[0] != pattern[0] {
+				return false
+			}
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return name == ""
+}
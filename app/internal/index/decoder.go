@@ -0,0 +1,318 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+var (
+	indexSignature = []byte("DIRC")
+	treeSignature  = []byte("TREE")
+)
+
+const (
+	flagAssumeValid = 0x8000
+	flagExtended    = 0x4000
+	flagStageMask   = 0x3000
+	flagStageShift  = 12
+	flagNameMask    = 0x0fff
+
+	extFlagSkipWorktree = 0x4000
+	extFlagIntentToAdd  = 0x2000
+
+	// Size of the fixed part of an entry, up to and including the flags,
+	// without the object id.
+	entryHeaderSize = 42
+)
+
+// Decode parses the index file of a repository of the given object format,
+// verifying its trailing checksum.
+func Decode(r io.Reader, format plumbing.ObjectFormat) (*Index, error) {
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashSize := format.Size()
+
+	if len(data) < 12+hashSize || !bytes.Equal(data[:4], indexSignature) {
+		return nil, errors.GitError{Message: "Bad index file signature"}
+	}
+
+	body := data[:len(data)-hashSize]
+
+	checksum, _ := format.HashFromBytes(data[len(body):])
+
+	if format.NewHasher(body).Sum() != checksum {
+		return nil, errors.GitError{Message: "Bad index file sha1 signature"}
+	}
+
+	idx := &Index{Version: binary.BigEndian.Uint32(body[4:8]), Format: format}
+
+	if idx.Version < minVersion || idx.Version > maxVersion {
+		return nil, errors.GitError{Message: fmt.Sprintf("Bad index version %d", idx.Version)}
+	}
+
+	d := &decoder{data: body, pos: 12, version: idx.Version, format: format}
+	count := binary.BigEndian.Uint32(body[8:12])
+
+	idx.Entries = make([]*Entry, 0, count)
+
+	for i := uint32(0); i < count; i++ {
+		e, err := d.readEntry()
+
+		if err != nil {
+			return nil, err
+		}
+
+		idx.Entries = append(idx.Entries, e)
+	}
+
+	for d.pos < len(body) {
+		if len(body)-d.pos < 8 {
+			return nil, errors.GitError{Message: "Index extension is truncated"}
+		}
+
+		var ext Extension
+		copy(ext.Signature[:], body[d.pos:])
+		size := int(binary.BigEndian.Uint32(body[d.pos+4:]))
+		d.pos += 8
+
+		if len(body)-d.pos < size {
+			return nil, errors.GitError{Message: "Index extension is truncated"}
+		}
+
+		ext.Data = body[d.pos : d.pos+size]
+		d.pos += size
+
+		switch {
+		case bytes.Equal(ext.Signature[:], treeSignature):
+			if idx.Cache, err = decodeTree(ext.Data, format); err != nil {
+				return nil, err
+			}
+		case ext.Signature[0] >= 'A' && ext.Signature[0] <= 'Z':
+			idx.Extensions = append(idx.Extensions, ext)
+		default:
+			return nil, errors.GitError{Message: fmt.Sprintf("Index uses the unsupported %s extension", ext)}
+		}
+	}
+
+	return idx, nil
+}
+
+type decoder struct {
+	data     []byte
+	pos      int
+	version  uint32
+	format   plumbing.ObjectFormat
+	lastName []byte
+}
+
+func (d *decoder) uint32() uint32 {
+	v := binary.BigEndian.Uint32(d.data[d.pos:])
+	d.pos += 4
+
+	return v
+}
+
+func (d *decoder) time() time.Time {
+	seconds := d.uint32()
+	nanos := d.uint32()
+
+	return time.Unix(int64(seconds), int64(nanos))
+}
+
+// Read the prefix-compression varint of version 4 entries.
+func (d *decoder) varint() (int, error) {
+	if d.pos >= len(d.data) {
+		return 0, errors.GitError{Message: "Index entry is truncated"}
+	}
+
+	c := d.data[d.pos]
+	d.pos++
+	value := int(c & 0x7f)
+
+	for c&0x80 != 0 {
+		if d.pos >= len(d.data) {
+			return 0, errors.GitError{Message: "Index entry is truncated"}
+		}
+
+		c = d.data[d.pos]
+		d.pos++
+		value = ((value + 1) << 7) | int(c&0x7f)
+	}
+
+	return value, nil
+}
+
+func (d *decoder) readEntry() (*Entry, error) {
+	start := d.pos
+
+	if len(d.data)-d.pos < entryHeaderSize+d.format.Size() {
+		return nil, errors.GitError{Message: "Index entry is truncated"}
+	}
+
+	e := &Entry{}
+	e.CTime = d.time()
+	e.MTime = d.time()
+	e.Dev = d.uint32()
+	e.Ino = d.uint32()
+	e.Mode = d.uint32()
+	e.UID = d.uint32()
+	e.GID = d.uint32()
+	e.Size = d.uint32()
+	e.Hash, _ = d.format.HashFromBytes(d.data[d.pos:])
+	d.pos += d.format.Size()
+
+	flags := binary.BigEndian.Uint16(d.data[d.pos:])
+	d.pos += 2
+
+	e.AssumeValid = flags&flagAssumeValid != 0
+	e.Stage = Stage((flags & flagStageMask) >> flagStageShift)
+
+	if flags&flagExtended != 0 {
+		if d.version < 3 {
+			return nil, errors.GitError{Message: "Extended index entry flags need index version 3"}
+		}
+
+		if len(d.data)-d.pos < 2 {
+			return nil, errors.GitError{Message: "Index entry is truncated"}
+		}
+
+		extended := binary.BigEndian.Uint16(d.data[d.pos:])
+		d.pos += 2
+
+		e.SkipWorktree = extended&extFlagSkipWorktree != 0
+		e.IntentToAdd = extended&extFlagIntentToAdd != 0
+	}
+
+	var name []byte
+
+	if d.version == 4 {
+		strip, err := d.varint()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if strip > len(d.lastName) {
+			return nil, errors.GitError{Message: "Malformed name field in the index"}
+		}
+
+		end := bytes.IndexByte(d.data[d.pos:], 0)
+
+		if end < 0 {
+			return nil, errors.GitError{Message: "Index entry is truncated"}
+		}
+
+		prefix := d.lastName[:len(d.lastName)-strip]
+		name = append(append([]byte{}, prefix...), d.data[d.pos:d.pos+end]...)
+		d.pos += end + 1
+	} else {
+		end := bytes.IndexByte(d.data[d.pos:], 0)
+
+		if end < 0 {
+			return nil, errors.GitError{Message: "Index entry is truncated"}
+		}
+
+		name = d.data[d.pos : d.pos+end]
+
+		// Entries are NUL padded to a multiple of eight bytes.
+		d.pos = start + (d.pos-start+end+8)&^7
+	}
+
+	if length := int(flags & flagNameMask); length != flagNameMask && length != len(name) {
+		return nil, errors.GitError{Message: fmt.Sprintf("Index entry name length mismatch for '%s'", name)}
+	}
+
+	e.Name = string(name)
+	d.lastName = name
+
+	return e, nil
+}
+
+// Parse the TREE extension, a pre-order list of
+// "<path>\0<entry count> <subtree count>\n<sha>" records.
+func decodeTree(data []byte, format plumbing.ObjectFormat) (*CachedTree, error) {
+	root, rest, err := decodeTreeNode(data, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		return nil, errors.GitError{Message: "Trailing data in the TREE extension"}
+	}
+
+	return root, nil
+}
+
+func decodeTreeNode(data []byte, format plumbing.ObjectFormat) (*CachedTree, []byte, error) {
+	malformed := errors.GitError{Message: "Malformed TREE extension"}
+
+	nul := bytes.IndexByte(data, 0)
+
+	if nul < 0 {
+		return nil, nil, malformed
+	}
+
+	node := &CachedTree{Name: string(data[:nul])}
+	data = data[nul+1:]
+
+	eol := bytes.IndexByte(data, '\n')
+
+	if eol < 0 {
+		return nil, nil, malformed
+	}
+
+	counts := bytes.Fields(data[:eol])
+	data = data[eol+1:]
+
+	if len(counts) != 2 {
+		return nil, nil, malformed
+	}
+
+	entryCount, err := strconv.Atoi(string(counts[0]))
+
+	if err != nil {
+		return nil, nil, malformed
+	}
+
+	subtreeCount, err := strconv.Atoi(string(counts[1]))
+
+	if err != nil || subtreeCount < 0 {
+		return nil, nil, malformed
+	}
+
+	node.EntryCount = entryCount
+
+	if entryCount >= 0 {
+		if len(data) < format.Size() {
+			return nil, nil, malformed
+		}
+
+		node.Hash, _ = format.HashFromBytes(data)
+		data = data[format.Size():]
+	}
+
+	for i := 0; i < subtreeCount; i++ {
+		var sub *CachedTree
+
+		if sub, data, err = decodeTreeNode(data, format); err != nil {
+			return nil, nil, err
+		}
+
+		node.Subtrees = append(node.Subtrees, sub)
+	}
+
+	return node, data, nil
+}
@@ -0,0 +1,168 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Encode writes the index in its on-disk format, followed by its checksum.
+// Version 2 indexes holding entries with extended flags are upgraded to 3.
+func (idx *Index) Encode(w io.Writer) error {
+	version := idx.Version
+
+	if version < minVersion {
+		version = DefaultVersion
+	}
+
+	for _, e := range idx.Entries {
+		if version == 2 && e.extended() {
+			version = 3
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.Write(indexSignature)
+	binary.Write(buf, binary.BigEndian, version)
+	binary.Write(buf, binary.BigEndian, uint32(len(idx.Entries)))
+
+	lastName := ""
+
+	for _, e := range idx.Entries {
+		encodeEntry(buf, e, version, lastName)
+		lastName = e.Name
+	}
+
+	if idx.Cache != nil {
+		tree := bytes.NewBuffer(nil)
+		encodeTree(tree, idx.Cache)
+		writeExtension(buf, treeSignature, tree.Bytes())
+	}
+
+	for _, ext := range idx.Extensions {
+		writeExtension(buf, ext.Signature[:], ext.Data)
+	}
+
+	checksum := idx.Format.NewHasher(buf.Bytes()).Sum()
+	buf.Write(checksum.Bytes())
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func writeTime(buf *bytes.Buffer, t time.Time) {
+	var seconds, nanos uint32
+
+	if !t.IsZero() {
+		seconds = uint32(t.Unix())
+		nanos = uint32(t.Nanosecond())
+	}
+
+	binary.Write(buf, binary.BigEndian, seconds)
+	binary.Write(buf, binary.BigEndian, nanos)
+}
+
+func encodeVarint(value int) []byte {
+	buf := []byte{byte(value & 0x7f)}
+	value >>= 7
+
+	for value > 0 {
+		value--
+		buf = append([]byte{0x80 | byte(value&0x7f)}, buf...)
+		value >>= 7
+	}
+
+	return buf
+}
+
+func encodeEntry(buf *bytes.Buffer, e *Entry, version uint32, lastName string) {
+	start := buf.Len()
+
+	writeTime(buf, e.CTime)
+	writeTime(buf, e.MTime)
+
+	for _, v := range []uint32{e.Dev, e.Ino, e.Mode, e.UID, e.GID, e.Size} {
+		binary.Write(buf, binary.BigEndian, v)
+	}
+
+	buf.Write(e.Hash.Bytes())
+
+	flags := uint16(e.Stage) << flagStageShift
+
+	if len(e.Name) < flagNameMask {
+		flags |= uint16(len(e.Name))
+	} else {
+		flags |= flagNameMask
+	}
+
+	if e.AssumeValid {
+		flags |= flagAssumeValid
+	}
+
+	if version >= 3 && e.extended() {
+		flags |= flagExtended
+	}
+
+	binary.Write(buf, binary.BigEndian, flags)
+
+	if flags&flagExtended != 0 {
+		extended := uint16(0)
+
+		if e.SkipWorktree {
+			extended |= extFlagSkipWorktree
+		}
+
+		if e.IntentToAdd {
+			extended |= extFlagIntentToAdd
+		}
+
+		binary.Write(buf, binary.BigEndian, extended)
+	}
+
+	if version == 4 {
+		common := 0
+
+		for common < len(lastName) && common < len(e.Name) && lastName[common] == e.Name[common] {
+			common++
+		}
+
+		buf.Write(encodeVarint(len(lastName) - common))
+		buf.WriteString(e.Name[common:])
+		buf.WriteByte(0)
+
+		return
+	}
+
+	buf.WriteString(e.Name)
+
+	// Pad with one to eight NULs to a multiple of eight bytes.
+	padding := 8 - (buf.Len()-start)%8
+
+	buf.Write(make([]byte, padding))
+}
+
+func encodeTree(buf *bytes.Buffer, node *CachedTree) {
+	buf.WriteString(node.Name)
+	buf.WriteByte(0)
+	buf.WriteString(strconv.Itoa(node.EntryCount))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(len(node.Subtrees)))
+	buf.WriteByte('\n')
+
+	if node.Valid() {
+		buf.Write(node.Hash.Bytes())
+	}
+
+	for _, sub := range node.Subtrees {
+		encodeTree(buf, sub)
+	}
+}
+
+func writeExtension(buf *bytes.Buffer, signature []byte, data []byte) {
+	buf.Write(signature)
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,246 @@
+package index
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Stage of an entry; non-zero stages hold the sides of a merge conflict.
+type Stage uint8
+
+const (
+	Merged Stage = 0
+	Base   Stage = 1
+	Ours   Stage = 2
+	Theirs Stage = 3
+)
+
+const (
+	DefaultVersion = 2
+
+	minVersion = 2
+	maxVersion = 4
+)
+
+// Entry is one staged path, along with the stat data of the file it was
+// staged from.
+type Entry struct {
+	CTime time.Time
+	MTime time.Time
+	Dev   uint32
+	Ino   uint32
+	Mode  uint32
+	UID   uint32
+	GID   uint32
+	Size  uint32
+	Hash  plumbing.Hash
+	Stage Stage
+	Name  string
+
+	AssumeValid  bool
+	SkipWorktree bool
+	IntentToAdd  bool
+}
+
+// Extended flags need index version 3 or later.
+func (e *Entry) extended() bool {
+	return e.SkipWorktree || e.IntentToAdd
+}
+
+// Index is the in-memory form of the .git/index file.
+type Index struct {
+	Version uint32
+	Entries []*Entry
+
+	// Format is the object format of the repository, naming the entries
+	// and checksumming the file.
+	Format plumbing.ObjectFormat
+
+	// Cache is the TREE extension, or nil if the index has none.
+	Cache *CachedTree
+
+	// Optional extensions git-ditto does not interpret, kept as-is.
+	Extensions []Extension
+}
+
+// Extension is a raw, uninterpreted index extension.
+type Extension struct {
+	Signature [4]byte
+	Data      []byte
+}
+
+// New creates an empty index for a repository of the given object format.
+func New(format plumbing.ObjectFormat) *Index {
+	return &Index{Version: DefaultVersion, Format: format}
+}
+
+func compareEntries(name string, stage Stage, e *Entry) int {
+	if c := strings.Compare(name, e.Name); c != 0 {
+		return c
+	}
+
+	return int(stage) - int(e.Stage)
+}
+
+func (idx *Index) search(name string, stage Stage) (int, bool) {
+	i := sort.Search(len(idx.Entries), func(i int) bool {
+		return compareEntries(name, stage, idx.Entries[i]) <= 0
+	})
+
+	return i, i < len(idx.Entries) && compareEntries(name, stage, idx.Entries[i]) == 0
+}
+
+// Entry returns the entry for name at the given stage.
+func (idx *Index) Entry(name string, stage Stage) (*Entry, bool) {
+	i, ok := idx.search(name, stage)
+
+	if !ok {
+		return nil, false
+	}
+
+	return idx.Entries[i], true
+}
+
+// Add inserts or replaces an entry, keeping entries sorted. Adding a merged
+// entry resolves any conflict stages recorded for the same path.
+func (idx *Index) Add(e *Entry) {
+	if e.Stage == Merged {
+		for stage := Base; stage <= Theirs; stage++ {
+			idx.remove(e.Name, stage)
+		}
+	} else {
+		idx.remove(e.Name, Merged)
+	}
+
+	i, ok := idx.search(e.Name, e.Stage)
+
+	if ok {
+		idx.Entries[i] = e
+	} else {
+		idx.Entries = append(idx.Entries, nil)
+		copy(idx.Entries[i+1:], idx.Entries[i:])
+		idx.Entries[i] = e
+	}
+
+	idx.Invalidate(e.Name)
+}
+
+// Remove drops all stages of name, reporting whether anything was removed.
+func (idx *Index) Remove(name string) bool {
+	removed := false
+
+	for stage := Merged; stage <= Theirs; stage++ {
+		removed = idx.remove(name, stage) || removed
+	}
+
+	if removed {
+		idx.Invalidate(name)
+	}
+
+	return removed
+}
+
+func (idx *Index) remove(name string, stage Stage) bool {
+	i, ok := idx.search(name, stage)
+
+	if !ok {
+		return false
+	}
+
+	idx.Entries = append(idx.Entries[:i], idx.Entries[i+1:]...)
+
+	return true
+}
+
+// Conflicted reports whether any path has unmerged stages.
+func (idx *Index) Conflicted() bool {
+	for _, e := range idx.Entries {
+		if e.Stage != Merged {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sort orders entries by name and stage, as required on disk.
+func (idx *Index) Sort() {
+	sort.SliceStable(idx.Entries, func(i, j int) bool {
+		return compareEntries(idx.Entries[i].Name, idx.Entries[i].Stage, idx.Entries[j]) < 0
+	})
+}
+
+// Invalidate marks the cached trees containing path as out of date.
+func (idx *Index) Invalidate(path string) {
+	if idx.Cache != nil {
+		idx.Cache.invalidate(strings.Split(path, "/"))
+	}
+}
+
+// CachedTree is a node of the TREE extension: the id of the tree object for
+// a directory of the index, valid when EntryCount is not negative.
+type CachedTree struct {
+	// Name of the directory relative to its parent; empty for the root.
+	Name       string
+	EntryCount int
+	Hash       plumbing.Hash
+	Subtrees   []*CachedTree
+}
+
+func (t *CachedTree) Valid() bool {
+	return t.EntryCount >= 0
+}
+
+// Find the cached tree for a directory path ("" for the root).
+func (t *CachedTree) Find(path string) (*CachedTree, bool) {
+	if path == "" {
+		return t, true
+	}
+
+	node := t
+
+	for _, component := range strings.Split(path, "/") {
+		var next *CachedTree
+
+		for _, sub := range node.Subtrees {
+			if sub.Name == component {
+				next = sub
+
+				break
+			}
+		}
+
+		if next == nil {
+			return nil, false
+		}
+
+		node = next
+	}
+
+	return node, true
+}
+
+// Invalidate every tree from this node down the directories of path.
+func (t *CachedTree) invalidate(components []string) {
+	t.EntryCount = -1
+
+	if len(components) <= 1 {
+		return
+	}
+
+	for _, sub := range t.Subtrees {
+		if sub.Name == components[0] {
+			sub.invalidate(components[1:])
+
+			return
+		}
+	}
+}
+
+func (s Extension) String() string {
+	return string(bytes.TrimRight(s.Signature[:], "\x00"))
+}
@@ -0,0 +1,98 @@
+package index_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func decodeFixture(t *testing.T, name string) (*index.Index, []byte) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile("testdata/" + name)
+
+	if err != nil {
+		t.Fatalf("Could not read fixture %s: %s", name, err.Error())
+	}
+
+	idx, err := index.Decode(bytes.NewReader(data), plumbing.SHA1)
+
+	if err != nil {
+		t.Fatalf("Could not decode fixture %s: %s", name, err.Error())
+	}
+
+	return idx, data
+}
+
+func TestRoundTrip(t *testing.T) {
+	cases := []struct {
+		fixture string
+		version uint32
+		entries int
+	}{
+		{fixture: "index.v2", version: 2, entries: 4},
+		{fixture: "index.v3", version: 3, entries: 5},
+		{fixture: "index.v4", version: 4, entries: 5},
+	}
+
+	for _, c := range cases {
+		idx, data := decodeFixture(t, c.fixture)
+
+		utils.Expect(t, idx.Version, c.version)
+		utils.Expect(t, len(idx.Entries), c.entries)
+
+		buf := bytes.NewBuffer(nil)
+
+		utils.Expect(t, idx.Encode(buf), nil)
+		utils.Expect(t, bytes.Equal(buf.Bytes(), data), true)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	idx, _ := decodeFixture(t, "index.v4")
+
+	names := []string{}
+
+	for _, e := range idx.Entries {
+		names = append(names, e.Name)
+	}
+
+	utils.Expect(t, names, []string{"a.txt", "dir/b.txt", "dir/sub/c.txt", "dir/sub/dd.txt", "new.txt"})
+
+	e, ok := idx.Entry("new.txt", index.Merged)
+
+	utils.Expect(t, ok, true)
+	utils.Expect(t, e.IntentToAdd, true)
+	utils.Expect(t, e.Mode, uint32(0100644))
+}
+
+func TestTreeCache(t *testing.T) {
+	idx, _ := decodeFixture(t, "index.v2")
+
+	utils.Expect(t, idx.Cache.Valid(), true)
+	utils.Expect(t, idx.Cache.EntryCount, 4)
+	utils.Expect(t, idx.Cache.Hash.String(), "c74fea438c266f4e1ad4a89194dc1b812e861bed")
+
+	sub, ok := idx.Cache.Find("dir/sub")
+
+	utils.Expect(t, ok, true)
+	utils.Expect(t, sub.EntryCount, 2)
+
+	// Staging a path invalidates the trees containing it, and only those.
+	e, _ := idx.Entry("dir/b.txt", index.Merged)
+	idx.Add(e)
+
+	dir, _ := idx.Cache.Find("dir")
+
+	utils.Expect(t, idx.Cache.Valid(), false)
+	utils.Expect(t, dir.Valid(), false)
+	utils.Expect(t, sub.Valid(), true)
+
+	utils.Expect(t, idx.Remove("a.txt"), true)
+	utils.Expect(t, idx.Remove("a.txt"), false)
+	utils.Expect(t, len(idx.Entries), 3)
+}
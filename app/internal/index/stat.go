@@ -0,0 +1,30 @@
+package index
+
+import (
+	"os"
+)
+
+// FillStat records the stat data of the file an entry is staged from, so
+// that unchanged files can later be detected without rehashing them.
+func (e *Entry) FillStat(info os.FileInfo) {
+	e.MTime = info.ModTime()
+	e.CTime = info.ModTime()
+	e.Size = uint32(info.Size())
+
+	fillSysStat(e, info)
+}
+
+// StatMatches reports whether the file looks unchanged since the entry was
+// staged, comparing the stat data Git compares.
+func (e *Entry) StatMatches(info os.FileInfo) bool {
+	other := &Entry{}
+	other.FillStat(info)
+
+	return e.MTime.Equal(other.MTime) &&
+		e.CTime.Equal(other.CTime) &&
+		e.Size == other.Size &&
+		e.Ino == other.Ino &&
+		e.Dev == other.Dev &&
+		e.UID == other.UID &&
+		e.GID == other.GID
+}
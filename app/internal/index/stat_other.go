@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package index
+
+import (
+	"os"
+)
+
+// Without inode data, the modification time and size are compared alone.
+func fillSysStat(e *Entry, info os.FileInfo) {
+}
@@ -0,0 +1,130 @@
+package merge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+)
+
+// Commits merges two commits from their merge bases. Several merge bases,
+// as criss-cross merges leave, are first merged into one virtual commit,
+// oldest first, their conflicts left in as they are; commits without a
+// merge base are merged from the empty tree.
+func Commits(ctx context.Context, git *fs.Git, ours, theirs plumbing.Hash, opts Options) (*Result, error) {
+	// Virtual commits only live in memory, but finding the merge bases of
+	// one needs it in an object store.
+	memory := odb.NewMemory(git.ObjectFormat())
+	scratch := fs.NewGit(git.GitDir(), git.ObjectFormat(), odb.NewLayered(git.Objects(), memory))
+	m := &commitMerge{ctx: ctx, git: scratch, virtual: fs.NewGit(git.GitDir(), git.ObjectFormat(), odb.NewLayered(memory, git.Objects()))}
+
+	return m.merge(ours, theirs, &opts, 0)
+}
+
+type commitMerge struct {
+	ctx context.Context
+
+	// Reads virtual commits too, writing to the repository.
+	git *fs.Git
+
+	// Writes virtual commits.
+	virtual *fs.Git
+}
+
+func (m *commitMerge) merge(ours, theirs plumbing.Hash, opts *Options, depth int) (*Result, error) {
+	bases, err := revision.MergeBases(m.ctx, m.git, ours, theirs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var base plumbing.Hash
+	var baseLabel string
+
+	switch len(bases) {
+	case 0:
+		baseLabel = "empty tree"
+	case 1:
+		base = bases[0]
+
+		if baseLabel, err = revision.Abbreviate(m.git, base, revision.DefaultAbbrev); err != nil {
+			return nil, err
+		}
+	default:
+		baseLabel = "merged common ancestors"
+
+		if base, err = m.mergeBases(bases, opts, depth); err != nil {
+			return nil, err
+		}
+	}
+
+	var trees [3]plumbing.Hash
+
+	for i, hash := range []plumbing.Hash{base, ours, theirs} {
+		if hash.IsZero() {
+			continue
+		}
+
+		c, err := m.read(hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		trees[i] = c.Tree
+	}
+
+	return mergeTrees(m.git, trees, opts, baseLabel, depth)
+}
+
+// Merge several merge bases, newest first, into a virtual commit.
+func (m *commitMerge) mergeBases(bases []plumbing.Hash, opts *Options, depth int) (plumbing.Hash, error) {
+	inner := *opts
+	inner.OurLabel, inner.TheirLabel = "Temporary merge branch 1", "Temporary merge branch 2"
+	merged := bases[len(bases)-1]
+
+	for i := len(bases) - 2; i >= 0; i-- {
+		result, err := m.merge(merged, bases[i], &inner, depth+1)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		virtual := commit.Commit{
+			Tree:      result.Tree,
+			Parents:   []plumbing.Hash{merged, bases[i]},
+			Author:    virtualIdent,
+			Committer: virtualIdent,
+			Message:   "merged tree\n",
+		}
+
+		if merged, err = m.virtual.WriteObject(objfile.Commit, virtual.Bytes()); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	return merged, nil
+}
+
+var virtualIdent = commit.Signature{Name: "git-ditto", Email: "ditto@merge", When: time.Unix(0, 0).UTC()}
+
+func (m *commitMerge) read(hash plumbing.Hash) (*commit.Commit, error) {
+	t, data, err := m.git.ReadObjectByHash(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t != objfile.Commit {
+		return nil, errors.GitError{Message: fmt.Sprintf("%s is a %s, not a commit", hash, t)}
+	}
+
+	return commit.Decode(data)
+}
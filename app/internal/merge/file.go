@@ -0,0 +1,401 @@
+package merge
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+)
+
+// Conflict styles, as merge.conflictStyle takes them: merge shows both
+// sides of a conflict, diff3 the base between them too.
+const (
+	StyleMerge = "merge"
+	StyleDiff3 = "diff3"
+)
+
+// DefaultMarkerSize is the length of conflict markers.
+const DefaultMarkerSize = 7
+
+// FileOptions tells how to merge the content of files.
+type FileOptions struct {
+	// The algorithm comparing the base with each side; Myers unless set.
+	Algorithm diff.Algorithm
+
+	// How the sides are named after the conflict markers.
+	OurLabel, BaseLabel, TheirLabel string
+
+	// StyleMerge or StyleDiff3; StyleMerge unless set.
+	Style string
+
+	// The length of the conflict markers, DefaultMarkerSize unless set.
+	MarkerSize int
+}
+
+// What a chunk of the merge takes.
+const (
+	chunkConflict  = 0
+	chunkOurs      = 1
+	chunkTheirs    = 2
+	chunkIdentical = 4
+)
+
+// A chunk of the merge where a side changed, as runs of lines of the base
+// and both sides.
+type chunk struct {
+	mode int
+
+	base, baseLines    int
+	ours, ourLines     int
+	theirs, theirLines int
+}
+
+// Content merges the changes from base to ours and from base to theirs
+// into one file, like git's xdiff merge: changes of one side are taken as
+// they are, the same change made on both sides once, and overlapping
+// changes are left as conflicts between markers, after being narrowed
+// down to the lines that differ. It returns the merged content and the
+// number of conflicts in it.
+func Content(base, ours, theirs []byte, opts FileOptions) ([]byte, int) {
+	m := &fileMerge{
+		base:   diff.SplitLines(base),
+		ours:   diff.SplitLines(ours),
+		theirs: diff.SplitLines(theirs),
+		opts:   opts,
+	}
+
+	if m.opts.Style == "" {
+		m.opts.Style = StyleMerge
+	}
+
+	if m.opts.MarkerSize <= 0 {
+		m.opts.MarkerSize = DefaultMarkerSize
+	}
+
+	lineOpts := diff.LineOptions{Algorithm: opts.Algorithm}
+	ourEdits := diff.Lines(m.base, m.ours, lineOpts)
+	theirEdits := diff.Lines(m.base, m.theirs, lineOpts)
+
+	switch {
+	case len(ourEdits) == 0:
+		return append([]byte(nil), theirs...), 0
+	case len(theirEdits) == 0:
+		return append([]byte(nil), ours...), 0
+	}
+
+	chunks := m.chunks(ourEdits, theirEdits)
+
+	// Showing the base of a conflict makes no sense once it is narrowed
+	// down.
+	if m.opts.Style != StyleDiff3 {
+		chunks = m.refine(chunks)
+		chunks = simplify(chunks)
+	}
+
+	return m.write(chunks)
+}
+
+type fileMerge struct {
+	base, ours, theirs [][]byte
+	opts               FileOptions
+}
+
+// Line up the edits of both sides into chunks, the edits of a side that
+// overlap or touch an edit of the other making a conflict together unless
+// they are the same.
+func (m *fileMerge) chunks(ourEdits, theirEdits []diff.Edit) []*chunk {
+	chunks := []*chunk{}
+	i, j := 0, 0
+
+	for i < len(ourEdits) && j < len(theirEdits) {
+		o, t := ourEdits[i], theirEdits[j]
+
+		if o.Old+o.OldLines < t.Old {
+			chunks = appendChunk(chunks, chunkOurs, o.Old, o.OldLines, o.New, o.NewLines, t.New-t.Old+o.Old, o.OldLines)
+			i++
+
+			continue
+		}
+
+		if t.Old+t.OldLines < o.Old {
+			chunks = appendChunk(chunks, chunkTheirs, t.Old, t.OldLines, o.New-o.Old+t.Old, t.OldLines, t.New, t.NewLines)
+			j++
+
+			continue
+		}
+
+		if o.Old != t.Old || o.OldLines != t.OldLines || o.NewLines != t.NewLines || !sameLines(m.ours[o.New:o.New+o.NewLines], m.theirs[t.New:t.New+t.NewLines]) {
+			// The conflict spans both edits, the side whose edit is
+			// shorter taking the base lines of the other as unchanged.
+			off := o.Old - t.Old
+			ffo := off + o.OldLines - t.OldLines
+			b, ourStart, theirStart := o.Old, o.New, t.New
+
+			if off > 0 {
+				b, ourStart = b-off, ourStart-off
+			} else {
+				theirStart += off
+			}
+
+			baseLines := o.Old + o.OldLines - b
+			ourLines := o.New + o.NewLines - ourStart
+			theirLines := t.New + t.NewLines - theirStart
+
+			if ffo < 0 {
+				baseLines, ourLines = baseLines-ffo, ourLines-ffo
+			} else {
+				theirLines += ffo
+			}
+
+			chunks = appendChunk(chunks, chunkConflict, b, baseLines, ourStart, ourLines, theirStart, theirLines)
+		}
+
+		ourEnd, theirEnd := o.Old+o.OldLines, t.Old+t.OldLines
+
+		if ourEnd >= theirEnd {
+			j++
+		}
+
+		if theirEnd >= ourEnd {
+			i++
+		}
+	}
+
+	for ; i < len(ourEdits); i++ {
+		o := ourEdits[i]
+		chunks = appendChunk(chunks, chunkOurs, o.Old, o.OldLines, o.New, o.NewLines, o.Old+len(m.theirs)-len(m.base), o.OldLines)
+	}
+
+	for ; j < len(theirEdits); j++ {
+		t := theirEdits[j]
+		chunks = appendChunk(chunks, chunkTheirs, t.Old, t.OldLines, t.Old+len(m.ours)-len(m.base), t.OldLines, t.New, t.NewLines)
+	}
+
+	return chunks
+}
+
+// Add a chunk, growing the last one instead when they overlap, into a
+// conflict unless they take the same side.
+func appendChunk(chunks []*chunk, mode, b, baseLines, ours, ourLines, theirs, theirLines int) []*chunk {
+	if n := len(chunks); n > 0 {
+		last := chunks[n-1]
+
+		if ours <= last.ours+last.ourLines || theirs <= last.theirs+last.theirLines {
+			if mode != last.mode {
+				last.mode = chunkConflict
+			}
+
+			last.baseLines = b + baseLines - last.base
+			last.ourLines = ours + ourLines - last.ours
+			last.theirLines = theirs + theirLines - last.theirs
+
+			return chunks
+		}
+	}
+
+	return append(chunks, &chunk{
+		mode: mode,
+		base: b, baseLines: baseLines,
+		ours: ours, ourLines: ourLines,
+		theirs: theirs, theirLines: theirLines,
+	})
+}
+
+func sameLines(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Narrow conflicts down to the lines where the sides differ, comparing one
+// with the other, which can split a conflict into several.
+func (m *fileMerge) refine(chunks []*chunk) []*chunk {
+	refined := make([]*chunk, 0, len(chunks))
+
+	for _, c := range chunks {
+		if c.mode != chunkConflict || c.ourLines == 0 || c.theirLines == 0 {
+			refined = append(refined, c)
+
+			continue
+		}
+
+		edits := diff.Lines(m.ours[c.ours:c.ours+c.ourLines], m.theirs[c.theirs:c.theirs+c.theirLines], diff.LineOptions{Algorithm: m.opts.Algorithm})
+
+		if len(edits) == 0 {
+			c.mode = chunkIdentical
+			refined = append(refined, c)
+
+			continue
+		}
+
+		// Only the lines of the sides are narrowed down: the base lines
+		// are those of the whole conflict.
+		for _, e := range edits {
+			refined = append(refined, &chunk{
+				mode: chunkConflict,
+				base: c.base, baseLines: c.baseLines,
+				ours: c.ours + e.Old, ourLines: e.OldLines,
+				theirs: c.theirs + e.New, theirLines: e.NewLines,
+			})
+		}
+	}
+
+	return refined
+}
+
+// Join conflicts at most three lines apart into one, which reads better
+// than several small ones.
+func simplify(chunks []*chunk) []*chunk {
+	if len(chunks) == 0 {
+		return chunks
+	}
+
+	simplified := []*chunk{chunks[0]}
+
+	for _, next := range chunks[1:] {
+		c := simplified[len(simplified)-1]
+
+		if c.mode != chunkConflict || next.mode != chunkConflict || next.ours-(c.ours+c.ourLines) > 3 {
+			simplified = append(simplified, next)
+
+			continue
+		}
+
+		c.baseLines = next.base + next.baseLines - c.base
+		c.ourLines = next.ours + next.ourLines - c.ours
+		c.theirLines = next.theirs + next.theirLines - c.theirs
+	}
+
+	return simplified
+}
+
+// Write the merged content: our side, with the changes of theirs taken in
+// and conflicts between markers.
+func (m *fileMerge) write(chunks []*chunk) ([]byte, int) {
+	var out bytes.Buffer
+	conflicts := 0
+	i := 0
+
+	for _, c := range chunks {
+		switch c.mode {
+		case chunkConflict:
+			writeLines(&out, m.ours[i:c.ours], false, false)
+			m.writeConflict(&out, c)
+			conflicts++
+		case chunkTheirs:
+			writeLines(&out, m.ours[i:c.ours], false, false)
+			writeLines(&out, m.theirs[c.theirs:c.theirs+c.theirLines], false, false)
+		default:
+			// Our lines, copied along with those around them.
+			continue
+		}
+
+		i = c.ours + c.ourLines
+	}
+
+	writeLines(&out, m.ours[i:], false, false)
+
+	return out.Bytes(), conflicts
+}
+
+func (m *fileMerge) writeConflict(out *bytes.Buffer, c *chunk) {
+	crlf := m.needsCR(c)
+	marker := func(char byte, label string) {
+		out.WriteString(strings.Repeat(string(char), m.opts.MarkerSize))
+
+		if label != "" {
+			out.WriteString(" " + label)
+		}
+
+		if crlf {
+			out.WriteByte('\r')
+		}
+
+		out.WriteByte('\n')
+	}
+
+	marker('<', m.opts.OurLabel)
+	writeLines(out, m.ours[c.ours:c.ours+c.ourLines], crlf, true)
+
+	if m.opts.Style == StyleDiff3 {
+		marker('|', m.opts.BaseLabel)
+		writeLines(out, m.base[c.base:c.base+c.baseLines], crlf, true)
+	}
+
+	marker('=', "")
+	writeLines(out, m.theirs[c.theirs:c.theirs+c.theirLines], crlf, true)
+	marker('>', m.opts.TheirLabel)
+}
+
+// Copy lines, ending the last one with a newline if it lacks one and
+// newline is set.
+func writeLines(out *bytes.Buffer, lines [][]byte, crlf, newline bool) {
+	for _, line := range lines {
+		out.Write(line)
+	}
+
+	if n := len(lines); n > 0 && newline && !bytes.HasSuffix(lines[n-1], []byte("\n")) {
+		if crlf {
+			out.WriteByte('\r')
+		}
+
+		out.WriteByte('\n')
+	}
+}
+
+// Whether the markers of a conflict end in CRLF: when the lines before it
+// on both sides, and the first line of the base, do.
+func (m *fileMerge) needsCR(c *chunk) bool {
+	crlf := eolCRLF(m.ours, maxInt(c.ours-1, 0))
+
+	if crlf != 0 {
+		crlf = eolCRLF(m.theirs, maxInt(c.theirs-1, 0))
+	}
+
+	if crlf != 0 {
+		crlf = eolCRLF(m.base, 0)
+	}
+
+	return crlf > 0
+}
+
+// Whether line i of a file ends in CRLF, 1 if so, 0 if not and -1 if
+// there is no telling.
+func eolCRLF(lines [][]byte, i int) int {
+	crlf := func(line []byte) int {
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			return 1
+		}
+
+		return 0
+	}
+
+	switch {
+	case i < len(lines)-1:
+		return crlf(lines[i])
+	case len(lines) == 0:
+		return -1
+	case bytes.HasSuffix(lines[i], []byte("\n")):
+		return crlf(lines[i])
+	case i == 0:
+		return -1
+	}
+
+	return crlf(lines[i-1])
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
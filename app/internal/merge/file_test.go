@@ -0,0 +1,52 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/merge"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func TestContent(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	opts := merge.FileOptions{OurLabel: "ours", BaseLabel: "base", TheirLabel: "theirs"}
+
+	cases := []struct {
+		ours, theirs string
+		style        string
+		expected     string
+		conflicts    int
+	}{
+		// Changes to different lines both apply.
+		{ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n", expected: "A\nb\nc\nd\nE\n"},
+		// The same change on both sides applies once.
+		{ours: "a\nb\nC\nd\ne\n", theirs: "a\nb\nC\nd\ne\n", expected: "a\nb\nC\nd\ne\n"},
+		{ours: "a\nb\nX\nd\ne\n", theirs: "a\nb\nY\nd\ne\n", conflicts: 1, expected: "a\nb\n" +
+			"<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\n" +
+			"d\ne\n"},
+		{ours: "a\nb\nX\nd\ne\n", theirs: "a\nb\nY\nd\ne\n", style: merge.StyleDiff3, conflicts: 1, expected: "a\nb\n" +
+			"<<<<<<< ours\nX\n||||||| base\nc\n=======\nY\n>>>>>>> theirs\n" +
+			"d\ne\n"},
+		// Lines common to both sides are left out of the conflict.
+		{ours: "X\nb\nc\nd\nZ\n", theirs: "Y\nb\nc\nd\nZ\n", conflicts: 1, expected: "" +
+			"<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\n" +
+			"b\nc\nd\nZ\n"},
+		// A missing final newline is kept.
+		{ours: "a\nb\nc\nd\ne", theirs: "A\nb\nc\nd\ne\n", expected: "A\nb\nc\nd\ne"},
+	}
+
+	for _, c := range cases {
+		opts.Style = c.style
+		out, conflicts := merge.Content([]byte(base), []byte(c.ours), []byte(c.theirs), opts)
+
+		utils.Expect(t, string(out), c.expected)
+		utils.Expect(t, conflicts, c.conflicts)
+	}
+
+	// Longer markers, as nested merges use.
+	opts.Style, opts.MarkerSize = "", 9
+	out, conflicts := merge.Content([]byte("a\n"), []byte("b\n"), []byte("c\n"), opts)
+
+	utils.Expect(t, string(out), "<<<<<<<<< ours\nb\n=========\nc\n>>>>>>>>> theirs\n")
+	utils.Expect(t, conflicts, 1)
+}
@@ -0,0 +1,758 @@
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/checkout"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+const (
+	modeTypeMask uint32 = 0170000
+	modeRegular  uint32 = 0100000
+	modeSymlink  uint32 = 0120000
+)
+
+// The sides of a merge, indexing stages and names alike.
+const (
+	sideBase   = 0
+	sideOurs   = 1
+	sideTheirs = 2
+)
+
+// Options tells how to merge.
+type Options struct {
+	// How our side and theirs are called in messages and conflict
+	// markers, like HEAD and the name of the branch merged.
+	OurLabel, TheirLabel string
+
+	// Renames finds the files renamed on each side, or nil not to look
+	// for renames.
+	Renames *diff.RenameOptions
+
+	// The algorithm comparing the content of files; Myers unless set.
+	Algorithm diff.Algorithm
+
+	// The conflict style, StyleMerge or StyleDiff3.
+	Style string
+}
+
+// Side is what one side of a merge has at a path: a file of some mode, or
+// nothing when the mode is zero.
+type Side struct {
+	Mode uint32
+	Hash plumbing.Hash
+}
+
+func (s Side) exists() bool {
+	return s.Mode != 0
+}
+
+func (s Side) isRegular() bool {
+	return s.Mode&modeTypeMask == modeRegular
+}
+
+// Conflict is a path the merge could not resolve, with what the base, our
+// side and theirs have there: the stages to record in the index.
+type Conflict struct {
+	Path   string
+	Stages [3]Side
+}
+
+// Stage returns the index stage for one of Stages.
+func (c *Conflict) Stage(i int) index.Stage {
+	return index.Base + index.Stage(i)
+}
+
+// Result is the outcome of a merge.
+type Result struct {
+	// Tree holds the merged files, conflicting ones as they are to be
+	// left in the working tree: with conflict markers, or the side that
+	// could be kept.
+	Tree plumbing.Hash
+
+	// The paths left with conflicts, sorted.
+	Conflicts []*Conflict
+
+	// What the merge did, path by path, like "Auto-merging a.txt".
+	Messages []string
+}
+
+// Clean tells whether the merge resolved every path.
+func (r *Result) Clean() bool {
+	return len(r.Conflicts) == 0
+}
+
+// A path of a merge, with what each side has there once renames are paired
+// up, and the path each side has it at.
+type mergeEntry struct {
+	path   string
+	stages [3]Side
+	names  [3]string
+
+	// Resolved while pairing renames.
+	done bool
+}
+
+type message struct {
+	path string
+	text string
+}
+
+type treeMerge struct {
+	git   *fs.Git
+	opts  *Options
+	depth int
+
+	// The files of the base and each side, by path.
+	sides [3]map[string]Side
+
+	entries  map[string]*mergeEntry
+	merged   map[string]Side
+	conflict map[string]*Conflict
+	messages []message
+}
+
+// Trees merges the changes from the base tree to ours and from the base to
+// theirs, the way git's ort strategy does: paths changed on one side only
+// take that change, files changed on both have their content merged, and
+// files renamed on one side get the changes of the other. A zero hash
+// stands for the empty tree. The merged files are written to the object
+// store along with the result tree.
+func Trees(git *fs.Git, base, ours, theirs plumbing.Hash, opts Options) (*Result, error) {
+	return mergeTrees(git, [3]plumbing.Hash{base, ours, theirs}, &opts, "", 0)
+}
+
+func mergeTrees(git *fs.Git, trees [3]plumbing.Hash, opts *Options, baseLabel string, depth int) (*Result, error) {
+	m := &treeMerge{
+		git:      git,
+		opts:     opts,
+		depth:    depth,
+		entries:  make(map[string]*mergeEntry),
+		merged:   make(map[string]Side),
+		conflict: make(map[string]*Conflict),
+	}
+
+	for i, hash := range trees {
+		files, err := checkout.ReadFiles(git, hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.sides[i] = make(map[string]Side, len(files))
+
+		for path, f := range files {
+			side := Side{Mode: f.Mode, Hash: f.Hash()}
+
+			m.sides[i][path] = side
+			m.entry(path).stages[i] = side
+		}
+	}
+
+	if opts.Renames != nil {
+		ourRenames, err := m.findRenames(trees, sideOurs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		theirRenames, err := m.findRenames(trees, sideTheirs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.pairRenames(sideOurs, ourRenames, theirRenames, baseLabel); err != nil {
+			return nil, err
+		}
+
+		if err := m.pairRenames(sideTheirs, theirRenames, ourRenames, baseLabel); err != nil {
+			return nil, err
+		}
+	}
+
+	paths := make([]string, 0, len(m.entries))
+
+	for path := range m.entries {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if e := m.entries[path]; !e.done {
+			if err := m.resolve(e, baseLabel); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	m.moveFilesInTheWay()
+
+	hash, err := m.writeTree()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.result(hash), nil
+}
+
+// The files renamed from the base to a side, new path by old. Like ort,
+// only files the other side changed are looked for among inexact renames,
+// the others merging the same whether renamed or not.
+func (m *treeMerge) findRenames(trees [3]plumbing.Hash, side int) (map[string]string, error) {
+	other := sideOurs + sideTheirs - side
+	changes, err := diff.Trees(m.git, trees[sideBase], trees[side], diff.CompareOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	opts := *m.opts.Renames
+	opts.Copies = false
+	exact := opts
+	exact.MinScore = diff.MaxScore
+
+	if changes, err = diff.DetectRenames(changes, exact); err != nil {
+		return nil, err
+	}
+
+	renames := make(map[string]string)
+	left := []*diff.Change{}
+
+	for _, c := range changes {
+		switch {
+		case c.Status == diff.Renamed:
+			renames[c.Old.Path] = c.New.Path
+		case c.Status == diff.Added:
+			left = append(left, c)
+		case c.Status == diff.Deleted && m.sides[other][c.Old.Path] != m.sides[sideBase][c.Old.Path]:
+			left = append(left, c)
+		}
+	}
+
+	if left, err = diff.DetectRenames(left, opts); err != nil {
+		return nil, err
+	}
+
+	for _, c := range left {
+		if c.Status == diff.Renamed {
+			renames[c.Old.Path] = c.New.Path
+		}
+	}
+
+	return renames, nil
+}
+
+func (m *treeMerge) entry(path string) *mergeEntry {
+	e, ok := m.entries[path]
+
+	if !ok {
+		e = &mergeEntry{path: path, names: [3]string{path, path, path}}
+		m.entries[path] = e
+	}
+
+	return e
+}
+
+func (m *treeMerge) label(side int) string {
+	if side == sideOurs {
+		return m.opts.OurLabel
+	}
+
+	return m.opts.TheirLabel
+}
+
+func (m *treeMerge) say(path, format string, args ...interface{}) {
+	if m.depth == 0 {
+		m.messages = append(m.messages, message{path: path, text: fmt.Sprintf(format, args...)})
+	}
+}
+
+// Move what the base and the other side have at the old path of each file
+// renamed on a side to its new path, so that the changes of both sides meet
+// there. Renames the other side cannot follow are conflicts.
+func (m *treeMerge) pairRenames(side int, renames, otherRenames map[string]string, baseLabel string) error {
+	other := sideOurs + sideTheirs - side
+	olds := make([]string, 0, len(renames))
+
+	for old := range renames {
+		olds = append(olds, old)
+	}
+
+	sort.Strings(olds)
+
+	for _, old := range olds {
+		new := renames[old]
+		otherNew, otherRenamed := otherRenames[old]
+		src, dst := m.entry(old), m.entry(new)
+
+		switch {
+		case otherRenamed && otherNew == new:
+			// Both sides renamed the file the same way, which only moves
+			// the base.
+			if side == sideOurs {
+				dst.stages[sideBase], dst.names[sideBase] = src.stages[sideBase], old
+				src.stages[sideBase] = Side{}
+			}
+		case otherRenamed:
+			if side == sideOurs {
+				if err := m.renameRename(old, new, otherNew, baseLabel); err != nil {
+					return err
+				}
+			}
+		case m.sides[other][new].exists() && !m.sides[other][old].exists():
+			// The other side deleted the file, and has one of its own at
+			// the new path.
+			m.say(new, "CONFLICT (rename/delete): %s renamed to %s in %s, but deleted in %s.", old, new, m.label(side), m.label(other))
+		case m.sides[other][new].exists():
+			if err := m.renameCollision(side, old, new, baseLabel); err != nil {
+				return err
+			}
+		case !m.sides[other][old].exists():
+			m.renameDelete(side, old, new)
+		default:
+			dst.stages[sideBase], dst.names[sideBase] = src.stages[sideBase], old
+			dst.stages[other], dst.names[other] = src.stages[other], old
+			src.stages[sideBase], src.stages[other] = Side{}, Side{}
+		}
+	}
+
+	return nil
+}
+
+// A file renamed on one side to a path the other side has a file at is
+// merged with what the other side has at its old path first, which then
+// conflicts with the file of the other side.
+func (m *treeMerge) renameCollision(side int, old, new, baseLabel string) error {
+	other := sideOurs + sideTheirs - side
+	src, dst := m.entry(old), m.entry(new)
+
+	var stages [3]Side
+	var names [3]string
+
+	stages[sideBase], names[sideBase] = src.stages[sideBase], old
+	stages[side], names[side] = dst.stages[side], new
+	stages[other], names[other] = src.stages[other], old
+
+	merged, clean, err := m.mergeFile(old, stages, names, baseLabel, 1)
+
+	if err != nil {
+		return err
+	}
+
+	if !clean {
+		m.say(old, "CONFLICT (rename involved in collision): rename of %s -> %s has content conflicts AND collides with another path; this may result in nested conflict markers.", old, new)
+	}
+
+	dst.stages[side] = merged
+	src.stages[sideBase], src.stages[other] = Side{}, Side{}
+
+	return nil
+}
+
+// A file renamed on one side and deleted on the other stays at its new
+// path, with the base as the other stage.
+func (m *treeMerge) renameDelete(side int, old, new string) {
+	src, dst := m.entry(old), m.entry(new)
+	base := src.stages[sideBase]
+	src.stages[sideBase] = Side{}
+	dst.done = true
+
+	deleted := sideOurs + sideTheirs - side
+
+	m.say(new, "CONFLICT (rename/delete): %s renamed to %s in %s, but deleted in %s.", old, new, m.label(side), m.label(deleted))
+
+	if dst.stages[side].Hash != base.Hash {
+		m.say(new, "CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.",
+			new, m.label(deleted), m.label(side), m.label(side), new)
+	}
+
+	m.take(new, dst.stages[side])
+
+	if m.depth == 0 {
+		c := m.conflictAt(new)
+		c.Stages[sideBase], c.Stages[side] = base, dst.stages[side]
+	}
+}
+
+// A file renamed differently on each side is merged and left at both new
+// paths, as the stage of the side that renamed it there, with the base at
+// the old path.
+func (m *treeMerge) renameRename(old, ourNew, theirNew, baseLabel string) error {
+	src, ours, theirs := m.entry(old), m.entry(ourNew), m.entry(theirNew)
+	sides := [3]Side{src.stages[sideBase], ours.stages[sideOurs], theirs.stages[sideTheirs]}
+	src.stages[sideBase] = Side{}
+	ours.done, theirs.done = true, true
+
+	merged, _, err := m.mergeFile(old, sides, [3]string{old, ourNew, theirNew}, baseLabel, 1)
+
+	if err != nil {
+		return err
+	}
+
+	m.say(old, "CONFLICT (rename/rename): %s renamed to %s in %s and to %s in %s.", old, ourNew, m.opts.OurLabel, theirNew, m.opts.TheirLabel)
+
+	m.take(ourNew, merged)
+	m.take(theirNew, merged)
+
+	if m.depth == 0 {
+		m.conflictAt(old).Stages[sideBase] = sides[sideBase]
+		m.conflictAt(ourNew).Stages[sideOurs] = merged
+		m.conflictAt(theirNew).Stages[sideTheirs] = merged
+	}
+
+	return nil
+}
+
+func (m *treeMerge) take(path string, side Side) {
+	if side.exists() {
+		m.merged[path] = side
+	}
+}
+
+func (m *treeMerge) conflictAt(path string) *Conflict {
+	c, ok := m.conflict[path]
+
+	if !ok {
+		c = &Conflict{Path: path}
+		m.conflict[path] = c
+	}
+
+	return c
+}
+
+// Record a conflict with the stages of an entry, unless merging merge
+// bases, which only need the tree.
+func (m *treeMerge) conflicted(e *mergeEntry) {
+	if m.depth == 0 {
+		m.conflictAt(e.path).Stages = e.stages
+	}
+}
+
+// Resolve what the sides have at a path.
+func (m *treeMerge) resolve(e *mergeEntry, baseLabel string) error {
+	base, ours, theirs := e.stages[sideBase], e.stages[sideOurs], e.stages[sideTheirs]
+
+	switch {
+	case ours == theirs:
+		m.take(e.path, ours)
+	case ours == base:
+		m.take(e.path, theirs)
+	case theirs == base:
+		m.take(e.path, ours)
+	case !ours.exists() || !theirs.exists():
+		m.modifyDelete(e)
+	case ours.Mode&modeTypeMask != theirs.Mode&modeTypeMask:
+		m.distinctTypes(e)
+	default:
+		merged, clean, err := m.mergeFile(e.path, e.stages, e.names, baseLabel, 0)
+
+		if err != nil {
+			return err
+		}
+
+		m.take(e.path, merged)
+
+		if !clean {
+			reason := "content"
+
+			if !base.exists() {
+				reason = "add/add"
+			}
+
+			m.say(e.path, "CONFLICT (%s): Merge conflict in %s", reason, e.path)
+			m.conflicted(e)
+		}
+	}
+
+	return nil
+}
+
+// A file deleted on one side and changed on the other is left as changed,
+// or as in the base when merging merge bases.
+func (m *treeMerge) modifyDelete(e *mergeEntry) {
+	if m.depth > 0 {
+		m.take(e.path, e.stages[sideBase])
+
+		return
+	}
+
+	deleted, modified := sideOurs, sideTheirs
+
+	if e.stages[sideOurs].exists() {
+		deleted, modified = sideTheirs, sideOurs
+	}
+
+	m.say(e.path, "CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.",
+		e.path, m.label(deleted), m.label(modified), m.label(modified), e.path)
+
+	m.take(e.path, e.stages[modified])
+	m.conflicted(e)
+}
+
+// Files of different types, like a regular file and a symbolic link,
+// cannot be merged: the regular file is moved out of the way of the other,
+// or both are when neither is regular.
+func (m *treeMerge) distinctTypes(e *mergeEntry) {
+	if m.depth > 0 {
+		m.take(e.path, e.stages[sideBase])
+
+		return
+	}
+
+	moveOurs := e.stages[sideOurs].isRegular()
+	moveTheirs := !moveOurs
+	howMany := "one"
+
+	if !moveOurs && !e.stages[sideTheirs].isRegular() {
+		moveOurs, howMany = true, "both"
+	}
+
+	m.say(e.path, "CONFLICT (distinct types): %s had different types on each side; renamed %s of them so each can be recorded somewhere.", e.path, howMany)
+
+	if e.stages[sideBase].exists() {
+		m.conflictAt(e.path).Stages[sideBase] = e.stages[sideBase]
+	}
+
+	for _, side := range []int{sideOurs, sideTheirs} {
+		path := e.path
+
+		if side == sideOurs && moveOurs || side == sideTheirs && moveTheirs {
+			path = m.uniquePath(e.path, m.label(side))
+		}
+
+		m.take(path, e.stages[side])
+		m.conflictAt(path).Stages[side] = e.stages[side]
+	}
+}
+
+// Merge a file both sides changed, returning the merged file and whether
+// the merge is clean. Conflict markers grow by extraMarker, which sets
+// apart those of files renamed differently on each side.
+func (m *treeMerge) mergeFile(path string, stages [3]Side, names [3]string, baseLabel string, extraMarker int) (Side, bool, error) {
+	base, ours, theirs := stages[sideBase], stages[sideOurs], stages[sideTheirs]
+	merged := Side{Mode: theirs.Mode}
+	clean := true
+
+	if ours.Mode != theirs.Mode && ours.Mode != base.Mode {
+		merged.Mode = ours.Mode
+		clean = theirs.Mode == base.Mode
+	}
+
+	switch {
+	case ours.Hash == theirs.Hash || ours.Hash == base.Hash:
+		merged.Hash = theirs.Hash
+	case theirs.Hash == base.Hash:
+		merged.Hash = ours.Hash
+	case ours.isRegular():
+		hash, contentClean, err := m.mergeContent(path, stages, names, baseLabel, extraMarker)
+
+		if err != nil {
+			return merged, false, err
+		}
+
+		merged.Hash = hash
+		clean = clean && contentClean
+	case ours.Mode&modeTypeMask == modeSymlink && m.depth > 0:
+		merged.Hash, clean = base.Hash, false
+	default:
+		// Symbolic links and submodules changed on both sides keep ours.
+		merged.Hash, clean = ours.Hash, false
+	}
+
+	return merged, clean, nil
+}
+
+// Merge the content of a regular file changed on both sides, writing the
+// merged blob.
+func (m *treeMerge) mergeContent(path string, stages [3]Side, names [3]string, baseLabel string, extraMarker int) (plumbing.Hash, bool, error) {
+	content := [3][]byte{}
+
+	for i, side := range stages {
+		if !side.exists() {
+			continue
+		}
+
+		_, data, err := m.git.ReadObjectByHash(side.Hash)
+
+		if err != nil {
+			return plumbing.ZeroHash, false, err
+		}
+
+		content[i] = data
+	}
+
+	labels := [3]string{baseLabel, m.opts.OurLabel, m.opts.TheirLabel}
+
+	// Name the paths too when renames put them apart.
+	if names[sideOurs] != names[sideTheirs] {
+		for i := range labels {
+			labels[i] += ":" + names[i]
+		}
+	}
+
+	var merged []byte
+	clean := true
+
+	if diff.IsBinary(content[sideBase]) || diff.IsBinary(content[sideOurs]) || diff.IsBinary(content[sideTheirs]) {
+		if m.depth > 0 {
+			merged = content[sideBase]
+		} else {
+			m.say(path, "warning: Cannot merge binary files: %s (%s vs. %s)", path, labels[sideOurs], labels[sideTheirs])
+			merged, clean = content[sideOurs], false
+		}
+	} else {
+		var conflicts int
+
+		merged, conflicts = Content(content[sideBase], content[sideOurs], content[sideTheirs], FileOptions{
+			Algorithm:  m.opts.Algorithm,
+			OurLabel:   labels[sideOurs],
+			BaseLabel:  labels[sideBase],
+			TheirLabel: labels[sideTheirs],
+			Style:      m.opts.Style,
+			MarkerSize: DefaultMarkerSize + 2*m.depth + extraMarker,
+		})
+		clean = conflicts == 0
+	}
+
+	m.say(path, "Auto-merging %s", path)
+
+	hash, err := m.git.WriteObject(objfile.Blob, merged)
+
+	return hash, clean, err
+}
+
+// A path for a file that cannot stay where it is, named after its side,
+// that no side uses: <path>~<label>, with a number added if need be.
+func (m *treeMerge) uniquePath(path, label string) string {
+	candidate := path + "~" + strings.Replace(label, "/", "_", -1)
+	unique := candidate
+
+	for i := 0; m.used(unique); i++ {
+		unique = fmt.Sprintf("%s_%d", candidate, i)
+	}
+
+	return unique
+}
+
+func (m *treeMerge) used(path string) bool {
+	if _, ok := m.entries[path]; ok {
+		return true
+	}
+
+	if _, ok := m.merged[path]; ok {
+		return true
+	}
+
+	_, ok := m.conflict[path]
+
+	return ok
+}
+
+// Move the files the merge leaves where the merge also leaves a directory
+// out of its way, the directory winning.
+func (m *treeMerge) moveFilesInTheWay() {
+	dirs := make(map[string]bool)
+
+	for path := range m.merged {
+		for i := strings.IndexByte(path, '/'); i >= 0; i = nextSlash(path, i) {
+			dirs[path[:i]] = true
+		}
+	}
+
+	paths := []string{}
+
+	for path := range m.merged {
+		if dirs[path] {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		file := m.merged[path]
+		side := sideOurs
+
+		if e := m.entries[path]; e != nil && !e.stages[sideOurs].exists() {
+			side = sideTheirs
+		}
+
+		moved := m.uniquePath(path, m.label(side))
+
+		m.say(path, "CONFLICT (file/directory): directory in the way of %s from %s; moving it to %s instead.", path, m.label(side), moved)
+
+		delete(m.merged, path)
+		m.merged[moved] = file
+
+		if m.depth > 0 {
+			continue
+		}
+
+		c, ok := m.conflict[path]
+
+		if !ok {
+			c = &Conflict{}
+			c.Stages[side] = file
+		}
+
+		delete(m.conflict, path)
+		c.Path = moved
+		m.conflict[moved] = c
+	}
+}
+
+func nextSlash(path string, i int) int {
+	next := strings.IndexByte(path[i+1:], '/')
+
+	if next < 0 {
+		return -1
+	}
+
+	return i + 1 + next
+}
+
+// Write the tree of the merged files.
+func (m *treeMerge) writeTree() (plumbing.Hash, error) {
+	idx := index.New(m.git.ObjectFormat())
+
+	for path, file := range m.merged {
+		idx.Entries = append(idx.Entries, &index.Entry{Name: path, Mode: file.Mode, Hash: file.Hash})
+	}
+
+	idx.Sort()
+
+	return tree.WriteFromIndex(idx, m.git.WriteObject)
+}
+
+func (m *treeMerge) result(hash plumbing.Hash) *Result {
+	r := &Result{Tree: hash, Conflicts: []*Conflict{}, Messages: []string{}}
+
+	for _, c := range m.conflict {
+		r.Conflicts = append(r.Conflicts, c)
+	}
+
+	sort.Slice(r.Conflicts, func(i, j int) bool {
+		return r.Conflicts[i].Path < r.Conflicts[j].Path
+	})
+
+	sort.SliceStable(m.messages, func(i, j int) bool {
+		return m.messages[i].path < m.messages[j].path
+	})
+
+	for _, msg := range m.messages {
+		r.Messages = append(r.Messages, msg.text)
+	}
+
+	return r
+}
@@ -0,0 +1,148 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/diff"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/merge"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Write a tree of regular files, by path.
+func writeTree(t *testing.T, git *fs.Git, files map[string]string) plumbing.Hash {
+	t.Helper()
+
+	idx := index.New(git.ObjectFormat())
+
+	for path, content := range files {
+		hash, err := git.WriteObject(objfile.Blob, []byte(content))
+		utils.Expect(t, err, nil)
+
+		idx.Add(&index.Entry{Name: path, Mode: tree.ModeBlob, Hash: hash})
+	}
+
+	hash, err := tree.WriteFromIndex(idx, git.WriteObject)
+	utils.Expect(t, err, nil)
+
+	return hash
+}
+
+// Read the files of a tree, by path.
+func readTree(t *testing.T, git *fs.Git, hash plumbing.Hash) map[string]string {
+	t.Helper()
+
+	entries, err := tree.ReadRecursive(git.ReadObjectByHash, hash)
+	utils.Expect(t, err, nil)
+
+	files := make(map[string]string)
+
+	for _, e := range entries {
+		_, data, err := git.ReadObjectByHash(e.Hash())
+		utils.Expect(t, err, nil)
+
+		files[e.Name] = string(data)
+	}
+
+	return files
+}
+
+func TestTrees(t *testing.T) {
+	git := fs.NewGit(t.TempDir(), plumbing.SHA1, odb.NewMemory(plumbing.SHA1))
+	opts := merge.Options{OurLabel: "HEAD", TheirLabel: "side", Renames: &diff.RenameOptions{}}
+
+	lines := "1\n2\n3\n4\n5\n6\n7\n8\n"
+
+	base := writeTree(t, git, map[string]string{
+		"a.txt": "a\n" + lines,
+		"b.txt": "b\n" + lines,
+		"c.txt": "c\n",
+		"d.txt": "d\n",
+	})
+
+	// Ours changes a.txt, renames b.txt, and changes c.txt and d.txt.
+	ours := writeTree(t, git, map[string]string{
+		"a.txt":     "A\n" + lines,
+		"dir/moved": "b\n" + lines,
+		"c.txt":     "ours\n",
+		"d.txt":     "d\nd\n",
+	})
+
+	// Theirs changes a.txt and b.txt, changes c.txt its own way and
+	// deletes d.txt.
+	theirs := writeTree(t, git, map[string]string{
+		"a.txt": "a\n" + lines + "9\n",
+		"b.txt": "b\n" + lines + "B\n",
+		"c.txt": "theirs\n",
+	})
+
+	result, err := merge.Trees(git, base, ours, theirs, opts)
+	utils.Expect(t, err, nil)
+
+	utils.Expect(t, result.Clean(), false)
+	utils.Expect(t, result.Messages, []string{
+		"Auto-merging a.txt",
+		"Auto-merging c.txt",
+		"CONFLICT (content): Merge conflict in c.txt",
+		"CONFLICT (modify/delete): d.txt deleted in side and modified in HEAD.  Version HEAD of d.txt left in tree.",
+	})
+
+	utils.Expect(t, readTree(t, git, result.Tree), map[string]string{
+		"a.txt":     "A\n" + lines + "9\n",
+		"c.txt":     "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> side\n",
+		"d.txt":     "d\nd\n",
+		"dir/moved": "b\n" + lines + "B\n",
+	})
+
+	utils.Expect(t, len(result.Conflicts), 2)
+	utils.Expect(t, result.Conflicts[0].Path, "c.txt")
+	utils.Expect(t, result.Conflicts[1].Path, "d.txt")
+
+	// The stages of a conflict are what the base and each side have.
+	d := result.Conflicts[1]
+
+	utils.Expect(t, d.Stages[0].Mode, tree.ModeBlob)
+	utils.Expect(t, d.Stages[1].Mode, tree.ModeBlob)
+	utils.Expect(t, d.Stages[2].Mode, uint32(0))
+	utils.Expect(t, d.Stage(0), index.Base)
+	utils.Expect(t, d.Stage(2), index.Theirs)
+
+	// Without renames, the rename is a modify/delete too.
+	opts.Renames = nil
+
+	result, err = merge.Trees(git, base, ours, theirs, opts)
+	utils.Expect(t, err, nil)
+
+	utils.Expect(t, len(result.Conflicts), 3)
+	utils.Expect(t, result.Conflicts[0].Path, "b.txt")
+}
+
+func TestTreesDirectoryFile(t *testing.T) {
+	git := fs.NewGit(t.TempDir(), plumbing.SHA1, odb.NewMemory(plumbing.SHA1))
+	opts := merge.Options{OurLabel: "HEAD", TheirLabel: "side"}
+
+	base := writeTree(t, git, map[string]string{"a": "a\n"})
+	ours := writeTree(t, git, map[string]string{"a": "a\n", "path": "file\n"})
+	theirs := writeTree(t, git, map[string]string{"a": "a\n", "path/b": "b\n"})
+
+	result, err := merge.Trees(git, base, ours, theirs, opts)
+	utils.Expect(t, err, nil)
+
+	utils.Expect(t, result.Messages, []string{
+		"CONFLICT (file/directory): directory in the way of path from HEAD; moving it to path~HEAD instead.",
+	})
+
+	utils.Expect(t, readTree(t, git, result.Tree), map[string]string{
+		"a":         "a\n",
+		"path/b":    "b\n",
+		"path~HEAD": "file\n",
+	})
+
+	utils.Expect(t, len(result.Conflicts), 1)
+	utils.Expect(t, result.Conflicts[0].Path, "path~HEAD")
+}
@@ -2,8 +2,10 @@ package objfile
 
 import (
 	"bufio"
+	"bytes"
 	"compress/zlib"
 	"io"
+	"io/ioutil"
 	"strconv"
 	"strings"
 
@@ -13,6 +15,9 @@ import (
 type Reader struct {
 	multi io.Reader
 	zlib  io.ReadCloser
+
+	// Underlying source, closed along with the reader if it is closable.
+	source io.Reader
 }
 
 func (r *Reader) Read(p []byte) (n int, err error) {
@@ -26,10 +31,33 @@ func NewReader(r io.Reader) (*Reader, error) {
 	}
 
 	return &Reader{
-		zlib: zlib,
+		zlib:   zlib,
+		source: r,
 	}, nil
 }
 
+// NewUncompressedReader reads an already inflated object, such as one
+// reconstructed from a packfile.
+func NewUncompressedReader(t GitObjectType, content []byte) *Reader {
+	data := append(getHeaderBytes(t, int64(len(content))), content...)
+
+	return &Reader{
+		zlib: ioutil.NopCloser(bytes.NewReader(data)),
+	}
+}
+
+func (r *Reader) Close() error {
+	err := r.zlib.Close()
+
+	if closer, ok := r.source.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
 func (r *Reader) prepareForRead(t GitObjectType, size int64) {
 	r.multi = r.zlib
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
 )
 
 type GitObjectType int
@@ -12,6 +13,7 @@ const (
 	Blob GitObjectType = iota
 	Tree
 	Commit
+	Tag
 )
 
 func ObjectTypeToNameMapping() map[GitObjectType]string {
@@ -19,6 +21,7 @@ func ObjectTypeToNameMapping() map[GitObjectType]string {
 		Blob:   "blob",
 		Tree:   "tree",
 		Commit: "commit",
+		Tag:    "tag",
 	}
 }
 
@@ -57,3 +60,6 @@ func (t GitObjectType) String() string {
 func (t GitObjectType) Bytes() []byte {
 	return []byte(t.String())
 }
+
+// ObjectReader reads a whole object from some object store by its id.
+type ObjectReader func(hash plumbing.Hash) (GitObjectType, []byte, error)
@@ -19,22 +19,30 @@ type Writer struct {
 	zlib   io.WriteCloser
 	multi  io.Writer
 	hasher plumbing.Hasher
+	format plumbing.ObjectFormat
 
 	closed    bool
 	remaining int64
 }
 
-func NewWriter(w io.Writer) (*Writer, error) {
+// NewWriter writes an object to w, naming it with the hash algorithm of
+// format.
+func NewWriter(w io.Writer, format plumbing.ObjectFormat) (*Writer, error) {
 	return &Writer{
-		raw:  w,
-		zlib: zlib.NewWriter(w),
+		raw:    w,
+		zlib:   zlib.NewWriter(w),
+		format: format,
 	}, nil
 }
 
 func getHeaderBytes(t GitObjectType, size int64) []byte {
+	return getLiteralHeaderBytes(t.String(), size)
+}
+
+func getLiteralHeaderBytes(typeName string, size int64) []byte {
 	buf := bytes.NewBufferString("")
 
-	buf.Write(t.Bytes())
+	buf.WriteString(typeName)
 	buf.Write([]byte{' '})
 	buf.Write([]byte(strconv.FormatInt(size, 10)))
 	buf.Write([]byte{0})
@@ -47,20 +55,26 @@ func (w *Writer) WriteHeader(t GitObjectType, size int64) error {
 		return errors.GitError{Message: "Invalid object type"}
 	}
 
+	return w.WriteLiteralHeader(t.String(), size)
+}
+
+// WriteLiteralHeader starts an object of any type name, even one Git does
+// not know about, for hash-object --literally.
+func (w *Writer) WriteLiteralHeader(typeName string, size int64) error {
 	if size < 0 {
 		return errors.GitError{Message: "Invalid object size"}
 	}
 
-	defer w.prepareForWrite(t, size)
+	defer w.prepareForWrite(typeName, size)
 
-	_, err := w.zlib.Write(getHeaderBytes(t, size))
+	_, err := w.zlib.Write(getLiteralHeaderBytes(typeName, size))
 
 	return err
 }
 
 // Initialize the writer to write content and update the sha sum as it does.
-func (w *Writer) prepareForWrite(t GitObjectType, size int64) {
-	w.hasher = plumbing.NewHasher(getHeaderBytes(t, size))
+func (w *Writer) prepareForWrite(typeName string, size int64) {
+	w.hasher = w.format.NewHasher(getLiteralHeaderBytes(typeName, size))
 	w.remaining = size
 
 	w.multi = io.MultiWriter(w.hasher, w.zlib)
@@ -101,3 +115,20 @@ func (w *Writer) Close() error {
 	w.closed = true
 	return nil
 }
+
+// HashObject computes the id of an object in the given format without
+// storing it.
+func HashObject(format plumbing.ObjectFormat, t GitObjectType, content []byte) plumbing.Hash {
+	hasher := format.NewHasher(getHeaderBytes(t, int64(len(content))))
+	hasher.Write(content)
+
+	return hasher.Sum()
+}
+
+// HashLiteral computes the id of an object of any type name without storing it.
+func HashLiteral(format plumbing.ObjectFormat, typeName string, content []byte) plumbing.Hash {
+	hasher := format.NewHasher(getLiteralHeaderBytes(typeName, int64(len(content))))
+	hasher.Write(content)
+
+	return hasher.Sum()
+}
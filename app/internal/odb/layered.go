@@ -0,0 +1,101 @@
+package odb
+
+import (
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Layered reads objects from a stack of stores, the first that has an object
+// winning, and writes new objects into the first store.
+type Layered struct {
+	layers []ObjectStore
+}
+
+// NewLayered stacks stores, writing into primary and falling back to the
+// others in order for reads.
+func NewLayered(primary ObjectStore, others ...ObjectStore) *Layered {
+	return &Layered{layers: append([]ObjectStore{primary}, others...)}
+}
+
+func (l *Layered) Has(hash plumbing.Hash) (bool, error) {
+	for _, layer := range l.layers {
+		if ok, err := layer.Has(hash); ok || err != nil {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (l *Layered) Get(hash plumbing.Hash) (objfile.GitObjectType, []byte, error) {
+	for _, layer := range l.layers {
+		t, content, err := layer.Get(hash)
+
+		if !IsNotFound(err) {
+			return t, content, err
+		}
+	}
+
+	return 0, nil, NotFoundError{Hash: hash}
+}
+
+func (l *Layered) Put(t objfile.GitObjectType, content []byte) (plumbing.Hash, error) {
+	return l.layers[0].Put(t, content)
+}
+
+// Iterate visits objects stored in several layers only once.
+func (l *Layered) Iterate(fn func(hash plumbing.Hash) error) error {
+	seen := make(map[plumbing.Hash]bool)
+
+	for _, layer := range l.layers {
+		err := layer.Iterate(func(hash plumbing.Hash) error {
+			if seen[hash] {
+				return nil
+			}
+
+			seen[hash] = true
+
+			return fn(hash)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Layered) FindPrefix(prefix string) ([]plumbing.Hash, error) {
+	seen := make(map[plumbing.Hash]bool)
+	matches := []plumbing.Hash{}
+
+	for _, layer := range l.layers {
+		found, err := FindPrefix(layer, prefix)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hash := range found {
+			if !seen[hash] {
+				seen[hash] = true
+				matches = append(matches, hash)
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+func (l *Layered) Stat(hash plumbing.Hash) (ObjectInfo, error) {
+	for _, layer := range l.layers {
+		info, err := layer.Stat(hash)
+
+		if !IsNotFound(err) {
+			return info, err
+		}
+	}
+
+	return ObjectInfo{}, NotFoundError{Hash: hash}
+}
@@ -0,0 +1,237 @@
+package odb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Loose stores each object zlib compressed in a file of its own, named by
+// its id under a directory of the first two hex digits.
+type Loose struct {
+	dir    string
+	format plumbing.ObjectFormat
+}
+
+// NewLoose creates a store of the loose objects under dir, usually
+// .git/objects, named with the hash algorithm of format.
+func NewLoose(dir string, format plumbing.ObjectFormat) *Loose {
+	return &Loose{dir: dir, format: format}
+}
+
+func (l *Loose) path(hash plumbing.Hash) string {
+	hex := hash.String()
+
+	return filepath.Join(l.dir, hex[:2], hex[2:])
+}
+
+// Open an object for streaming, reading its header first.
+func (l *Loose) open(hash plumbing.Hash) (*objfile.Reader, error) {
+	f, err := os.Open(l.path(hash))
+
+	if os.IsNotExist(err) {
+		return nil, NotFoundError{Hash: hash}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	objReader, err := objfile.NewReader(f)
+
+	if err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return objReader, nil
+}
+
+func (l *Loose) Has(hash plumbing.Hash) (bool, error) {
+	return utils.PathExists(l.path(hash)), nil
+}
+
+func (l *Loose) Get(hash plumbing.Hash) (objfile.GitObjectType, []byte, error) {
+	objReader, err := l.open(hash)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer objReader.Close()
+
+	t, _, err := objReader.Header()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	content, err := ioutil.ReadAll(objReader)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return t, content, nil
+}
+
+func (l *Loose) Put(t objfile.GitObjectType, content []byte) (plumbing.Hash, error) {
+	hash := objfile.HashObject(l.format, t, content)
+
+	return hash, l.write(hash, func(w *objfile.Writer) error {
+		return w.WriteHeader(t, int64(len(content)))
+	}, content)
+}
+
+// PutLiteral writes an object of any type name, even one Git does not know
+// about, for hash-object --literally.
+func (l *Loose) PutLiteral(typeName string, content []byte) (plumbing.Hash, error) {
+	hash := objfile.HashLiteral(l.format, typeName, content)
+
+	return hash, l.write(hash, func(w *objfile.Writer) error {
+		return w.WriteLiteralHeader(typeName, int64(len(content)))
+	}, content)
+}
+
+// Write an object to a temporary file and move it into place, so that readers
+// never see a partially written object.
+func (l *Loose) write(hash plumbing.Hash, writeHeader func(w *objfile.Writer) error, content []byte) error {
+	objectPath := l.path(hash)
+
+	if utils.PathExists(objectPath) {
+		return nil
+	}
+
+	if err := os.MkdirAll(l.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	tempFile, err := ioutil.TempFile(l.dir, "tmp_obj_")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	objWriter, err := objfile.NewWriter(tempFile, l.format)
+
+	if err != nil {
+		return err
+	}
+
+	if err = writeHeader(objWriter); err != nil {
+		return err
+	}
+
+	if _, err = objWriter.Write(content); err != nil {
+		return err
+	}
+
+	if err = objWriter.Close(); err != nil {
+		return err
+	}
+
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(objectPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), objectPath)
+}
+
+func (l *Loose) Iterate(fn func(hash plumbing.Hash) error) error {
+	dirs, err := filepath.Glob(filepath.Join(l.dir, "[0-9a-f][0-9a-f]"))
+
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if err = l.iterateDir(filepath.Base(dir), fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Call fn for the objects in one of the fan-out directories.
+func (l *Loose) iterateDir(fanout string, fn func(hash plumbing.Hash) error) error {
+	names, err := ioutil.ReadDir(filepath.Join(l.dir, fanout))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, info := range names {
+		hash, err := plumbing.NewHash(fanout + info.Name())
+
+		if err != nil || hash.Format() != l.format {
+			continue
+		}
+
+		if err = fn(hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindPrefix only needs to read the fan-out directory of the prefix.
+func (l *Loose) FindPrefix(prefix string) ([]plumbing.Hash, error) {
+	matches := []plumbing.Hash{}
+
+	if len(prefix) < 2 {
+		return matches, nil
+	}
+
+	err := l.iterateDir(prefix[:2], func(hash plumbing.Hash) error {
+		if strings.HasPrefix(hash.String(), prefix) {
+			matches = append(matches, hash)
+		}
+
+		return nil
+	})
+
+	return matches, err
+}
+
+// Stat only inflates the header of the object.
+func (l *Loose) Stat(hash plumbing.Hash) (ObjectInfo, error) {
+	var info ObjectInfo
+
+	objReader, err := l.open(hash)
+
+	if err != nil {
+		return info, err
+	}
+
+	defer objReader.Close()
+
+	stat, err := os.Stat(l.path(hash))
+
+	if err != nil {
+		return info, err
+	}
+
+	info.Type, info.Size, err = objReader.Header()
+	info.DiskSize = stat.Size()
+
+	return info, err
+}
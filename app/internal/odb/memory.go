@@ -0,0 +1,81 @@
+package odb
+
+import (
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+type memoryObject struct {
+	t       objfile.GitObjectType
+	content []byte
+}
+
+// Memory keeps objects in memory only, for tests and for objects that need
+// not outlive the process.
+type Memory struct {
+	objects map[plumbing.Hash]memoryObject
+	format  plumbing.ObjectFormat
+}
+
+// NewMemory creates an empty store naming objects with the hash algorithm
+// of format.
+func NewMemory(format plumbing.ObjectFormat) *Memory {
+	return &Memory{objects: make(map[plumbing.Hash]memoryObject), format: format}
+}
+
+func (m *Memory) Has(hash plumbing.Hash) (bool, error) {
+	_, ok := m.objects[hash]
+
+	return ok, nil
+}
+
+func (m *Memory) Get(hash plumbing.Hash) (objfile.GitObjectType, []byte, error) {
+	obj, ok := m.objects[hash]
+
+	if !ok {
+		return 0, nil, NotFoundError{Hash: hash}
+	}
+
+	return obj.t, append([]byte(nil), obj.content...), nil
+}
+
+func (m *Memory) Put(t objfile.GitObjectType, content []byte) (plumbing.Hash, error) {
+	hash := objfile.HashObject(m.format, t, content)
+
+	if _, ok := m.objects[hash]; !ok {
+		m.objects[hash] = memoryObject{t: t, content: append([]byte(nil), content...)}
+	}
+
+	return hash, nil
+}
+
+// Iterate visits the objects sorted by id, so that results are stable.
+func (m *Memory) Iterate(fn func(hash plumbing.Hash) error) error {
+	hashes := make([]plumbing.Hash, 0, len(m.objects))
+
+	for hash := range m.objects {
+		hashes = append(hashes, hash)
+	}
+
+	sortHashes(hashes)
+
+	for _, hash := range hashes {
+		if err := fn(hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) Stat(hash plumbing.Hash) (ObjectInfo, error) {
+	obj, ok := m.objects[hash]
+
+	if !ok {
+		return ObjectInfo{}, NotFoundError{Hash: hash}
+	}
+
+	size := int64(len(obj.content))
+
+	return ObjectInfo{Type: obj.t, Size: size, DiskSize: size}, nil
+}
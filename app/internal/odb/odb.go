@@ -0,0 +1,116 @@
+// Package odb abstracts the object database behind an ObjectStore interface,
+// with stores for loose objects, packfiles, memory and layers of other stores.
+package odb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// ObjectStore stores objects by their id.
+type ObjectStore interface {
+	// Has reports whether the object is in the store.
+	Has(hash plumbing.Hash) (bool, error)
+
+	// Get reads an object fully into memory, returning its type and content.
+	Get(hash plumbing.Hash) (objfile.GitObjectType, []byte, error)
+
+	// Put writes an object, returning its id. Writing an object that is
+	// already stored is a no-op.
+	Put(t objfile.GitObjectType, content []byte) (plumbing.Hash, error)
+
+	// Iterate calls fn once for each object in the store, in no particular
+	// order, stopping at the first error fn returns.
+	Iterate(fn func(hash plumbing.Hash) error) error
+
+	// Stat describes how an object is stored, without reading all of it
+	// when the store allows that.
+	Stat(hash plumbing.Hash) (ObjectInfo, error)
+}
+
+// ObjectInfo describes how an object is stored, without its content.
+type ObjectInfo struct {
+	Type objfile.GitObjectType
+	Size int64
+
+	// Bytes taken up on disk, compressed, in the loose file or the pack.
+	DiskSize int64
+
+	// The object a packed delta is based on, zero for everything else.
+	DeltaBase plumbing.Hash
+}
+
+// NotFoundError is returned for objects missing from a store.
+type NotFoundError struct {
+	Hash plumbing.Hash
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("Object %s not found", e.Hash)
+}
+
+// IsNotFound reports whether err means that an object does not exist.
+func IsNotFound(err error) bool {
+	_, ok := err.(NotFoundError)
+
+	return ok
+}
+
+// prefixFinder is implemented by stores that can find objects by an
+// abbreviated id without iterating over all of them.
+type prefixFinder interface {
+	FindPrefix(prefix string) ([]plumbing.Hash, error)
+}
+
+// FindPrefix finds the objects of a store whose id starts with the given hex
+// prefix.
+func FindPrefix(store ObjectStore, prefix string) ([]plumbing.Hash, error) {
+	prefix = strings.ToLower(prefix)
+
+	if finder, ok := store.(prefixFinder); ok {
+		return finder.FindPrefix(prefix)
+	}
+
+	matches := []plumbing.Hash{}
+
+	err := store.Iterate(func(hash plumbing.Hash) error {
+		if strings.HasPrefix(hash.String(), prefix) {
+			matches = append(matches, hash)
+		}
+
+		return nil
+	})
+
+	return matches, err
+}
+
+// List the ids of all objects in a store, sorted, giving up once ctx is
+// done.
+func List(ctx context.Context, store ObjectStore) ([]plumbing.Hash, error) {
+	hashes := []plumbing.Hash{}
+
+	err := store.Iterate(func(hash plumbing.Hash) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hashes = append(hashes, hash)
+
+		return nil
+	})
+
+	sortHashes(hashes)
+
+	return hashes, err
+}
+
+func sortHashes(hashes []plumbing.Hash) {
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].Compare(hashes[j]) < 0
+	})
+}
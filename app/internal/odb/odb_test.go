@@ -0,0 +1,173 @@
+package odb_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/odb"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pack"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+const (
+	blobA     = "78981922613b2afb6025042ff6bd878ac1994e85"
+	emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+)
+
+func newLoose(t *testing.T) *odb.Loose {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "git_ditto_odb")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return odb.NewLoose(dir, plumbing.SHA1)
+}
+
+func hashStrings(hashes []plumbing.Hash) []string {
+	names := []string{}
+
+	for _, hash := range hashes {
+		names = append(names, hash.String())
+	}
+
+	return names
+}
+
+// Exercise the operations every store supports.
+func testStore(t *testing.T, store odb.ObjectStore) {
+	t.Helper()
+
+	hash, err := store.Put(objfile.Blob, []byte("a\n"))
+
+	utils.Expect(t, err, nil)
+	utils.Expect(t, hash.String(), blobA)
+
+	// Writing the same object again is a no-op.
+	_, err = store.Put(objfile.Blob, []byte("a\n"))
+	utils.Expect(t, err, nil)
+
+	tree, err := store.Put(objfile.Tree, nil)
+	utils.Expect(t, err, nil)
+
+	ok, err := store.Has(hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, ok, true)
+
+	objType, content, err := store.Get(hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, objType, objfile.Blob)
+	utils.Expect(t, string(content), "a\n")
+
+	info, err := store.Stat(tree)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, info.Type, objfile.Tree)
+	utils.Expect(t, info.Size, int64(0))
+
+	missing := objfile.HashObject(plumbing.SHA1, objfile.Blob, []byte("missing\n"))
+
+	ok, err = store.Has(missing)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, ok, false)
+
+	_, _, err = store.Get(missing)
+	utils.Expect(t, odb.IsNotFound(err), true)
+
+	_, err = store.Stat(missing)
+	utils.Expect(t, odb.IsNotFound(err), true)
+
+	hashes, err := odb.List(context.Background(), store)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, hashStrings(hashes), []string{emptyTree, blobA})
+
+	matches, err := odb.FindPrefix(store, "7898")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, hashStrings(matches), []string{blobA})
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, odb.NewMemory(plumbing.SHA1))
+}
+
+func TestLoose(t *testing.T) {
+	store := newLoose(t)
+
+	testStore(t, store)
+
+	hash, err := store.PutLiteral("foo", []byte("x"))
+	utils.Expect(t, err, nil)
+	utils.Expect(t, hash.String(), "e7ba76d95050d6668b78f62010713932d4576a19")
+}
+
+func TestLayered(t *testing.T) {
+	lower := odb.NewMemory(plumbing.SHA1)
+	upper := odb.NewMemory(plumbing.SHA1)
+
+	_, err := lower.Put(objfile.Blob, []byte("a\n"))
+	utils.Expect(t, err, nil)
+
+	store := odb.NewLayered(upper, lower)
+	testStore(t, store)
+
+	// New objects only go into the first layer, and objects in several
+	// layers are listed once.
+	tree := objfile.HashObject(plumbing.SHA1, objfile.Tree, nil)
+
+	ok, _ := lower.Has(tree)
+	utils.Expect(t, ok, false)
+
+	ok, _ = upper.Has(tree)
+	utils.Expect(t, ok, true)
+}
+
+func TestPacked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git_ditto_odb")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	// Two blobs alike enough for the smaller to be stored as a delta of the
+	// larger.
+	base := []byte(strings.Repeat("line of the base blob\n", 20))
+	target := append([]byte("one more line first\n"), base[:len(base)-22]...)
+	objects := []pack.Object{}
+
+	for _, data := range [][]byte{base, target} {
+		objects = append(objects, pack.Object{
+			Hash: objfile.HashObject(plumbing.SHA1, objfile.Blob, data),
+			Type: objfile.Blob,
+			Data: data,
+		})
+	}
+
+	var packData, indexData bytes.Buffer
+
+	entries, checksum, err := pack.WritePack(&packData, plumbing.SHA1, objects, 10, 50)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, pack.WriteIndex(&indexData, entries, checksum), nil)
+
+	name := filepath.Join(dir, "pack-"+checksum.String())
+	utils.Expect(t, ioutil.WriteFile(name+".pack", packData.Bytes(), 0644), nil)
+	utils.Expect(t, ioutil.WriteFile(name+".idx", indexData.Bytes(), 0644), nil)
+
+	store := odb.NewPacked(dir, plumbing.SHA1, nil)
+
+	info, err := store.Stat(objects[1].Hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, info.Type, objfile.Blob)
+	utils.Expect(t, info.Size, int64(len(target)))
+	utils.Expect(t, info.DeltaBase, objects[0].Hash)
+
+	info, err = store.Stat(objects[0].Hash)
+	utils.Expect(t, err, nil)
+	utils.Expect(t, info.Size, int64(len(base)))
+	utils.Expect(t, info.DeltaBase.IsZero(), true)
+}
@@ -0,0 +1,185 @@
+package odb
+
+import (
+	"path/filepath"
+	"sort"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pack"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	utils "github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+// Packed reads the objects of the packfiles in a directory. It is read-only:
+// packs are written whole, by pack-objects and index-pack.
+type Packed struct {
+	dir    string
+	format plumbing.ObjectFormat
+
+	// Resolve is consulted for REF_DELTA bases missing from the packs.
+	Resolve pack.ExternalResolver
+
+	loaded bool
+	packs  []*pack.Packfile
+}
+
+// NewPacked creates a store of the packfiles under dir, usually
+// .git/objects/pack, of objects named in the given format. The packs are
+// opened on first use.
+func NewPacked(dir string, format plumbing.ObjectFormat, resolve pack.ExternalResolver) *Packed {
+	return &Packed{dir: dir, format: format, Resolve: resolve}
+}
+
+// Packs gets the packfiles of the store, opening them if needed.
+func (p *Packed) Packs() ([]*pack.Packfile, error) {
+	if p.loaded {
+		return p.packs, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(p.dir, "*.pack"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		packfile, err := pack.Open(path, p.format)
+
+		if err != nil {
+			utils.WarningLogger.Printf("Skipping unreadable pack %s: %s\n", path, err.Error())
+
+			continue
+		}
+
+		packfile.Resolve = p.Resolve
+
+		p.packs = append(p.packs, packfile)
+	}
+
+	p.loaded = true
+
+	return p.packs, nil
+}
+
+// Find the pack holding an object, nil if none does.
+func (p *Packed) find(hash plumbing.Hash) (*pack.Packfile, error) {
+	packs, err := p.Packs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, packfile := range packs {
+		if packfile.Has(hash) {
+			return packfile, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *Packed) Has(hash plumbing.Hash) (bool, error) {
+	packfile, err := p.find(hash)
+
+	return packfile != nil, err
+}
+
+func (p *Packed) Get(hash plumbing.Hash) (objfile.GitObjectType, []byte, error) {
+	packfile, err := p.find(hash)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if packfile == nil {
+		return 0, nil, NotFoundError{Hash: hash}
+	}
+
+	return packfile.Get(hash)
+}
+
+func (p *Packed) Put(t objfile.GitObjectType, content []byte) (plumbing.Hash, error) {
+	return plumbing.ZeroHash, errors.GitError{Message: "Cannot write single objects into packfiles"}
+}
+
+func (p *Packed) Iterate(fn func(hash plumbing.Hash) error) error {
+	packs, err := p.Packs()
+
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+
+	for _, packfile := range packs {
+		for _, hash := range packfile.Index.Hashes {
+			if seen[hash] {
+				continue
+			}
+
+			seen[hash] = true
+
+			if err = fn(hash); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// FindPrefix searches the pack indexes, which are sorted by id.
+func (p *Packed) FindPrefix(prefix string) ([]plumbing.Hash, error) {
+	packs, err := p.Packs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []plumbing.Hash{}
+
+	for _, packfile := range packs {
+		matches = append(matches, packfile.Index.FindPrefix(prefix)...)
+	}
+
+	return matches, nil
+}
+
+// Stat reads the header of the pack entry, and those of its delta chain for
+// the type, without resolving deltas.
+func (p *Packed) Stat(hash plumbing.Hash) (ObjectInfo, error) {
+	var info ObjectInfo
+
+	packfile, err := p.find(hash)
+
+	if err != nil {
+		return info, err
+	}
+
+	if packfile == nil {
+		return info, NotFoundError{Hash: hash}
+	}
+
+	entry, diskSize, err := packfile.Stat(hash)
+
+	if err != nil {
+		return info, err
+	}
+
+	t, size, err := packfile.Info(hash)
+
+	if err != nil {
+		return info, err
+	}
+
+	info.Type, info.Size, info.DiskSize = t, size, diskSize
+
+	if entry.Type.IsDelta() {
+		info.DeltaBase = entry.BaseHash
+	}
+
+	return info, nil
+}
@@ -0,0 +1,74 @@
+package pack
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+)
+
+// How many bytes of resolved delta bases a pack keeps around.
+const deltaBaseCacheLimit = 16 << 20
+
+// A least recently used cache of the delta bases resolved in a pack, by the
+// offset of their entry, so that deltas against the same base do not each
+// apply the whole chain below it again. Packs are read from several
+// goroutines at once, so the cache is locked.
+type baseCache struct {
+	mu sync.Mutex
+
+	limit int
+	size  int
+
+	// Most recently used first.
+	order   *list.List
+	entries map[int64]*list.Element
+}
+
+type cachedBase struct {
+	offset int64
+	t      objfile.GitObjectType
+	data   []byte
+}
+
+func newBaseCache(limit int) *baseCache {
+	return &baseCache{limit: limit, order: list.New(), entries: make(map[int64]*list.Element)}
+}
+
+func (c *baseCache) get(offset int64) (objfile.GitObjectType, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[offset]
+
+	if !ok {
+		return 0, nil, false
+	}
+
+	c.order.MoveToFront(e)
+	base := e.Value.(*cachedBase)
+
+	return base.t, base.data, true
+}
+
+// Keep a base, dropping the least recently used ones to make room. Bases
+// larger than the whole cache are not kept.
+func (c *baseCache) add(offset int64, t objfile.GitObjectType, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[offset]; ok || len(data) > c.limit {
+		return
+	}
+
+	for c.size+len(data) > c.limit {
+		last := c.order.Back()
+		base := c.order.Remove(last).(*cachedBase)
+
+		delete(c.entries, base.offset)
+		c.size -= len(base.data)
+	}
+
+	c.entries[offset] = c.order.PushFront(&cachedBase{offset: offset, t: t, data: data})
+	c.size += len(data)
+}
@@ -0,0 +1,77 @@
+package pack
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+func cached(c *baseCache, offset int64) bool {
+	_, _, ok := c.get(offset)
+
+	return ok
+}
+
+func TestBaseCache(t *testing.T) {
+	c := newBaseCache(10)
+
+	c.add(1, objfile.Blob, []byte("aaaa"))
+	c.add(2, objfile.Blob, []byte("bbbb"))
+
+	objType, data, ok := c.get(1)
+	utils.Expect(t, ok, true)
+	utils.Expect(t, objType, objfile.Blob)
+	utils.Expect(t, string(data), "aaaa")
+
+	// Making room drops the least recently used base, which 1 no longer
+	// is.
+	c.add(3, objfile.Tree, []byte("cccc"))
+
+	utils.Expect(t, cached(c, 1), true)
+	utils.Expect(t, cached(c, 2), false)
+	utils.Expect(t, cached(c, 3), true)
+	utils.Expect(t, c.size, 8)
+
+	// A base larger than the whole cache is not kept, nor does it push the
+	// others out.
+	c.add(4, objfile.Blob, []byte("dddddddddddd"))
+
+	utils.Expect(t, cached(c, 4), false)
+	utils.Expect(t, cached(c, 1), true)
+	utils.Expect(t, c.size, 8)
+
+	// One that fills it leaves nothing else.
+	c.add(5, objfile.Blob, []byte("eeeeeeeeee"))
+
+	utils.Expect(t, cached(c, 5), true)
+	utils.Expect(t, cached(c, 1), false)
+	utils.Expect(t, cached(c, 3), false)
+	utils.Expect(t, c.size, 10)
+	utils.Expect(t, c.order.Len(), 1)
+}
+
+func TestBaseCacheConcurrent(t *testing.T) {
+	c := newBaseCache(64)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for offset := int64(0); offset < 100; offset++ {
+				c.add(offset+int64(i), objfile.Blob, []byte("base"))
+				c.get(offset)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	utils.Expect(t, c.size <= 64, true)
+	utils.Expect(t, c.size, 4*len(c.entries))
+}
@@ -0,0 +1,104 @@
+package pack
+
+import (
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+var errDeltaCorrupt = errors.GitError{Message: "Corrupt delta data"}
+
+// Read a little-endian base-128 size from the start of a delta, returning it
+// along with the remaining bytes.
+func readDeltaSize(delta []byte) (int64, []byte, error) {
+	var size int64
+	shift := uint(0)
+
+	for i, c := range delta {
+		size |= int64(c&0x7f) << shift
+		shift += 7
+
+		if c&0x80 == 0 {
+			return size, delta[i+1:], nil
+		}
+	}
+
+	return 0, nil, errDeltaCorrupt
+}
+
+// ApplyDelta reconstructs an object from its base and a git delta, a list of
+// copy-from-base and insert-literal instructions.
+func ApplyDelta(base, delta []byte) ([]byte, error) {
+	sourceSize, delta, err := readDeltaSize(delta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if sourceSize != int64(len(base)) {
+		return nil, errors.GitError{Message: "Delta base size mismatch"}
+	}
+
+	targetSize, delta, err := readDeltaSize(delta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	target := make([]byte, 0, targetSize)
+
+	for len(delta) > 0 {
+		cmd := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case cmd&0x80 != 0:
+			var offset, size uint32
+
+			for i := uint(0); i < 4; i++ {
+				if cmd&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errDeltaCorrupt
+					}
+
+					offset |= uint32(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+
+			for i := uint(0); i < 3; i++ {
+				if cmd&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errDeltaCorrupt
+					}
+
+					size |= uint32(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+
+			if size == 0 {
+				size = 0x10000
+			}
+
+			if uint64(offset)+uint64(size) > uint64(len(base)) {
+				return nil, errDeltaCorrupt
+			}
+
+			target = append(target, base[offset:offset+size]...)
+		case cmd != 0:
+			if int(cmd) > len(delta) {
+				return nil, errDeltaCorrupt
+			}
+
+			target = append(target, delta[:cmd]...)
+			delta = delta[cmd:]
+		default:
+			return nil, errDeltaCorrupt
+		}
+	}
+
+	if int64(len(target)) != targetSize {
+		return nil, errors.GitError{Message: "Delta target size mismatch"}
+	}
+
+	return target, nil
+}
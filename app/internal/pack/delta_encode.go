@@ -0,0 +1,163 @@
+package pack
+
+import (
+	"bytes"
+	"hash/fnv"
+)
+
+const (
+	// Size of the blocks of the base that are indexed for matching.
+	deltaBlockSize = 16
+
+	maxCopySize   = 0x10000
+	maxInsertSize = 0x7f
+)
+
+func appendDeltaSize(delta []byte, size int) []byte {
+	for size >= 0x80 {
+		delta = append(delta, byte(size&0x7f)|0x80)
+		size >>= 7
+	}
+
+	return append(delta, byte(size))
+}
+
+func blockHash(block []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(block)
+
+	return h.Sum32()
+}
+
+// deltaIndex maps hashes of the aligned blocks of a base to their offsets, so
+// that targets can be matched against it repeatedly.
+type deltaIndex struct {
+	base   []byte
+	blocks map[uint32][]int
+}
+
+func newDeltaIndex(base []byte) *deltaIndex {
+	idx := &deltaIndex{
+		base:   base,
+		blocks: make(map[uint32][]int, len(base)/deltaBlockSize),
+	}
+
+	for i := 0; i+deltaBlockSize <= len(base); i += deltaBlockSize {
+		h := blockHash(base[i : i+deltaBlockSize])
+		idx.blocks[h] = append(idx.blocks[h], i)
+	}
+
+	return idx
+}
+
+// Find the longest match for the start of target in the base.
+func (idx *deltaIndex) match(target []byte) (offset, length int) {
+	if len(target) < deltaBlockSize {
+		return 0, 0
+	}
+
+	for _, candidate := range idx.blocks[blockHash(target[:deltaBlockSize])] {
+		n := 0
+
+		for candidate+n < len(idx.base) && n < len(target) && idx.base[candidate+n] == target[n] {
+			n++
+		}
+
+		if n > length {
+			offset, length = candidate, n
+		}
+	}
+
+	if length < deltaBlockSize {
+		return 0, 0
+	}
+
+	return offset, length
+}
+
+func appendInsert(delta []byte, literal []byte) []byte {
+	for len(literal) > 0 {
+		n := len(literal)
+
+		if n > maxInsertSize {
+			n = maxInsertSize
+		}
+
+		delta = append(delta, byte(n))
+		delta = append(delta, literal[:n]...)
+		literal = literal[n:]
+	}
+
+	return delta
+}
+
+func appendCopy(delta []byte, offset, length int) []byte {
+	for length > 0 {
+		n := length
+
+		if n > maxCopySize {
+			n = maxCopySize
+		}
+
+		cmd := byte(0x80)
+		args := []byte{}
+
+		for i := uint(0); i < 4; i++ {
+			if b := byte(offset >> (8 * i)); b != 0 {
+				cmd |= 1 << i
+				args = append(args, b)
+			}
+		}
+
+		size := n
+
+		if size == maxCopySize {
+			size = 0
+		}
+
+		for i := uint(0); i < 3; i++ {
+			if b := byte(size >> (8 * i)); b != 0 {
+				cmd |= 0x10 << i
+				args = append(args, b)
+			}
+		}
+
+		delta = append(append(delta, cmd), args...)
+		offset += n
+		length -= n
+	}
+
+	return delta
+}
+
+// DiffDelta computes a delta that rebuilds target from base, in the format
+// understood by ApplyDelta.
+func DiffDelta(base, target []byte) []byte {
+	return newDeltaIndex(base).diff(target)
+}
+
+func (idx *deltaIndex) diff(target []byte) []byte {
+	delta := appendDeltaSize(nil, len(idx.base))
+	delta = appendDeltaSize(delta, len(target))
+
+	literal := bytes.NewBuffer(nil)
+
+	for pos := 0; pos < len(target); {
+		offset, length := idx.match(target[pos:])
+
+		if length == 0 {
+			literal.WriteByte(target[pos])
+			pos++
+
+			continue
+		}
+
+		delta = appendInsert(delta, literal.Bytes())
+		literal.Reset()
+
+		delta = appendCopy(delta, offset, length)
+		pos += length
+	}
+
+	return appendInsert(delta, literal.Bytes())
+}
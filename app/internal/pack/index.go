@@ -0,0 +1,180 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+var idxMagic = []byte{0xff, 't', 'O', 'c'}
+
+const (
+	idxVersion = 2
+
+	// Offsets with the MSB set index into the 64-bit offset table.
+	largeOffsetFlag = 0x80000000
+)
+
+// Index is an in-memory version 2 pack index (.idx). Its hashes are all of
+// the object format of the repository, which the file does not record.
+type Index struct {
+	Fanout        [256]uint32
+	Hashes        []plumbing.Hash
+	CRC32         []uint32
+	Offsets       []int64
+	PackChecksum  plumbing.Hash
+	IndexChecksum plumbing.Hash
+}
+
+// ReadIndex parses a version 2 pack index of a repository of the given
+// object format, verifying its trailing checksum.
+func ReadIndex(r io.Reader, format plumbing.ObjectFormat) (*Index, error) {
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashSize := format.Size()
+
+	if len(data) < 8+256*4+2*hashSize || !bytes.Equal(data[:4], idxMagic) {
+		return nil, errors.GitError{Message: "Unsupported pack index: bad signature"}
+	}
+
+	if version := binary.BigEndian.Uint32(data[4:8]); version != idxVersion {
+		return nil, errors.GitError{Message: "Unsupported pack index version"}
+	}
+
+	hasher := format.NewHasher(data[:len(data)-hashSize])
+
+	idx := &Index{}
+	idx.IndexChecksum, _ = format.HashFromBytes(data[len(data)-hashSize:])
+
+	if hasher.Sum() != idx.IndexChecksum {
+		return nil, errors.GitError{Message: "Pack index checksum mismatch"}
+	}
+
+	idx.PackChecksum, _ = format.HashFromBytes(data[len(data)-2*hashSize:])
+
+	pos := 8
+
+	for i := range idx.Fanout {
+		idx.Fanout[i] = binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+	}
+
+	count := int(idx.Fanout[255])
+
+	if len(data) < pos+count*(hashSize+8)+2*hashSize {
+		return nil, errors.GitError{Message: "Pack index is truncated"}
+	}
+
+	idx.Hashes = make([]plumbing.Hash, count)
+
+	for i := range idx.Hashes {
+		idx.Hashes[i], _ = format.HashFromBytes(data[pos:])
+		pos += hashSize
+	}
+
+	idx.CRC32 = make([]uint32, count)
+
+	for i := range idx.CRC32 {
+		idx.CRC32[i] = binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+	}
+
+	smallOffsets := data[pos : pos+count*4]
+	pos += count * 4
+	largeOffsets := data[pos : len(data)-2*hashSize]
+
+	idx.Offsets = make([]int64, count)
+
+	for i := range idx.Offsets {
+		offset := binary.BigEndian.Uint32(smallOffsets[i*4:])
+
+		if offset&largeOffsetFlag == 0 {
+			idx.Offsets[i] = int64(offset)
+			continue
+		}
+
+		large := int(offset&^largeOffsetFlag) * 8
+
+		if large+8 > len(largeOffsets) {
+			return nil, errors.GitError{Message: "Pack index has an invalid 64-bit offset"}
+		}
+
+		idx.Offsets[i] = int64(binary.BigEndian.Uint64(largeOffsets[large:]))
+	}
+
+	return idx, nil
+}
+
+// Find returns the position of hash in the index.
+func (idx *Index) Find(hash plumbing.Hash) (int, bool) {
+	lo, hi := idx.fanoutRange(hash.Bytes()[0])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return idx.Hashes[lo+i].Compare(hash) >= 0
+	})
+
+	if i < hi && idx.Hashes[i] == hash {
+		return i, true
+	}
+
+	return i, false
+}
+
+// FindPrefix returns the hashes in the index starting with the given hex
+// prefix, in order.
+func (idx *Index) FindPrefix(prefix string) []plumbing.Hash {
+	lo, hi := 0, len(idx.Hashes)
+
+	if len(prefix) >= 2 {
+		if first, err := strconv.ParseUint(prefix[:2], 16, 8); err == nil {
+			lo, hi = idx.fanoutRange(byte(first))
+		}
+	}
+
+	matches := []plumbing.Hash{}
+
+	for i := lo; i < hi; i++ {
+		if strings.HasPrefix(idx.Hashes[i].String(), prefix) {
+			matches = append(matches, idx.Hashes[i])
+		}
+	}
+
+	return matches
+}
+
+// FindOffset returns the offset of the object with the given hash in the pack.
+func (idx *Index) FindOffset(hash plumbing.Hash) (int64, bool) {
+	i, ok := idx.Find(hash)
+
+	if !ok {
+		return 0, false
+	}
+
+	return idx.Offsets[i], true
+}
+
+// Count is the number of objects in the pack.
+func (idx *Index) Count() int {
+	return len(idx.Hashes)
+}
+
+func (idx *Index) fanoutRange(first byte) (int, int) {
+	lo := 0
+
+	if first > 0 {
+		lo = int(idx.Fanout[first-1])
+	}
+
+	return lo, int(idx.Fanout[first])
+}
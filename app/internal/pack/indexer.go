@@ -0,0 +1,209 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// An entry read while scanning a pack, along with its resolved object.
+type scannedEntry struct {
+	header EntryHeader
+	data   []byte
+	crc    uint32
+
+	resolved bool
+	t        objfile.GitObjectType
+	content  []byte
+	hash     plumbing.Hash
+}
+
+func (e *scannedEntry) resolve(format plumbing.ObjectFormat, t objfile.GitObjectType, content []byte) {
+	e.resolved = true
+	e.t = t
+	e.content = content
+	e.hash = objfile.HashObject(format, t, content)
+}
+
+// IndexPack scans a complete pack, verifying its trailing checksum and
+// computing the id of every object in the given format (resolving deltas,
+// with resolve used for REF_DELTA bases that are not in the pack). It returns
+// the entries needed to write the pack's index and the pack checksum.
+func IndexPack(data []byte, format plumbing.ObjectFormat, resolve ExternalResolver) ([]IndexEntry, plumbing.Hash, error) {
+	checksum := format.ZeroHash()
+	hashSize := format.Size()
+
+	if len(data) < packHeaderSize+hashSize {
+		return nil, checksum, errors.GitError{Message: "Pack is truncated"}
+	}
+
+	count, err := parsePackHeader(data[:packHeaderSize])
+
+	if err != nil {
+		return nil, checksum, err
+	}
+
+	end := len(data) - hashSize
+	checksum, _ = format.HashFromBytes(data[end:])
+
+	if format.NewHasher(data[:end]).Sum() != checksum {
+		return nil, checksum, errors.GitError{Message: "Pack checksum mismatch"}
+	}
+
+	entries := make([]*scannedEntry, 0, count)
+	byOffset := make(map[int64]*scannedEntry, count)
+	offset := int64(packHeaderSize)
+
+	for i := uint32(0); i < count; i++ {
+		if offset >= int64(end) {
+			return nil, checksum, errors.GitError{Message: "Pack has fewer objects than its header claims"}
+		}
+
+		r := newEntryReader(bytes.NewReader(data[offset:end]))
+
+		header, err := readEntryHeader(r, offset, format)
+
+		if err != nil {
+			return nil, checksum, err
+		}
+
+		content, err := inflate(r, header.Size)
+
+		if err != nil {
+			return nil, checksum, err
+		}
+
+		entry := &scannedEntry{
+			header: header,
+			data:   content,
+			crc:    crc32.ChecksumIEEE(data[offset : offset+r.count]),
+		}
+
+		if !header.Type.IsDelta() {
+			t, err := header.Type.GitObjectType()
+
+			if err != nil {
+				return nil, checksum, err
+			}
+
+			entry.resolve(format, t, content)
+		}
+
+		entries = append(entries, entry)
+		byOffset[offset] = entry
+		offset += r.count
+	}
+
+	if offset != int64(end) {
+		return nil, checksum, errors.GitError{Message: "Pack has trailing garbage"}
+	}
+
+	if err := resolveDeltas(format, entries, byOffset, resolve); err != nil {
+		return nil, checksum, err
+	}
+
+	result := make([]IndexEntry, len(entries))
+
+	for i, e := range entries {
+		result[i] = IndexEntry{Hash: e.hash, Offset: e.header.Offset, CRC32: e.crc}
+	}
+
+	return result, checksum, nil
+}
+
+// Resolve deltas in passes until every entry is resolved, since a base can
+// itself be a delta appearing anywhere in the pack.
+func resolveDeltas(format plumbing.ObjectFormat, entries []*scannedEntry, byOffset map[int64]*scannedEntry, resolve ExternalResolver) error {
+	byHash := make(map[plumbing.Hash]*scannedEntry, len(entries))
+
+	for _, e := range entries {
+		if e.resolved {
+			byHash[e.hash] = e
+		}
+	}
+
+	for {
+		progress, pending := false, 0
+
+		for _, e := range entries {
+			if e.resolved {
+				continue
+			}
+
+			var baseType objfile.GitObjectType
+			var base []byte
+
+			if e.header.Type == OFSDeltaObject {
+				b, ok := byOffset[e.header.BaseOffset]
+
+				if !ok {
+					return errors.GitError{Message: fmt.Sprintf("Delta base at offset %d is not an object", e.header.BaseOffset)}
+				}
+
+				if !b.resolved {
+					pending++
+
+					continue
+				}
+
+				baseType, base = b.t, b.content
+			} else if b, ok := byHash[e.header.BaseHash]; ok {
+				baseType, base = b.t, b.content
+			} else {
+				pending++
+
+				continue
+			}
+
+			content, err := ApplyDelta(base, e.data)
+
+			if err != nil {
+				return err
+			}
+
+			e.resolve(format, baseType, content)
+			byHash[e.hash] = e
+			progress = true
+		}
+
+		if pending == 0 {
+			return nil
+		}
+
+		if progress {
+			continue
+		}
+
+		// Only REF_DELTA bases from outside the pack can still be missing.
+		for _, e := range entries {
+			if e.resolved || e.header.Type != REFDeltaObject {
+				continue
+			}
+
+			if resolve == nil {
+				return errors.GitError{Message: fmt.Sprintf("Delta base %s is not in the pack", e.header.BaseHash)}
+			}
+
+			baseType, base, err := resolve(e.header.BaseHash)
+
+			if err != nil {
+				return err
+			}
+
+			content, err := ApplyDelta(base, e.data)
+
+			if err != nil {
+				return err
+			}
+
+			e.resolve(format, baseType, content)
+			byHash[e.hash] = e
+
+			break
+		}
+	}
+}
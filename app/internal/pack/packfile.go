@@ -0,0 +1,543 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// ObjectType is the 3-bit object type stored in pack entry headers.
+type ObjectType byte
+
+const (
+	CommitObject   ObjectType = 1
+	TreeObject     ObjectType = 2
+	BlobObject     ObjectType = 3
+	TagObject      ObjectType = 4
+	OFSDeltaObject ObjectType = 6
+	REFDeltaObject ObjectType = 7
+)
+
+var packMagic = []byte("PACK")
+
+const (
+	packVersion    = 2
+	packHeaderSize = 12
+
+	// Longest delta chain followed before giving up on a corrupt pack.
+	maxDeltaDepth = 4096
+)
+
+func (t ObjectType) IsDelta() bool {
+	return t == OFSDeltaObject || t == REFDeltaObject
+}
+
+// GitObjectType maps a non-delta pack type to the object type.
+func (t ObjectType) GitObjectType() (objfile.GitObjectType, error) {
+	switch t {
+	case CommitObject:
+		return objfile.Commit, nil
+	case TreeObject:
+		return objfile.Tree, nil
+	case BlobObject:
+		return objfile.Blob, nil
+	case TagObject:
+		return objfile.Tag, nil
+	}
+
+	return 0, errors.GitError{Message: fmt.Sprintf("Unsupported pack object type %d", t)}
+}
+
+// PackObjectType maps an object type to its pack type.
+func PackObjectType(t objfile.GitObjectType) (ObjectType, error) {
+	switch t {
+	case objfile.Commit:
+		return CommitObject, nil
+	case objfile.Tree:
+		return TreeObject, nil
+	case objfile.Blob:
+		return BlobObject, nil
+	case objfile.Tag:
+		return TagObject, nil
+	}
+
+	return 0, errors.GitError{Message: fmt.Sprintf("Object type %s cannot be packed", t)}
+}
+
+// ExternalResolver looks up objects that are not in the pack itself, such as
+// the bases of REF_DELTA entries in thin packs.
+type ExternalResolver func(hash plumbing.Hash) (objfile.GitObjectType, []byte, error)
+
+// Packfile gives random access to the objects of a .pack file through its
+// index.
+type Packfile struct {
+	Index *Index
+	Path  string
+
+	// Resolve is consulted for REF_DELTA bases missing from this pack.
+	Resolve ExternalResolver
+
+	format plumbing.ObjectFormat
+	file   *os.File
+
+	// Entry offsets in pack order, to find where each entry ends.
+	offsets []int64
+	hashAt  map[int64]plumbing.Hash
+
+	bases *baseCache
+}
+
+// Open opens a .pack file along with the .idx file next to it, naming
+// objects with the hash algorithm of format.
+func Open(packPath string, format plumbing.ObjectFormat) (*Packfile, error) {
+	idxPath := strings.TrimSuffix(packPath, ".pack") + ".idx"
+
+	idxFile, err := os.Open(idxPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer idxFile.Close()
+
+	idx, err := ReadIndex(bufio.NewReader(idxFile), format)
+
+	if err != nil {
+		return nil, &errors.PathError{Op: "read", Path: idxPath, Err: err}
+	}
+
+	f, err := os.Open(packPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, packHeaderSize)
+
+	if _, err = io.ReadFull(f, header); err != nil {
+		f.Close()
+
+		return nil, &errors.PathError{Op: "read", Path: packPath, Err: err}
+	}
+
+	count, err := parsePackHeader(header)
+
+	if err != nil {
+		f.Close()
+
+		return nil, &errors.PathError{Op: "read", Path: packPath, Err: err}
+	}
+
+	if int(count) != idx.Count() {
+		f.Close()
+
+		return nil, &errors.PathError{
+			Op:   "read",
+			Path: packPath,
+			Err:  errors.GitError{Message: "Object count does not match its index"},
+		}
+	}
+
+	return &Packfile{
+		Index:  idx,
+		Path:   packPath,
+		format: format,
+		file:   f,
+		bases:  newBaseCache(deltaBaseCacheLimit),
+	}, nil
+}
+
+func parsePackHeader(header []byte) (uint32, error) {
+	if !bytes.Equal(header[:4], packMagic) {
+		return 0, errors.GitError{Message: "Bad pack signature"}
+	}
+
+	if version := binary.BigEndian.Uint32(header[4:8]); version != packVersion && version != 3 {
+		return 0, errors.GitError{Message: fmt.Sprintf("Unsupported pack version %d", version)}
+	}
+
+	return binary.BigEndian.Uint32(header[8:12]), nil
+}
+
+func (p *Packfile) Close() error {
+	return p.file.Close()
+}
+
+func (p *Packfile) Has(hash plumbing.Hash) bool {
+	_, ok := p.Index.Find(hash)
+
+	return ok
+}
+
+// Get reads the object with the given hash, resolving delta chains.
+func (p *Packfile) Get(hash plumbing.Hash) (objfile.GitObjectType, []byte, error) {
+	offset, ok := p.Index.FindOffset(hash)
+
+	if !ok {
+		return 0, nil, errors.GitError{Message: fmt.Sprintf("Object %s not found in pack %s", hash, p.Path)}
+	}
+
+	return p.ObjectAt(offset)
+}
+
+// ObjectAt reads the object whose entry starts at offset.
+func (p *Packfile) ObjectAt(offset int64) (objfile.GitObjectType, []byte, error) {
+	return p.objectAt(offset, 0)
+}
+
+func (p *Packfile) objectAt(offset int64, depth int) (objfile.GitObjectType, []byte, error) {
+	if depth > maxDeltaDepth {
+		return 0, nil, errors.GitError{Message: "Delta chain too deep"}
+	}
+
+	r := newEntryReader(io.NewSectionReader(p.file, offset, 1<<62))
+
+	entry, err := readEntryHeader(r, offset, p.format)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	data, err := inflate(r, entry.Size)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !entry.Type.IsDelta() {
+		t, err := entry.Type.GitObjectType()
+
+		return t, data, err
+	}
+
+	var baseType objfile.GitObjectType
+	var base []byte
+
+	if entry.Type == OFSDeltaObject {
+		baseType, base, err = p.baseAt(entry.BaseOffset, depth+1)
+	} else if baseOffset, ok := p.Index.FindOffset(entry.BaseHash); ok {
+		baseType, base, err = p.baseAt(baseOffset, depth+1)
+	} else if p.Resolve != nil {
+		baseType, base, err = p.Resolve(entry.BaseHash)
+	} else {
+		err = errors.GitError{Message: fmt.Sprintf("Delta base %s not found", entry.BaseHash)}
+	}
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	target, err := ApplyDelta(base, data)
+
+	return baseType, target, err
+}
+
+// Resolve the base of a delta, keeping it for the other deltas against it.
+// Bases are only read, never handed out, so they can be shared.
+func (p *Packfile) baseAt(offset int64, depth int) (objfile.GitObjectType, []byte, error) {
+	if t, data, ok := p.bases.get(offset); ok {
+		return t, data, nil
+	}
+
+	t, data, err := p.objectAt(offset, depth)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	p.bases.add(offset, t, data)
+
+	return t, data, nil
+}
+
+// Stat reads the header of an object's entry, filling in the BaseHash of
+// OFS_DELTA entries too, and returns the number of bytes the entry takes up
+// in the pack.
+func (p *Packfile) Stat(hash plumbing.Hash) (EntryHeader, int64, error) {
+	offset, ok := p.Index.FindOffset(hash)
+
+	if !ok {
+		return EntryHeader{}, 0, errors.GitError{Message: fmt.Sprintf("Object %s not found in pack %s", hash, p.Path)}
+	}
+
+	entry, err := readEntryHeader(newEntryReader(io.NewSectionReader(p.file, offset, 1<<62)), offset, p.format)
+
+	if err != nil {
+		return entry, 0, err
+	}
+
+	if err = p.loadOffsets(); err != nil {
+		return entry, 0, err
+	}
+
+	if entry.Type == OFSDeltaObject {
+		entry.BaseHash = p.hashAt[entry.BaseOffset]
+	}
+
+	// Entries end where the next one starts, or at the trailing checksum.
+	i := sort.Search(len(p.offsets), func(i int) bool {
+		return p.offsets[i] > offset
+	})
+
+	end := p.offsets[len(p.offsets)-1]
+
+	if i < len(p.offsets) {
+		end = p.offsets[i]
+	}
+
+	return entry, end - offset, nil
+}
+
+// Info gives the type and size of the object with the given hash. Deltas
+// start with the size of the object they make, so only the headers of the
+// entries down the delta chain are read, which gives the type.
+func (p *Packfile) Info(hash plumbing.Hash) (objfile.GitObjectType, int64, error) {
+	offset, ok := p.Index.FindOffset(hash)
+
+	if !ok {
+		return 0, 0, errors.GitError{Message: fmt.Sprintf("Object %s not found in pack %s", hash, p.Path)}
+	}
+
+	return p.infoAt(offset, 0)
+}
+
+func (p *Packfile) infoAt(offset int64, depth int) (objfile.GitObjectType, int64, error) {
+	if depth > maxDeltaDepth {
+		return 0, 0, errors.GitError{Message: "Delta chain too deep"}
+	}
+
+	r := newEntryReader(io.NewSectionReader(p.file, offset, 1<<62))
+
+	entry, err := readEntryHeader(r, offset, p.format)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !entry.Type.IsDelta() {
+		t, err := entry.Type.GitObjectType()
+
+		return t, entry.Size, err
+	}
+
+	size, err := deltaTargetSize(r, entry.Size)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var baseType objfile.GitObjectType
+
+	if entry.Type == OFSDeltaObject {
+		baseType, _, err = p.infoAt(entry.BaseOffset, depth+1)
+	} else if baseOffset, ok := p.Index.FindOffset(entry.BaseHash); ok {
+		baseType, _, err = p.infoAt(baseOffset, depth+1)
+	} else if p.Resolve != nil {
+		// Bases outside the pack can only be read whole.
+		baseType, _, err = p.Resolve(entry.BaseHash)
+	} else {
+		err = errors.GitError{Message: fmt.Sprintf("Delta base %s not found", entry.BaseHash)}
+	}
+
+	return baseType, size, err
+}
+
+func (p *Packfile) loadOffsets() error {
+	if p.offsets != nil {
+		return nil
+	}
+
+	info, err := p.file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	p.hashAt = make(map[int64]plumbing.Hash, p.Index.Count())
+	p.offsets = make([]int64, 0, p.Index.Count()+1)
+
+	for i, hash := range p.Index.Hashes {
+		p.hashAt[p.Index.Offsets[i]] = hash
+		p.offsets = append(p.offsets, p.Index.Offsets[i])
+	}
+
+	p.offsets = append(p.offsets, info.Size()-int64(p.format.Size()))
+
+	sort.Slice(p.offsets, func(i, j int) bool {
+		return p.offsets[i] < p.offsets[j]
+	})
+
+	return nil
+}
+
+// EntryHeader is the decoded header of a single pack entry.
+type EntryHeader struct {
+	Type ObjectType
+
+	// Size of the inflated entry data; for deltas, the size of the delta.
+	Size int64
+
+	// Offset of the entry within the pack.
+	Offset int64
+
+	// BaseOffset is the absolute offset of the base of an OFS_DELTA.
+	BaseOffset int64
+
+	// BaseHash is the id of the base of a REF_DELTA.
+	BaseHash plumbing.Hash
+}
+
+// entryReader reads pack data byte-wise while counting consumed bytes, so
+// that zlib stops exactly at the end of each entry.
+type entryReader struct {
+	r     *bufio.Reader
+	count int64
+}
+
+func newEntryReader(r io.Reader) *entryReader {
+	return &entryReader{r: bufio.NewReader(r)}
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.count += int64(n)
+
+	return n, err
+}
+
+func (r *entryReader) ReadByte() (byte, error) {
+	c, err := r.r.ReadByte()
+
+	if err == nil {
+		r.count++
+	}
+
+	return c, err
+}
+
+func readEntryHeader(r *entryReader, offset int64, format plumbing.ObjectFormat) (EntryHeader, error) {
+	entry := EntryHeader{Offset: offset}
+
+	c, err := r.ReadByte()
+
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Type = ObjectType((c >> 4) & 0x7)
+	entry.Size = int64(c & 0x0f)
+	shift := uint(4)
+
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return entry, err
+		}
+
+		entry.Size |= int64(c&0x7f) << shift
+		shift += 7
+	}
+
+	switch entry.Type {
+	case CommitObject, TreeObject, BlobObject, TagObject:
+	case OFSDeltaObject:
+		if c, err = r.ReadByte(); err != nil {
+			return entry, err
+		}
+
+		relative := int64(c & 0x7f)
+
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return entry, err
+			}
+
+			relative = ((relative + 1) << 7) | int64(c&0x7f)
+		}
+
+		if relative <= 0 || relative > offset {
+			return entry, errors.GitError{Message: "Delta base offset out of bounds"}
+		}
+
+		entry.BaseOffset = offset - relative
+	case REFDeltaObject:
+		base := make([]byte, format.Size())
+
+		if _, err = io.ReadFull(r, base); err != nil {
+			return entry, err
+		}
+
+		entry.BaseHash, _ = format.HashFromBytes(base)
+	default:
+		return entry, errors.GitError{Message: fmt.Sprintf("Invalid pack object type %d at offset %d", entry.Type, offset)}
+	}
+
+	return entry, nil
+}
+
+// Read the size of the object a delta makes from the start of its entry
+// data, without inflating the rest of it.
+func deltaTargetSize(r *entryReader, deltaSize int64) (int64, error) {
+	zr, err := zlib.NewReader(r)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer zr.Close()
+
+	// The sizes of the base and of the target come first, each taking at
+	// most 10 bytes.
+	head := make([]byte, 20)
+
+	if deltaSize < int64(len(head)) {
+		head = head[:deltaSize]
+	}
+
+	if _, err = io.ReadFull(zr, head); err != nil {
+		return 0, errors.GitError{Message: "Pack entry is shorter than its header claims"}
+	}
+
+	_, rest, err := readDeltaSize(head)
+
+	if err != nil {
+		return 0, err
+	}
+
+	size, _, err := readDeltaSize(rest)
+
+	return size, err
+}
+
+func inflate(r *entryReader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer zr.Close()
+
+	data := make([]byte, size)
+
+	if _, err = io.ReadFull(zr, data); err != nil {
+		return nil, errors.GitError{Message: "Pack entry is shorter than its header claims"}
+	}
+
+	// Drain the stream so that the checksum is verified and consumed.
+	if n, err := io.Copy(ioutil.Discard, zr); err != nil || n != 0 {
+		return nil, errors.GitError{Message: "Pack entry is longer than its header claims"}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,282 @@
+package pack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+const (
+	DefaultWindow = 10
+	DefaultDepth  = 50
+
+	// Objects smaller than this are never deltified.
+	minDeltaSize = 64
+)
+
+// Object is a fully inflated object to be written into a pack.
+type Object struct {
+	Hash plumbing.Hash
+	Type objfile.GitObjectType
+	Data []byte
+}
+
+// IndexEntry locates one object of a pack, as recorded in its index.
+type IndexEntry struct {
+	Hash   plumbing.Hash
+	Offset int64
+	CRC32  uint32
+}
+
+// Choice of how an object is stored in the pack.
+type packedObject struct {
+	*Object
+
+	base  int // position of the delta base in the write order, or -1
+	delta []byte
+	depth int
+}
+
+// Pick delta bases using a sliding window over objects ordered by type and
+// decreasing size, so that every base is written before its deltas.
+func selectDeltas(objects []Object, window, depth int) []*packedObject {
+	order := make([]*packedObject, len(objects))
+
+	for i := range objects {
+		order[i] = &packedObject{Object: &objects[i], base: -1}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Type != order[j].Type {
+			return order[i].Type < order[j].Type
+		}
+
+		return len(order[i].Data) > len(order[j].Data)
+	})
+
+	indexes := make(map[int]*deltaIndex)
+
+	for i, target := range order {
+		if len(target.Data) < minDeltaSize {
+			continue
+		}
+
+		for j := i - 1; j >= 0 && j >= i-window; j-- {
+			candidate := order[j]
+
+			if candidate.Type != target.Type || candidate.depth >= depth || len(candidate.Data) < minDeltaSize {
+				continue
+			}
+
+			idx, ok := indexes[j]
+
+			if !ok {
+				idx = newDeltaIndex(candidate.Data)
+				indexes[j] = idx
+			}
+
+			delta := idx.diff(target.Data)
+
+			// Only keep deltas that save a meaningful amount of space.
+			if len(delta) >= len(target.Data)/2 {
+				continue
+			}
+
+			if target.base < 0 || len(delta) < len(target.delta) {
+				target.base = j
+				target.delta = delta
+				target.depth = candidate.depth + 1
+			}
+		}
+
+		// Drop indexes that have slid out of the window.
+		delete(indexes, i-window)
+	}
+
+	return order
+}
+
+func encodeEntryHeader(t ObjectType, size int64) []byte {
+	c := byte(t)<<4 | byte(size&0x0f)
+	size >>= 4
+
+	header := []byte{}
+
+	for size > 0 {
+		header = append(header, c|0x80)
+		c = byte(size & 0x7f)
+		size >>= 7
+	}
+
+	return append(header, c)
+}
+
+func encodeOffsetDelta(relative int64) []byte {
+	buf := []byte{byte(relative & 0x7f)}
+	relative >>= 7
+
+	for relative > 0 {
+		relative--
+		buf = append([]byte{0x80 | byte(relative&0x7f)}, buf...)
+		relative >>= 7
+	}
+
+	return buf
+}
+
+func deflate(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	zw := zlib.NewWriter(buf)
+
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WritePack writes objects as a version 2 pack, deltifying them against up to
+// window preceding similar objects with chains of at most depth deltas, and
+// checksummed with the hash algorithm of format. It returns the entries for
+// the pack's index and the pack checksum.
+func WritePack(w io.Writer, format plumbing.ObjectFormat, objects []Object, window, depth int) ([]IndexEntry, plumbing.Hash, error) {
+	hasher := format.NewHasher(nil)
+	out := io.MultiWriter(w, hasher)
+
+	header := make([]byte, packHeaderSize)
+	copy(header, packMagic)
+	binary.BigEndian.PutUint32(header[4:], packVersion)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(objects)))
+
+	if _, err := out.Write(header); err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+
+	offset := int64(packHeaderSize)
+	order := selectDeltas(objects, window, depth)
+	offsets := make([]int64, len(order))
+	entries := make([]IndexEntry, 0, len(order))
+
+	for i, obj := range order {
+		var raw []byte
+
+		if obj.base >= 0 {
+			raw = encodeEntryHeader(OFSDeltaObject, int64(len(obj.delta)))
+			raw = append(raw, encodeOffsetDelta(offset-offsets[obj.base])...)
+		} else {
+			t, err := PackObjectType(obj.Type)
+
+			if err != nil {
+				return nil, plumbing.ZeroHash, err
+			}
+
+			raw = encodeEntryHeader(t, int64(len(obj.Data)))
+		}
+
+		data := obj.Data
+
+		if obj.base >= 0 {
+			data = obj.delta
+		}
+
+		compressed, err := deflate(data)
+
+		if err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+
+		raw = append(raw, compressed...)
+
+		if _, err = out.Write(raw); err != nil {
+			return nil, plumbing.ZeroHash, err
+		}
+
+		offsets[i] = offset
+		entries = append(entries, IndexEntry{
+			Hash:   obj.Hash,
+			Offset: offset,
+			CRC32:  crc32.ChecksumIEEE(raw),
+		})
+
+		offset += int64(len(raw))
+	}
+
+	checksum := hasher.Sum()
+
+	if _, err := w.Write(checksum.Bytes()); err != nil {
+		return nil, plumbing.ZeroHash, err
+	}
+
+	return entries, checksum, nil
+}
+
+// WriteIndex writes a version 2 pack index for the given entries, in the
+// object format of the pack checksum.
+func WriteIndex(w io.Writer, entries []IndexEntry, packChecksum plumbing.Hash) error {
+	sorted := append([]IndexEntry{}, entries...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Hash.Compare(sorted[j].Hash) < 0
+	})
+
+	buf := bytes.NewBuffer(nil)
+	buf.Write(idxMagic)
+	binary.Write(buf, binary.BigEndian, uint32(idxVersion))
+
+	var fanout [256]uint32
+
+	for _, e := range sorted {
+		fanout[e.Hash.Bytes()[0]]++
+	}
+
+	total := uint32(0)
+
+	for i := range fanout {
+		total += fanout[i]
+		binary.Write(buf, binary.BigEndian, total)
+	}
+
+	for _, e := range sorted {
+		buf.Write(e.Hash.Bytes())
+	}
+
+	for _, e := range sorted {
+		binary.Write(buf, binary.BigEndian, e.CRC32)
+	}
+
+	large := []uint64{}
+
+	for _, e := range sorted {
+		if e.Offset < largeOffsetFlag {
+			binary.Write(buf, binary.BigEndian, uint32(e.Offset))
+
+			continue
+		}
+
+		binary.Write(buf, binary.BigEndian, uint32(len(large))|largeOffsetFlag)
+		large = append(large, uint64(e.Offset))
+	}
+
+	for _, offset := range large {
+		binary.Write(buf, binary.BigEndian, offset)
+	}
+
+	buf.Write(packChecksum.Bytes())
+
+	checksum := packChecksum.Format().NewHasher(buf.Bytes()).Sum()
+	buf.Write(checksum.Bytes())
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
@@ -0,0 +1,155 @@
+package pathspec
+
+import (
+	"path"
+	"strings"
+)
+
+// Pathspec limits commands to a set of paths. Each pattern is relative to
+// the top of the working tree and matches either the path itself, anything
+// below it when it names a directory, or paths matching it as a glob, where
+// '*' also matches across directories.
+type Pathspec struct {
+	Patterns []string
+}
+
+func New(patterns []string) Pathspec {
+	cleaned := make([]string, 0, len(patterns))
+
+	for _, p := range patterns {
+		p = strings.TrimSuffix(path.Clean("/" + p)[1:], "/")
+		cleaned = append(cleaned, p)
+	}
+
+	return Pathspec{Patterns: cleaned}
+}
+
+// Empty pathspecs match every path.
+func (p Pathspec) Empty() bool {
+	return len(p.Patterns) == 0
+}
+
+// Literal pathspecs name paths outright, without globs, and do not match
+// everything.
+func (p Pathspec) Literal() bool {
+	if p.Empty() {
+		return false
+	}
+
+	for _, pattern := range p.Patterns {
+		if pattern == "" || hasGlob(pattern) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p Pathspec) Match(name string) bool {
+	if p.Empty() {
+		return true
+	}
+
+	for i := range p.Patterns {
+		if p.MatchPattern(i, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchPattern reports whether the i-th pattern matches name.
+func (p Pathspec) MatchPattern(i int, name string) bool {
+	pattern := p.Patterns[i]
+
+	if pattern == "" || pattern == name || strings.HasPrefix(name, pattern+"/") {
+		return true
+	}
+
+	if !hasGlob(pattern) {
+		return false
+	}
+
+	return wildmatch(pattern, name)
+}
+
+// MatchesDirectory reports whether anything below the directory dir can be
+// matched, so that walks can skip directories that cannot.
+func (p Pathspec) MatchesDirectory(dir string) bool {
+	if p.Empty() {
+		return true
+	}
+
+	for _, pattern := range p.Patterns {
+		if pattern == "" || hasGlob(pattern) || pattern == dir ||
+			strings.HasPrefix(pattern, dir+"/") || strings.HasPrefix(dir, pattern+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// Match name against a glob where '*' can match '/' (fnmatch without
+// FNM_PATHNAME, as used by Git pathspecs).
+func wildmatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if pattern == "" {
+				return true
+			}
+
+			for i := 0; i <= len(name); i++ {
+				if wildmatch(pattern, name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case '?':
+			if name == "" {
+				return false
+			}
+		case '[':
+			end := strings.IndexByte(pattern[1:], ']')
+
+			if end < 0 || name == "" {
+				return false
+			}
+
+			if matched, err := path.Match(pattern[:end+2], name[:1]); err != nil || !matched {
+				return false
+			}
+
+			pattern = pattern[end+2:]
+			name = name[1:]
+
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+		default:
+			if name == "" || name[0] != pattern[0] {
+				return false
+			}
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return name == ""
+}
@@ -0,0 +1,104 @@
+package plumbing
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// ObjectFormat is the hash algorithm naming the objects of a repository, set
+// by extensions.objectFormat.
+type ObjectFormat uint8
+
+const (
+	// SHA1 is the format of repositories that do not say otherwise.
+	SHA1 ObjectFormat = iota
+	SHA256
+)
+
+// The widest object id of any format, in bytes.
+const maxHashSize = sha256.Size
+
+// ParseObjectFormat maps an algorithm name, as in extensions.objectFormat,
+// to its ObjectFormat. An empty name is SHA1.
+func ParseObjectFormat(name string) (ObjectFormat, error) {
+	switch name {
+	case "", "sha1":
+		return SHA1, nil
+	case "sha256":
+		return SHA256, nil
+	}
+
+	return SHA1, errors.GitError{Message: fmt.Sprintf("unknown hash algorithm '%s'", name)}
+}
+
+func (f ObjectFormat) String() string {
+	if f == SHA256 {
+		return "sha256"
+	}
+
+	return "sha1"
+}
+
+// Size is the width of object ids in bytes.
+func (f ObjectFormat) Size() int {
+	if f == SHA256 {
+		return sha256.Size
+	}
+
+	return sha1.Size
+}
+
+// HexSize is the width of object ids in hex digits.
+func (f ObjectFormat) HexSize() int {
+	return 2 * f.Size()
+}
+
+// ZeroHash is the all-zero object id of the format.
+func (f ObjectFormat) ZeroHash() Hash {
+	return Hash{format: f}
+}
+
+// NewHasher starts hashing bytes with the algorithm of the format.
+func (f ObjectFormat) NewHasher(bytes []byte) Hasher {
+	var h hash.Hash
+
+	if f == SHA256 {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+
+	h.Write(bytes)
+
+	return Hasher{Hash: h, format: f}
+}
+
+// Read an object id of the format from the start of data.
+func (f ObjectFormat) HashFromBytes(data []byte) (Hash, error) {
+	h := Hash{format: f}
+
+	if len(data) < f.Size() {
+		return h, errors.GitError{Message: fmt.Sprintf("truncated %s object id", f)}
+	}
+
+	copy(h.sum[:], data[:f.Size()])
+
+	return h, nil
+}
+
+type Hasher struct {
+	hash.Hash
+
+	format ObjectFormat
+}
+
+func (h Hasher) Sum() Hash {
+	hash := Hash{format: h.format}
+	copy(hash.sum[:], h.Hash.Sum(nil))
+
+	return hash
+}
@@ -0,0 +1,73 @@
+package plumbing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+)
+
+// Hash is an object id of any format. Hashes are comparable, and those of
+// different formats never compare equal.
+type Hash struct {
+	sum    [maxHashSize]byte
+	format ObjectFormat
+}
+
+// ZeroHash is the all-zero SHA-1 object id, used to denote a missing
+// object. IsZero tells the missing objects of every format apart.
+var ZeroHash Hash
+
+// NewHash parses a full hex object id, its format told by its length.
+func NewHash(s string) (h Hash, err error) {
+	switch len(s) {
+	case SHA1.HexSize():
+		h.format = SHA1
+	case SHA256.HexSize():
+		h.format = SHA256
+	default:
+		return h, errors.GitError{Message: "Not a valid object name " + s}
+	}
+
+	if _, err = hex.Decode(h.sum[:h.format.Size()], []byte(s)); err != nil {
+		return ZeroHash, errors.GitError{Message: "Not a valid object name " + s}
+	}
+
+	return h, nil
+}
+
+// Format is the hash algorithm the id was made with.
+func (h Hash) Format() ObjectFormat {
+	return h.format
+}
+
+// Bytes is the raw id, as stored in trees, the index and packs.
+func (h Hash) Bytes() []byte {
+	return append([]byte(nil), h.sum[:h.format.Size()]...)
+}
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h.sum[:h.format.Size()])
+}
+
+func (h Hash) IsZero() bool {
+	return h.sum == [maxHashSize]byte{}
+}
+
+// Compare orders hashes by their bytes, like their hex forms sort.
+func (h Hash) Compare(other Hash) int {
+	return bytes.Compare(h.sum[:h.format.Size()], other.sum[:other.format.Size()])
+}
+
+// NewHashFromBytes takes a raw object id, its format told by its length.
+func NewHashFromBytes(data []byte) (Hash, error) {
+	switch len(data) {
+	case SHA1.Size():
+		return SHA1.HashFromBytes(data)
+	case SHA256.Size():
+		return SHA256.HashFromBytes(data)
+	}
+
+	return ZeroHash, errors.GitError{Message: fmt.Sprintf("Invalid object id length %d", len(data))}
+}
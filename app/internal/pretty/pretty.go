@@ -0,0 +1,408 @@
+// Package pretty formats commits for display, in the built-in formats of
+// git log --pretty (oneline, short, medium, full, fuller and raw) or in
+// user formats with %-placeholders.
+package pretty
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/revision"
+)
+
+var builtins = map[string]bool{
+	"oneline": true,
+	"short":   true,
+	"medium":  true,
+	"full":    true,
+	"fuller":  true,
+	"raw":     true,
+}
+
+// Formatter formats commits in one format.
+type Formatter struct {
+	git *fs.Git
+
+	// The built-in format, or the user format when it is "".
+	builtin string
+	format  string
+
+	// Whether a newline separates commits, or terminates each of them.
+	separated  bool
+	terminated bool
+
+	// Show abbreviated object names in the built-in formats.
+	AbbrevCommit bool
+
+	// The time relative dates are relative to.
+	Now time.Time
+}
+
+// New parses a --pretty format: the name of a built-in format,
+// format:<string>, whose commits are separated by newlines, or
+// tformat:<string>, whose commits are terminated by them. Any other string
+// with a % in it is taken as a tformat.
+func New(git *fs.Git, spec string) (*Formatter, error) {
+	f := &Formatter{git: git, Now: time.Now()}
+
+	switch {
+	case builtins[spec]:
+		f.builtin = spec
+		f.separated = spec != "oneline"
+		f.terminated = spec == "oneline"
+	case spec == "":
+		f.builtin = "medium"
+		f.separated = true
+	case strings.HasPrefix(spec, "format:"):
+		f.format = strings.TrimPrefix(spec, "format:")
+		f.separated = true
+	case strings.HasPrefix(spec, "tformat:"):
+		f.format = strings.TrimPrefix(spec, "tformat:")
+		f.terminated = true
+	case strings.Contains(spec, "%"):
+		f.format = spec
+		f.terminated = true
+	default:
+		return nil, errors.GitError{Message: fmt.Sprintf("invalid --pretty format: %s", spec)}
+	}
+
+	return f, nil
+}
+
+// Separated reports whether a newline goes between formatted commits.
+func (f *Formatter) Separated() bool {
+	return f.separated
+}
+
+// Terminated reports whether a newline goes after each formatted commit.
+func (f *Formatter) Terminated() bool {
+	return f.terminated
+}
+
+// Format a commit. The multi-line built-in formats end with a newline;
+// oneline and user formats end where their format does.
+func (f *Formatter) Format(hash plumbing.Hash, c *commit.Commit) (string, error) {
+	if f.builtin == "" {
+		return f.expand(hash, c)
+	}
+
+	var b strings.Builder
+
+	name, err := f.name(hash, f.AbbrevCommit)
+
+	if err != nil {
+		return "", err
+	}
+
+	if f.builtin == "oneline" {
+		return name + " " + Subject(c.Message), nil
+	}
+
+	fmt.Fprintf(&b, "commit %s\n", name)
+
+	if f.builtin == "raw" {
+		fmt.Fprintf(&b, "tree %s\n", c.Tree)
+
+		for _, parent := range c.Parents {
+			fmt.Fprintf(&b, "parent %s\n", parent)
+		}
+
+		fmt.Fprintf(&b, "author %s\ncommitter %s\n", c.Author, c.Committer)
+	} else {
+		if len(c.Parents) > 1 {
+			parents, err := f.names(c.Parents, true)
+
+			if err != nil {
+				return "", err
+			}
+
+			fmt.Fprintf(&b, "Merge: %s\n", parents)
+		}
+
+		switch f.builtin {
+		case "short":
+			fmt.Fprintf(&b, "Author: %s\n", ident(c.Author))
+		case "medium":
+			fmt.Fprintf(&b, "Author: %s\nDate:   %s\n", ident(c.Author), commit.FormatDate(c.Author.When, commit.DateDefault, f.Now))
+		case "full":
+			fmt.Fprintf(&b, "Author: %s\nCommit: %s\n", ident(c.Author), ident(c.Committer))
+		case "fuller":
+			fmt.Fprintf(&b, "Author:     %s\nAuthorDate: %s\nCommit:     %s\nCommitDate: %s\n",
+				ident(c.Author), commit.FormatDate(c.Author.When, commit.DateDefault, f.Now),
+				ident(c.Committer), commit.FormatDate(c.Committer.When, commit.DateDefault, f.Now))
+		}
+	}
+
+	lines := messageLines(c.Message, f.builtin == "short")
+
+	if len(lines) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, line := range lines {
+		if f.builtin != "raw" {
+			line = expandTabs(line)
+		}
+
+		b.WriteString("    " + line + "\n")
+	}
+
+	return b.String(), nil
+}
+
+func ident(sig commit.Signature) string {
+	return sig.Name + " <" + sig.Email + ">"
+}
+
+// The lines of a message without trailing whitespace, and without the
+// blank lines around it. Only the first paragraph is kept if asked.
+func messageLines(message string, firstParagraph bool) []string {
+	lines := []string{}
+
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimRight(line, " \t\r\v\f")
+
+		if line == "" && len(lines) == 0 {
+			continue
+		}
+
+		if line == "" && firstParagraph {
+			break
+		}
+
+		lines = append(lines, line)
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Expand tabs to the next multiple of eight columns.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var b strings.Builder
+
+	column := 0
+
+	for _, r := range line {
+		if r != '\t' {
+			b.WriteRune(r)
+			column++
+
+			continue
+		}
+
+		spaces := 8 - column%8
+		b.WriteString(strings.Repeat(" ", spaces))
+		column += spaces
+	}
+
+	return b.String()
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// The first paragraph of a message, its lines joined with spaces, and the
+// rest of the message after the blank lines following it.
+func splitMessage(message string) (string, string) {
+	lines := strings.Split(message, "\n")
+
+	for len(lines) > 0 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+
+	subject := []string{}
+
+	for len(lines) > 0 && !isBlank(lines[0]) {
+		subject = append(subject, strings.TrimRight(lines[0], " \t\r\v\f"))
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+
+	return strings.Join(subject, " "), strings.Join(lines, "\n")
+}
+
+// Subject is the first paragraph of a message, joined into a single line.
+func Subject(message string) string {
+	subject, _ := splitMessage(message)
+
+	return subject
+}
+
+// Body is the message after its subject paragraph.
+func Body(message string) string {
+	_, body := splitMessage(message)
+
+	return body
+}
+
+func (f *Formatter) name(hash plumbing.Hash, abbrev bool) (string, error) {
+	if !abbrev {
+		return hash.String(), nil
+	}
+
+	return revision.Abbreviate(f.git, hash, revision.DefaultAbbrev)
+}
+
+func (f *Formatter) names(hashes []plumbing.Hash, abbrev bool) (string, error) {
+	names := make([]string, 0, len(hashes))
+
+	for _, hash := range hashes {
+		name, err := f.name(hash, abbrev)
+
+		if err != nil {
+			return "", err
+		}
+
+		names = append(names, name)
+	}
+
+	return strings.Join(names, " "), nil
+}
+
+// Expand the placeholders of a user format. Unknown ones are kept as they
+// are, like git does.
+func (f *Formatter) expand(hash plumbing.Hash, c *commit.Commit) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(f.format); i++ {
+		if f.format[i] != '%' || i+1 == len(f.format) {
+			b.WriteByte(f.format[i])
+
+			continue
+		}
+
+		value, n, err := f.placeholder(f.format[i+1:], hash, c)
+
+		if err != nil {
+			return "", err
+		}
+
+		if n == 0 {
+			b.WriteByte('%')
+
+			continue
+		}
+
+		b.WriteString(value)
+		i += n
+	}
+
+	return b.String(), nil
+}
+
+// Expand the placeholder at the start of spec, returning the length of it
+// or 0 if it is not one.
+func (f *Formatter) placeholder(spec string, hash plumbing.Hash, c *commit.Commit) (string, int, error) {
+	switch spec[0] {
+	case '%':
+		return "%", 1, nil
+	case 'n':
+		return "\n", 1, nil
+	case 'H':
+		return hash.String(), 1, nil
+	case 'h':
+		name, err := f.name(hash, true)
+
+		return name, 1, err
+	case 'T':
+		return c.Tree.String(), 1, nil
+	case 't':
+		name, err := f.name(c.Tree, true)
+
+		return name, 1, err
+	case 'P':
+		names, err := f.names(c.Parents, false)
+
+		return names, 1, err
+	case 'p':
+		names, err := f.names(c.Parents, true)
+
+		return names, 1, err
+	case 's':
+		return Subject(c.Message), 1, nil
+	case 'b':
+		return Body(c.Message), 1, nil
+	case 'B':
+		return c.Message, 1, nil
+	case 'a', 'c':
+		if len(spec) < 2 {
+			return "", 0, nil
+		}
+
+		sig := c.Author
+
+		if spec[0] == 'c' {
+			sig = c.Committer
+		}
+
+		value, ok := f.signature(sig, spec[1])
+
+		if !ok {
+			return "", 0, nil
+		}
+
+		return value, 2, nil
+	case 'x':
+		if len(spec) < 3 {
+			return "", 0, nil
+		}
+
+		n, err := strconv.ParseUint(spec[1:3], 16, 8)
+
+		if err != nil {
+			return "", 0, nil
+		}
+
+		return string([]byte{byte(n)}), 3, nil
+	}
+
+	return "", 0, nil
+}
+
+// Expand the %a<x> and %c<x> placeholders of a signature.
+func (f *Formatter) signature(sig commit.Signature, field byte) (string, bool) {
+	styles := map[byte]string{
+		'd': commit.DateDefault,
+		'r': commit.DateRelative,
+		't': commit.DateUnix,
+		'i': commit.DateISO,
+		'I': commit.DateISOStrict,
+		'D': commit.DateRFC,
+		's': commit.DateShort,
+	}
+
+	switch field {
+	case 'n':
+		return sig.Name, true
+	case 'e':
+		return sig.Email, true
+	case 'l':
+		return strings.SplitN(sig.Email, "@", 2)[0], true
+	}
+
+	style, ok := styles[field]
+
+	if !ok {
+		return "", false
+	}
+
+	return commit.FormatDate(sig.When, style, f.Now), true
+}
@@ -0,0 +1,116 @@
+package refs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+const (
+	packedRefsFile   = "packed-refs"
+	packedRefsHeader = "# pack-refs with: peeled fully-peeled sorted \n"
+)
+
+// Parse a packed-refs file: one "<hash> <name>" line per ref, optionally
+// followed by a "^<hash>" line giving the object an annotated tag peels to.
+func parsePackedRefs(r io.Reader) ([]*Ref, error) {
+	refs := []*Ref{}
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			if len(refs) == 0 {
+				return nil, errors.GitError{Message: "unexpected line in packed-refs: " + line}
+			}
+
+			peeled, err := plumbing.NewHash(line[1:])
+
+			if err != nil {
+				return nil, err
+			}
+
+			refs[len(refs)-1].Peeled = peeled
+		default:
+			fields := strings.SplitN(line, " ", 2)
+
+			if len(fields) != 2 {
+				return nil, errors.GitError{Message: "unexpected line in packed-refs: " + line}
+			}
+
+			hash, err := plumbing.NewHash(fields[0])
+
+			if err != nil {
+				return nil, err
+			}
+
+			refs = append(refs, &Ref{Name: fields[1], Hash: hash})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+
+	return refs, nil
+}
+
+func encodePackedRefs(refs []*Ref) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(packedRefsHeader)
+
+	for _, ref := range refs {
+		fmt.Fprintf(&buf, "%s %s\n", ref.Hash, ref.Name)
+
+		if !ref.Peeled.IsZero() {
+			fmt.Fprintf(&buf, "^%s\n", ref.Peeled)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Read the packed refs, sorted by name. A missing file holds no refs.
+func (s *Store) packedRefs() ([]*Ref, error) {
+	f, err := os.Open(s.path(packedRefsFile))
+
+	if os.IsNotExist(err) {
+		return []*Ref{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return parsePackedRefs(f)
+}
+
+func findPacked(refs []*Ref, name string) (*Ref, bool) {
+	i := sort.Search(len(refs), func(i int) bool {
+		return refs[i].Name >= name
+	})
+
+	if i < len(refs) && refs[i].Name == name {
+		return refs[i], true
+	}
+
+	return nil, false
+}
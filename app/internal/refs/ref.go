@@ -0,0 +1,93 @@
+package refs
+
+import (
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+const (
+	Head = "HEAD"
+
+	HeadsPrefix   = "refs/heads/"
+	TagsPrefix    = "refs/tags/"
+	RemotesPrefix = "refs/remotes/"
+
+	symrefPrefix = "ref: "
+)
+
+// Ref is a named pointer into the object store. Symbolic refs point at
+// another ref instead, named by Target.
+type Ref struct {
+	Name string
+
+	// The object the ref points at. For symbolic refs, this is the object
+	// the target resolves to, if known.
+	Hash plumbing.Hash
+
+	Target string
+
+	// The object an annotated tag ultimately points at, when known from
+	// the peeled lines of packed-refs.
+	Peeled plumbing.Hash
+}
+
+func (r *Ref) IsSymbolic() bool {
+	return r.Target != ""
+}
+
+// ShortName strips the well-known prefixes off a ref name, e.g.
+// refs/heads/master becomes master.
+func ShortName(name string) string {
+	for _, prefix := range []string{HeadsPrefix, TagsPrefix, RemotesPrefix, "refs/"} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+
+	return name
+}
+
+// ValidName reports whether name is a well-formed ref name, following the
+// rules of git check-ref-format.
+func ValidName(name string) bool {
+	if name == "" || name == "@" {
+		return false
+	}
+
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") || strings.HasPrefix(name, "/") {
+		return false
+	}
+
+	for _, bad := range []string{"..", "@{", "//"} {
+		if strings.Contains(name, bad) {
+			return false
+		}
+	}
+
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?*[\\", c) {
+			return false
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Refs directly under the git directory, like HEAD or MERGE_HEAD, are
+// all-caps; everything else lives under refs/.
+func isRootRef(name string) bool {
+	for _, c := range name {
+		if (c < 'A' || c > 'Z') && c != '_' {
+			return false
+		}
+	}
+
+	return name != ""
+}
@@ -0,0 +1,195 @@
+package refs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+const logsDir = "logs"
+
+// ReflogEntry records one update of a ref: its old and new values, who
+// made the change and when, and why.
+type ReflogEntry struct {
+	Old       plumbing.Hash
+	New       plumbing.Hash
+	Committer commit.Signature
+	Message   string
+}
+
+// Parse a reflog line of the form
+//
+//	<old> <new> Name <email> 1234567890 +0000\t<message>
+func parseReflogEntry(line string) (ReflogEntry, error) {
+	var entry ReflogEntry
+
+	fields := strings.SplitN(line, " ", 3)
+
+	if len(fields) != 3 {
+		return entry, errors.GitError{Message: "malformed reflog line: " + line}
+	}
+
+	var err error
+
+	if entry.Old, err = plumbing.NewHash(fields[0]); err != nil {
+		return entry, err
+	}
+
+	if entry.New, err = plumbing.NewHash(fields[1]); err != nil {
+		return entry, err
+	}
+
+	ident := fields[2]
+
+	if tab := strings.IndexByte(ident, '\t'); tab >= 0 {
+		entry.Message = ident[tab+1:]
+		ident = ident[:tab]
+	}
+
+	entry.Committer, err = commit.ParseSignature([]byte(ident))
+
+	return entry, err
+}
+
+// Read the reflog of a ref, oldest entry first. Refs without a log have an
+// empty one.
+func (s *Store) Reflog(name string) ([]ReflogEntry, error) {
+	f, err := os.Open(s.reflogPath(name))
+
+	if os.IsNotExist(err) {
+		return []ReflogEntry{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	entries := []ReflogEntry{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			continue
+		}
+
+		entry, err := parseReflogEntry(line)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+func (s *Store) reflogPath(name string) string {
+	return filepath.Join(s.gitDir, logsDir, filepath.FromSlash(name))
+}
+
+// Whether updates of a ref are logged. core.logAllRefUpdates, true unless
+// the repository is bare, logs HEAD, branches, remote-tracking branches and
+// notes, and "always" every ref. A ref with a log keeps it up to date
+// either way.
+func (s *Store) logsUpdates(cfg *config.Config, name string) (bool, error) {
+	if _, err := os.Stat(s.reflogPath(name)); err == nil {
+		return true, nil
+	}
+
+	if value, ok := cfg.Get("core.logAllRefUpdates"); ok && strings.EqualFold(value, "always") {
+		return true, nil
+	}
+
+	bare, err := cfg.Bool("core.bare", false)
+
+	if err != nil {
+		return false, err
+	}
+
+	logAll, err := cfg.Bool("core.logAllRefUpdates", !bare)
+
+	if err != nil || !logAll {
+		return false, err
+	}
+
+	for _, prefix := range []string{HeadsPrefix, RemotesPrefix, "refs/notes/"} {
+		if strings.HasPrefix(name, prefix) {
+			return true, nil
+		}
+	}
+
+	return name == Head, nil
+}
+
+// Append an entry to the reflog of a ref, if its updates are logged. The
+// message is folded onto one line.
+func (s *Store) appendReflog(name string, old, new plumbing.Hash, message string) error {
+	cfg, err := config.LoadAll(s.gitDir)
+
+	if err != nil {
+		return err
+	}
+
+	if logged, err := s.logsUpdates(cfg, name); err != nil || !logged {
+		return err
+	}
+
+	committer, err := commit.Ident(commit.Committer, cfg)
+
+	if err != nil {
+		return err
+	}
+
+	line := old.String() + " " + new.String() + " " + committer.String()
+
+	if message = strings.Join(strings.Fields(message), " "); message != "" {
+		line += "\t" + message
+	}
+
+	path := s.reflogPath(name)
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.WriteString(line + "\n"); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// Remove the reflog of a deleted ref, along with the directories it leaves
+// empty.
+func (s *Store) deleteReflog(name string) error {
+	if err := os.Remove(s.reflogPath(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for dir := filepath.Dir(s.reflogPath(name)); dir != filepath.Join(s.gitDir, logsDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return nil
+}
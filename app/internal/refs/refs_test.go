@@ -0,0 +1,194 @@
+package refs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/utils"
+)
+
+const (
+	commitA = "7078f528f708716be0f9b508ae564c6e756d90dd"
+	commitB = "55b9b76ee1bdb2419f4e7d0a91e6032581094259"
+	tagV1   = "547c4b231cf741aa48b7ae1ef94f2434c6c7af42"
+)
+
+func hash(t *testing.T, s string) plumbing.Hash {
+	t.Helper()
+
+	h, err := plumbing.NewHash(s)
+	utils.Expect(t, err, nil)
+
+	return h
+}
+
+// A git directory with HEAD on master, master and v1 packed, and master
+// overridden by a loose ref.
+func newStore(t *testing.T) (*refs.Store, string) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "git_ditto_refs")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	files := map[string]string{
+		"HEAD": "ref: refs/heads/master\n",
+		"packed-refs": "# pack-refs with: peeled fully-peeled sorted \n" +
+			commitA + " refs/heads/master\n" +
+			tagV1 + " refs/tags/v1\n" +
+			"^" + commitB + "\n",
+		"refs/heads/master": commitB + "\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		utils.Expect(t, os.MkdirAll(filepath.Dir(path), 0755), nil)
+		utils.Expect(t, ioutil.WriteFile(path, []byte(content), 0644), nil)
+	}
+
+	return refs.NewStore(dir), dir
+}
+
+func TestRead(t *testing.T) {
+	store, _ := newStore(t)
+
+	head, err := store.Read("HEAD")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, head.Target, "refs/heads/master")
+
+	branch, resolved, err := store.Head()
+	utils.Expect(t, err, nil)
+	utils.Expect(t, branch, "refs/heads/master")
+	utils.Expect(t, resolved, hash(t, commitB))
+
+	v1, err := store.Read("refs/tags/v1")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, v1.Hash, hash(t, tagV1))
+	utils.Expect(t, v1.Peeled, hash(t, commitB))
+
+	missing, err := store.Read("refs/heads/missing")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, missing == nil, true)
+
+	ref, err := store.Dwim("v1")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, ref.Name, "refs/tags/v1")
+
+	all, err := store.List("refs/")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(all), 2)
+	utils.Expect(t, all[0].Name, "refs/heads/master")
+	utils.Expect(t, all[0].Hash, hash(t, commitB))
+}
+
+func TestUpdate(t *testing.T) {
+	store, dir := newStore(t)
+
+	old := hash(t, commitA)
+
+	// master is at B through its loose ref.
+	err := store.Update("HEAD", hash(t, commitA), &old, false, "")
+	utils.Expect(t, err != nil, true)
+
+	old = hash(t, commitB)
+
+	utils.Expect(t, store.Update("HEAD", hash(t, commitA), &old, false, ""), nil)
+	utils.ExpectFileContent(t, filepath.Join(dir, "refs/heads/master"), commitA+"\n")
+
+	utils.Expect(t, store.Update("refs/heads/topic/one", hash(t, commitA), &plumbing.ZeroHash, false, ""), nil)
+	utils.Expect(t, store.Update("refs/heads/topic", hash(t, commitA), nil, false, "") != nil, true)
+	utils.Expect(t, store.Update("refs/heads/bad..name", hash(t, commitA), nil, false, "") != nil, true)
+
+	// The ref in the way is reported, not that it could not be read.
+	err = store.Update("refs/heads/master/x", hash(t, commitA), nil, false, "")
+	utils.Expect(t, err.Error(), "cannot lock ref 'refs/heads/master/x': 'refs/heads/master' exists; cannot create 'refs/heads/master/x'")
+
+	utils.Expect(t, store.Delete("refs/heads/topic/one", nil, false, ""), nil)
+	utils.Expect(t, utils.PathExists(filepath.Join(dir, "refs/heads/topic")), false)
+
+	utils.Expect(t, store.Delete("refs/tags/v1", nil, false, ""), nil)
+	utils.ExpectFileContent(t, filepath.Join(dir, "packed-refs"),
+		"# pack-refs with: peeled fully-peeled sorted \n"+commitA+" refs/heads/master\n")
+
+	utils.Expect(t, store.SetSymbolic("HEAD", "refs/heads/other", ""), nil)
+	utils.ExpectFileContent(t, filepath.Join(dir, "HEAD"), "ref: refs/heads/other\n")
+}
+
+func TestReflogUpdates(t *testing.T) {
+	store, dir := newStore(t)
+
+	a, b := hash(t, commitA), hash(t, commitB)
+
+	// Updating the branch HEAD is on logs both, and HEAD even with no change.
+	utils.Expect(t, store.Update("HEAD", a, nil, false, "reset: moving to A"), nil)
+	utils.Expect(t, store.Update("refs/heads/master", a, nil, false, "again"), nil)
+
+	master, err := store.Reflog("refs/heads/master")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(master), 1)
+	utils.Expect(t, master[0].Old, b)
+	utils.Expect(t, master[0].New, a)
+	utils.Expect(t, master[0].Message, "reset: moving to A")
+
+	head, err := store.Reflog("HEAD")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(head), 2)
+	utils.Expect(t, head[1].Old, a)
+	utils.Expect(t, head[1].Message, "again")
+
+	// Tags are not logged unless they have a log already.
+	utils.Expect(t, store.Update("refs/tags/v2", a, nil, false, "tag"), nil)
+	utils.Expect(t, utils.PathExists(filepath.Join(dir, "logs/refs/tags/v2")), false)
+
+	// Switching branches logs HEAD once the new branch exists.
+	utils.Expect(t, store.Update("refs/heads/topic/one", b, nil, false, "branch: Created"), nil)
+	utils.Expect(t, store.SetSymbolic("HEAD", "refs/heads/topic/one", "checkout: moving"), nil)
+
+	head, err = store.Reflog("HEAD")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(head), 3)
+	utils.Expect(t, head[2].Old, a)
+	utils.Expect(t, head[2].New, b)
+
+	// Deleting a branch removes its log, and HEAD logs it if it pointed
+	// there.
+	utils.Expect(t, store.Delete("refs/heads/topic/one", nil, false, "deleted"), nil)
+	utils.Expect(t, utils.PathExists(filepath.Join(dir, "logs/refs/heads/topic")), false)
+
+	head, err = store.Reflog("HEAD")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(head), 4)
+	utils.Expect(t, head[3].Old, b)
+	utils.Expect(t, head[3].New, plumbing.ZeroHash)
+
+	// In a SHA-256 repository, missing values are logged with its zero id.
+	dir, err = ioutil.TempDir("", "git_ditto_refs")
+	utils.Expect(t, err, nil)
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	utils.Expect(t, ioutil.WriteFile(filepath.Join(dir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644), nil)
+
+	store = refs.NewStore(dir)
+	a = hash(t, "ad3e12a4b9d3c2a0fe1a6d4c1c2df1c0bde5a8c5e1c3d1f8e6b8d7a4c3b2a190")
+
+	utils.Expect(t, store.Update("HEAD", a, nil, false, "created"), nil)
+	utils.Expect(t, store.Delete("refs/heads/main", nil, false, "deleted"), nil)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "logs/HEAD"))
+	utils.Expect(t, err, nil)
+	utils.Expect(t, strings.Fields(string(content))[0], strings.Repeat("0", 64))
+
+	head, err = store.Reflog("HEAD")
+	utils.Expect(t, err, nil)
+	utils.Expect(t, len(head), 2)
+	utils.Expect(t, head[0].Old, plumbing.SHA256.ZeroHash())
+	utils.Expect(t, head[1].New, plumbing.SHA256.ZeroHash())
+}
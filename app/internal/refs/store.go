@@ -0,0 +1,277 @@
+package refs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Symbolic refs pointing at each other beyond this depth are a loop.
+const maxSymrefDepth = 5
+
+// Store reads and writes the refs of a repository: loose refs, one file
+// each under the git directory, and packed refs, which they take
+// precedence over.
+type Store struct {
+	gitDir string
+}
+
+func NewStore(gitDir string) *Store {
+	return &Store{gitDir: gitDir}
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.gitDir, filepath.FromSlash(name))
+}
+
+// Read a loose ref file, returning nil if there is none.
+func (s *Store) readLoose(name string) (*Ref, error) {
+	data, err := ioutil.ReadFile(s.path(name))
+
+	// A ref in the way of one of its directories means it does not exist
+	// either.
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.ENOTDIR {
+		return nil, nil
+	}
+
+	if err != nil {
+		// A directory of that name holds other refs, not this one.
+		if info, statErr := os.Stat(s.path(name)); statErr == nil && info.IsDir() {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	content := strings.TrimSpace(string(data))
+
+	if strings.HasPrefix(content, symrefPrefix) {
+		return &Ref{Name: name, Target: strings.TrimPrefix(content, symrefPrefix)}, nil
+	}
+
+	hash, err := plumbing.NewHash(content)
+
+	if err != nil {
+		return nil, errors.GitError{Message: fmt.Sprintf("invalid ref %s: %s", name, content)}
+	}
+
+	return &Ref{Name: name, Hash: hash}, nil
+}
+
+// Read a ref without following symbolic refs, returning nil if it does not
+// exist.
+func (s *Store) Read(name string) (*Ref, error) {
+	ref, err := s.readLoose(name)
+
+	if err != nil || ref != nil {
+		return ref, err
+	}
+
+	packed, err := s.packedRefs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ref, ok := findPacked(packed, name); ok {
+		return ref, nil
+	}
+
+	return nil, nil
+}
+
+// Follow symbolic refs from name, returning the name of the direct ref at
+// the end of the chain along with the ref itself, nil if that ref does not
+// exist yet (like the branch of a new repository).
+func (s *Store) Follow(name string) (string, *Ref, error) {
+	for depth := 0; depth < maxSymrefDepth; depth++ {
+		ref, err := s.Read(name)
+
+		if err != nil || ref == nil {
+			return name, nil, err
+		}
+
+		if !ref.IsSymbolic() {
+			return name, ref, nil
+		}
+
+		name = ref.Target
+	}
+
+	return "", nil, errors.GitError{Message: fmt.Sprintf("symbolic ref loop at %s", name)}
+}
+
+// Resolve a ref to the object it points at, following symbolic refs.
+func (s *Store) Resolve(name string) (plumbing.Hash, error) {
+	_, ref, err := s.Follow(name)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if ref == nil {
+		return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("Not a valid ref: %s", name)}
+	}
+
+	return ref.Hash, nil
+}
+
+// Read HEAD, returning the branch ref it points at ("" when HEAD is
+// detached) and the commit it resolves to (zero when the branch is unborn).
+func (s *Store) Head() (string, plumbing.Hash, error) {
+	head, err := s.Read(Head)
+
+	if err != nil {
+		return "", plumbing.ZeroHash, err
+	}
+
+	if head == nil {
+		return "", plumbing.ZeroHash, errors.GitError{Message: "HEAD not found"}
+	}
+
+	if !head.IsSymbolic() {
+		return "", head.Hash, nil
+	}
+
+	_, ref, err := s.Follow(head.Target)
+
+	if err != nil || ref == nil {
+		return head.Target, plumbing.ZeroHash, err
+	}
+
+	return head.Target, ref.Hash, nil
+}
+
+// The rules git uses to expand a short name into a full ref name, most
+// specific first.
+var dwimRules = []string{
+	"%s",
+	"refs/%s",
+	"refs/tags/%s",
+	"refs/heads/%s",
+	"refs/remotes/%s",
+	"refs/remotes/%s/HEAD",
+}
+
+// Expand a possibly abbreviated ref name, like master or origin, into the
+// full name of an existing ref. Returns nil if none matches.
+func (s *Store) Dwim(name string) (*Ref, error) {
+	for _, rule := range dwimRules {
+		full := fmt.Sprintf(rule, name)
+
+		// Only the well-known root refs can live outside of refs/.
+		if rule == "%s" && !strings.HasPrefix(full, "refs/") && !isRootRef(full) {
+			continue
+		}
+
+		if !ValidName(full) {
+			continue
+		}
+
+		_, ref, err := s.Follow(full)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ref != nil {
+			return &Ref{Name: full, Hash: ref.Hash, Peeled: ref.Peeled}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// List the refs under prefix (like refs/tags/) sorted by name, loose refs
+// shadowing packed ones. Symbolic refs are listed with the object they
+// resolve to; dangling ones are left out.
+func (s *Store) List(prefix string) ([]*Ref, error) {
+	byName := make(map[string]*Ref)
+
+	packed, err := s.packedRefs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range packed {
+		if strings.HasPrefix(ref.Name, prefix) {
+			byName[ref.Name] = ref
+		}
+	}
+
+	root := s.path("refs")
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.gitDir, path)
+
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+
+		if !strings.HasPrefix(name, prefix) || !ValidName(name) {
+			return nil
+		}
+
+		ref, err := s.readLoose(name)
+
+		if err != nil {
+			return err
+		}
+
+		if ref.IsSymbolic() {
+			_, target, err := s.Follow(ref.Target)
+
+			if err != nil {
+				return err
+			}
+
+			if target == nil {
+				return nil
+			}
+
+			ref.Hash = target.Hash
+		}
+
+		byName[name] = ref
+
+		return nil
+	})
+
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	refs := make([]*Ref, 0, len(byName))
+
+	for _, ref := range byName {
+		refs = append(refs, ref)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+
+	return refs, nil
+}
@@ -0,0 +1,308 @@
+package refs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Check the current value of a locked ref against the expected old value:
+// nil expects nothing, the zero hash expects the ref not to exist.
+func checkOldValue(name string, current *Ref, old *plumbing.Hash) error {
+	if old == nil {
+		return nil
+	}
+
+	switch {
+	case old.IsZero() && current != nil:
+		return errors.GitError{Message: fmt.Sprintf("cannot lock ref '%s': reference already exists", name)}
+	case !old.IsZero() && current == nil:
+		return errors.GitError{Message: fmt.Sprintf("cannot lock ref '%s': unable to resolve reference '%s'", name, name)}
+	case !old.IsZero() && current.Hash != *old:
+		return errors.GitError{Message: fmt.Sprintf("cannot lock ref '%s': is at %s but expected %s", name, current.Hash, *old)}
+	}
+
+	return nil
+}
+
+// Refuse to create refs/heads/a/b next to refs/heads/a, or the other way
+// round, as they could not both exist as files.
+func (s *Store) checkNameConflict(name string) error {
+	existing, err := s.List("refs/")
+
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range existing {
+		if strings.HasPrefix(ref.Name, name+"/") || strings.HasPrefix(name, ref.Name+"/") {
+			return errors.GitError{Message: fmt.Sprintf("cannot lock ref '%s': '%s' exists; cannot create '%s'", name, ref.Name, name)}
+		}
+	}
+
+	return nil
+}
+
+// Lock a loose ref, creating its directory, and read its current value
+// while holding the lock.
+func (s *Store) lock(name string) (*fs.LockFile, *Ref, error) {
+	if err := os.MkdirAll(filepath.Dir(s.path(name)), os.ModePerm); err != nil {
+		return nil, nil, err
+	}
+
+	lock, err := fs.Lock(s.path(name))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := s.Read(name)
+
+	if err != nil {
+		lock.Rollback()
+
+		return nil, nil, err
+	}
+
+	return lock, current, nil
+}
+
+// Write a loose ref under its lock, returning the value it had before.
+func (s *Store) write(name, content string, old *plumbing.Hash) (*Ref, error) {
+	lock, current, err := s.lock(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil && current.IsSymbolic() {
+		current.Hash, _ = s.Resolve(current.Target)
+	}
+
+	if err = checkOldValue(name, current, old); err != nil {
+		lock.Rollback()
+
+		return nil, err
+	}
+
+	if _, err = lock.WriteString(content); err != nil {
+		lock.Rollback()
+
+		return nil, err
+	}
+
+	return current, lock.Commit()
+}
+
+// The object a ref pointed at, the zero id of the format if it did not
+// exist.
+func valueOf(ref *Ref, format plumbing.ObjectFormat) plumbing.Hash {
+	if ref == nil || ref.Hash.IsZero() {
+		return format.ZeroHash()
+	}
+
+	return ref.Hash
+}
+
+// Log an update of a ref, and of HEAD when it points at the ref. Like in
+// git, HEAD is logged even when the value stays the same.
+func (s *Store) logUpdate(name string, old, new plumbing.Hash, message string) error {
+	if old != new {
+		if err := s.appendReflog(name, old, new, message); err != nil {
+			return err
+		}
+	}
+
+	if name == Head {
+		return nil
+	}
+
+	head, err := s.Read(Head)
+
+	if err != nil || head == nil || head.Target != name {
+		return err
+	}
+
+	return s.appendReflog(Head, old, new, message)
+}
+
+// Point a ref at an object, checking its old value first unless old is nil.
+// Symbolic refs are followed unless noDeref is set, in which case they are
+// overwritten with a direct ref. The update is logged with the message.
+func (s *Store) Update(name string, hash plumbing.Hash, old *plumbing.Hash, noDeref bool, message string) error {
+	if name != Head && !ValidName(name) {
+		return errors.GitError{Message: fmt.Sprintf("refusing to update ref with bad name '%s'", name)}
+	}
+
+	// A ref in the way of the name is reported before following it, which
+	// would find nothing there.
+	if err := s.checkNameConflict(name); err != nil {
+		return err
+	}
+
+	if !noDeref {
+		target, _, err := s.Follow(name)
+
+		if err != nil {
+			return err
+		}
+
+		if target != name {
+			if err = s.checkNameConflict(target); err != nil {
+				return err
+			}
+		}
+
+		name = target
+	}
+
+	current, err := s.write(name, hash.String()+"\n", old)
+
+	if err != nil {
+		return err
+	}
+
+	return s.logUpdate(name, valueOf(current, hash.Format()), hash, message)
+}
+
+// Make name a symbolic ref pointing at target. The change is logged with
+// the message once target exists.
+func (s *Store) SetSymbolic(name, target, message string) error {
+	if !ValidName(name) || !strings.HasPrefix(target, "refs/") || !ValidName(target) {
+		return errors.GitError{Message: fmt.Sprintf("refusing to point %s outside of refs/: %s", name, target)}
+	}
+
+	current, err := s.write(name, symrefPrefix+target+"\n", nil)
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.Resolve(target)
+
+	if err != nil || hash.IsZero() {
+		return nil
+	}
+
+	return s.appendReflog(name, valueOf(current, hash.Format()), hash, message)
+}
+
+// Delete a ref, both loose and packed, checking its old value first unless
+// old is nil. Symbolic refs are followed unless noDeref is set. The ref's
+// log goes with it, and HEAD logs the deletion when it points at the ref.
+func (s *Store) Delete(name string, old *plumbing.Hash, noDeref bool, message string) error {
+	if !noDeref {
+		target, _, err := s.Follow(name)
+
+		if err != nil {
+			return err
+		}
+
+		name = target
+	}
+
+	lock, current, err := s.lock(name)
+
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		lock.Rollback()
+		s.removeEmptyDirs(name)
+
+		return errors.GitError{Message: fmt.Sprintf("unable to delete '%s': reference does not exist", name)}
+	}
+
+	if old != nil && !old.IsZero() {
+		if err = checkOldValue(name, current, old); err != nil {
+			lock.Rollback()
+
+			return err
+		}
+	}
+
+	if err = s.deletePacked(name); err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	if err = os.Remove(s.path(name)); err != nil && !os.IsNotExist(err) {
+		lock.Rollback()
+
+		return err
+	}
+
+	// The lock goes before the directories holding it can be removed.
+	if err = lock.Rollback(); err != nil {
+		return err
+	}
+
+	s.removeEmptyDirs(name)
+
+	if err = s.deleteReflog(name); err != nil {
+		return err
+	}
+
+	head, err := s.Read(Head)
+
+	if err != nil || head == nil || head.Target != name {
+		return err
+	}
+
+	return s.appendReflog(Head, current.Hash, current.Hash.Format().ZeroHash(), message)
+}
+
+// Rewrite packed-refs without the given ref, if it is in there.
+func (s *Store) deletePacked(name string) error {
+	lock, err := fs.Lock(s.path(packedRefsFile))
+
+	if err != nil {
+		return err
+	}
+
+	packed, err := s.packedRefs()
+
+	if err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	kept := make([]*Ref, 0, len(packed))
+
+	for _, ref := range packed {
+		if ref.Name != name {
+			kept = append(kept, ref)
+		}
+	}
+
+	if len(kept) == len(packed) {
+		return lock.Rollback()
+	}
+
+	if _, err = lock.Write(encodePackedRefs(kept)); err != nil {
+		lock.Rollback()
+
+		return err
+	}
+
+	return lock.Commit()
+}
+
+// Remove the directories left empty by deleting a loose ref, keeping the
+// top-level ones like refs/heads.
+func (s *Store) removeEmptyDirs(name string) {
+	for dir := path.Dir(name); strings.Count(dir, "/") >= 2; dir = path.Dir(dir) {
+		if os.Remove(s.path(dir)) != nil {
+			return
+		}
+	}
+}
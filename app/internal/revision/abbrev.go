@@ -0,0 +1,33 @@
+package revision
+
+import (
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// DefaultAbbrev is the length object names are abbreviated to by default.
+const DefaultAbbrev = 7
+
+// Abbreviate returns the shortest prefix of hash, at least minLen digits
+// long, that names no other object in the repository.
+func Abbreviate(git *fs.Git, hash plumbing.Hash, minLen int) (string, error) {
+	full := hash.String()
+
+	if minLen < minAbbrev {
+		minLen = minAbbrev
+	}
+
+	for n := minLen; n < len(full); n++ {
+		matches, err := git.FindObjects(full[:n])
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(matches) <= 1 {
+			return full[:n], nil
+		}
+	}
+
+	return full, nil
+}
@@ -0,0 +1,86 @@
+package revision
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commitgraph"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// commitReader reads what walks need of commits: their parents, root tree
+// and commit date. These come from the commit-graph when it has them,
+// without the rest of the commit, which has to be parsed separately.
+// Walks read every commit they visit through it, so it is where they give
+// up once ctx is done.
+type commitReader struct {
+	ctx   context.Context
+	git   *fs.Git
+	graph *commitgraph.Graph
+}
+
+func newCommitReader(ctx context.Context, git *fs.Git) *commitReader {
+	// Walks do without a commit-graph they cannot read, like Git does.
+	graph, _ := commitgraph.Open(git.ObjectDir(), git.ObjectFormat())
+
+	return &commitReader{ctx: ctx, git: git, graph: graph}
+}
+
+// Read a commit, from the commit-graph if it has it.
+func (r *commitReader) read(hash plumbing.Hash) (*commit.Commit, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c, ok, err := r.graph.Lookup(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return r.parse(hash)
+	}
+
+	return &commit.Commit{
+		Tree:      c.Tree,
+		Parents:   c.Parents,
+		Committer: commit.Signature{When: time.Unix(c.Date, 0)},
+	}, nil
+}
+
+// Parse a commit in full from the object store.
+func (r *commitReader) parse(hash plumbing.Hash) (*commit.Commit, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t, data, err := r.git.ReadObjectByHash(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t != objfile.Commit {
+		return nil, errors.GitError{Message: fmt.Sprintf("%s is a %s, not a commit", hash, t)}
+	}
+
+	return commit.Decode(data)
+}
+
+// Whether read only returns part of the commit.
+func (r *commitReader) partial(hash plumbing.Hash) bool {
+	return r.graph.Has(hash)
+}
+
+// The generation number of a commit, when the commit-graph has it.
+func (r *commitReader) generation(hash plumbing.Hash) (uint64, bool) {
+	generation, ok, err := r.graph.Generation(hash)
+
+	return generation, ok && err == nil
+}
@@ -0,0 +1,260 @@
+package revision
+
+import (
+	"container/heap"
+	"context"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Flags painted on commits while looking for merge bases.
+const (
+	paintOne walkFlag = 1 << iota
+	paintTwo
+	paintStale
+	paintResult
+)
+
+// Walk down from one and the others, newest first, painting the ancestors
+// of each. The commits reached from both sides are the common ancestors;
+// the ones below them are stale. Returns the common ancestors found, newest
+// first, and the paint of each commit.
+func paintDownToCommon(commits *commitReader, one plumbing.Hash, others []plumbing.Hash) ([]plumbing.Hash, map[plumbing.Hash]walkFlag, error) {
+	paint := make(map[plumbing.Hash]walkFlag)
+	queue := commitQueue{}
+	count := 0
+
+	push := func(hash plumbing.Hash) error {
+		c, err := commits.read(hash)
+
+		if err != nil {
+			return err
+		}
+
+		heap.Push(&queue, &queued{hash: hash, commit: c, order: count})
+		count++
+
+		return nil
+	}
+
+	paint[one] |= paintOne
+
+	if err := push(one); err != nil {
+		return nil, nil, err
+	}
+
+	for _, other := range others {
+		paint[other] |= paintTwo
+
+		if err := push(other); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Whether any commit queued can still lead to a new common ancestor.
+	interesting := func() bool {
+		for _, q := range queue {
+			if paint[q.hash]&paintStale == 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	found := []plumbing.Hash{}
+
+	for interesting() {
+		next := heap.Pop(&queue).(*queued)
+		flags := paint[next.hash] & (paintOne | paintTwo | paintStale)
+
+		if flags == paintOne|paintTwo {
+			if paint[next.hash]&paintResult == 0 {
+				paint[next.hash] |= paintResult
+				found = append(found, next.hash)
+			}
+
+			flags |= paintStale
+		}
+
+		for _, parent := range next.commit.Parents {
+			if paint[parent]&flags == flags {
+				continue
+			}
+
+			paint[parent] |= flags
+
+			if err := push(parent); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return found, paint, nil
+}
+
+// MergeBases returns the best common ancestors of one and any of the
+// others, newest first: the common ancestors that are not ancestors of
+// another one.
+func MergeBases(ctx context.Context, git *fs.Git, one plumbing.Hash, others ...plumbing.Hash) ([]plumbing.Hash, error) {
+	for _, other := range others {
+		if other == one {
+			return []plumbing.Hash{one}, nil
+		}
+	}
+
+	commits := newCommitReader(ctx, git)
+	found, paint, err := paintDownToCommon(commits, one, others)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []plumbing.Hash{}
+
+	for _, hash := range found {
+		if paint[hash]&paintStale == 0 {
+			candidates = append(candidates, hash)
+		}
+	}
+
+	return removeRedundant(commits, candidates)
+}
+
+// Drop the commits that are ancestors of another one of the list, keeping
+// the order of the rest. The commits must be distinct.
+func removeRedundant(commits *commitReader, hashes []plumbing.Hash) ([]plumbing.Hash, error) {
+	if len(hashes) <= 1 {
+		return hashes, nil
+	}
+
+	kept := []plumbing.Hash{}
+
+	for i, hash := range hashes {
+		redundant := false
+
+		for j, other := range hashes {
+			if i == j {
+				continue
+			}
+
+			var err error
+
+			if redundant, err = isAncestor(commits, hash, other); err != nil {
+				return nil, err
+			}
+
+			if redundant {
+				break
+			}
+		}
+
+		if !redundant {
+			kept = append(kept, hash)
+		}
+	}
+
+	return kept, nil
+}
+
+// OctopusMergeBases returns the best common ancestors of all the commits
+// together, for merging them all at once: the merge bases of the first
+// two, then of those with the third, and so on.
+func OctopusMergeBases(ctx context.Context, git *fs.Git, hashes ...plumbing.Hash) ([]plumbing.Hash, error) {
+	if len(hashes) == 0 {
+		return []plumbing.Hash{}, nil
+	}
+
+	result := []plumbing.Hash{hashes[0]}
+
+	for _, hash := range hashes[1:] {
+		next := []plumbing.Hash{}
+		seen := make(map[plumbing.Hash]bool)
+
+		for _, previous := range result {
+			bases, err := MergeBases(ctx, git, hash, previous)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, base := range bases {
+				if !seen[base] {
+					seen[base] = true
+					next = append(next, base)
+				}
+			}
+		}
+
+		result = next
+	}
+
+	return Independent(ctx, git, result...)
+}
+
+// Independent returns the commits that cannot be reached from any other
+// of them, in the order given, like git merge-base --independent.
+func Independent(ctx context.Context, git *fs.Git, hashes ...plumbing.Hash) ([]plumbing.Hash, error) {
+	distinct := []plumbing.Hash{}
+	seen := make(map[plumbing.Hash]bool)
+
+	for _, hash := range hashes {
+		if !seen[hash] {
+			seen[hash] = true
+			distinct = append(distinct, hash)
+		}
+	}
+
+	return removeRedundant(newCommitReader(ctx, git), distinct)
+}
+
+// IsAncestor reports whether ancestor can be reached from descendant,
+// which counts as its own ancestor.
+func IsAncestor(ctx context.Context, git *fs.Git, ancestor, descendant plumbing.Hash) (bool, error) {
+	return isAncestor(newCommitReader(ctx, git), ancestor, descendant)
+}
+
+func isAncestor(commits *commitReader, ancestor, descendant plumbing.Hash) (bool, error) {
+	if ancestor == descendant {
+		return true, nil
+	}
+
+	// Generation numbers fall from child to parent, so nothing at or below
+	// the ancestor's generation can lead to it.
+	floor, pruning := commits.generation(ancestor)
+
+	seen := map[plumbing.Hash]bool{descendant: true}
+	stack := []plumbing.Hash{descendant}
+
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		c, err := commits.read(hash)
+
+		if err != nil {
+			return false, err
+		}
+
+		for _, parent := range c.Parents {
+			if parent == ancestor {
+				return true, nil
+			}
+
+			if seen[parent] {
+				continue
+			}
+
+			seen[parent] = true
+
+			if generation, ok := commits.generation(parent); pruning && ok && generation <= floor {
+				continue
+			}
+
+			stack = append(stack, parent)
+		}
+	}
+
+	return false, nil
+}
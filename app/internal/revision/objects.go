@@ -0,0 +1,88 @@
+package revision
+
+import (
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// Objects lists the trees and blobs reachable from the commits of a walk,
+// with the path each was first found at, the root trees having an empty
+// one. Those also reachable from the hidden parents of the commits are
+// left out, as are submodule commits.
+func (w *Walker) Objects(entries []*Entry, fn func(hash plumbing.Hash, path string) error) error {
+	done := make(map[plumbing.Hash]bool)
+
+	for _, entry := range entries {
+		for _, parent := range entry.Parents {
+			if w.flags[parent]&hiddenFlag == 0 {
+				continue
+			}
+
+			c, err := w.readCommit(parent)
+
+			if err != nil {
+				return err
+			}
+
+			if err := w.walkTree(c.Tree, "", done, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if err := w.walkTree(entry.Commit.Tree, "", done, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Walk a tree depth first, calling fn on the objects not done yet, if it
+// is set, and marking them done.
+func (w *Walker) walkTree(hash plumbing.Hash, path string, done map[plumbing.Hash]bool, fn func(plumbing.Hash, string) error) error {
+	if done[hash] {
+		return nil
+	}
+
+	done[hash] = true
+
+	if fn != nil {
+		if err := fn(hash, path); err != nil {
+			return err
+		}
+	}
+
+	entries, err := tree.ReadTree(w.git.ReadObjectByHash, hash)
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name := e.Name
+
+		if path != "" {
+			name = path + "/" + e.Name
+		}
+
+		switch {
+		case e.IsTree():
+			err = w.walkTree(e.Hash(), name, done, fn)
+		case e.Mode == tree.ModeGitlink:
+		case !done[e.Hash()]:
+			done[e.Hash()] = true
+
+			if fn != nil {
+				err = fn(e.Hash(), name)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
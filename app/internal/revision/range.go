@@ -0,0 +1,202 @@
+package revision
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+)
+
+// Tip is a commit a walk starts from, or hides along with its ancestors.
+type Tip struct {
+	Hash   plumbing.Hash
+	Hidden bool
+
+	// On the left side of a symmetric difference.
+	Left bool
+}
+
+// ParentShorthand splits a revision ending in one of the shorthands for
+// the parents of a commit, ^@, ^! or ^-<n>, into the revision and the
+// shorthand without its caret.
+func ParentShorthand(arg string) (rev, shorthand string, ok bool) {
+	i := strings.LastIndexByte(arg, '^')
+
+	if i <= 0 {
+		return "", "", false
+	}
+
+	shorthand = arg[i+1:]
+
+	switch {
+	case shorthand == "@" || shorthand == "!":
+	case strings.HasPrefix(shorthand, "-"):
+		if _, err := shorthandParent(shorthand); err != nil {
+			return "", "", false
+		}
+	default:
+		return "", "", false
+	}
+
+	return arg[:i], shorthand, true
+}
+
+// shorthandParent is the number of the parent excluded by a ^-<n>
+// shorthand, 1 when n is left out.
+func shorthandParent(shorthand string) (int, error) {
+	if shorthand == "-" {
+		return 1, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(shorthand, "-"))
+
+	if err != nil || n <= 0 || !isDigits(shorthand[1:]) {
+		return 0, NotFoundError{Rev: "^" + shorthand}
+	}
+
+	return n, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// ResolveRange resolves a revision or a range of them to the tips of a
+// walk: ^<rev> hides a revision, <a>..<b> lists the commits reachable from
+// b but not a, and <a>...<b> those reachable from either but not both. An
+// omitted end of a range stands for HEAD. <rev>^@ stands for the parents of
+// rev, <rev>^! for rev without its parents and <rev>^-<n> for
+// <rev>^<n>..<rev>.
+func (r *Resolver) ResolveRange(arg string) ([]Tip, error) {
+	if rev, shorthand, ok := ParentShorthand(arg); ok && !strings.Contains(arg, "..") {
+		return r.resolveParentShorthand(rev, shorthand, arg)
+	}
+
+	if i := strings.Index(arg, "..."); i >= 0 {
+		left, err := r.resolveEnd(arg[:i], arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := r.resolveEnd(arg[i+3:], arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bases, err := MergeBases(r.ctx, r.git, left, right)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tips := []Tip{{Hash: left, Left: true}, {Hash: right}}
+
+		for _, base := range bases {
+			tips = append(tips, Tip{Hash: base, Hidden: true})
+		}
+
+		return tips, nil
+	}
+
+	if i := strings.Index(arg, ".."); i >= 0 {
+		from, err := r.resolveEnd(arg[:i], arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := r.resolveEnd(arg[i+2:], arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []Tip{{Hash: from, Hidden: true}, {Hash: to}}, nil
+	}
+
+	if strings.HasPrefix(arg, "^") {
+		hash, err := r.resolveCommit(arg[1:], arg)
+
+		return []Tip{{Hash: hash, Hidden: true}}, err
+	}
+
+	hash, err := r.resolveCommit(arg, arg)
+
+	return []Tip{{Hash: hash}}, err
+}
+
+func (r *Resolver) resolveParentShorthand(rev, shorthand, arg string) ([]Tip, error) {
+	hash, err := r.resolveCommit(rev, arg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := r.readCommit(hash, rev)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tips := []Tip{}
+
+	switch shorthand {
+	case "@":
+		for _, parent := range c.Parents {
+			tips = append(tips, Tip{Hash: parent})
+		}
+	case "!":
+		tips = append(tips, Tip{Hash: hash})
+
+		for _, parent := range c.Parents {
+			tips = append(tips, Tip{Hash: parent, Hidden: true})
+		}
+	default:
+		n, err := shorthandParent(shorthand)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if n > len(c.Parents) {
+			return nil, NotFoundError{Rev: arg}
+		}
+
+		tips = append(tips, Tip{Hash: c.Parents[n-1], Hidden: true}, Tip{Hash: hash})
+	}
+
+	return tips, nil
+}
+
+// Resolve an end of a range, HEAD when it is empty.
+func (r *Resolver) resolveEnd(rev, arg string) (plumbing.Hash, error) {
+	if rev == "" {
+		rev = refs.Head
+	}
+
+	return r.resolveCommit(rev, arg)
+}
+
+func (r *Resolver) resolveCommit(rev, arg string) (plumbing.Hash, error) {
+	hash, err := r.Resolve(rev)
+
+	if err != nil {
+		if IsNotFound(err) {
+			return plumbing.ZeroHash, NotFoundError{Rev: arg}
+		}
+
+		return plumbing.ZeroHash, err
+	}
+
+	return r.Peel(hash, objfile.Commit, rev)
+}
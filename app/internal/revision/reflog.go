@@ -0,0 +1,272 @@
+package revision
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/config"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+)
+
+const checkoutPrefix = "checkout: moving from "
+
+// The full name of the ref a revision's base names, with "" meaning the
+// current branch.
+func (r *Resolver) refName(name string) (string, error) {
+	if name == "" || name == "@" {
+		branch, _, err := r.refs.Head()
+
+		if err != nil || branch == "" {
+			return refs.Head, err
+		}
+
+		return branch, nil
+	}
+
+	ref, err := r.refs.Dwim(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	if ref == nil {
+		return "", NotFoundError{Rev: name}
+	}
+
+	return ref.Name, nil
+}
+
+// Resolve <ref>@{<spec>}: @{upstream}, @{<n>}, @{<date>} and @{-<n>}.
+func (r *Resolver) resolveAtSuffix(name, spec, rev string) (plumbing.Hash, error) {
+	if name == "" && strings.HasPrefix(spec, "-") {
+		branch, err := r.previousBranch(spec)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		return r.resolveBase(branch)
+	}
+
+	full, err := r.refName(name)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if isUpstreamSpec(spec) {
+		upstream, err := r.Upstream(full)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		return r.refs.Resolve(upstream)
+	}
+
+	if !isDigits(spec) {
+		when, err := commit.ParseApproxDate(spec, time.Now())
+
+		if err != nil {
+			return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("unsupported reflog selector '%s' in %s", spec, rev)}
+		}
+
+		return r.reflogAt(full, when)
+	}
+
+	n, err := strconv.Atoi(spec)
+
+	if err != nil {
+		return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("unsupported reflog selector '%s' in %s", spec, rev)}
+	}
+
+	entries, err := r.refs.Reflog(full)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if n >= len(entries) {
+		return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("log for '%s' only has %d entries", refs.ShortName(full), len(entries))}
+	}
+
+	return entries[len(entries)-1-n].New, nil
+}
+
+// The value a ref had at a given time, from its reflog.
+func (r *Resolver) reflogAt(full string, when time.Time) (plumbing.Hash, error) {
+	entries, err := r.refs.Reflog(full)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if len(entries) == 0 {
+		return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("log for '%s' is empty", refs.ShortName(full))}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Committer.When.After(when) {
+			return entries[i].New, nil
+		}
+	}
+
+	// Before the log starts, the ref had the value its first entry replaced,
+	// if it had one.
+	if first := entries[0]; !first.Old.IsZero() {
+		return first.Old, nil
+	}
+
+	return entries[0].New, nil
+}
+
+func isUpstreamSpec(spec string) bool {
+	spec = strings.ToLower(spec)
+
+	return spec == "u" || spec == "upstream"
+}
+
+// The branch checked out before the current one, n checkouts ago, as
+// recorded in the reflog of HEAD.
+func (r *Resolver) previousBranch(spec string) (string, error) {
+	n, err := strconv.Atoi(spec[1:])
+
+	if err != nil || n <= 0 {
+		return "", NotFoundError{Rev: "@{" + spec + "}"}
+	}
+
+	entries, err := r.refs.Reflog(refs.Head)
+
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(entries[i].Message, checkoutPrefix) {
+			continue
+		}
+
+		if n--; n == 0 {
+			from := strings.TrimPrefix(entries[i].Message, checkoutPrefix)
+
+			return strings.SplitN(from, " to ", 2)[0], nil
+		}
+	}
+
+	return "", NotFoundError{Rev: "@{" + spec + "}"}
+}
+
+// The full name of the ref a branch tracks, from the branch.<name>.remote
+// and branch.<name>.merge settings.
+func (r *Resolver) Upstream(branch string) (string, error) {
+	if !strings.HasPrefix(branch, refs.HeadsPrefix) {
+		return "", errors.GitError{Message: "HEAD does not point to a branch"}
+	}
+
+	short := strings.TrimPrefix(branch, refs.HeadsPrefix)
+
+	cfg, err := config.LoadAll(r.git.GitDir())
+
+	if err != nil {
+		return "", err
+	}
+
+	remote, hasRemote := cfg.Get("branch." + short + ".remote")
+	merge, hasMerge := cfg.Get("branch." + short + ".merge")
+
+	if !hasRemote || !hasMerge {
+		return "", errors.GitError{Message: fmt.Sprintf("no upstream configured for branch '%s'", short)}
+	}
+
+	if remote == "." {
+		return merge, nil
+	}
+
+	// The remote's fetch refspecs say where its branches are tracked.
+	for _, e := range cfg.Entries {
+		if e.Section != "remote" || e.Subsection != remote || e.Key != "fetch" {
+			continue
+		}
+
+		if tracking, ok := mapRefspec(e.Value, merge); ok {
+			return tracking, nil
+		}
+	}
+
+	return "", errors.GitError{Message: fmt.Sprintf("upstream branch '%s' not stored as a remote-tracking branch", merge)}
+}
+
+// Map a ref through a refspec like +refs/heads/*:refs/remotes/origin/*.
+func mapRefspec(refspec, name string) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(refspec, "+"), ":", 2)
+
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	src, dst := parts[0], parts[1]
+
+	star := strings.IndexByte(src, '*')
+
+	if star < 0 {
+		return dst, src == name
+	}
+
+	prefix, suffix := src[:star], src[star+1:]
+
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) < len(prefix)+len(suffix) {
+		return "", false
+	}
+
+	matched := name[len(prefix) : len(name)-len(suffix)]
+
+	return strings.Replace(dst, "*", matched, 1), true
+}
+
+// The full ref name a revision refers to, like refs/heads/master for
+// master or HEAD@{upstream}, or "" if it does not name a ref.
+func (r *Resolver) SymbolicFullName(rev string) (string, error) {
+	if rev == "@" {
+		rev = refs.Head
+	}
+
+	if i := strings.Index(rev, "@{"); i >= 0 && strings.HasSuffix(rev, "}") {
+		name, spec := rev[:i], rev[i+2:len(rev)-1]
+
+		switch {
+		case name == "" && strings.HasPrefix(spec, "-"):
+			branch, err := r.previousBranch(spec)
+
+			if err != nil {
+				return "", err
+			}
+
+			return r.SymbolicFullName(branch)
+		case isUpstreamSpec(spec):
+			full, err := r.refName(name)
+
+			if err != nil {
+				return "", err
+			}
+
+			return r.Upstream(full)
+		}
+
+		return "", nil
+	}
+
+	ref, err := r.refs.Dwim(rev)
+
+	if err != nil || ref == nil {
+		return "", err
+	}
+
+	name, _, err := r.refs.Follow(ref.Name)
+
+	return name, err
+}
@@ -0,0 +1,457 @@
+package revision
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tag"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// Abbreviated object names need at least this many hex digits.
+const minAbbrev = 4
+
+// NotFoundError is returned for revisions that do not name any object.
+type NotFoundError struct {
+	Rev string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("Not a valid object name %s", e.Rev)
+}
+
+// IsNotFound reports whether err is a NotFoundError.
+func IsNotFound(err error) bool {
+	_, ok := err.(NotFoundError)
+
+	return ok
+}
+
+// AmbiguousError is returned for abbreviated object names matching more
+// than one object.
+type AmbiguousError struct {
+	Prefix string
+}
+
+func (e AmbiguousError) Error() string {
+	return fmt.Sprintf("short object ID %s is ambiguous", e.Prefix)
+}
+
+// IsAmbiguous reports whether err is an AmbiguousError.
+func IsAmbiguous(err error) bool {
+	_, ok := err.(AmbiguousError)
+
+	return ok
+}
+
+// Resolver turns revisions, as described in gitrevisions(7), into object
+// names: abbreviated hashes, ref names, reflog entries, ancestry and
+// peeling operators, and paths inside trees or the index.
+type Resolver struct {
+	ctx  context.Context
+	git  *fs.Git
+	refs *refs.Store
+}
+
+func NewResolver(git *fs.Git) *Resolver {
+	return &Resolver{ctx: context.Background(), git: git, refs: refs.NewStore(git.GitDir())}
+}
+
+// WithContext returns a copy of the resolver whose walks, for :/<regex>
+// and <a>...<b>, give up once ctx is done.
+func (r *Resolver) WithContext(ctx context.Context) *Resolver {
+	copied := *r
+	copied.ctx = ctx
+
+	return &copied
+}
+
+// Resolve a revision to the name of the object it refers to.
+func (r *Resolver) Resolve(rev string) (plumbing.Hash, error) {
+	if strings.HasPrefix(rev, ":/") {
+		return r.searchAll(rev[2:], rev)
+	}
+
+	if i := indexOutsideBraces(rev, ":"); i >= 0 {
+		if i == 0 {
+			return r.resolveIndexPath(rev[1:], rev)
+		}
+
+		treeish, err := r.Resolve(rev[:i])
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		return r.resolveTreePath(treeish, rev[i+1:], rev[:i])
+	}
+
+	end := indexOutsideBraces(rev, "~^")
+
+	if end < 0 {
+		end = len(rev)
+	}
+
+	hash, err := r.resolveBase(rev[:end])
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return r.applyOperators(hash, rev, end)
+}
+
+// Find the first of chars in s that is not inside a @{...} or ^{...}.
+func indexOutsideBraces(s, chars string) int {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '{' && i > 0 && (s[i-1] == '@' || s[i-1] == '^'):
+			depth++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case depth == 0 && strings.IndexByte(chars, s[i]) >= 0:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Resolve the part of a revision before any ~ or ^ operators.
+func (r *Resolver) resolveBase(base string) (plumbing.Hash, error) {
+	if base == "@" {
+		base = refs.Head
+	}
+
+	if i := strings.Index(base, "@{"); i >= 0 && strings.HasSuffix(base, "}") {
+		return r.resolveAtSuffix(base[:i], base[i+2:len(base)-1], base)
+	}
+
+	hexLength := r.git.ObjectFormat().HexSize()
+
+	if len(base) == hexLength {
+		if hash, err := plumbing.NewHash(base); err == nil {
+			return hash, nil
+		}
+	}
+
+	if base != "" {
+		ref, err := r.refs.Dwim(base)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if ref != nil {
+			return ref.Hash, nil
+		}
+	}
+
+	if len(base) >= minAbbrev && len(base) < hexLength && isHex(base) {
+		matches, err := r.git.FindObjects(base)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if len(matches) > 1 {
+			return plumbing.ZeroHash, AmbiguousError{Prefix: base}
+		}
+
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+	}
+
+	return plumbing.ZeroHash, NotFoundError{Rev: base}
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply the ~<n>, ^<n> and ^{...} operators found in rev from start on.
+func (r *Resolver) applyOperators(hash plumbing.Hash, rev string, start int) (plumbing.Hash, error) {
+	var err error
+
+	for i := start; i < len(rev); {
+		op := rev[i]
+		i++
+
+		if op == '^' && i < len(rev) && rev[i] == '{' {
+			end := strings.IndexByte(rev[i:], '}')
+
+			if end < 0 {
+				return plumbing.ZeroHash, NotFoundError{Rev: rev}
+			}
+
+			if hash, err = r.peelSpec(hash, rev[i+1:i+end], rev[:i+end+1]); err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			i += end + 1
+
+			continue
+		}
+
+		digits := i
+
+		for i < len(rev) && rev[i] >= '0' && rev[i] <= '9' {
+			i++
+		}
+
+		n := 1
+
+		if i > digits {
+			if n, err = strconv.Atoi(rev[digits:i]); err != nil {
+				return plumbing.ZeroHash, NotFoundError{Rev: rev}
+			}
+		}
+
+		switch op {
+		case '~':
+			hash, err = r.ancestor(hash, n, rev[:i])
+		case '^':
+			hash, err = r.parent(hash, n, rev[:i])
+		default:
+			err = NotFoundError{Rev: rev}
+		}
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	return hash, nil
+}
+
+func (r *Resolver) readCommit(hash plumbing.Hash, rev string) (*commit.Commit, error) {
+	hash, err := r.Peel(hash, objfile.Commit, rev)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, data, err := r.git.ReadObjectByHash(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return commit.Decode(data)
+}
+
+// The n-th generation ancestor, following first parents.
+func (r *Resolver) ancestor(hash plumbing.Hash, n int, rev string) (plumbing.Hash, error) {
+	hash, err := r.Peel(hash, objfile.Commit, rev)
+
+	for ; err == nil && n > 0; n-- {
+		var c *commit.Commit
+
+		if c, err = r.readCommit(hash, rev); err != nil {
+			break
+		}
+
+		if len(c.Parents) == 0 {
+			return plumbing.ZeroHash, NotFoundError{Rev: rev}
+		}
+
+		hash = c.Parents[0]
+	}
+
+	return hash, err
+}
+
+// The n-th parent of a commit, or the commit itself for n = 0.
+func (r *Resolver) parent(hash plumbing.Hash, n int, rev string) (plumbing.Hash, error) {
+	if n == 0 {
+		return r.Peel(hash, objfile.Commit, rev)
+	}
+
+	c, err := r.readCommit(hash, rev)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if n > len(c.Parents) {
+		return plumbing.ZeroHash, NotFoundError{Rev: rev}
+	}
+
+	return c.Parents[n-1], nil
+}
+
+// Apply ^{<type>}, ^{} or ^{/<regex>}.
+func (r *Resolver) peelSpec(hash plumbing.Hash, spec, rev string) (plumbing.Hash, error) {
+	switch {
+	case spec == "":
+		return r.peelTags(hash)
+	case spec == "object":
+		if _, _, err := r.git.ReadObjectByHash(hash); err != nil {
+			return plumbing.ZeroHash, NotFoundError{Rev: rev}
+		}
+
+		return hash, nil
+	case strings.HasPrefix(spec, "/"):
+		start, err := r.Peel(hash, objfile.Commit, rev)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		return r.searchMessages([]plumbing.Hash{start}, spec[1:], rev)
+	}
+
+	t, err := objfile.DetectObjectType(spec)
+
+	if err != nil {
+		return plumbing.ZeroHash, NotFoundError{Rev: rev}
+	}
+
+	return r.Peel(hash, t, rev)
+}
+
+// Follow annotated tags until reaching an object that is not a tag.
+func (r *Resolver) peelTags(hash plumbing.Hash) (plumbing.Hash, error) {
+	for {
+		t, data, err := r.git.ReadObjectByHash(hash)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if t != objfile.Tag {
+			return hash, nil
+		}
+
+		decoded, err := tag.Decode(data)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		hash = decoded.Object
+	}
+}
+
+// Peel an object until it has the wanted type: tags are followed to the
+// object they point at and commits to their tree.
+func (r *Resolver) Peel(hash plumbing.Hash, want objfile.GitObjectType, rev string) (plumbing.Hash, error) {
+	for {
+		t, data, err := r.git.ReadObjectByHash(hash)
+
+		if err != nil {
+			return plumbing.ZeroHash, NotFoundError{Rev: rev}
+		}
+
+		if t == want {
+			return hash, nil
+		}
+
+		switch {
+		case t == objfile.Tag:
+			decoded, err := tag.Decode(data)
+
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			hash = decoded.Object
+		case t == objfile.Commit && want == objfile.Tree:
+			c, err := commit.Decode(data)
+
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			hash = c.Tree
+		default:
+			return plumbing.ZeroHash, errors.GitError{
+				Message: fmt.Sprintf("%s: expected %s type, but the object dereferences to %s type", rev, want, t),
+			}
+		}
+	}
+}
+
+// Look up <tree-ish>:<path>.
+func (r *Resolver) resolveTreePath(treeish plumbing.Hash, path, rev string) (plumbing.Hash, error) {
+	hash, err := r.Peel(treeish, objfile.Tree, rev)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	path = strings.Trim(path, "/")
+
+	if path == "" {
+		return hash, nil
+	}
+
+	for _, component := range strings.Split(path, "/") {
+		entries, err := tree.ReadTree(r.git.ReadObjectByHash, hash)
+
+		if err != nil {
+			return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("path '%s' does not exist in '%s'", path, rev)}
+		}
+
+		found := false
+
+		for i := range entries {
+			if entries[i].Name == component {
+				hash, found = entries[i].Hash(), true
+
+				break
+			}
+		}
+
+		if !found {
+			return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("path '%s' does not exist in '%s'", path, rev)}
+		}
+	}
+
+	return hash, nil
+}
+
+// Look up :[<stage>:]<path> in the index.
+func (r *Resolver) resolveIndexPath(spec, rev string) (plumbing.Hash, error) {
+	stage := index.Merged
+	path := spec
+
+	if len(spec) >= 2 && spec[1] == ':' && spec[0] >= '0' && spec[0] <= '3' {
+		stage = index.Stage(spec[0] - '0')
+		path = spec[2:]
+	}
+
+	idx, err := r.git.ReadIndex()
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if e, ok := idx.Entry(path, stage); ok {
+		return e.Hash, nil
+	}
+
+	if _, ok := idx.Entry(path, index.Ours); ok && stage == index.Merged {
+		return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("path '%s' is in the index, but not at stage 0", path)}
+	}
+
+	return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("path '%s' does not exist in the index", path)}
+}
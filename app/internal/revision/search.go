@@ -0,0 +1,109 @@
+package revision
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+)
+
+// Find the youngest commit reachable from starts whose message matches
+// pattern, visiting commits newest first.
+func (r *Resolver) searchMessages(starts []plumbing.Hash, pattern, rev string) (plumbing.Hash, error) {
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("invalid regex in %s: %s", rev, err.Error())}
+	}
+
+	type candidate struct {
+		hash   plumbing.Hash
+		commit *commit.Commit
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	queue := []candidate{}
+
+	push := func(hash plumbing.Hash) error {
+		if seen[hash] {
+			return nil
+		}
+
+		seen[hash] = true
+
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+
+		_, data, err := r.git.ReadObjectByHash(hash)
+
+		if err != nil {
+			return err
+		}
+
+		c, err := commit.Decode(data)
+
+		if err != nil {
+			return err
+		}
+
+		queue = append(queue, candidate{hash: hash, commit: c})
+
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].commit.Committer.When.After(queue[j].commit.Committer.When)
+		})
+
+		return nil
+	}
+
+	for _, start := range starts {
+		if err := push(start); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		if re.MatchString(next.commit.Message) {
+			return next.hash, nil
+		}
+
+		for _, parent := range next.commit.Parents {
+			if err := push(parent); err != nil {
+				return plumbing.ZeroHash, err
+			}
+		}
+	}
+
+	return plumbing.ZeroHash, NotFoundError{Rev: rev}
+}
+
+// Search the messages of the commits reachable from any ref, for :/<regex>.
+func (r *Resolver) searchAll(pattern, rev string) (plumbing.Hash, error) {
+	all, err := r.refs.List("refs/")
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	starts := []plumbing.Hash{}
+
+	if head, err := r.refs.Resolve(refs.Head); err == nil {
+		all = append(all, &refs.Ref{Name: refs.Head, Hash: head})
+	}
+
+	for _, ref := range all {
+		if hash, err := r.Peel(ref.Hash, objfile.Commit, ref.Name); err == nil {
+			starts = append(starts, hash)
+		}
+	}
+
+	return r.searchMessages(starts, pattern, rev)
+}
@@ -0,0 +1,414 @@
+package revision
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// With the generation numbers of a commit-graph, a topological walk lists
+// commits as it goes instead of going through the whole history first,
+// like Git's incremental topological walk. Two walks go down the history
+// ahead of the commits listed, by generation: one exploring it, hiding and
+// simplifying commits, and one counting the children of each commit. A
+// commit can be listed once all the children counted have been.
+
+// The generation of commits missing from the commit-graph, above any other.
+const infiniteGeneration = math.MaxUint64
+
+// A commit waiting in a walk by generation. Commits of the same generation
+// go by commit date, then in the order they were queued.
+type generationQueued struct {
+	hash       plumbing.Hash
+	generation uint64
+	when       time.Time
+	order      int
+}
+
+type generationQueue []*generationQueued
+
+func (q generationQueue) Len() int { return len(q) }
+
+func (q generationQueue) Less(i, j int) bool {
+	if q[i].generation != q[j].generation {
+		return q[i].generation > q[j].generation
+	}
+
+	if !q[i].when.Equal(q[j].when) {
+		return q[i].when.After(q[j].when)
+	}
+
+	return q[i].order < q[j].order
+}
+
+func (q generationQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *generationQueue) Push(x interface{}) { *q = append(*q, x.(*generationQueued)) }
+
+func (q *generationQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return item
+}
+
+type topoWalk struct {
+	explore  generationQueue
+	indegree generationQueue
+	explored map[plumbing.Hash]bool
+	counted  map[plumbing.Hash]bool
+
+	// One more than the children of each commit counted and not listed yet.
+	degrees map[plumbing.Hash]int
+
+	// The lowest generation the walks have to reach.
+	minGeneration uint64
+
+	// The commits ready to be listed: a stack, or a queue by date.
+	stack  []plumbing.Hash
+	byDate commitQueue
+
+	count int
+}
+
+// Whether a topological walk can go by generation numbers.
+func (w *Walker) isIncremental() bool {
+	return (w.opts.TopoOrder || w.opts.DateOrder) && !w.opts.AncestryPath && w.commits.graph.HasGenerations()
+}
+
+func (w *Walker) generation(hash plumbing.Hash) uint64 {
+	if generation, ok := w.commits.generation(hash); ok {
+		return generation
+	}
+
+	return infiniteGeneration
+}
+
+// Start the walks from the tips, newest first.
+func (w *Walker) startTopo() error {
+	t := &topoWalk{
+		explored:      make(map[plumbing.Hash]bool),
+		counted:       make(map[plumbing.Hash]bool),
+		degrees:       make(map[plumbing.Hash]int),
+		minGeneration: infiniteGeneration,
+	}
+	w.topo = t
+
+	tips := make([]*queued, 0, len(w.tips))
+
+	for _, hash := range w.tips {
+		c, err := w.readCommit(hash)
+
+		if err != nil {
+			return err
+		}
+
+		tips = append(tips, &queued{hash: hash, commit: c})
+	}
+
+	sort.SliceStable(tips, func(i, j int) bool {
+		return tips[i].commit.Committer.When.After(tips[j].commit.Committer.When)
+	})
+
+	for _, tip := range tips {
+		if err := w.enqueue(&t.explore, t.explored, tip.hash); err != nil {
+			return err
+		}
+
+		if err := w.enqueue(&t.indegree, t.counted, tip.hash); err != nil {
+			return err
+		}
+
+		if generation := w.generation(tip.hash); generation < t.minGeneration {
+			t.minGeneration = generation
+		}
+
+		t.degrees[tip.hash] = 1
+	}
+
+	if err := w.countToDepth(t.minGeneration); err != nil {
+		return err
+	}
+
+	for _, tip := range tips {
+		if t.degrees[tip.hash] == 1 {
+			w.ready(tip.hash, tip.commit)
+		}
+	}
+
+	// The tips ready first are listed in the order they were sorted.
+	for i, j := 0, len(t.stack)-1; i < j; i, j = i+1, j-1 {
+		t.stack[i], t.stack[j] = t.stack[j], t.stack[i]
+	}
+
+	return nil
+}
+
+// Queue a commit in one of the walks by generation, once.
+func (w *Walker) enqueue(q *generationQueue, queued map[plumbing.Hash]bool, hash plumbing.Hash) error {
+	if queued[hash] {
+		return nil
+	}
+
+	c, err := w.readCommit(hash)
+
+	if err != nil {
+		return err
+	}
+
+	queued[hash] = true
+	heap.Push(q, &generationQueued{hash: hash, generation: w.generation(hash), when: c.Committer.When, order: w.topo.count})
+	w.topo.count++
+
+	return nil
+}
+
+// Make a commit ready to be listed.
+func (w *Walker) ready(hash plumbing.Hash, c *commit.Commit) {
+	t := w.topo
+
+	if w.opts.DateOrder {
+		heap.Push(&t.byDate, &queued{hash: hash, commit: c, order: t.count})
+		t.count++
+	} else {
+		t.stack = append(t.stack, hash)
+	}
+}
+
+// Explore the history down to a generation, hiding the ancestors of
+// hidden commits and simplifying the others.
+func (w *Walker) exploreToDepth(cutoff uint64) error {
+	t := w.topo
+
+	for t.explore.Len() > 0 && t.explore[0].generation >= cutoff {
+		next := heap.Pop(&t.explore).(*generationQueued)
+
+		if !w.opts.Since.IsZero() && next.when.Before(w.opts.Since) {
+			w.flags[next.hash] |= hiddenFlag
+		}
+
+		v, err := w.process(next.hash)
+
+		if err != nil {
+			return err
+		}
+
+		if w.flags[next.hash]&hiddenFlag != 0 {
+			w.hideParents(next.hash)
+		}
+
+		for _, parent := range v.parents {
+			if err := w.enqueue(&t.explore, t.explored, parent); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Count the children of the commits down to a generation.
+func (w *Walker) countToDepth(cutoff uint64) error {
+	t := w.topo
+
+	for t.indegree.Len() > 0 && t.indegree[0].generation >= cutoff {
+		next := heap.Pop(&t.indegree).(*generationQueued)
+
+		if err := w.exploreToDepth(next.generation); err != nil {
+			return err
+		}
+
+		v, err := w.process(next.hash)
+
+		if err != nil {
+			return err
+		}
+
+		for _, parent := range v.parents {
+			if t.degrees[parent] > 0 {
+				t.degrees[parent]++
+			} else {
+				t.degrees[parent] = 2
+			}
+
+			if err := w.enqueue(&t.indegree, t.counted, parent); err != nil {
+				return err
+			}
+
+			if w.opts.FirstParent {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Process a commit the first time a topological walk reaches it: hide the
+// parents of a hidden commit, or simplify the history of another.
+func (w *Walker) process(hash plumbing.Hash) (*visited, error) {
+	if v, ok := w.visits[hash]; ok {
+		return v, nil
+	}
+
+	c, err := w.readCommit(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	v := &visited{entry: &Entry{Hash: hash, Commit: c}}
+	w.visits[hash] = v
+
+	if w.flags[hash]&hiddenFlag != 0 {
+		v.parents, v.state = c.Parents, hidden
+
+		for _, parent := range v.parents {
+			w.flags[parent] |= hiddenFlag
+
+			if _, err := w.readCommit(parent); err != nil {
+				return nil, err
+			}
+
+			w.hideParents(parent)
+		}
+
+		return v, nil
+	}
+
+	if v.parents, v.state, err = w.simplify(hash, c); err != nil {
+		return nil, err
+	}
+
+	v.entry.Parents = v.parents
+	w.parents[hash] = v.parents
+
+	for i, parent := range v.parents {
+		if i > 0 && w.opts.FirstParent {
+			break
+		}
+
+		w.flags[parent] |= w.flags[hash] & leftFlag
+	}
+
+	return v, nil
+}
+
+// Count a listed commit out of the children of its parents, making those
+// left without children ready.
+func (w *Walker) expand(hash plumbing.Hash) error {
+	t := w.topo
+	v, err := w.process(hash)
+
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range v.parents {
+		if w.flags[parent]&hiddenFlag != 0 {
+			continue
+		}
+
+		p, err := w.readCommit(parent)
+
+		if err != nil {
+			return err
+		}
+
+		if generation := w.generation(parent); generation < t.minGeneration {
+			t.minGeneration = generation
+
+			if err := w.countToDepth(generation); err != nil {
+				return err
+			}
+		}
+
+		if t.degrees[parent]--; t.degrees[parent] == 1 {
+			w.ready(parent, p)
+		}
+
+		if w.opts.FirstParent {
+			break
+		}
+	}
+
+	return nil
+}
+
+// List the next commit of a topological walk going by generation numbers.
+func (w *Walker) nextTopo() (*Entry, error) {
+	if w.topo == nil {
+		if err := w.startTopo(); err != nil {
+			return nil, err
+		}
+	}
+
+	t := w.topo
+
+	for len(t.stack) > 0 || t.byDate.Len() > 0 {
+		var hash plumbing.Hash
+
+		if w.opts.DateOrder {
+			hash = heap.Pop(&t.byDate).(*queued).hash
+		} else {
+			hash = t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+		}
+
+		t.degrees[hash] = 0
+
+		c, err := w.readCommit(hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !w.opts.Since.IsZero() && c.Committer.When.Before(w.opts.Since) {
+			continue
+		}
+
+		if err := w.expand(hash); err != nil {
+			return nil, err
+		}
+
+		v := w.visits[hash]
+		flags := w.flags[hash]
+
+		if v.state != shown || flags&(hiddenFlag|listedFlag) != 0 {
+			continue
+		}
+
+		if !w.opts.Until.IsZero() && c.Committer.When.After(w.opts.Until) {
+			continue
+		}
+
+		if len(w.opts.Authors) > 0 || len(w.opts.Grep) > 0 {
+			if err := w.parse(v.entry); err != nil {
+				return nil, err
+			}
+		}
+
+		if !w.matches(v.entry.Commit) {
+			continue
+		}
+
+		parents, err := w.rewriteParents(v.parents, w.process)
+
+		if err != nil {
+			return nil, err
+		}
+
+		w.flags[hash] |= listedFlag
+		v.entry.Parents = parents
+		v.entry.Left = flags&leftFlag != 0
+
+		return w.output(v.entry)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,919 @@
+package revision
+
+import (
+	"container/heap"
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commitgraph"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/fs"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/pathspec"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/tree"
+)
+
+// WalkOptions select the commits a Walker lists, and their order.
+type WalkOptions struct {
+	// Follow only the first parent of merges.
+	FirstParent bool
+
+	// Only list commits changing these paths, simplifying history to the
+	// parents that explain their content.
+	Paths pathspec.Pathspec
+
+	// Hide commits committed before Since or after Until, when set. The
+	// walk does not go past commits older than Since.
+	Since, Until time.Time
+
+	// Hide commits whose author matches none of Authors, or whose message
+	// matches none of Grep, when set.
+	Authors []*regexp.Regexp
+	Grep    []*regexp.Regexp
+
+	// Hide commits with fewer parents than MinParents, or more than
+	// MaxParents unless it is negative.
+	MinParents int
+	MaxParents int
+
+	// List no parent before all its children, keeping lines of history
+	// together. This walks the whole history before listing anything.
+	TopoOrder bool
+
+	// Like TopoOrder, but otherwise listing commits newest first.
+	DateOrder bool
+
+	// List commits with only their parents, root tree and commit date when
+	// the commit-graph has them, instead of parsing them in full.
+	SkipParsing bool
+
+	// Only list commits that are descendants of a hidden tip and ancestors
+	// of another tip.
+	AncestryPath bool
+}
+
+// Entry is a commit listed by a Walker. Parents are its parents after
+// history simplification; in a limited or topological walk they also skip
+// the commits it dropped, so that they lead to other listed commits. Left is
+// set for the commits reachable from the left side of a symmetric
+// difference.
+type Entry struct {
+	Hash    plumbing.Hash
+	Commit  *commit.Commit
+	Parents []plumbing.Hash
+	Left    bool
+
+	// Whether Commit was parsed in full.
+	parsed bool
+}
+
+// Flags a walk keeps about the commits it reaches.
+type walkFlag uint8
+
+const (
+	// Reachable from a hidden tip, and not listed.
+	hiddenFlag walkFlag = 1 << iota
+
+	// Reachable from the left side of a symmetric difference.
+	leftFlag
+
+	// Listed already by a topological walk going by generation numbers.
+	listedFlag
+)
+
+// Walker lists the commits reachable from a set of tips and not from the
+// hidden ones, newest first, or in topological order.
+type Walker struct {
+	git     *fs.Git
+	commits *commitReader
+	opts    WalkOptions
+	queue   commitQueue
+	seen    map[plumbing.Hash]bool
+	flags   map[plumbing.Hash]walkFlag
+	count   int
+
+	// The parents of each commit read, or the one history simplification
+	// kept, to hide them along with it.
+	parents map[plumbing.Hash][]plumbing.Hash
+
+	// The tips added, and the hidden ones, which ancestry paths start from.
+	tips    []plumbing.Hash
+	bottoms []plumbing.Hash
+
+	// The commits left to list, once a limited walk is done.
+	sorted  []*Entry
+	limited bool
+
+	// The commits processed by a topological walk going by generation
+	// numbers, which then keeps its state in topo.
+	visits map[plumbing.Hash]*visited
+	topo   *topoWalk
+}
+
+func NewWalker(ctx context.Context, git *fs.Git, opts WalkOptions) *Walker {
+	return &Walker{
+		git:     git,
+		commits: newCommitReader(ctx, git),
+		opts:    opts,
+		seen:    make(map[plumbing.Hash]bool),
+		flags:   make(map[plumbing.Hash]walkFlag),
+		parents: make(map[plumbing.Hash][]plumbing.Hash),
+		visits:  make(map[plumbing.Hash]*visited),
+	}
+}
+
+// A commit waiting to be visited. Commits committed at the same time are
+// visited in the order they were queued.
+type queued struct {
+	hash   plumbing.Hash
+	commit *commit.Commit
+	order  int
+}
+
+type commitQueue []*queued
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	a, b := q[i].commit.Committer.When, q[j].commit.Committer.When
+
+	if !a.Equal(b) {
+		return a.After(b)
+	}
+
+	return q[i].order < q[j].order
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*queued)) }
+
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return item
+}
+
+func (w *Walker) readCommit(hash plumbing.Hash) (*commit.Commit, error) {
+	c, err := w.commits.read(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := w.parents[hash]; !ok {
+		w.parents[hash] = c.Parents
+	}
+
+	return c, nil
+}
+
+// Add a tip to the walk: a commit to list the history of, or to hide along
+// with its ancestors.
+func (w *Walker) Add(tip Tip) error {
+	if tip.Left {
+		w.flags[tip.Hash] |= leftFlag
+	}
+
+	if !w.seen[tip.Hash] {
+		w.tips = append(w.tips, tip.Hash)
+	}
+
+	if err := w.Push(tip.Hash); err != nil {
+		return err
+	}
+
+	if tip.Hidden {
+		w.bottoms = append(w.bottoms, tip.Hash)
+		w.flags[tip.Hash] |= hiddenFlag
+		w.hideParents(tip.Hash)
+	}
+
+	return nil
+}
+
+// Hide the parents of a commit, and their ancestors as far as they have
+// been read.
+func (w *Walker) hideParents(hash plumbing.Hash) {
+	for _, parent := range w.parents[hash] {
+		w.hide(parent)
+	}
+}
+
+// Hide a commit, and its ancestors as far as they have been read.
+func (w *Walker) hide(hash plumbing.Hash) {
+	stack := []plumbing.Hash{hash}
+
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if w.flags[hash]&hiddenFlag != 0 {
+			continue
+		}
+
+		w.flags[hash] |= hiddenFlag
+		stack = append(stack, w.parents[hash]...)
+	}
+}
+
+// Push adds a commit to start walking from.
+func (w *Walker) Push(hash plumbing.Hash) error {
+	if w.seen[hash] {
+		return nil
+	}
+
+	c, err := w.readCommit(hash)
+
+	if err != nil {
+		return err
+	}
+
+	w.seen[hash] = true
+	heap.Push(&w.queue, &queued{hash: hash, commit: c, order: w.count})
+	w.count++
+
+	return nil
+}
+
+// Next returns the next commit to list, or nil once there are none left.
+func (w *Walker) Next() (*Entry, error) {
+	if w.isLimited() {
+		if !w.limited {
+			if err := w.limit(); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(w.sorted) == 0 {
+			return nil, nil
+		}
+
+		next := w.sorted[0]
+		w.sorted = w.sorted[1:]
+
+		return w.output(next)
+	}
+
+	if w.isIncremental() {
+		return w.nextTopo()
+	}
+
+	for w.queue.Len() > 0 {
+		v, err := w.visit()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if v.state == shown {
+			v.entry.Left = w.flags[v.entry.Hash]&leftFlag != 0
+
+			return w.output(v.entry)
+		}
+	}
+
+	return nil, nil
+}
+
+// Parse an entry before listing it, unless only its header is wanted.
+func (w *Walker) output(e *Entry) (*Entry, error) {
+	if !w.opts.SkipParsing {
+		if err := w.parse(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// Whether the walk has to go through the whole history before listing
+// anything: to sort it without generation numbers, or because hidden
+// commits may be found late.
+func (w *Walker) isLimited() bool {
+	if w.opts.TopoOrder || w.opts.DateOrder || w.opts.AncestryPath {
+		return !w.isIncremental()
+	}
+
+	return len(w.bottoms) > 0
+}
+
+// What a walk does with a commit it visits.
+type visitState int
+
+const (
+	shown visitState = iota
+
+	// Reachable from a hidden tip.
+	hidden
+
+	// Hidden by history simplification, its content explained by a parent.
+	simplified
+
+	// Hidden by the author, message or parent count filters.
+	filtered
+
+	// Committed outside of Since and Until, and left out altogether.
+	outOfRange
+)
+
+// A commit visited by a walk. Its parents, after history simplification,
+// are the ones that order a topological walk.
+type visited struct {
+	entry   *Entry
+	parents []plumbing.Hash
+	state   visitState
+}
+
+// Visit the newest queued commit, queueing the parents the walk follows.
+func (w *Walker) visit() (*visited, error) {
+	next := heap.Pop(&w.queue).(*queued)
+	c := next.commit
+	v := &visited{entry: &Entry{Hash: next.hash, Commit: c}, state: outOfRange}
+	flags := w.flags[next.hash]
+
+	// The ancestors of hidden commits are hidden as well, through all
+	// their parents even when following first parents.
+	if flags&hiddenFlag != 0 {
+		v.parents, v.state = c.Parents, hidden
+
+		for _, parent := range v.parents {
+			w.flags[parent] |= hiddenFlag
+
+			if err := w.Push(parent); err != nil {
+				return nil, err
+			}
+
+			w.hideParents(parent)
+		}
+
+		return v, nil
+	}
+
+	if !w.opts.Since.IsZero() && c.Committer.When.Before(w.opts.Since) {
+		return v, nil
+	}
+
+	var err error
+
+	if v.parents, v.state, err = w.simplify(next.hash, c); err != nil {
+		return nil, err
+	}
+
+	v.entry.Parents = v.parents
+	w.parents[next.hash] = v.parents
+
+	for i, parent := range v.parents {
+		if i > 0 && w.opts.FirstParent {
+			break
+		}
+
+		w.flags[parent] |= flags & leftFlag
+
+		if err := w.Push(parent); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case !w.opts.Until.IsZero() && c.Committer.When.After(w.opts.Until):
+		v.state = outOfRange
+	case v.state == shown:
+		if len(w.opts.Authors) > 0 || len(w.opts.Grep) > 0 {
+			if err := w.parse(v.entry); err != nil {
+				return nil, err
+			}
+		}
+
+		if !w.matches(v.entry.Commit) {
+			v.state = filtered
+		}
+	}
+
+	return v, nil
+}
+
+// Parse the commit of an entry in full, when it was read from the
+// commit-graph.
+func (w *Walker) parse(e *Entry) error {
+	if e.parsed || !w.commits.partial(e.Hash) {
+		e.parsed = true
+
+		return nil
+	}
+
+	c, err := w.commits.parse(e.Hash)
+
+	if err != nil {
+		return err
+	}
+
+	e.Commit, e.parsed = c, true
+
+	return nil
+}
+
+// Limit the parents of a commit to a single one explaining the paths
+// walked, when there is one. The commit is then not shown, as it changes
+// none of them. Hidden parents other than the hidden tips are irrelevant:
+// they explain nothing, and only count when all parents are irrelevant.
+func (w *Walker) simplify(hash plumbing.Hash, c *commit.Commit) ([]plumbing.Hash, visitState, error) {
+	if w.opts.Paths.Empty() {
+		return c.Parents, shown, nil
+	}
+
+	if len(c.Parents) == 0 {
+		changed, err := w.treeChanged(plumbing.ZeroHash, c.Tree, "")
+
+		if err != nil || changed {
+			return nil, shown, err
+		}
+
+		return nil, simplified, nil
+	}
+
+	relevantParents := 0
+	relevantChange, irrelevantChange := false, false
+
+	for i, parent := range c.Parents {
+		// Only the first parent can explain a commit when following
+		// first parents.
+		if i > 0 && w.opts.FirstParent {
+			break
+		}
+
+		relevant := w.isRelevant(parent)
+
+		if relevant {
+			relevantParents++
+		}
+
+		changed, err := w.parentChanged(hash, c, i)
+
+		if err != nil {
+			return nil, shown, err
+		}
+
+		switch {
+		case !changed && relevant:
+			return []plumbing.Hash{parent}, simplified, nil
+		case changed && relevant:
+			relevantChange = true
+		case changed:
+			irrelevantChange = true
+		}
+	}
+
+	if relevantParents > 0 && relevantChange || relevantParents == 0 && irrelevantChange {
+		return c.Parents, shown, nil
+	}
+
+	return c.Parents, simplified, nil
+}
+
+// Whether a commit changes any of the paths walked compared to its i-th
+// parent. The changed-path Bloom filters of the commit-graph, which are
+// against first parents, answer most of these without reading trees.
+func (w *Walker) parentChanged(hash plumbing.Hash, c *commit.Commit, i int) (bool, error) {
+	if i == 0 && w.opts.Paths.Literal() {
+		if filter, ok := w.commits.graph.BloomFilter(hash); ok && !w.maybeChanged(filter) {
+			return false, nil
+		}
+	}
+
+	p, err := w.readCommit(c.Parents[i])
+
+	if err != nil {
+		return false, err
+	}
+
+	return w.treeChanged(p.Tree, c.Tree, "")
+}
+
+func (w *Walker) maybeChanged(filter *commitgraph.BloomFilter) bool {
+	for _, pattern := range w.opts.Paths.Patterns {
+		if filter.MaybeChanged(pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether a parent can explain the content of a commit: it is not hidden,
+// or it is one of the hidden tips.
+func (w *Walker) isRelevant(hash plumbing.Hash) bool {
+	if w.flags[hash]&hiddenFlag == 0 {
+		return true
+	}
+
+	for _, bottom := range w.bottoms {
+		if bottom == hash {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether the commit passes the author, message and parent count filters.
+func (w *Walker) matches(c *commit.Commit) bool {
+	if len(c.Parents) < w.opts.MinParents {
+		return false
+	}
+
+	if w.opts.MaxParents >= 0 && len(c.Parents) > w.opts.MaxParents {
+		return false
+	}
+
+	if !matchesAny(w.opts.Authors, c.Author.Name+" <"+c.Author.Email+">") {
+		return false
+	}
+
+	return matchesAny(w.opts.Grep, c.Message)
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Walker) readTree(hash plumbing.Hash) (map[string]tree.Entry, error) {
+	entries := make(map[string]tree.Entry)
+
+	if hash.IsZero() {
+		return entries, nil
+	}
+
+	list, err := tree.ReadTree(w.git.ReadObjectByHash, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range list {
+		entries[e.Name] = e
+	}
+
+	return entries, nil
+}
+
+// Whether any path walked differs between two trees, the zero hash
+// standing for the empty tree.
+func (w *Walker) treeChanged(from, to plumbing.Hash, prefix string) (bool, error) {
+	if from == to {
+		return false, nil
+	}
+
+	old, err := w.readTree(from)
+
+	if err != nil {
+		return false, err
+	}
+
+	cur, err := w.readTree(to)
+
+	if err != nil {
+		return false, err
+	}
+
+	names := make(map[string]bool)
+
+	for name := range old {
+		names[name] = true
+	}
+
+	for name := range cur {
+		names[name] = true
+	}
+
+	for name := range names {
+		a, inOld := old[name]
+		b, inNew := cur[name]
+
+		if inOld && inNew && a.Mode == b.Mode && a.Hash() == b.Hash() {
+			continue
+		}
+
+		path := prefix + name
+
+		if w.opts.Paths.Match(path) {
+			return true, nil
+		}
+
+		if !w.opts.Paths.MatchesDirectory(path) {
+			continue
+		}
+
+		// Only trees can hold paths below this one.
+		subtree := func(e tree.Entry, ok bool) plumbing.Hash {
+			if ok && e.IsTree() {
+				return e.Hash()
+			}
+
+			return plumbing.ZeroHash
+		}
+
+		changed, err := w.treeChanged(subtree(a, inOld), subtree(b, inNew), path+"/")
+
+		if err != nil || changed {
+			return changed, err
+		}
+	}
+
+	return false, nil
+}
+
+// How many hidden commits a limited walk goes through once only hidden ones
+// are left to visit, in case clock skew hides newer commits behind them.
+const slop = 5
+
+// Whether a limited walk has to go on after visiting a hidden commit
+// committed at the given time.
+func (w *Walker) stillInteresting(when time.Time) bool {
+	if w.queue.Len() == 0 {
+		return false
+	}
+
+	if w.queue[0].commit.Committer.When.After(when) {
+		return true
+	}
+
+	for _, q := range w.queue {
+		if w.flags[q.hash]&hiddenFlag == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Walk the whole history, until only hidden commits are left, and sort it
+// when asked to. Then keep the commits shown, rewriting their parents past
+// the commits simplified away.
+func (w *Walker) limit() error {
+	w.limited = true
+
+	visits := make(map[plumbing.Hash]*visited)
+	list := []*visited{}
+	left := slop
+
+	for w.queue.Len() > 0 {
+		v, err := w.visit()
+
+		if err != nil {
+			return err
+		}
+
+		visits[v.entry.Hash] = v
+
+		if v.state == hidden {
+			if w.stillInteresting(v.entry.Commit.Committer.When) {
+				left = slop
+			} else if left--; left == 0 {
+				break
+			}
+
+			continue
+		}
+
+		if v.state != outOfRange {
+			list = append(list, v)
+		}
+	}
+
+	if w.opts.AncestryPath {
+		w.limitToAncestryPath(list)
+	}
+
+	if w.opts.TopoOrder || w.opts.DateOrder {
+		list = topoSort(list, w.opts.DateOrder)
+	}
+
+	lookup := func(hash plumbing.Hash) (*visited, error) {
+		return visits[hash], nil
+	}
+
+	for _, v := range list {
+		// Commits can turn out to be hidden after they were visited.
+		flags := w.flags[v.entry.Hash]
+
+		if v.state != shown || flags&hiddenFlag != 0 {
+			continue
+		}
+
+		parents, err := w.rewriteParents(v.entry.Parents, lookup)
+
+		if err != nil {
+			return err
+		}
+
+		v.entry.Parents = parents
+		v.entry.Left = flags&leftFlag != 0
+		w.sorted = append(w.sorted, v.entry)
+	}
+
+	return nil
+}
+
+// Rewrite the parents of a listed commit past the commits history
+// simplification dropped, to the first ones listed or hidden. Parents
+// whose history ends first are dropped.
+func (w *Walker) rewriteParents(parents []plumbing.Hash, lookup func(plumbing.Hash) (*visited, error)) ([]plumbing.Hash, error) {
+	rewrite := func(hash plumbing.Hash) (plumbing.Hash, bool, error) {
+		for {
+			v, err := lookup(hash)
+
+			if err != nil {
+				return hash, false, err
+			}
+
+			if v == nil || v.state != simplified || w.flags[hash]&hiddenFlag != 0 {
+				return hash, true, nil
+			}
+
+			if len(v.parents) == 0 {
+				return hash, false, nil
+			}
+
+			parent, ok := w.relevantParent(v.parents)
+
+			if !ok {
+				return hash, true, nil
+			}
+
+			hash = parent
+		}
+	}
+
+	rewritten := []plumbing.Hash{}
+	added := make(map[plumbing.Hash]bool)
+
+	for _, parent := range parents {
+		parent, ok, err := rewrite(parent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && !added[parent] {
+			rewritten = append(rewritten, parent)
+			added[parent] = true
+		}
+	}
+
+	return rewritten, nil
+}
+
+// The parent a simplified commit stands for: its first one when it has a
+// single one or when following first parents, or else its only relevant
+// one, if it has exactly one.
+func (w *Walker) relevantParent(parents []plumbing.Hash) (plumbing.Hash, bool) {
+	if len(parents) == 1 || w.opts.FirstParent {
+		return parents[0], true
+	}
+
+	relevant, found := plumbing.ZeroHash, false
+
+	for _, parent := range parents {
+		if !w.isRelevant(parent) {
+			continue
+		}
+
+		if found {
+			return plumbing.ZeroHash, false
+		}
+
+		relevant, found = parent, true
+	}
+
+	return relevant, found
+}
+
+// Hide the commits walked that do not descend from a hidden tip. They are
+// still sorted, like the others.
+func (w *Walker) limitToAncestryPath(list []*visited) {
+	onPath := make(map[plumbing.Hash]bool)
+
+	for _, bottom := range w.bottoms {
+		onPath[bottom] = true
+	}
+
+	for progress := true; progress; {
+		progress = false
+
+		for _, v := range list {
+			if onPath[v.entry.Hash] {
+				continue
+			}
+
+			for _, parent := range v.parents {
+				if onPath[parent] {
+					onPath[v.entry.Hash] = true
+					progress = true
+
+					break
+				}
+			}
+		}
+	}
+
+	for _, v := range list {
+		if !onPath[v.entry.Hash] {
+			w.flags[v.entry.Hash] |= hiddenFlag
+		}
+	}
+}
+
+// Sort commits so that none comes before its children. When free to
+// choose, the newest commit comes first if sorting by date; otherwise
+// sorting starts from the first tip and follows each line of history as
+// far as it goes.
+func topoSort(list []*visited, byDate bool) []*visited {
+	byHash := make(map[plumbing.Hash]*visited)
+	children := make(map[plumbing.Hash]int)
+
+	for _, v := range list {
+		byHash[v.entry.Hash] = v
+	}
+
+	for _, v := range list {
+		for _, parent := range v.parents {
+			if _, ok := byHash[parent]; ok {
+				children[parent]++
+			}
+		}
+	}
+
+	// The commits ready to be listed: a stack, or a queue by date.
+	stack := []*visited{}
+	queue := commitQueue{}
+	count := 0
+
+	push := func(v *visited) {
+		if byDate {
+			heap.Push(&queue, &queued{hash: v.entry.Hash, commit: v.entry.Commit, order: count})
+			count++
+		} else {
+			stack = append(stack, v)
+		}
+	}
+
+	pop := func() *visited {
+		if byDate {
+			return byHash[heap.Pop(&queue).(*queued).hash]
+		}
+
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		return v
+	}
+
+	if byDate {
+		for _, v := range list {
+			if children[v.entry.Hash] == 0 {
+				push(v)
+			}
+		}
+	} else {
+		for i := len(list) - 1; i >= 0; i-- {
+			if children[list[i].entry.Hash] == 0 {
+				push(list[i])
+			}
+		}
+	}
+
+	sorted := make([]*visited, 0, len(list))
+
+	for len(stack) > 0 || queue.Len() > 0 {
+		v := pop()
+		sorted = append(sorted, v)
+
+		for _, parent := range v.parents {
+			p, ok := byHash[parent]
+
+			if !ok {
+				continue
+			}
+
+			if children[parent]--; children[parent] == 0 {
+				push(p)
+			}
+		}
+	}
+
+	return sorted
+}
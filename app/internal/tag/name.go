@@ -0,0 +1,13 @@
+package tag
+
+import (
+	"strings"
+
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/refs"
+)
+
+// ValidName reports whether name can be used as a tag, that is whether
+// refs/tags/<name> is a valid ref name.
+func ValidName(name string) bool {
+	return !strings.HasPrefix(name, "-") && refs.ValidName(refs.TagsPrefix+name)
+}
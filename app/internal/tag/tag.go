@@ -0,0 +1,93 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/commit"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// Tag is the parsed form of an annotated tag object.
+type Tag struct {
+	Object plumbing.Hash
+	Type   objfile.GitObjectType
+	Name   string
+
+	// Tagger is nil for (old) tags that were created without one.
+	Tagger *commit.Signature
+
+	// Message includes any trailing signature, as Git stores it.
+	Message string
+}
+
+// Decode parses the content of a tag object (without the object header),
+// requiring the object, type and tag headers in that order.
+func Decode(data []byte) (*Tag, error) {
+	headers, message, err := commit.ParseHeaders(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expected := []string{"object", "type", "tag"}
+
+	if len(headers) < len(expected) {
+		return nil, errors.GitError{Message: "Malformed tag: missing headers"}
+	}
+
+	for i, key := range expected {
+		if headers[i].Key != key {
+			return nil, errors.GitError{Message: fmt.Sprintf("Malformed tag: expected '%s' header, got '%s'", key, headers[i].Key)}
+		}
+	}
+
+	t := &Tag{
+		Name:    string(headers[2].Value),
+		Message: string(message),
+	}
+
+	if t.Object, err = plumbing.NewHash(string(headers[0].Value)); err != nil {
+		return nil, err
+	}
+
+	if t.Type, err = objfile.DetectObjectType(string(headers[1].Value)); err != nil {
+		return nil, err
+	}
+
+	for _, h := range headers[3:] {
+		if h.Key != "tagger" {
+			continue
+		}
+
+		tagger, err := commit.ParseSignature(h.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		t.Tagger = &tagger
+	}
+
+	return t, nil
+}
+
+// Bytes serializes the tag into the content of a tag object.
+func (t *Tag) Bytes() []byte {
+	buf := bytes.NewBufferString("")
+
+	commit.WriteHeader(buf, "object", t.Object.String())
+	commit.WriteHeader(buf, "type", t.Type.String())
+	commit.WriteHeader(buf, "tag", t.Name)
+
+	if t.Tagger != nil {
+		commit.WriteHeader(buf, "tagger", t.Tagger.String())
+	}
+
+	buf.WriteByte('\n')
+	buf.WriteString(t.Message)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,208 @@
+package tree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/index"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// ObjectWriter stores an object, returning its id.
+type ObjectWriter func(t objfile.GitObjectType, content []byte) (plumbing.Hash, error)
+
+// WriteFromIndex writes one tree object per directory of the index and
+// returns the id of the root tree. Valid trees of the TREE extension are
+// reused, and the extension is refreshed with every tree written.
+func WriteFromIndex(idx *index.Index, write ObjectWriter) (plumbing.Hash, error) {
+	for _, e := range idx.Entries {
+		if e.Stage != index.Merged {
+			return plumbing.ZeroHash, errors.GitError{Message: fmt.Sprintf("%s: unmerged (stage %d)", e.Name, e.Stage)}
+		}
+	}
+
+	cache, err := writeIndexTree(idx.Entries, "", idx.Cache, write)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	idx.Cache = cache
+
+	return cache.Hash, nil
+}
+
+func findSubtree(cached *index.CachedTree, name string) *index.CachedTree {
+	if cached == nil {
+		return nil
+	}
+
+	for _, sub := range cached.Subtrees {
+		if sub.Name == name {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// Write the tree for the directory prefix, given the index entries under it.
+func writeIndexTree(entries []*index.Entry, prefix string, cached *index.CachedTree, write ObjectWriter) (*index.CachedTree, error) {
+	node := &index.CachedTree{}
+	treeEntries := []Entry{}
+	invalid := false
+
+	for i := 0; i < len(entries); {
+		e := entries[i]
+		name := e.Name[len(prefix):]
+
+		slash := strings.IndexByte(name, '/')
+
+		if slash < 0 {
+			i++
+
+			// Intent-to-add entries are not part of the tree yet.
+			if e.IntentToAdd {
+				invalid = true
+
+				continue
+			}
+
+			treeEntries = append(treeEntries, Entry{Mode: e.Mode, Name: name, Sha: e.Hash.Bytes()})
+
+			continue
+		}
+
+		dir := name[:slash]
+		subPrefix := prefix + dir + "/"
+		end := i
+
+		for end < len(entries) && strings.HasPrefix(entries[end].Name, subPrefix) {
+			end++
+		}
+
+		sub := findSubtree(cached, dir)
+
+		if sub == nil || !sub.Valid() || sub.EntryCount != end-i {
+			var err error
+
+			if sub, err = writeIndexTree(entries[i:end], subPrefix, sub, write); err != nil {
+				return nil, err
+			}
+		}
+
+		sub.Name = dir
+		node.Subtrees = append(node.Subtrees, sub)
+		invalid = invalid || !sub.Valid()
+
+		treeEntries = append(treeEntries, Entry{Mode: ModeTree, Name: dir, Sha: sub.Hash.Bytes()})
+		i = end
+	}
+
+	SortEntries(treeEntries)
+
+	hash, err := write(objfile.Tree, Encode(treeEntries))
+
+	if err != nil {
+		return nil, err
+	}
+
+	node.Hash = hash
+	node.EntryCount = len(entries)
+
+	if invalid {
+		node.EntryCount = -1
+	}
+
+	return node, nil
+}
+
+// Mode to record for a file in the working tree.
+func ModeFromFileInfo(info os.FileInfo) uint32 {
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return ModeSymlink
+	case info.IsDir():
+		return ModeTree
+	case info.Mode()&0111 != 0:
+		return ModeExecutable
+	}
+
+	return ModeBlob
+}
+
+// WriteFromDirectory writes blobs for every file below dir and one tree per
+// non-empty directory, returning the id of the tree for dir. Directories
+// named .git are skipped.
+func WriteFromDirectory(dir string, write ObjectWriter) (plumbing.Hash, error) {
+	hash, _, err := writeDirectoryTree(dir, write)
+
+	return hash, err
+}
+
+func writeDirectoryTree(dir string, write ObjectWriter) (plumbing.Hash, bool, error) {
+	infos, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		return plumbing.ZeroHash, false, err
+	}
+
+	entries := []Entry{}
+
+	for _, info := range infos {
+		path := filepath.Join(dir, info.Name())
+		mode := ModeFromFileInfo(info)
+
+		var hash plumbing.Hash
+
+		switch mode {
+		case ModeTree:
+			if info.Name() == ".git" {
+				continue
+			}
+
+			var empty bool
+
+			if hash, empty, err = writeDirectoryTree(path, write); err != nil {
+				return hash, false, err
+			}
+
+			if empty {
+				continue
+			}
+		case ModeSymlink:
+			target, err := os.Readlink(path)
+
+			if err != nil {
+				return hash, false, err
+			}
+
+			if hash, err = write(objfile.Blob, []byte(target)); err != nil {
+				return hash, false, err
+			}
+		default:
+			content, err := ioutil.ReadFile(path)
+
+			if err != nil {
+				return hash, false, err
+			}
+
+			if hash, err = write(objfile.Blob, content); err != nil {
+				return hash, false, err
+			}
+		}
+
+		entries = append(entries, Entry{Mode: mode, Name: info.Name(), Sha: hash.Bytes()})
+	}
+
+	SortEntries(entries)
+
+	hash, err := write(objfile.Tree, Encode(entries))
+
+	return hash, len(entries) == 0, err
+}
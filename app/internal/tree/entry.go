@@ -2,7 +2,6 @@ package tree
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -10,6 +9,18 @@ import (
 
 	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
 	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// File modes recorded in tree entries.
+const (
+	ModeTree       uint32 = 0040000
+	ModeBlob       uint32 = 0100644
+	ModeExecutable uint32 = 0100755
+	ModeSymlink    uint32 = 0120000
+	ModeGitlink    uint32 = 0160000
+
+	modeTypeMask uint32 = 0170000
 )
 
 type Entry struct {
@@ -18,82 +29,42 @@ type Entry struct {
 	Sha  []byte
 }
 
-func TreeEntryIterator(r *objfile.Reader) func() (Entry, error) {
-	scanner := bufio.NewScanner(r)
-
-	tokenType := 0
-
-	tokenScanner := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		advance = 0
-
-		for _, c := range data {
-			shouldReturn := false
-
-			switch tokenType {
-			case 0:
-				shouldReturn = (c == ' ')
-				advance = 1
-			case 1:
-				shouldReturn = (c == 0x0)
-				advance = 1
-			case 2:
-				shouldReturn = (len(token) == 20)
-			}
-
-			if shouldReturn {
-				break
-			}
-
-			token = append(token, c)
-		}
-
-		advance += len(token)
-
-		return
-	}
-
-	scanner.Split(tokenScanner)
+// TreeEntryIterator reads the entries of a tree object of the given format
+// one at a time, returning io.EOF after the last one.
+func TreeEntryIterator(r io.Reader, format plumbing.ObjectFormat) func() (Entry, error) {
+	reader := bufio.NewReader(r)
 
 	return func() (entry Entry, err error) {
-		tokenType = 0
+		rawMode, err := reader.ReadBytes(' ')
 
-		if scanner.Scan() {
-			rawMode := scanner.Bytes()
-
-			modeVal, err := strconv.ParseInt(string(rawMode), 10, 32)
-
-			if err != nil {
-				return entry, err
+		if err != nil {
+			if err == io.EOF && len(rawMode) > 0 {
+				err = errors.GitError{Message: "Could not find tree entry name field"}
 			}
 
-			entry.Mode = uint32(modeVal)
-		} else {
-			err = scanner.Err()
+			return
+		}
 
-			if err == nil {
-				err = io.EOF
-			}
+		modeVal, err := strconv.ParseUint(string(rawMode[:len(rawMode)-1]), 8, 32)
 
-			return
+		if err != nil {
+			return entry, errors.GitError{Message: fmt.Sprintf("Malformed tree entry mode: '%s'", rawMode)}
 		}
 
-		tokenType += 1
+		entry.Mode = uint32(modeVal)
 
-		if scanner.Scan() {
-			rawName := scanner.Bytes()
+		rawName, err := reader.ReadBytes(0x0)
 
-			entry.Name = string(rawName)
-		} else {
+		if err != nil {
 			err = errors.GitError{Message: "Could not find tree entry name field"}
 
 			return
 		}
 
-		tokenType += 1
+		entry.Name = string(rawName[:len(rawName)-1])
+		entry.Sha = make([]byte, format.Size())
 
-		if scanner.Scan() {
-			entry.Sha = scanner.Bytes()
-		} else {
+		if _, err = io.ReadFull(reader, entry.Sha); err != nil {
 			err = errors.GitError{Message: "Could not find tree entry sha field"}
 
 			return
@@ -103,29 +74,26 @@ func TreeEntryIterator(r *objfile.Reader) func() (Entry, error) {
 	}
 }
 
-func (e *Entry) String(nameOnly bool) string {
-	buf := bytes.NewBufferString("")
-
-	if !nameOnly {
-		fmt.Fprintf(buf, "%06d\t", e.Mode)
+// Type of the object the entry points at.
+func (e *Entry) Type() objfile.GitObjectType {
+	switch e.Mode & modeTypeMask {
+	case ModeTree:
+		return objfile.Tree
+	case ModeGitlink:
+		return objfile.Commit
 	}
 
-	objectType := objfile.Tree
-
-	if e.Mode >= 100_000 {
-		objectType = objfile.Blob
-	}
+	return objfile.Blob
+}
 
-	if !nameOnly {
-		fmt.Fprintf(buf, "%s\t", objectType.String())
-	}
+func (e *Entry) IsTree() bool {
+	return e.Mode&modeTypeMask == ModeTree
+}
 
-	if !nameOnly {
-		fmt.Fprintf(buf, "%s\t", hex.EncodeToString(e.Sha))
+func (e *Entry) String(nameOnly bool) string {
+	if nameOnly {
+		return e.Name
 	}
 
-	fmt.Fprint(buf, e.Name)
-
-	return buf.String()
+	return fmt.Sprintf("%06o %s %s\t%s", e.Mode, e.Type(), hex.EncodeToString(e.Sha), e.Name)
 }
-
@@ -0,0 +1,89 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	errors "github.com/shikharbhardwaj/codecrafters-git-go/app/errors"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/objfile"
+	"github.com/shikharbhardwaj/codecrafters-git-go/app/internal/plumbing"
+)
+
+// ReadTree reads the entries of a single tree object.
+func ReadTree(read objfile.ObjectReader, hash plumbing.Hash) ([]Entry, error) {
+	t, data, err := read(hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t != objfile.Tree {
+		return nil, errors.GitError{Message: fmt.Sprintf("%s is a %s, not a tree", hash, t)}
+	}
+
+	return Decode(data, hash.Format())
+}
+
+// Decode parses the content of a tree object of the given format.
+func Decode(data []byte, format plumbing.ObjectFormat) ([]Entry, error) {
+	entries := []Entry{}
+	iterator := TreeEntryIterator(bytes.NewReader(data), format)
+
+	for {
+		entry, err := iterator()
+
+		if err == io.EOF {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+// ReadRecursive lists the non-tree entries of a tree and all its subtrees,
+// named by their full slash separated paths, in index order.
+func ReadRecursive(read objfile.ObjectReader, hash plumbing.Hash) ([]Entry, error) {
+	return readRecursive(read, hash, "")
+}
+
+func readRecursive(read objfile.ObjectReader, hash plumbing.Hash, prefix string) ([]Entry, error) {
+	entries, err := ReadTree(read, hash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Entry{}
+
+	for _, e := range entries {
+		e.Name = prefix + e.Name
+
+		if !e.IsTree() {
+			result = append(result, e)
+
+			continue
+		}
+
+		children, err := readRecursive(read, e.Hash(), e.Name+"/")
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, children...)
+	}
+
+	return result, nil
+}
+
+// Hash returns the id of the object the entry points at.
+func (e *Entry) Hash() plumbing.Hash {
+	hash, _ := plumbing.NewHashFromBytes(e.Sha)
+
+	return hash
+}
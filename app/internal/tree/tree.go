@@ -0,0 +1,39 @@
+package tree
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+)
+
+// Name used to order an entry: trees sort as if their name ended in '/'.
+func sortName(e *Entry) string {
+	if e.IsTree() {
+		return e.Name + "/"
+	}
+
+	return e.Name
+}
+
+// SortEntries orders entries the way Git requires within a tree object.
+func SortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return sortName(&entries[i]) < sortName(&entries[j])
+	})
+}
+
+// Encode serializes sorted entries into the content of a tree object, a
+// sequence of "<octal mode> <name>\0<binary sha>" records.
+func Encode(entries []Entry) []byte {
+	buf := bytes.NewBuffer(nil)
+
+	for _, e := range entries {
+		buf.WriteString(strconv.FormatUint(uint64(e.Mode), 8))
+		buf.WriteByte(' ')
+		buf.WriteString(e.Name)
+		buf.WriteByte(0)
+		buf.Write(e.Sha)
+	}
+
+	return buf.Bytes()
+}
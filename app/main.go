@@ -40,7 +40,25 @@ func main() {
 		commands.CatFileCommand,
 		commands.HashObjectCommand,
 		commands.LsTreeCommand,
-        commands.WriteTreeCommand,
+		commands.WriteTreeCommand,
+		commands.CommitTreeCommand,
+		commands.PackObjectsCommand,
+		commands.IndexPackCommand,
+		commands.MkTagCommand,
+		commands.TagCommand,
+		commands.AddCommand,
+		commands.StatusCommand,
+		commands.UpdateRefCommand,
+		commands.SymbolicRefCommand,
+		commands.ShowRefCommand,
+		commands.RevParseCommand,
+		commands.ConfigCommand,
+		commands.LogCommand,
+		commands.RevListCommand,
+		commands.CommitGraphCommand,
+		commands.DiffCommand,
+		commands.MergeBaseCommand,
+		commands.MergeCommand,
 	}
 
 	app.Run(os.Args)